@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AccesoHandler struct {
+	accesoService port.AccesoService
+}
+
+func (a AccesoHandler) ObtenerListaIntentosLogin(c *fiber.Ctx) error {
+	list, err := a.accesoService.ObtenerListaIntentosLogin(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (a AccesoHandler) ObtenerActividadSospechosa(c *fiber.Ctx) error {
+	list, err := a.accesoService.ObtenerActividadSospechosa(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (a AccesoHandler) DesbloquearUsuario(c *fiber.Ctx) error {
+	usuarioId, err := c.ParamsInt("usuarioId", 0)
+	if err != nil || usuarioId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del usuario debe ser un número válido mayor a 0"))
+	}
+	err = a.accesoService.DesbloquearUsuario(c.UserContext(), &usuarioId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Usuario desbloqueado correctamente"))
+}
+
+func NewAccesoHandler(accesoService port.AccesoService) *AccesoHandler {
+	return &AccesoHandler{accesoService: accesoService}
+}
+
+var _ port.AccesoHandler = (*AccesoHandler)(nil)
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditoriaHandler struct {
+	auditoriaService port.AuditoriaService
+}
+
+func (a AuditoriaHandler) ObtenerListaAuditoria(c *fiber.Ctx) error {
+	list, err := a.auditoriaService.ObtenerListaAuditoria(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (a AuditoriaHandler) ObtenerAuditoriaById(c *fiber.Ctx) error {
+	auditoriaId, err := c.ParamsInt("auditoriaId", 0)
+	if err != nil || auditoriaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del registro de auditoría debe ser un número válido mayor a 0"))
+	}
+	id := int64(auditoriaId)
+	item, err := a.auditoriaService.ObtenerAuditoriaById(c.UserContext(), &id)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(item)
+}
+
+func NewAuditoriaHandler(auditoriaService port.AuditoriaService) *AuditoriaHandler {
+	return &AuditoriaHandler{auditoriaService: auditoriaService}
+}
+
+var _ port.AuditoriaHandler = (*AuditoriaHandler)(nil)
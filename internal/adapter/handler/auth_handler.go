@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -38,6 +39,12 @@ func (a *AuthHandler) RegisterWithEmail(c *fiber.Ctx) error {
 	if verified, ok := token.Claims["email_verified"].(bool); !ok || !verified {
 		return c.Status(http.StatusUnauthorized).JSON(util.NewMessage("Correo no verificado"))
 	}
+	emailCuenta, _ := token.Claims["email"].(string)
+
+	// Vincular la cuenta de Firebase para asociarla luego con un cliente
+	if err := a.authService.RegistrarCuentaCliente(c.UserContext(), token.UID, emailCuenta); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage("Error al registrar la cuenta"))
+	}
 
 	// Crear JWT propio
 	expAccess := time.Now().UTC().Add(24 * time.Hour)
@@ -67,6 +74,11 @@ func (a *AuthHandler) LoginWithGoogle(c *fiber.Ctx) error {
 	// También puedes obtener el correo del token
 	email := token.Claims["email"].(string)
 
+	// Vincular la cuenta de Firebase para asociarla luego con un cliente
+	if err := a.authService.RegistrarCuentaCliente(c.UserContext(), token.UID, email); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage("Error al registrar la cuenta"))
+	}
+
 	expAccess := time.Now().UTC().Add(60 * 24 * time.Hour)
 	accessToken, err := util.Token.CreateToken(jwt.MapClaims{
 		"userId":     token.UID,
@@ -97,6 +109,11 @@ func (a *AuthHandler) LoginWithEmail(c *fiber.Ctx) error {
 	// También puedes obtener el correo del token
 	email := token.Claims["email"].(string)
 
+	// Vincular la cuenta de Firebase para asociarla luego con un cliente
+	if err := a.authService.RegistrarCuentaCliente(c.UserContext(), token.UID, email); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage("Error al registrar la cuenta"))
+	}
+
 	// Crear JWT propio
 	expAccess := time.Now().UTC().Add(60 * 24 * time.Hour)
 	accessToken, err := util.Token.CreateToken(jwt.MapClaims{
@@ -113,58 +130,35 @@ func (a *AuthHandler) LoginWithEmail(c *fiber.Ctx) error {
 }
 
 func (a *AuthHandler) RefreshOrVerify(c *fiber.Ctx) error {
-	now := time.Now().UTC()
-
-	// Verificar refresh-token
-	claims, err := util.Token.VerifyToken(c.Cookies("refresh-token"))
+	tokenResponse, err := a.authService.RenovarToken(c.UserContext(), c.Cookies("refresh-token"), sesionCliente(c))
 	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Code != http.StatusUnauthorized {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"authenticated": false, "message": "Usuario no autorizado"})
 	}
 
-	username, ok := claims["username"].(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"authenticated": false})
-	}
-	userId, ok := claims["userId"].(float64)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"authenticated": false})
-	}
-
-	// Expiraciones
-	expAccess := now.Add(1 * time.Hour)
-	expRefresh := now.Add(7 * 24 * time.Hour)
-
-	// Crear tokens
-	accessToken, _ := util.Token.CreateToken(jwt.MapClaims{
-		"userId":     userId,
-		"username":   username,
-		"expiration": expAccess.Unix(),
-		"type":       "access-token-adm",
-	})
-	refreshToken, _ := util.Token.CreateToken(jwt.MapClaims{
-		"userId":     userId,
-		"username":   username,
-		"expiration": expRefresh.Unix(),
-		"type":       "refresh-token-adm",
-	})
-
+	now := time.Now().UTC()
 	// Guardar cookies cross-domain
-	util.SetCookie(c, "access-token", accessToken, expAccess.Sub(now), true, false, now)
-	util.SetCookie(c, "exp-access-token", fmt.Sprintf("%d", expAccess.Unix()), expAccess.Sub(now), false, false, now)
+	util.SetCookie(c, "access-token", tokenResponse.AccessToken, tokenResponse.ExpAccessToken.Sub(now), true, false, now)
+	util.SetCookie(c, "exp-access-token", fmt.Sprintf("%d", tokenResponse.ExpAccessToken.Unix()), tokenResponse.ExpAccessToken.Sub(now), false, false, now)
 
-	// Renovar refresh token si queda menos de 6 horas
-	expFloat, _ := claims["expiration"].(float64)
-	expRefreshCurrent := time.Unix(int64(expFloat), 0)
-	if time.Until(expRefreshCurrent) < 6*time.Hour {
-		util.SetCookie(c, "refresh-token", refreshToken, expRefresh.Sub(now), true, false, now)
-		util.SetCookie(c, "exp-refresh-token", fmt.Sprintf("%d", expRefresh.Unix()), expRefresh.Sub(now), false, false, now)
+	// El refresh token rota en cada renovación; una petición concurrente conserva el ya emitido
+	if tokenResponse.RefreshRotado {
+		util.SetCookie(c, "refresh-token", tokenResponse.RefreshToken, tokenResponse.ExpRefreshToken.Sub(now), true, false, now)
+		util.SetCookie(c, "exp-refresh-token", fmt.Sprintf("%d", tokenResponse.ExpRefreshToken.Unix()), tokenResponse.ExpRefreshToken.Sub(now), false, false, now)
 	}
 
 	// Respuesta explícita
-	return c.JSON(fiber.Map{"authenticated": true, "message": "Sesión activa"})
+	return c.JSON(fiber.Map{"authenticated": true, "message": tokenResponse.Message})
 }
 
 func (a *AuthHandler) Logout(c *fiber.Ctx) error {
+	if err := a.authService.CerrarSesion(c.UserContext(), c.Cookies("refresh-token")); err != nil {
+		log.Print(err.Error())
+	}
 	util.DeleteCookie(c, "access-token", true)
 	util.DeleteCookie(c, "refresh-token", true)
 	util.DeleteCookie(c, "exp-access-token", false)
@@ -180,7 +174,7 @@ func (a *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	ctx := c.UserContext()
-	tokenResponse, err := a.authService.ObtenerTokenByCredencial(ctx, &credentials)
+	tokenResponse, err := a.authService.ObtenerTokenByCredencial(ctx, &credentials, sesionCliente(c))
 	if err != nil {
 		log.Print(err.Error())
 		var errorResponse *datatype.ErrorResponse
@@ -190,17 +184,113 @@ func (a *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
 	}
 
+	// El inicio de sesión puede continuar con otro paso usando el token de desafío
+	if tokenResponse.TokenDesafio != "" {
+		return c.JSON(tokenResponse)
+	}
+
+	guardarCookiesSesion(c, tokenResponse)
+	return c.JSON(util.NewMessage("Usuario autenticado"))
+}
+
+func (a *AuthHandler) VerificarDobleFactor(c *fiber.Ctx) error {
+	var request domain.DesafioDobleFactorRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	tokenResponse, err := a.authService.VerificarDobleFactor(c.UserContext(), &request, sesionCliente(c))
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	guardarCookiesSesion(c, tokenResponse)
+	return c.JSON(tokenResponse)
+}
+
+func (a *AuthHandler) CambiarPasswordDesafio(c *fiber.Ctx) error {
+	var request domain.DesafioPasswordRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	tokenResponse, err := a.authService.CambiarPasswordDesafio(c.UserContext(), &request, sesionCliente(c))
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	guardarCookiesSesion(c, tokenResponse)
+	return c.JSON(util.NewMessage(tokenResponse.Message))
+}
+
+func (a *AuthHandler) IniciarDobleFactorDesafio(c *fiber.Ctx) error {
+	var request domain.DesafioDobleFactorRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	configuracion, err := a.authService.IniciarDobleFactorDesafio(c.UserContext(), request.TokenDesafio, sesionCliente(c))
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(configuracion)
+}
+
+func (a *AuthHandler) ConfirmarDobleFactorDesafio(c *fiber.Ctx) error {
+	var request domain.DesafioDobleFactorRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	tokenResponse, err := a.authService.ConfirmarDobleFactorDesafio(c.UserContext(), &request, sesionCliente(c))
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	guardarCookiesSesion(c, tokenResponse)
+	// Los códigos de recuperación solo se muestran esta vez
+	return c.JSON(tokenResponse)
+}
+
+// guardarCookiesSesion guarda los tokens de la sesión recién creada; no hay sesión si queda un paso pendiente
+func guardarCookiesSesion(c *fiber.Ctx, tokenResponse *domain.TokenResponse) {
+	if tokenResponse.TokenDesafio != "" {
+		return
+	}
 	now := time.Now().UTC()
 
 	// Refresh token: 7 días
 	util.SetCookie(c, "refresh-token", tokenResponse.RefreshToken, tokenResponse.ExpRefreshToken.Sub(now), true, false, now)
 	util.SetCookie(c, "exp-refresh-token", fmt.Sprintf("%d", tokenResponse.ExpRefreshToken.Unix()), tokenResponse.ExpRefreshToken.Sub(now), false, false, now)
 
-	// Access token: 15 minutos
+	// Access token: 1 hora
 	util.SetCookie(c, "access-token", tokenResponse.AccessToken, tokenResponse.ExpAccessToken.Sub(now), true, false, now)
 	util.SetCookie(c, "exp-access-token", fmt.Sprintf("%d", tokenResponse.ExpAccessToken.Unix()), tokenResponse.ExpAccessToken.Sub(now), false, false, now)
+}
 
-	return c.JSON(util.NewMessage("Usuario autenticado"))
+// sesionCliente obtiene los datos del dispositivo; el frontend puede nombrarlo con la cabecera X-Dispositivo
+func sesionCliente(c *fiber.Ctx) *domain.SesionCliente {
+	cliente := &domain.SesionCliente{Ip: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if dispositivo := strings.TrimSpace(c.Get("X-Dispositivo")); dispositivo != "" {
+		if len(dispositivo) > 100 {
+			dispositivo = dispositivo[:100]
+		}
+		cliente.Dispositivo = &dispositivo
+	}
+	return cliente
 }
 
 func NewAuthHandler(authService port.AuthService) *AuthHandler {
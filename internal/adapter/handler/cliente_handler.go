@@ -120,6 +120,83 @@ func (c2 ClienteHandler) DeshabilitarCliente(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(util.NewMessage("Cliente actualizado correctamente"))
 }
 
+func (c2 ClienteHandler) ObtenerPerfil(c *fiber.Ctx) error {
+	cuenta, err := c2.clienteService.ObtenerPerfil(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(&cuenta)
+}
+
+func (c2 ClienteHandler) GuardarPerfil(c *fiber.Ctx) error {
+	var request domain.PerfilClienteRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err := c2.clienteService.GuardarPerfil(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Datos de facturación guardados correctamente"))
+}
+
+func (c2 ClienteHandler) VerificarPerfil(c *fiber.Ctx) error {
+	var request domain.VerificarCuentaRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err := c2.clienteService.VerificarPerfil(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Cuenta verificada correctamente"))
+}
+
+func (c2 ClienteHandler) ObtenerListaCuentasCliente(c *fiber.Ctx) error {
+	list, err := c2.clienteService.ObtenerListaCuentasCliente(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(&list)
+}
+
+func (c2 ClienteHandler) VerificarCuentaCliente(c *fiber.Ctx) error {
+	usuarioUid := c.Params("usuarioUid")
+	if usuarioUid == "" {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El UID de la cuenta es obligatorio"))
+	}
+	err := c2.clienteService.VerificarCuentaCliente(c.UserContext(), usuarioUid)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Cuenta verificada correctamente"))
+}
+
 func NewClienteHandler(clienteService port.ClienteService) ClienteHandler {
 	return ClienteHandler{clienteService: clienteService}
 }
@@ -95,7 +95,9 @@ func (c2 CompraHandler) RegistrarCompra(c *fiber.Ctx) error {
 	if err != nil || compraId <= 0 {
 		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la compra debe ser un número válido mayor a 0"))
 	}
-	err = c2.compraService.RegistrarCompra(c.UserContext(), &compraId)
+	// Con proponerPrecioVenta el precio de venta de la compra queda como propuesta por aprobar
+	proponerPrecioVenta := c.QueryBool("proponerPrecioVenta", false)
+	err = c2.compraService.RegistrarCompra(c.UserContext(), &compraId, proponerPrecioVenta)
 	if err != nil {
 		log.Print(err.Error())
 		var errorResponse *datatype.ErrorResponse
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CuentaServicioHandler struct {
+	cuentaServicioService port.CuentaServicioService
+}
+
+func (cs CuentaServicioHandler) ListarCuentasServicio(c *fiber.Ctx) error {
+	list, err := cs.cuentaServicioService.ListarCuentasServicio(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (cs CuentaServicioHandler) ObtenerCuentaServicioById(c *fiber.Ctx) error {
+	cuentaId, err := c.ParamsInt("cuentaId", 0)
+	if err != nil || cuentaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la cuenta de servicio debe ser un número válido mayor a 0"))
+	}
+	cuenta, err := cs.cuentaServicioService.ObtenerCuentaServicioById(c.UserContext(), &cuentaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(cuenta)
+}
+
+func (cs CuentaServicioHandler) RegistrarCuentaServicio(c *fiber.Ctx) error {
+	var request domain.CuentaServicioRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	cuenta, err := cs.cuentaServicioService.RegistrarCuentaServicio(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(cuenta, "Cuenta de servicio registrada correctamente"))
+}
+
+func (cs CuentaServicioHandler) ModificarCuentaServicio(c *fiber.Ctx) error {
+	var request domain.CuentaServicioRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	cuentaId, err := c.ParamsInt("cuentaId", 0)
+	if err != nil || cuentaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la cuenta de servicio debe ser un número válido mayor a 0"))
+	}
+	err = cs.cuentaServicioService.ModificarCuentaServicio(c.UserContext(), &cuentaId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusAccepted).JSON(util.NewMessage("Cuenta de servicio actualizada correctamente"))
+}
+
+func (cs CuentaServicioHandler) HabilitarCuentaServicio(c *fiber.Ctx) error {
+	cuentaId, err := c.ParamsInt("cuentaId", 0)
+	if err != nil || cuentaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la cuenta de servicio debe ser un número válido mayor a 0"))
+	}
+	err = cs.cuentaServicioService.HabilitarCuentaServicio(c.UserContext(), &cuentaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Cuenta de servicio habilitada correctamente"))
+}
+
+func (cs CuentaServicioHandler) DeshabilitarCuentaServicio(c *fiber.Ctx) error {
+	cuentaId, err := c.ParamsInt("cuentaId", 0)
+	if err != nil || cuentaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la cuenta de servicio debe ser un número válido mayor a 0"))
+	}
+	err = cs.cuentaServicioService.DeshabilitarCuentaServicio(c.UserContext(), &cuentaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Cuenta de servicio deshabilitada correctamente"))
+}
+
+// GenerarApiKey devuelve la API key completa una sola vez; después solo se muestra su prefijo
+func (cs CuentaServicioHandler) GenerarApiKey(c *fiber.Ctx) error {
+	var request domain.ApiKeyRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	cuentaId, err := c.ParamsInt("cuentaId", 0)
+	if err != nil || cuentaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la cuenta de servicio debe ser un número válido mayor a 0"))
+	}
+	apiKey, err := cs.cuentaServicioService.GenerarApiKey(c.UserContext(), &cuentaId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(apiKey, "API key generada correctamente, guárdela porque no se volverá a mostrar"))
+}
+
+func (cs CuentaServicioHandler) RevocarApiKey(c *fiber.Ctx) error {
+	cuentaId, err := c.ParamsInt("cuentaId", 0)
+	if err != nil || cuentaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la cuenta de servicio debe ser un número válido mayor a 0"))
+	}
+	apiKeyId, err := c.ParamsInt("apiKeyId", 0)
+	if err != nil || apiKeyId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la API key debe ser un número válido mayor a 0"))
+	}
+	err = cs.cuentaServicioService.RevocarApiKey(c.UserContext(), &cuentaId, &apiKeyId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("API key revocada correctamente"))
+}
+
+func NewCuentaServicioHandler(cuentaServicioService port.CuentaServicioService) *CuentaServicioHandler {
+	return &CuentaServicioHandler{cuentaServicioService: cuentaServicioService}
+}
+
+var _ port.CuentaServicioHandler = (*CuentaServicioHandler)(nil)
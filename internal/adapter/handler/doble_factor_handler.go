@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DobleFactorHandler struct {
+	dobleFactorService port.DobleFactorService
+}
+
+func (d DobleFactorHandler) ObtenerEstado(c *fiber.Ctx) error {
+	estado, err := d.dobleFactorService.ObtenerEstado(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(estado)
+}
+
+func (d DobleFactorHandler) IniciarConfiguracion(c *fiber.Ctx) error {
+	configuracion, err := d.dobleFactorService.IniciarConfiguracion(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(configuracion)
+}
+
+func (d DobleFactorHandler) ActivarDobleFactor(c *fiber.Ctx) error {
+	var request domain.DobleFactorCodigoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	codigos, err := d.dobleFactorService.ActivarDobleFactor(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessageData(codigos, "Verificación en dos pasos activada correctamente"))
+}
+
+func (d DobleFactorHandler) DesactivarDobleFactor(c *fiber.Ctx) error {
+	var request domain.DobleFactorCodigoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err := d.dobleFactorService.DesactivarDobleFactor(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Verificación en dos pasos desactivada correctamente"))
+}
+
+func (d DobleFactorHandler) RegenerarCodigosRecuperacion(c *fiber.Ctx) error {
+	var request domain.DobleFactorCodigoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	codigos, err := d.dobleFactorService.RegenerarCodigosRecuperacion(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessageData(codigos, "Códigos de recuperación generados correctamente"))
+}
+
+func (d DobleFactorHandler) RestablecerDobleFactor(c *fiber.Ctx) error {
+	usuarioId, err := c.ParamsInt("usuarioId", 0)
+	if err != nil || usuarioId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del usuario debe ser un número válido mayor a 0"))
+	}
+	err = d.dobleFactorService.RestablecerDobleFactor(c.UserContext(), &usuarioId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Verificación en dos pasos restablecida correctamente"))
+}
+
+func NewDobleFactorHandler(dobleFactorService port.DobleFactorService) *DobleFactorHandler {
+	return &DobleFactorHandler{dobleFactorService: dobleFactorService}
+}
+
+var _ port.DobleFactorHandler = (*DobleFactorHandler)(nil)
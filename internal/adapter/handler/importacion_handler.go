@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportacionHandler struct {
+	importacionService port.ImportacionService
+}
+
+func (i ImportacionHandler) ImportarProductos(c *fiber.Ctx) error {
+	archivo, err := c.FormFile("archivo")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El archivo es obligatorio"))
+	}
+	// Con dryRun se valida el archivo completo sin guardar cambios
+	dryRun := c.QueryBool("dryRun", false)
+	resultado, err := i.importacionService.ImportarProductos(c.UserContext(), archivo, dryRun)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	if len(resultado.Errores) > 0 {
+		return c.Status(http.StatusUnprocessableEntity).JSON(util.NewMessageData(resultado, "El archivo tiene errores, no se importó ningún producto"))
+	}
+	if !resultado.Aplicado {
+		return c.JSON(util.NewMessageData(resultado, "El archivo es válido, no se guardaron cambios"))
+	}
+	return c.JSON(util.NewMessageData(resultado, "Productos importados correctamente"))
+}
+
+func (i ImportacionHandler) ImportarStockInicial(c *fiber.Ctx) error {
+	archivo, err := c.FormFile("archivo")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El archivo es obligatorio"))
+	}
+	dryRun := c.QueryBool("dryRun", false)
+	resultado, err := i.importacionService.ImportarStockInicial(c.UserContext(), archivo, dryRun)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	if len(resultado.Errores) > 0 {
+		return c.Status(http.StatusUnprocessableEntity).JSON(util.NewMessageData(resultado, "El archivo tiene errores, no se cargó ningún lote"))
+	}
+	if !resultado.Aplicado {
+		return c.JSON(util.NewMessageData(resultado, "El archivo es válido, no se guardaron cambios"))
+	}
+	return c.JSON(util.NewMessageData(resultado, "Inventario inicial cargado correctamente"))
+}
+
+func (i ImportacionHandler) ExportarProductos(c *fiber.Ctx) error {
+	formato := strings.ToLower(c.Query("formato", util.FormatoXLSX))
+	data, contentType, err := i.importacionService.ExportarProductos(c.UserContext(), formato)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	c.Set("Content-Type", contentType)
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="productos.%s"`, formato))
+	return c.Send(data)
+}
+
+func NewImportacionHandler(importacionService port.ImportacionService) *ImportacionHandler {
+	return &ImportacionHandler{importacionService: importacionService}
+}
+
+var _ port.ImportacionHandler = (*ImportacionHandler)(nil)
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InteraccionHandler struct {
+	interaccionService port.InteraccionService
+}
+
+func (i InteraccionHandler) ObtenerListaInteracciones(c *fiber.Ctx) error {
+	list, err := i.interaccionService.ObtenerListaInteracciones(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (i InteraccionHandler) ObtenerInteraccionById(c *fiber.Ctx) error {
+	interaccionId, err := c.ParamsInt("interaccionId", 0)
+	if err != nil || interaccionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la interacción debe ser un número válido mayor a 0"))
+	}
+	interaccion, err := i.interaccionService.ObtenerInteraccionById(c.UserContext(), &interaccionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(interaccion)
+}
+
+func (i InteraccionHandler) RegistrarInteraccion(c *fiber.Ctx) error {
+	var request domain.InteraccionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	id, err := i.interaccionService.RegistrarInteraccion(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.InteraccionId{Id: *id}, "Interacción registrada correctamente"))
+}
+
+func (i InteraccionHandler) ModificarInteraccion(c *fiber.Ctx) error {
+	interaccionId, err := c.ParamsInt("interaccionId", 0)
+	if err != nil || interaccionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la interacción debe ser un número válido mayor a 0"))
+	}
+	var request domain.InteraccionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = i.interaccionService.ModificarInteraccion(c.UserContext(), &interaccionId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Interacción modificada correctamente"))
+}
+
+func (i InteraccionHandler) EliminarInteraccion(c *fiber.Ctx) error {
+	interaccionId, err := c.ParamsInt("interaccionId", 0)
+	if err != nil || interaccionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la interacción debe ser un número válido mayor a 0"))
+	}
+	err = i.interaccionService.EliminarInteraccion(c.UserContext(), &interaccionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Interacción eliminada correctamente"))
+}
+
+func NewInteraccionHandler(interaccionService port.InteraccionService) *InteraccionHandler {
+	return &InteraccionHandler{interaccionService: interaccionService}
+}
+
+var _ port.InteraccionHandler = (*InteraccionHandler)(nil)
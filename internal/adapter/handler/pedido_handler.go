@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/service"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PedidoHandler struct {
+	pedidoService port.PedidoService
+}
+
+func (p PedidoHandler) ObtenerCarrito(c *fiber.Ctx) error {
+	carrito, err := p.pedidoService.ObtenerCarrito(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(carrito)
+}
+
+func (p PedidoHandler) GuardarItemCarrito(c *fiber.Ctx) error {
+	var request domain.CarritoItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err := p.pedidoService.GuardarItemCarrito(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Carrito actualizado correctamente"))
+}
+
+func (p PedidoHandler) VaciarCarrito(c *fiber.Ctx) error {
+	err := p.pedidoService.VaciarCarrito(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Carrito vaciado correctamente"))
+}
+
+func (p PedidoHandler) RegistrarPedido(c *fiber.Ctx) error {
+	var request domain.PedidoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+
+	// Correo del usuario para contactarlo sobre el pedido
+	if userId, ok := c.UserContext().Value(util.ContextUserIdKey).(string); ok {
+		fb := service.GetFirebaseClient()
+		user, err := fb.AuthClient.GetUser(context.Background(), userId)
+		if err != nil {
+			log.Println("Error al verificar uid de usuario", err)
+			return c.Status(http.StatusInternalServerError).JSON(util.NewMessage("Error al obtener los datos del usuario"))
+		}
+		request.Email = user.Email
+	}
+
+	pedidoId, err := p.pedidoService.RegistrarPedido(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.PedidoId{Id: *pedidoId}, "Pedido registrado correctamente"))
+}
+
+func (p PedidoHandler) ObtenerMisPedidos(c *fiber.Ctx) error {
+	list, err := p.pedidoService.ObtenerMisPedidos(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PedidoHandler) ObtenerMiPedidoById(c *fiber.Ctx) error {
+	pedidoId, err := c.ParamsInt("pedidoId", 0)
+	if err != nil || pedidoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del pedido debe ser un número válido mayor a 0"))
+	}
+	pedido, err := p.pedidoService.ObtenerMiPedidoById(c.UserContext(), &pedidoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(pedido)
+}
+
+func (p PedidoHandler) CancelarMiPedido(c *fiber.Ctx) error {
+	pedidoId, err := c.ParamsInt("pedidoId", 0)
+	if err != nil || pedidoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del pedido debe ser un número válido mayor a 0"))
+	}
+	err = p.pedidoService.CancelarMiPedido(c.UserContext(), &pedidoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Pedido cancelado correctamente"))
+}
+
+func (p PedidoHandler) ObtenerListaPedidos(c *fiber.Ctx) error {
+	list, err := p.pedidoService.ObtenerListaPedidos(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PedidoHandler) ObtenerPedidoById(c *fiber.Ctx) error {
+	pedidoId, err := c.ParamsInt("pedidoId", 0)
+	if err != nil || pedidoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del pedido debe ser un número válido mayor a 0"))
+	}
+	pedido, err := p.pedidoService.ObtenerPedidoById(c.UserContext(), &pedidoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(pedido)
+}
+
+func (p PedidoHandler) ActualizarEstadoPedido(c *fiber.Ctx) error {
+	pedidoId, err := c.ParamsInt("pedidoId", 0)
+	if err != nil || pedidoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del pedido debe ser un número válido mayor a 0"))
+	}
+	var request domain.PedidoEstadoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = p.pedidoService.ActualizarEstadoPedido(c.UserContext(), &pedidoId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Estado del pedido actualizado correctamente"))
+}
+
+func (p PedidoHandler) EntregarPedido(c *fiber.Ctx) error {
+	pedidoId, err := c.ParamsInt("pedidoId", 0)
+	if err != nil || pedidoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del pedido debe ser un número válido mayor a 0"))
+	}
+	var request domain.EntregarPedidoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	ventaId, err := p.pedidoService.EntregarPedido(c.UserContext(), &pedidoId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessageData(domain.VentaResponse{VentaId: *ventaId}, "Pedido entregado y venta registrada correctamente"))
+}
+
+func NewPedidoHandler(pedidoService port.PedidoService) *PedidoHandler {
+	return &PedidoHandler{pedidoService: pedidoService}
+}
+
+var _ port.PedidoHandler = (*PedidoHandler)(nil)
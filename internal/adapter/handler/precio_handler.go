@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PrecioHandler struct {
+	precioService port.PrecioService
+}
+
+func (p PrecioHandler) ObtenerHistorialPrecios(c *fiber.Ctx) error {
+	productoId, err := uuid.Parse(c.Params("productoId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Formato de id no válido"))
+	}
+	list, err := p.precioService.ObtenerHistorialPrecios(c.UserContext(), &productoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PrecioHandler) ObtenerListaPreciosProgramados(c *fiber.Ctx) error {
+	list, err := p.precioService.ObtenerListaPreciosProgramados(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PrecioHandler) ProgramarPrecio(c *fiber.Ctx) error {
+	var request domain.PrecioProgramadoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	id, err := p.precioService.ProgramarPrecio(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.PrecioProgramadoId{Id: *id}, "Precio programado correctamente"))
+}
+
+func (p PrecioHandler) AprobarPrecioProgramado(c *fiber.Ctx) error {
+	precioProgramadoId, err := c.ParamsInt("precioProgramadoId", 0)
+	if err != nil || precioProgramadoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del precio programado debe ser un número válido mayor a 0"))
+	}
+	var request domain.AprobarPrecioRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+		}
+	}
+	err = p.precioService.AprobarPrecioProgramado(c.UserContext(), &precioProgramadoId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Precio aprobado correctamente"))
+}
+
+func (p PrecioHandler) CancelarPrecioProgramado(c *fiber.Ctx) error {
+	precioProgramadoId, err := c.ParamsInt("precioProgramadoId", 0)
+	if err != nil || precioProgramadoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del precio programado debe ser un número válido mayor a 0"))
+	}
+	err = p.precioService.CancelarPrecioProgramado(c.UserContext(), &precioProgramadoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Precio programado cancelado correctamente"))
+}
+
+func (p PrecioHandler) PrevisualizarCambioMasivo(c *fiber.Ctx) error {
+	var request domain.CambioMasivoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	list, err := p.precioService.PrevisualizarCambioMasivo(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PrecioHandler) AplicarCambioMasivo(c *fiber.Ctx) error {
+	var request domain.CambioMasivoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	id, err := p.precioService.AplicarCambioMasivo(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.CambioMasivoId{Id: *id}, "Cambio masivo de precios aplicado correctamente"))
+}
+
+func (p PrecioHandler) ObtenerListaCambiosMasivos(c *fiber.Ctx) error {
+	list, err := p.precioService.ObtenerListaCambiosMasivos(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PrecioHandler) ObtenerCambioMasivoById(c *fiber.Ctx) error {
+	cambioMasivoId, err := c.ParamsInt("cambioMasivoId", 0)
+	if err != nil || cambioMasivoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del cambio masivo debe ser un número válido mayor a 0"))
+	}
+	detail, err := p.precioService.ObtenerCambioMasivoById(c.UserContext(), &cambioMasivoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(detail)
+}
+
+func (p PrecioHandler) RevertirCambioMasivo(c *fiber.Ctx) error {
+	cambioMasivoId, err := c.ParamsInt("cambioMasivoId", 0)
+	if err != nil || cambioMasivoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del cambio masivo debe ser un número válido mayor a 0"))
+	}
+	resultado, err := p.precioService.RevertirCambioMasivo(c.UserContext(), &cambioMasivoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessageData(resultado, "Cambio masivo de precios revertido correctamente"))
+}
+
+func NewPrecioHandler(precioService port.PrecioService) *PrecioHandler {
+	return &PrecioHandler{precioService: precioService}
+}
+
+var _ port.PrecioHandler = (*PrecioHandler)(nil)
@@ -8,6 +8,7 @@ import (
 	"farma-santi_backend/internal/core/util"
 	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
@@ -212,6 +213,41 @@ func (p ProductoHandler) RegistrarProducto(c *fiber.Ctx) error {
 	return c.Status(http.StatusCreated).JSON(util.NewMessage("Producto registrado correctamente"))
 }
 
+func (p ProductoHandler) ObtenerEquivalentesProducto(c *fiber.Ctx) error {
+	productoId, err := uuid.Parse(c.Params("productoId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(util.NewMessage("Formato de id no válido"))
+	}
+	list, err := p.productoService.ObtenerEquivalentesProducto(c.UserContext(), &productoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return c.JSON(&list)
+}
+
+func (p ProductoHandler) EscanearCodigo(c *fiber.Ctx) error {
+	// Los códigos GS1 pueden incluir paréntesis y separadores codificados en la URL
+	codigo, err := url.PathUnescape(c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(util.NewMessage("Formato de código no válido"))
+	}
+	resultado, err := p.productoService.EscanearCodigo(c.UserContext(), codigo)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return c.JSON(&resultado)
+}
+
 func NewProductoHandler(productoService port.ProductoService) *ProductoHandler {
 	return &ProductoHandler{productoService}
 }
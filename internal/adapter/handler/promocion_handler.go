@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromocionHandler struct {
+	promocionService port.PromocionService
+}
+
+func (p PromocionHandler) ObtenerListaPromociones(c *fiber.Ctx) error {
+	list, err := p.promocionService.ObtenerListaPromociones(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (p PromocionHandler) ObtenerPromocionById(c *fiber.Ctx) error {
+	promocionId, err := c.ParamsInt("promocionId", 0)
+	if err != nil || promocionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la promoción debe ser un número válido mayor a 0"))
+	}
+	promocion, err := p.promocionService.ObtenerPromocionById(c.UserContext(), &promocionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(promocion)
+}
+
+func (p PromocionHandler) RegistrarPromocion(c *fiber.Ctx) error {
+	var request domain.PromocionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	promocionId, err := p.promocionService.RegistrarPromocion(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.PromocionId{Id: *promocionId}, "Promoción registrada correctamente"))
+}
+
+func (p PromocionHandler) ModificarPromocion(c *fiber.Ctx) error {
+	promocionId, err := c.ParamsInt("promocionId", 0)
+	if err != nil || promocionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la promoción debe ser un número válido mayor a 0"))
+	}
+	var request domain.PromocionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = p.promocionService.ModificarPromocion(c.UserContext(), &promocionId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Promoción modificada correctamente"))
+}
+
+func (p PromocionHandler) HabilitarPromocion(c *fiber.Ctx) error {
+	promocionId, err := c.ParamsInt("promocionId", 0)
+	if err != nil || promocionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la promoción debe ser un número válido mayor a 0"))
+	}
+	err = p.promocionService.HabilitarPromocion(c.UserContext(), &promocionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Promoción habilitada correctamente"))
+}
+
+func (p PromocionHandler) DeshabilitarPromocion(c *fiber.Ctx) error {
+	promocionId, err := c.ParamsInt("promocionId", 0)
+	if err != nil || promocionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la promoción debe ser un número válido mayor a 0"))
+	}
+	err = p.promocionService.DeshabilitarPromocion(c.UserContext(), &promocionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Promoción deshabilitada correctamente"))
+}
+
+func (p PromocionHandler) ObtenerEfectividadPromociones(c *fiber.Ctx) error {
+	list, err := p.promocionService.ObtenerEfectividadPromociones(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func NewPromocionHandler(promocionService port.PromocionService) *PromocionHandler {
+	return &PromocionHandler{promocionService: promocionService}
+}
+
+var _ port.PromocionHandler = (*PromocionHandler)(nil)
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RecetaHandler struct {
+	recetaService port.RecetaService
+}
+
+func (r RecetaHandler) ObtenerListaRecetas(c *fiber.Ctx) error {
+	list, err := r.recetaService.ObtenerListaRecetas(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (r RecetaHandler) ObtenerRecetaById(c *fiber.Ctx) error {
+	recetaId, err := c.ParamsInt("recetaId", 0)
+	if err != nil || recetaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la receta debe ser un número válido mayor a 0"))
+	}
+	receta, err := r.recetaService.ObtenerRecetaById(c.UserContext(), &recetaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(receta)
+}
+
+func (r RecetaHandler) ObtenerImagenReceta(c *fiber.Ctx) error {
+	recetaId, err := c.ParamsInt("recetaId", 0)
+	if err != nil || recetaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la receta debe ser un número válido mayor a 0"))
+	}
+	ruta, err := r.recetaService.ObtenerImagenReceta(c.UserContext(), &recetaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	if err := c.SendFile(ruta); err != nil {
+		log.Println("Error al enviar imagen de receta:", err)
+		return c.Status(http.StatusNotFound).JSON(util.NewMessage("Imagen de receta no encontrada"))
+	}
+	return nil
+}
+
+func (r RecetaHandler) RegistrarReceta(c *fiber.Ctx) error {
+	var request domain.RecetaRequest
+	if err := json.Unmarshal([]byte(c.FormValue("body")), &request); err != nil {
+		log.Println("Error al deserializar body:", err)
+		return c.Status(fiber.StatusBadRequest).JSON(util.NewMessage("Error al leer el formulario"))
+	}
+
+	// La imagen de la receta es opcional
+	imagen, err := c.FormFile("imagen")
+	if err != nil {
+		imagen = nil
+	}
+
+	recetaId, err := r.recetaService.RegistrarReceta(c.UserContext(), &request, imagen)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.RecetaId{Id: *recetaId}, "Receta registrada correctamente"))
+}
+
+func (r RecetaHandler) ObtenerRegistroControlados(c *fiber.Ctx) error {
+	list, err := r.recetaService.ObtenerRegistroControlados(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func NewRecetaHandler(recetaService port.RecetaService) *RecetaHandler {
+	return &RecetaHandler{recetaService: recetaService}
+}
+
+var _ port.RecetaHandler = (*RecetaHandler)(nil)
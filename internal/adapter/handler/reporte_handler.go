@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"farma-santi_backend/internal/core/domain"
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
 	"farma-santi_backend/internal/core/util"
@@ -189,6 +190,170 @@ func (r ReporteHandler) ReporteLotesProductosPDF(c *fiber.Ctx) error {
 	return c.Send(doc.GetBytes())
 }
 
+func (r ReporteHandler) ReportePromocionesPDF(c *fiber.Ctx) error {
+	doc, err := r.reporteService.ReportePromocionesPDF(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", "inline; filename=reporte-promociones.pdf")
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReporteControladosPDF(c *fiber.Ctx) error {
+	doc, err := r.reporteService.ReporteControladosPDF(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", "inline; filename=registro-controlados.pdf")
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReporteEtiquetasPDF(c *fiber.Ctx) error {
+	var request domain.EtiquetaRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	doc, err := r.reporteService.ReporteEtiquetasPDF(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", "inline; filename=etiquetas.pdf")
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReporteEtiquetasCompraPDF(c *fiber.Ctx) error {
+	compraId, err := c.ParamsInt("compraId", 0)
+	if err != nil || compraId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la compra debe ser un número válido mayor a 0"))
+	}
+	doc, err := r.reporteService.ReporteEtiquetasCompraPDF(c.UserContext(), &compraId, c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", fmt.Sprintf("inline; filename=etiquetas-compra-%d.pdf", compraId))
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReporteRetiroLotePDF(c *fiber.Ctx) error {
+	retiroId, err := c.ParamsInt("retiroId", 0)
+	if err != nil || retiroId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del retiro debe ser un número válido mayor a 0"))
+	}
+	doc, err := r.reporteService.ReporteRetiroLotePDF(c.UserContext(), &retiroId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", fmt.Sprintf("inline; filename=retiro-lote-%d.pdf", retiroId))
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReportePickingVentaPDF(c *fiber.Ctx) error {
+	ventaId, err := c.ParamsInt("ventaId", 0)
+	if err != nil || ventaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la venta debe ser un número válido mayor a 0"))
+	}
+	doc, err := r.reporteService.ReportePickingVentaPDF(c.UserContext(), &ventaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", fmt.Sprintf("inline; filename=picking-venta-%d.pdf", ventaId))
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReportePickingTrasladoPDF(c *fiber.Ctx) error {
+	trasladoId, err := c.ParamsInt("trasladoId", 0)
+	if err != nil || trasladoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del traslado debe ser un número válido mayor a 0"))
+	}
+	doc, err := r.reporteService.ReportePickingTrasladoPDF(c.UserContext(), &trasladoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", fmt.Sprintf("inline; filename=picking-traslado-%d.pdf", trasladoId))
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
+func (r ReporteHandler) ReporteAuditoriaPDF(c *fiber.Ctx) error {
+	doc, err := r.reporteService.ReporteAuditoriaPDF(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+
+	c.Response().Header.Set("Content-Type", "application/pdf")
+	c.Response().Header.Set("Content-Disposition", "inline; filename=reporte-auditoria.pdf")
+	c.Response().Header.Set("Content-Transfer-Encoding", "binary")
+
+	return c.Send(doc.GetBytes())
+}
+
 func NewReporteHandler(reporteService port.ReporteService) *ReporteHandler {
 	return &ReporteHandler{reporteService: reporteService}
 }
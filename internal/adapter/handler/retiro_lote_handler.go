@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RetiroLoteHandler struct {
+	retiroLoteService port.RetiroLoteService
+}
+
+func (r RetiroLoteHandler) ObtenerListaRetiros(c *fiber.Ctx) error {
+	list, err := r.retiroLoteService.ObtenerListaRetiros(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (r RetiroLoteHandler) ObtenerRetiroById(c *fiber.Ctx) error {
+	retiroId, err := c.ParamsInt("retiroId", 0)
+	if err != nil || retiroId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del retiro debe ser un número válido mayor a 0"))
+	}
+	retiro, err := r.retiroLoteService.ObtenerRetiroById(c.UserContext(), &retiroId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(retiro)
+}
+
+func (r RetiroLoteHandler) RegistrarRetiro(c *fiber.Ctx) error {
+	var request domain.RetiroLoteRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	id, err := r.retiroLoteService.RegistrarRetiro(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.RetiroLoteId{Id: *id}, "Lote retirado y puesto en cuarentena correctamente"))
+}
+
+func (r RetiroLoteHandler) ObtenerVentasLote(c *fiber.Ctx) error {
+	loteId, err := c.ParamsInt("loteProductoId", 0)
+	if err != nil || loteId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del lote debe ser un número válido mayor a 0"))
+	}
+	list, err := r.retiroLoteService.ObtenerVentasLote(c.UserContext(), &loteId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func NewRetiroLoteHandler(retiroLoteService port.RetiroLoteService) *RetiroLoteHandler {
+	return &RetiroLoteHandler{retiroLoteService: retiroLoteService}
+}
+
+var _ port.RetiroLoteHandler = (*RetiroLoteHandler)(nil)
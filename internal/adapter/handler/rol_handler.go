@@ -136,6 +136,78 @@ func (r RolHandler) ObtenerRolById(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(&rol)
 }
 
+func (r RolHandler) ListarPermisos(c *fiber.Ctx) error {
+	permisos, err := r.rolService.ListarPermisos(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(permisos)
+}
+
+func (r RolHandler) ObtenerPermisosRol(c *fiber.Ctx) error {
+	rolId, err := c.ParamsInt("rolId")
+	if err != nil || rolId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del rol debe ser un número válido mayor a 0"))
+	}
+	permisos, err := r.rolService.ObtenerPermisosRol(c.UserContext(), &rolId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(permisos)
+}
+
+func (r RolHandler) AsignarPermisosRol(c *fiber.Ctx) error {
+	rolId, err := c.ParamsInt("rolId")
+	if err != nil || rolId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del rol debe ser un número válido mayor a 0"))
+	}
+	var request domain.RolPermisosRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = r.rolService.AsignarPermisosRol(c.UserContext(), &rolId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Permisos del rol actualizados correctamente"))
+}
+
+func (r RolHandler) ModificarDobleFactorRol(c *fiber.Ctx) error {
+	rolId, err := c.ParamsInt("rolId")
+	if err != nil || rolId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del rol debe ser un número válido mayor a 0"))
+	}
+	var request domain.RolDobleFactorRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = r.rolService.ModificarDobleFactorRol(c.UserContext(), &rolId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Verificación en dos pasos del rol actualizada correctamente"))
+}
+
 func NewRolHandler(rolService port.RolService) *RolHandler {
 	return &RolHandler{rolService}
 }
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SesionHandler struct {
+	sesionService port.SesionService
+}
+
+func (s SesionHandler) ObtenerMisSesiones(c *fiber.Ctx) error {
+	list, err := s.sesionService.ObtenerMisSesiones(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (s SesionHandler) CerrarTodasLasSesiones(c *fiber.Ctx) error {
+	err := s.sesionService.CerrarTodasLasSesiones(c.UserContext())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	util.DeleteCookie(c, "access-token", true)
+	util.DeleteCookie(c, "refresh-token", true)
+	util.DeleteCookie(c, "exp-access-token", false)
+	util.DeleteCookie(c, "exp-refresh-token", false)
+	return c.JSON(util.NewMessage("Todas las sesiones fueron cerradas"))
+}
+
+func (s SesionHandler) ObtenerSesionesUsuario(c *fiber.Ctx) error {
+	usuarioId, err := c.ParamsInt("usuarioId", 0)
+	if err != nil || usuarioId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del usuario debe ser un número válido mayor a 0"))
+	}
+	list, err := s.sesionService.ObtenerSesionesUsuario(c.UserContext(), &usuarioId, c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (s SesionHandler) RevocarSesion(c *fiber.Ctx) error {
+	sesionId, err := uuid.Parse(c.Params("sesionId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Formato de id no válido"))
+	}
+	err = s.sesionService.RevocarSesion(c.UserContext(), &sesionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Sesión revocada correctamente"))
+}
+
+func (s SesionHandler) RevocarSesionesUsuario(c *fiber.Ctx) error {
+	usuarioId, err := c.ParamsInt("usuarioId", 0)
+	if err != nil || usuarioId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del usuario debe ser un número válido mayor a 0"))
+	}
+	err = s.sesionService.RevocarSesionesUsuario(c.UserContext(), &usuarioId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Sesiones del usuario revocadas correctamente"))
+}
+
+func NewSesionHandler(sesionService port.SesionService) *SesionHandler {
+	return &SesionHandler{sesionService: sesionService}
+}
+
+var _ port.SesionHandler = (*SesionHandler)(nil)
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UbicacionHandler struct {
+	ubicacionService port.UbicacionService
+}
+
+func (u UbicacionHandler) ObtenerListaUbicaciones(c *fiber.Ctx) error {
+	list, err := u.ubicacionService.ObtenerListaUbicaciones(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (u UbicacionHandler) ObtenerUbicacionById(c *fiber.Ctx) error {
+	ubicacionId, err := c.ParamsInt("ubicacionId", 0)
+	if err != nil || ubicacionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la ubicación debe ser un número válido mayor a 0"))
+	}
+	ubicacion, err := u.ubicacionService.ObtenerUbicacionById(c.UserContext(), &ubicacionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(ubicacion)
+}
+
+func (u UbicacionHandler) RegistrarUbicacion(c *fiber.Ctx) error {
+	var request domain.UbicacionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	id, err := u.ubicacionService.RegistrarUbicacion(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.UbicacionId{Id: *id}, "Ubicación registrada correctamente"))
+}
+
+func (u UbicacionHandler) ModificarUbicacion(c *fiber.Ctx) error {
+	ubicacionId, err := c.ParamsInt("ubicacionId", 0)
+	if err != nil || ubicacionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la ubicación debe ser un número válido mayor a 0"))
+	}
+	var request domain.UbicacionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = u.ubicacionService.ModificarUbicacion(c.UserContext(), &ubicacionId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Ubicación modificada correctamente"))
+}
+
+func (u UbicacionHandler) EliminarUbicacion(c *fiber.Ctx) error {
+	ubicacionId, err := c.ParamsInt("ubicacionId", 0)
+	if err != nil || ubicacionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la ubicación debe ser un número válido mayor a 0"))
+	}
+	err = u.ubicacionService.EliminarUbicacion(c.UserContext(), &ubicacionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Ubicación eliminada correctamente"))
+}
+
+func (u UbicacionHandler) ObtenerStockUbicacion(c *fiber.Ctx) error {
+	ubicacionId, err := c.ParamsInt("ubicacionId", 0)
+	if err != nil || ubicacionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de la ubicación debe ser un número válido mayor a 0"))
+	}
+	list, err := u.ubicacionService.ObtenerStockUbicacion(c.UserContext(), &ubicacionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (u UbicacionHandler) ObtenerListaTraslados(c *fiber.Ctx) error {
+	list, err := u.ubicacionService.ObtenerListaTraslados(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(list)
+}
+
+func (u UbicacionHandler) ObtenerTrasladoById(c *fiber.Ctx) error {
+	trasladoId, err := c.ParamsInt("trasladoId", 0)
+	if err != nil || trasladoId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' del traslado debe ser un número válido mayor a 0"))
+	}
+	traslado, err := u.ubicacionService.ObtenerTrasladoById(c.UserContext(), &trasladoId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(traslado)
+}
+
+func (u UbicacionHandler) RegistrarTraslado(c *fiber.Ctx) error {
+	var request domain.TrasladoRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	id, err := u.ubicacionService.RegistrarTraslado(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.TrasladoId{Id: *id}, "Traslado registrado correctamente"))
+}
+
+func NewUbicacionHandler(ubicacionService port.UbicacionService) *UbicacionHandler {
+	return &UbicacionHandler{ubicacionService: ubicacionService}
+}
+
+var _ port.UbicacionHandler = (*UbicacionHandler)(nil)
@@ -170,6 +170,27 @@ func (u UsuarioHandler) RegistrarUsuario(c *fiber.Ctx) error {
 	return c.Status(http.StatusCreated).JSON(util.NewMessageData(usuarioDetalle, "Usuario creado correctamente"))
 }
 
+func (u UsuarioHandler) CambiarPassword(c *fiber.Ctx) error {
+	var request domain.CambiarPasswordRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err := u.usuarioService.CambiarPassword(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Contraseña actualizada correctamente"))
+}
+
+func (u UsuarioHandler) ObtenerPoliticaPassword(c *fiber.Ctx) error {
+	return c.JSON(u.usuarioService.ObtenerPoliticaPassword())
+}
+
 func NewUsuarioHandler(usuarioService port.UsuarioService) UsuarioHandler {
 	return UsuarioHandler{usuarioService}
 }
@@ -1,12 +1,10 @@
 package handler
 
 import (
-	"context"
 	"errors"
 	"farma-santi_backend/internal/core/domain"
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
-	"farma-santi_backend/internal/core/service"
 	"farma-santi_backend/internal/core/util"
 	"log"
 	"net/http"
@@ -24,20 +22,7 @@ func (v VentaHandler) ObtenerVentaByIdShared(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de venta debe ser un número válido mayor a 0"))
 	}
 
-	val := c.UserContext().Value(util.ContextUserIdKey)
-	userId, ok := val.(string)
-	if !ok {
-		return datatype.NewBadRequestError("UID de usuario inválido o no encontrado en el contexto")
-	}
-	fb := service.GetFirebaseClient()
-	token, err := fb.AuthClient.GetUser(context.Background(), userId)
-	if err != nil {
-		log.Println("Error al verificar uid de usuario", err)
-		return datatype.NewInternalServerErrorGeneric()
-	}
-	email := token.Email
-
-	venta, err := v.ventaService.ObtenerVentaById(c.UserContext(), &ventaId)
+	venta, err := v.ventaService.ObtenerVentaByIdShared(c.UserContext(), &ventaId)
 	if err != nil {
 		log.Print(err.Error())
 		var errorResponse *datatype.ErrorResponse
@@ -46,30 +31,11 @@ func (v VentaHandler) ObtenerVentaByIdShared(c *fiber.Ctx) error {
 		}
 		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
 	}
-	if venta.Cliente.Email != email {
-		return c.Status(http.StatusNotFound).JSON("Compra no encontrada")
-	}
 	return c.JSON(&venta)
 }
 
 func (v VentaHandler) ObtenerListaVentasShared(c *fiber.Ctx) error {
-	val := c.UserContext().Value(util.ContextUserIdKey)
-	userId, ok := val.(string)
-	if !ok {
-		return datatype.NewBadRequestError("UID de usuario inválido o no encontrado en el contexto")
-	}
-	fb := service.GetFirebaseClient()
-	token, err := fb.AuthClient.GetUser(context.Background(), userId)
-	if err != nil {
-		log.Println("Error al verificar uid de usuario", err)
-		return datatype.NewInternalServerErrorGeneric()
-	}
-	email := token.Email
-	filtros := map[string]string{
-		"email":  email,
-		"estado": "Realizada",
-	}
-	list, err := v.ventaService.ObtenerListaVentas(c.UserContext(), filtros)
+	list, err := v.ventaService.ObtenerListaVentasShared(c.UserContext())
 	if err != nil {
 		log.Print(err.Error())
 		var errorResponse *datatype.ErrorResponse
@@ -105,15 +71,18 @@ func (v VentaHandler) RegistrarVenta(c *fiber.Ctx) error {
 		log.Print(err.Error())
 		var errorResponse *datatype.ErrorResponse
 		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
 
 		if errors.As(err, &errorResponse) {
 			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
 		} else if errors.As(err, &errorDataResponse) {
 			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
 		}
 		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
 	}
-	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.VentaResponse{VentaId: *ventaId}, "Venta registrada correctamente"))
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.VentaResponse{VentaId: *ventaId, Advertencias: venta.Advertencias}, "Venta registrada correctamente"))
 }
 
 func (v VentaHandler) ObtenerVentaById(c *fiber.Ctx) error {
@@ -167,6 +136,164 @@ func (v VentaHandler) FacturarVentaById(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(util.NewMessage("Venta anulada correctamente"))
 }
 
+func (v VentaHandler) CotizarVenta(c *fiber.Ctx) error {
+	var request domain.CotizacionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	cotizacion, err := v.ventaService.CotizarVenta(c.UserContext(), &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(&cotizacion)
+}
+
+func (v VentaHandler) ObtenerListaCotizaciones(c *fiber.Ctx) error {
+	list, err := v.ventaService.ObtenerListaCotizaciones(c.UserContext(), c.Queries())
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(&list)
+}
+
+func (v VentaHandler) ObtenerCotizacionById(c *fiber.Ctx) error {
+	cotizacionId, err := c.ParamsInt("cotizacionId")
+	if err != nil || cotizacionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de cotización debe ser un número válido mayor a 0"))
+	}
+	cotizacion, err := v.ventaService.ObtenerCotizacionById(c.UserContext(), &cotizacionId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(&cotizacion)
+}
+
+func (v VentaHandler) ConvertirCotizacion(c *fiber.Ctx) error {
+	cotizacionId, err := c.ParamsInt("cotizacionId")
+	if err != nil || cotizacionId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de cotización debe ser un número válido mayor a 0"))
+	}
+	var request domain.ConvertirCotizacionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	ventaId, err := v.ventaService.ConvertirCotizacion(c.UserContext(), &cotizacionId, &request)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.VentaResponse{VentaId: *ventaId}, "Venta registrada correctamente"))
+}
+
+func (v VentaHandler) RegistrarVentaEnEspera(c *fiber.Ctx) error {
+	var venta domain.VentaRequest
+	if err := c.BodyParser(&venta); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	ventaId, err := v.ventaService.RegistrarVentaEnEspera(c.UserContext(), &venta)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.Status(http.StatusCreated).JSON(util.NewMessageData(domain.VentaResponse{VentaId: *ventaId, Advertencias: venta.Advertencias}, "Venta puesta en espera correctamente"))
+}
+
+func (v VentaHandler) ModificarVentaEnEspera(c *fiber.Ctx) error {
+	ventaId, err := c.ParamsInt("ventaId")
+	if err != nil || ventaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de venta debe ser un número válido mayor a 0"))
+	}
+	var venta domain.VentaRequest
+	if err := c.BodyParser(&venta); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("Petición inválida: datos incompletos o incorrectos"))
+	}
+	err = v.ventaService.ModificarVentaEnEspera(c.UserContext(), &ventaId, &venta)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessage("Venta en espera modificada correctamente"))
+}
+
+func (v VentaHandler) FinalizarVentaEnEspera(c *fiber.Ctx) error {
+	ventaId, err := c.ParamsInt("ventaId")
+	if err != nil || ventaId <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(util.NewMessage("El 'id' de venta debe ser un número válido mayor a 0"))
+	}
+	id, err := v.ventaService.FinalizarVentaEnEspera(c.UserContext(), &ventaId)
+	if err != nil {
+		log.Print(err.Error())
+		var errorResponse *datatype.ErrorResponse
+		var errorDataResponse *datatype.ErrorDataResponse[domain.ProductoId]
+		var errorAdvertencias *datatype.ErrorDataResponse[[]domain.Advertencia]
+
+		if errors.As(err, &errorResponse) {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
+		} else if errors.As(err, &errorDataResponse) {
+			return c.Status(errorDataResponse.Code).JSON(&errorDataResponse)
+		} else if errors.As(err, &errorAdvertencias) {
+			return c.Status(errorAdvertencias.Code).JSON(&errorAdvertencias)
+		}
+		return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+	}
+	return c.JSON(util.NewMessageData(domain.VentaResponse{VentaId: *id}, "Venta registrada correctamente"))
+}
+
 func NewVentaHandler(ventaService port.VentaService) *VentaHandler {
 	return &VentaHandler{ventaService: ventaService}
 }
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AccesoRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (a AccesoRepository) RegistrarIntentoLogin(ctx context.Context, intento *domain.IntentoLogin) error {
+	_, err := a.pool.Exec(ctx, `
+        INSERT INTO intento_login (username, usuario_id, exitoso, motivo, ip, user_agent)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, intento.Username, intento.UsuarioId, intento.Exitoso, intento.Motivo, intento.Ip, intento.UserAgent)
+	if err != nil {
+		log.Println("Error al registrar intento de inicio de sesión:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// RegistrarFalloLogin suma un intento fallido; desde maxIntentos la cuenta se bloquea y cada fallo
+// posterior duplica el bloqueo hasta el máximo indicado
+func (a AccesoRepository) RegistrarFalloLogin(ctx context.Context, usuarioId int, maxIntentos int, bloqueo, bloqueoMaximo time.Duration) (int, *time.Time, error) {
+	var intentos int
+	var bloqueadoHasta *time.Time
+	err := a.pool.QueryRow(ctx, `
+        UPDATE usuario
+        SET intentos_fallidos = intentos_fallidos + 1,
+            bloqueado_hasta   = CASE
+                                    WHEN intentos_fallidos + 1 >= $2 THEN NOW() + make_interval(
+                                            secs => LEAST($3 * POWER(2, LEAST(intentos_fallidos + 1 - $2, 20)), $4))
+                                    ELSE bloqueado_hasta END
+        WHERE id = $1
+        RETURNING intentos_fallidos, bloqueado_hasta
+    `, usuarioId, maxIntentos, bloqueo.Seconds(), bloqueoMaximo.Seconds()).Scan(&intentos, &bloqueadoHasta)
+	if err != nil {
+		log.Println("Error al registrar intento fallido:", err)
+		return 0, nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return intentos, bloqueadoHasta, nil
+}
+
+func (a AccesoRepository) ReiniciarIntentosLogin(ctx context.Context, usuarioId int) error {
+	_, err := a.pool.Exec(ctx, `
+        UPDATE usuario SET intentos_fallidos = 0, bloqueado_hasta = NULL
+        WHERE id = $1 AND (intentos_fallidos > 0 OR bloqueado_hasta IS NOT NULL)
+    `, usuarioId)
+	if err != nil {
+		log.Println("Error al reiniciar intentos fallidos:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (a AccesoRepository) DesbloquearUsuario(ctx context.Context, usuarioId *int) error {
+	tx, err := a.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var intentos int
+	var bloqueadoHasta *time.Time
+	err = tx.QueryRow(ctx, `SELECT intentos_fallidos, bloqueado_hasta FROM usuario WHERE id = $1 FOR UPDATE`, *usuarioId).Scan(&intentos, &bloqueadoHasta)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Usuario no encontrado")
+		}
+		log.Println("Error al obtener usuario:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if intentos == 0 && (bloqueadoHasta == nil || bloqueadoHasta.Before(time.Now())) {
+		return datatype.NewConflictError("El usuario no está bloqueado")
+	}
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE usuario SET intentos_fallidos = 0, bloqueado_hasta = NULL WHERE id = $1`, *usuarioId)
+	if err != nil {
+		log.Println("Error al desbloquear usuario:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId, domain.AccionDesbloquear, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (a AccesoRepository) ObtenerListaIntentosLogin(ctx context.Context, filtros map[string]string) (*[]domain.IntentoLoginInfo, error) {
+	query := `
+        SELECT il.id, il.username,
+               CASE WHEN u.id IS NULL THEN NULL ELSE jsonb_build_object('id', u.id, 'username', u.username, 'estado', u.estado) END,
+               il.exitoso, il.motivo, il.ip, il.user_agent, il.created_at
+        FROM intento_login il
+        LEFT JOIN usuario u ON u.id = il.usuario_id
+    `
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	if username := filtros["username"]; username != "" {
+		filters = append(filters, fmt.Sprintf("il.username = $%d", i))
+		args = append(args, username)
+		i++
+	}
+
+	if usuarioId := filtros["usuarioId"]; usuarioId != "" {
+		filters = append(filters, fmt.Sprintf("il.usuario_id::TEXT = $%d", i))
+		args = append(args, usuarioId)
+		i++
+	}
+
+	if exitoso := filtros["exitoso"]; exitoso != "" {
+		valor, err := strconv.ParseBool(exitoso)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de exitoso debe ser true o false")
+		}
+		filters = append(filters, fmt.Sprintf("il.exitoso = $%d", i))
+		args = append(args, valor)
+		i++
+	}
+
+	if ip := filtros["ip"]; ip != "" {
+		filters = append(filters, fmt.Sprintf("il.ip = $%d", i))
+		args = append(args, ip)
+		i++
+	}
+
+	if fechaInicio := filtros["fechaInicio"]; fechaInicio != "" {
+		filters = append(filters, fmt.Sprintf("il.created_at >= $%d::DATE", i))
+		args = append(args, fechaInicio)
+		i++
+	}
+
+	if fechaFin := filtros["fechaFin"]; fechaFin != "" {
+		filters = append(filters, fmt.Sprintf("il.created_at < $%d::DATE + 1", i))
+		args = append(args, fechaFin)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY il.created_at DESC, il.id DESC"
+
+	if limitStr := filtros["limit"]; limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, datatype.NewBadRequestError("El valor de limit debe ser un número entero positivo")
+		}
+		query += fmt.Sprintf(" LIMIT $%d", i)
+		args = append(args, limit)
+		i++
+
+		if offsetStr := filtros["offset"]; offsetStr != "" {
+			offset, err := strconv.Atoi(offsetStr)
+			if err != nil || offset < 0 {
+				return nil, datatype.NewBadRequestError("El valor de offset debe ser un número entero no negativo")
+			}
+			query += fmt.Sprintf(" OFFSET $%d", i)
+			args = append(args, offset)
+			i++
+		}
+	}
+
+	rows, err := a.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar intentos de inicio de sesión:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.IntentoLoginInfo, 0)
+	for rows.Next() {
+		var item domain.IntentoLoginInfo
+		if err := rows.Scan(&item.Id, &item.Username, &item.Usuario, &item.Exitoso, &item.Motivo, &item.Ip, &item.UserAgent, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear intento de inicio de sesión:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+// ObtenerActividadSospechosa agrupa los intentos fallidos desde la fecha indicada por IP y por nombre de usuario;
+// una IP también es sospechosa si probó varios nombres de usuario
+func (a AccesoRepository) ObtenerActividadSospechosa(ctx context.Context, desde time.Time, minimoFallidos int) (*[]domain.ActividadSospechosa, error) {
+	rows, err := a.pool.Query(ctx, `
+        SELECT $3::TEXT, il.ip, COUNT(*), COUNT(DISTINCT il.username), 1, MIN(il.created_at), MAX(il.created_at), NULL::TIMESTAMPTZ
+        FROM intento_login il
+        WHERE NOT il.exitoso AND il.created_at >= $1 AND il.ip IS NOT NULL
+        GROUP BY il.ip
+        HAVING COUNT(*) >= $2 OR COUNT(DISTINCT il.username) >= 3
+        UNION ALL
+        SELECT $4::TEXT, il.username, COUNT(*), 1, COUNT(DISTINCT il.ip), MIN(il.created_at), MAX(il.created_at),
+               MAX(u.bloqueado_hasta) FILTER (WHERE u.bloqueado_hasta > NOW())
+        FROM intento_login il
+        LEFT JOIN usuario u ON u.username = il.username
+        WHERE NOT il.exitoso AND il.created_at >= $1
+        GROUP BY il.username
+        HAVING COUNT(*) >= $2
+        ORDER BY 3 DESC, 7 DESC
+    `, desde, minimoFallidos, domain.SospechosoIp, domain.SospechosoUsername)
+	if err != nil {
+		log.Println("Error al obtener actividad sospechosa:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.ActividadSospechosa, 0)
+	for rows.Next() {
+		var item domain.ActividadSospechosa
+		if err := rows.Scan(&item.Tipo, &item.Valor, &item.Fallidos, &item.UsuariosDistintos, &item.IpsDistintas,
+			&item.PrimerIntento, &item.UltimoIntento, &item.BloqueadoHasta); err != nil {
+			log.Println("Error al escanear actividad sospechosa:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func NewAccesoRepository(pool *pgxpool.Pool) *AccesoRepository {
+	return &AccesoRepository{pool: pool}
+}
+
+var _ port.AccesoRepository = (*AccesoRepository)(nil)
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditoriaRepository struct {
+	pool *pgxpool.Pool
+}
+
+const queryAuditoriaInfo = `
+SELECT a.id,
+       CASE WHEN u.id IS NULL THEN NULL ELSE jsonb_build_object('id', u.id, 'username', u.username) END AS usuario,
+       a.ip,
+       a.entidad,
+       a.entidad_id,
+       a.accion,
+       a.antes,
+       a.despues,
+       a.created_at
+FROM auditoria a
+LEFT JOIN usuario u ON u.id = a.usuario_id
+`
+
+func (a AuditoriaRepository) ObtenerListaAuditoria(ctx context.Context, filtros map[string]string) (*[]domain.AuditoriaInfo, error) {
+	query := queryAuditoriaInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	if entidad := filtros["entidad"]; entidad != "" {
+		filters = append(filters, fmt.Sprintf("a.entidad = $%d", i))
+		args = append(args, entidad)
+		i++
+	}
+
+	if entidadId := filtros["entidadId"]; entidadId != "" {
+		filters = append(filters, fmt.Sprintf("a.entidad_id = $%d", i))
+		args = append(args, entidadId)
+		i++
+	}
+
+	if accion := filtros["accion"]; accion != "" {
+		filters = append(filters, fmt.Sprintf("a.accion = $%d", i))
+		args = append(args, accion)
+		i++
+	}
+
+	if usuarioId := filtros["usuarioId"]; usuarioId != "" {
+		filters = append(filters, fmt.Sprintf("a.usuario_id::TEXT = $%d", i))
+		args = append(args, usuarioId)
+		i++
+	}
+
+	if fechaInicio := filtros["fechaInicio"]; fechaInicio != "" {
+		filters = append(filters, fmt.Sprintf("a.created_at >= $%d::DATE", i))
+		args = append(args, fechaInicio)
+		i++
+	}
+
+	if fechaFin := filtros["fechaFin"]; fechaFin != "" {
+		filters = append(filters, fmt.Sprintf("a.created_at < $%d::DATE + 1", i))
+		args = append(args, fechaFin)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY a.created_at DESC, a.id DESC"
+
+	if limitStr := filtros["limit"]; limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, datatype.NewBadRequestError("El valor de limit debe ser un número entero positivo")
+		}
+		query += fmt.Sprintf(" LIMIT $%d", i)
+		args = append(args, limit)
+		i++
+
+		if offsetStr := filtros["offset"]; offsetStr != "" {
+			offset, err := strconv.Atoi(offsetStr)
+			if err != nil || offset < 0 {
+				return nil, datatype.NewBadRequestError("El valor de offset debe ser un número entero no negativo")
+			}
+			query += fmt.Sprintf(" OFFSET $%d", i)
+			args = append(args, offset)
+		}
+	}
+
+	rows, err := a.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar auditoría:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.AuditoriaInfo, 0)
+	for rows.Next() {
+		var item domain.AuditoriaInfo
+		if err := rows.Scan(&item.Id, &item.Usuario, &item.Ip, &item.Entidad, &item.EntidadId, &item.Accion,
+			&item.Antes, &item.Despues, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear auditoría:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (a AuditoriaRepository) ObtenerAuditoriaById(ctx context.Context, id *int64) (*domain.AuditoriaInfo, error) {
+	var item domain.AuditoriaInfo
+	err := a.pool.QueryRow(ctx, queryAuditoriaInfo+` WHERE a.id = $1`, *id).
+		Scan(&item.Id, &item.Usuario, &item.Ip, &item.Entidad, &item.EntidadId, &item.Accion,
+			&item.Antes, &item.Despues, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Registro de auditoría no encontrado")
+		}
+		log.Println("Error al obtener auditoría:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &item, nil
+}
+
+// consultasAuditoria obtienen el estado de cada entidad auditada como JSON, sin datos sensibles
+var consultasAuditoria = map[string]string{
+	domain.AuditoriaProducto: `
+        SELECT to_jsonb(p)
+                   || jsonb_build_object(
+                       'categorias', (SELECT COALESCE(jsonb_agg(pc.categoria_id ORDER BY pc.categoria_id), '[]')
+                                      FROM producto_categoria pc WHERE pc.producto_id = p.id),
+                       'principiosActivos', (SELECT COALESCE(jsonb_agg(to_jsonb(ppa) - 'producto_id' ORDER BY ppa.principio_activo_id), '[]')
+                                             FROM producto_principio_activo ppa WHERE ppa.producto_id = p.id),
+                       'codigosBarra', (SELECT COALESCE(jsonb_agg(cb.codigo ORDER BY cb.id), '[]')
+                                        FROM codigo_barra cb WHERE cb.producto_id = p.id))
+        FROM producto p
+        WHERE p.id::TEXT = $1`,
+	domain.AuditoriaLoteProducto: `SELECT to_jsonb(lp) FROM lote_producto lp WHERE lp.id::TEXT = $1`,
+	domain.AuditoriaCompra: `
+        SELECT to_jsonb(c)
+                   || jsonb_build_object('detalles', (SELECT COALESCE(jsonb_agg(to_jsonb(dc) - 'compra_id' ORDER BY dc.id), '[]')
+                                                      FROM detalle_compra dc WHERE dc.compra_id = c.id))
+        FROM compra c
+        WHERE c.id::TEXT = $1`,
+	domain.AuditoriaVenta: `
+        SELECT to_jsonb(v)
+                   || jsonb_build_object('detalles', (SELECT COALESCE(jsonb_agg(to_jsonb(dv) - 'venta_id' ORDER BY dv.id), '[]')
+                                                      FROM detalle_venta dv WHERE dv.venta_id = v.id))
+        FROM venta v
+        WHERE v.id::TEXT = $1`,
+	domain.AuditoriaUsuario: `
+        SELECT (to_jsonb(u) - 'password')
+                   || jsonb_build_object(
+                       'persona', (SELECT to_jsonb(pe) FROM persona pe WHERE pe.id = u.persona_id),
+                       'roles', (SELECT COALESCE(jsonb_agg(ur.rol_id ORDER BY ur.rol_id), '[]')
+                                 FROM usuario_rol ur WHERE ur.usuario_id = u.id),
+                       'dobleFactor', EXISTS (SELECT 1 FROM usuario_doble_factor df
+                                              WHERE df.usuario_id = u.id AND df.activo),
+                       'apiKeys', (SELECT COALESCE(jsonb_agg(k.prefijo ORDER BY k.id), '[]')
+                                   FROM api_key k WHERE k.usuario_id = u.id AND k.revocada_at IS NULL))
+        FROM usuario u
+        WHERE u.id::TEXT = $1`,
+	domain.AuditoriaRol: `
+        SELECT to_jsonb(r)
+                   || jsonb_build_object('permisos', (SELECT COALESCE(jsonb_agg(p.codigo ORDER BY p.codigo), '[]')
+                                                      FROM rol_permiso rp
+                                                      INNER JOIN permiso p ON p.id = rp.permiso_id
+                                                      WHERE rp.rol_id = r.id))
+        FROM rol r
+        WHERE r.id::TEXT = $1`,
+	domain.AuditoriaCliente: `SELECT to_jsonb(c) FROM cliente c WHERE c.id::TEXT = $1`,
+}
+
+// estadoAuditoria obtiene el estado actual de una entidad; devuelve nil si no existe
+func estadoAuditoria(ctx context.Context, tx pgx.Tx, entidad string, entidadId interface{}) (map[string]interface{}, error) {
+	query, ok := consultasAuditoria[entidad]
+	if !ok {
+		log.Println("Entidad sin consulta de auditoría:", entidad)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	var estado map[string]interface{}
+	err := tx.QueryRow(ctx, query, fmt.Sprint(entidadId)).Scan(&estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Println("Error al obtener estado para auditoría:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return estado, nil
+}
+
+// registrarAuditoria guarda en la bitácora el cambio de una entidad dentro de la misma transacción;
+// antes es el estado previo obtenido con estadoAuditoria (nil en las altas)
+func registrarAuditoria(ctx context.Context, tx pgx.Tx, entidad string, entidadId interface{}, accion string, antes map[string]interface{}) error {
+	despues, err := estadoAuditoria(ctx, tx, entidad, entidadId)
+	if err != nil {
+		return err
+	}
+	antes, despues = diferenciasAuditoria(antes, despues)
+	if antes == nil && despues == nil {
+		return nil
+	}
+
+	usuarioId := usuarioContexto(ctx)
+	var ip *string
+	if val, ok := ctx.Value(util.ContextClientIpKey).(string); ok && val != "" {
+		ip = &val
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO auditoria (usuario_id, ip, entidad, entidad_id, accion, antes, despues)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, usuarioId, ip, entidad, fmt.Sprint(entidadId), accion, antes, despues)
+	if err != nil {
+		log.Println("Error al registrar auditoría:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// usuarioContexto obtiene el id del usuario de la petición; los clientes en línea llevan un uid de texto y quedan sin usuario
+func usuarioContexto(ctx context.Context) *int {
+	if id, ok := ctx.Value(util.ContextUserIdKey).(int); ok {
+		return &id
+	}
+	return nil
+}
+
+// diferenciasAuditoria deja solo los campos que cambiaron; las altas y bajas se guardan completas
+func diferenciasAuditoria(antes, despues map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if antes == nil || despues == nil {
+		return antes, despues
+	}
+	cambiosAntes := make(map[string]interface{})
+	cambiosDespues := make(map[string]interface{})
+	for campo, valor := range despues {
+		if previo, ok := antes[campo]; !ok || !reflect.DeepEqual(previo, valor) {
+			cambiosAntes[campo] = antes[campo]
+			cambiosDespues[campo] = valor
+		}
+	}
+	for campo, previo := range antes {
+		if _, ok := despues[campo]; !ok {
+			cambiosAntes[campo] = previo
+			cambiosDespues[campo] = nil
+		}
+	}
+	if len(cambiosDespues) == 0 {
+		return nil, nil
+	}
+	return cambiosAntes, cambiosDespues
+}
+
+func NewAuditoriaRepository(pool *pgxpool.Pool) *AuditoriaRepository {
+	return &AuditoriaRepository{pool: pool}
+}
+
+var _ port.AuditoriaRepository = (*AuditoriaRepository)(nil)
@@ -11,6 +11,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -136,6 +137,9 @@ func (c ClienteRepository) RegistrarCliente(ctx context.Context, request *domain
 		log.Printf("Error inesperado al registrar cliente: %v", err)
 		return nil, datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCliente, clienteId, domain.AccionCrear, nil); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		log.Printf("Error al confirmar transacción de cliente: %v", err)
@@ -171,6 +175,11 @@ func (c ClienteRepository) ModificarClienteById(ctx context.Context, id *int, re
 		return datatype.NewInternalServerErrorGeneric()
 	}
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaCliente, *id)
+	if err != nil {
+		return err
+	}
+
 	// Normalizar nit_ci: si es 0, tratarlo como NULL
 	var nitCi interface{}
 	if request.NitCi != nil && *request.NitCi == 0 {
@@ -221,6 +230,9 @@ func (c ClienteRepository) ModificarClienteById(ctx context.Context, id *int, re
 		log.Printf("Error inesperado al registrar cliente: %v", err)
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCliente, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
 
 	if err = tx.Commit(ctx); err != nil {
 		log.Printf("Error al confirmar transacción de cliente con id %d: %v", *id, err)
@@ -242,6 +254,10 @@ func (c ClienteRepository) HabilitarCliente(ctx context.Context, id *int) error
 			_ = tx.Rollback(ctx)
 		}
 	}()
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaCliente, *id)
+	if err != nil {
+		return err
+	}
 	query := `UPDATE cliente SET estado='Activo',deleted_at=NULL WHERE id=$1`
 	ct, err := tx.Exec(ctx, query, *id)
 	if err != nil {
@@ -251,6 +267,9 @@ func (c ClienteRepository) HabilitarCliente(ctx context.Context, id *int) error
 	if ct.RowsAffected() == 0 {
 		return datatype.NewNotFoundError("No existe el cliente")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCliente, *id, domain.AccionHabilitar, antes); err != nil {
+		return err
+	}
 	err = tx.Commit(ctx)
 	if err != nil {
 		log.Println("Error al confirmar transacción:", err)
@@ -272,6 +291,10 @@ func (c ClienteRepository) DeshabilitarCliente(ctx context.Context, id *int) err
 			_ = tx.Rollback(ctx)
 		}
 	}()
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaCliente, *id)
+	if err != nil {
+		return err
+	}
 	query := `UPDATE cliente SET estado='Inactivo',deleted_at=CURRENT_TIMESTAMP WHERE id=$1`
 	ct, err := tx.Exec(ctx, query, *id)
 	if err != nil {
@@ -282,6 +305,9 @@ func (c ClienteRepository) DeshabilitarCliente(ctx context.Context, id *int) err
 	if ct.RowsAffected() == 0 {
 		return datatype.NewNotFoundError("No existe el cliente")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCliente, *id, domain.AccionDeshabilitar, antes); err != nil {
+		return err
+	}
 	err = tx.Commit(ctx)
 	if err != nil {
 		log.Println("Error al confirmar transacción:", err)
@@ -291,6 +317,213 @@ func (c ClienteRepository) DeshabilitarCliente(ctx context.Context, id *int) err
 	return nil
 }
 
+func (c ClienteRepository) RegistrarCuentaCliente(ctx context.Context, usuarioUid string, email string) error {
+	_, err := c.pool.Exec(ctx, `
+        INSERT INTO cliente_cuenta (usuario_uid, email)
+        VALUES ($1, NULLIF($2, ''))
+        ON CONFLICT (usuario_uid) DO UPDATE SET email = COALESCE(EXCLUDED.email, cliente_cuenta.email), updated_at = NOW()
+    `, usuarioUid, email)
+	if err != nil {
+		log.Println("Error al registrar cuenta de cliente:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+const queryClienteCuenta = `
+SELECT cc.usuario_uid,
+       cc.email,
+       cc.verificado,
+       cc.verificado_at,
+       CASE WHEN c.id IS NULL THEN NULL ELSE jsonb_build_object(
+               'id', c.id,
+               'nitCi', c.nit_ci,
+               'complemento', c.complemento,
+               'tipo', c.tipo,
+               'razonSocial', c.razon_social,
+               'email', COALESCE(c.email, ''),
+               'telefono', c.telefono,
+               'estado', c.estado,
+               'createdAt', c.created_at,
+               'deletedAt', c.deleted_at
+       ) END AS cliente,
+       cc.created_at
+FROM cliente_cuenta cc
+LEFT JOIN cliente c ON c.id = cc.cliente_id
+`
+
+func (c ClienteRepository) ObtenerCuentaCliente(ctx context.Context, usuarioUid string) (*domain.ClienteCuenta, error) {
+	var item domain.ClienteCuenta
+	err := c.pool.QueryRow(ctx, queryClienteCuenta+` WHERE cc.usuario_uid = $1`, usuarioUid).
+		Scan(&item.UsuarioUid, &item.Email, &item.Verificado, &item.VerificadoAt, &item.Cliente, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Cuenta no registrada, inicie sesión nuevamente")
+		}
+		log.Println("Error al obtener cuenta de cliente:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &item, nil
+}
+
+func (c ClienteRepository) ObtenerListaCuentasCliente(ctx context.Context, filtros map[string]string) (*[]domain.ClienteCuenta, error) {
+	query := queryClienteCuenta + ` WHERE cc.cliente_id IS NOT NULL`
+
+	var args []interface{}
+	i := 1
+
+	// Filtrar por estado de verificación (true/false)
+	if verificado := filtros["verificado"]; verificado != "" {
+		query += fmt.Sprintf(" AND cc.verificado = $%d", i)
+		args = append(args, verificado == "true")
+		i++
+	}
+	query += " ORDER BY cc.updated_at DESC"
+
+	rows, err := c.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar cuentas de cliente:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.ClienteCuenta, 0)
+	for rows.Next() {
+		var item domain.ClienteCuenta
+		if err := rows.Scan(&item.UsuarioUid, &item.Email, &item.Verificado, &item.VerificadoAt, &item.Cliente, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear cuenta de cliente:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (c ClienteRepository) GuardarPerfilCliente(ctx context.Context, usuarioUid string, request *domain.PerfilClienteRequest) error {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var clienteActual *int
+	var verificado bool
+	var email *string
+	err = tx.QueryRow(ctx, `SELECT cliente_id, verificado, email FROM cliente_cuenta WHERE usuario_uid = $1 FOR UPDATE`, usuarioUid).
+		Scan(&clienteActual, &verificado, &email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Cuenta no registrada, inicie sesión nuevamente")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Buscar si el NIT/CI ya pertenece a un cliente registrado en farmacia
+	var clienteId int
+	var clienteEmail *string
+	err = tx.QueryRow(ctx, `
+        SELECT id, email FROM cliente
+        WHERE tipo = $1 AND nit_ci = $2 AND COALESCE(complemento, '') = COALESCE($3, '')
+        FOR UPDATE`, request.Tipo, request.NitCi, request.Complemento).Scan(&clienteId, &clienteEmail)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Cliente nuevo: no tiene compras previas, el vínculo queda verificado
+		err = tx.QueryRow(ctx, `
+            INSERT INTO cliente (nit_ci, complemento, tipo, razon_social, email, estado, telefono)
+            VALUES ($1, $2, $3, $4, $5, 'Activo', $6)
+            RETURNING id
+        `, request.NitCi, request.Complemento, request.Tipo, request.RazonSocial, email, request.Telefono).Scan(&clienteId)
+		if err != nil {
+			log.Println("Error al registrar cliente desde perfil:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaCliente, clienteId, domain.AccionCrear, nil); err != nil {
+			return err
+		}
+		verificado = true
+
+	case clienteActual != nil && *clienteActual == clienteId:
+		// Mismo cliente: solo una cuenta verificada puede modificar sus datos
+		if !verificado {
+			return datatype.NewConflictError("La cuenta debe estar verificada para modificar los datos del cliente")
+		}
+		antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaCliente, clienteId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE cliente SET razon_social = $1, telefono = $2 WHERE id = $3`, request.RazonSocial, request.Telefono, clienteId)
+		if err != nil {
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaCliente, clienteId, domain.AccionModificar, antes); err != nil {
+			return err
+		}
+
+	default:
+		// Cliente existente con otra cuenta o sin cuenta: se verifica solo si el correo coincide
+		verificado = email != nil && clienteEmail != nil && strings.EqualFold(strings.TrimSpace(*email), strings.TrimSpace(*clienteEmail))
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE cliente_cuenta
+        SET cliente_id = $1, verificado = $2,
+            verificado_at = CASE WHEN $2 THEN COALESCE(verificado_at, NOW()) ELSE NULL END,
+            updated_at = NOW()
+        WHERE usuario_uid = $3
+    `, clienteId, verificado, usuarioUid)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (c ClienteRepository) VerificarCuentaCliente(ctx context.Context, usuarioUid string, codigoVenta *string) error {
+	var clienteId *int
+	err := c.pool.QueryRow(ctx, `SELECT cliente_id FROM cliente_cuenta WHERE usuario_uid = $1`, usuarioUid).Scan(&clienteId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Cuenta de cliente no encontrada")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if clienteId == nil {
+		return datatype.NewBadRequestError("La cuenta no tiene datos de facturación registrados")
+	}
+
+	// El cliente demuestra la titularidad con el código de una de sus compras
+	if codigoVenta != nil {
+		var existe bool
+		err = c.pool.QueryRow(ctx, `
+            SELECT EXISTS(SELECT 1 FROM venta WHERE codigo = $1 AND cliente_id = $2 AND estado = 'Realizada')
+        `, strings.TrimSpace(*codigoVenta), *clienteId).Scan(&existe)
+		if err != nil {
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if !existe {
+			return datatype.NewBadRequestError("El código de venta no corresponde al cliente")
+		}
+	}
+
+	_, err = c.pool.Exec(ctx, `
+        UPDATE cliente_cuenta SET verificado = TRUE, verificado_at = NOW(), updated_at = NOW()
+        WHERE usuario_uid = $1 AND NOT verificado
+    `, usuarioUid)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
 func NewClienteRepository(pool *pgxpool.Pool) *ClienteRepository {
 	return &ClienteRepository{pool: pool}
 }
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -77,13 +78,13 @@ func (c CompraRepository) RegistrarOrdenCompra(ctx context.Context, request *dom
 	}
 
 	for _, detalle := range request.Detalles {
-		query = `INSERT INTO detalle_compra(cantidad, precio_compra,precio_venta, compra_id, lote_producto_id) VALUES ($1, $2, $3, $4, $5)`
-		_, err := tx.Exec(ctx, query, detalle.Cantidad, detalle.PrecioCompra, detalle.PrecioVenta, compraId, detalle.LoteProductoId)
-		if err != nil {
-			log.Println("Ha ocurrido un error al insertar detalles de la compra:", err.Error())
-			return nil, datatype.NewStatusServiceUnavailableErrorGeneric()
+		if err := registrarDetalleCompra(ctx, tx, int(compraId), detalle); err != nil {
+			return nil, err
 		}
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCompra, compraId, domain.AccionCrear, nil); err != nil {
+		return nil, err
+	}
 
 	// Confirmar transacción
 	err = tx.Commit(ctx)
@@ -120,6 +121,10 @@ func (c CompraRepository) ModificarOrdenCompra(ctx context.Context, id *int, req
 	if !existe {
 		return datatype.NewNotFoundError("La compra no existe o fue eliminada")
 	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaCompra, *id)
+	if err != nil {
+		return err
+	}
 
 	var total float64
 	for _, detalle := range request.Detalles {
@@ -140,13 +145,13 @@ func (c CompraRepository) ModificarOrdenCompra(ctx context.Context, id *int, req
 	}
 
 	for _, detalle := range request.Detalles {
-		query = `INSERT INTO detalle_compra(cantidad, precio_compra,precio_venta, compra_id, lote_producto_id) VALUES ($1, $2, $3, $4, $5)`
-		_, err := tx.Exec(ctx, query, detalle.Cantidad, detalle.PrecioCompra, detalle.PrecioVenta, *id, detalle.LoteProductoId)
-		if err != nil {
-			log.Println("Ha ocurrido un error al insertar detalles de la compra:", err.Error())
-			return datatype.NewStatusServiceUnavailableErrorGeneric()
+		if err := registrarDetalleCompra(ctx, tx, *id, detalle); err != nil {
+			return err
 		}
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCompra, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
 	// Confirmar transacción
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -182,6 +187,10 @@ func (c CompraRepository) AnularOrdenCompra(ctx context.Context, id *int) error
 	if !existe {
 		return datatype.NewNotFoundError("La compra no fue encontrada o no está en estado pendiente")
 	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaCompra, *id)
+	if err != nil {
+		return err
+	}
 
 	// Actualizar el estado a 'Anulado'
 	updateQuery := `UPDATE compra SET estado = 'Anulado',deleted_at=CURRENT_TIMESTAMP WHERE id = $1`
@@ -190,6 +199,9 @@ func (c CompraRepository) AnularOrdenCompra(ctx context.Context, id *int) error
 		log.Println("Error al anular compra:", err)
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCompra, *id, domain.AccionAnular, antes); err != nil {
+		return err
+	}
 
 	// Confirmar transacción
 	err = tx.Commit(ctx)
@@ -200,7 +212,7 @@ func (c CompraRepository) AnularOrdenCompra(ctx context.Context, id *int) error
 	return nil
 }
 
-func (c CompraRepository) RegistrarCompra(ctx context.Context, id *int) error {
+func (c CompraRepository) RegistrarCompra(ctx context.Context, id *int, proponerPrecioVenta bool) error {
 	var compra domain.CompraDAO
 
 	query := `SELECT c.id, c.estado, c.total, c.comentario, c.laboratorio_id, c.usuario_id, c.detalles 
@@ -237,21 +249,31 @@ func (c CompraRepository) RegistrarCompra(ctx context.Context, id *int) error {
 	}()
 
 	// Lock de compra
-	lockCompraQuery := `SELECT id FROM compra WHERE id = $1 FOR UPDATE`
-	_, err = tx.Exec(ctx, lockCompraQuery, *id)
+	lockCompraQuery := `SELECT codigo FROM compra WHERE id = $1 FOR UPDATE`
+	var codigoCompra *string
+	err = tx.QueryRow(ctx, lockCompraQuery, *id).Scan(&codigoCompra)
 	if err != nil {
 		log.Println("Error al bloquear compra:", err)
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	antesCompra, err := estadoAuditoria(ctx, tx, domain.AuditoriaCompra, *id)
+	if err != nil {
+		return err
+	}
 
 	for _, detalle := range compra.Detalles {
 		// Lock de lote_producto
-		lockLoteQuery := `SELECT id FROM lote_producto WHERE id = $1 FOR UPDATE`
-		_, err := tx.Exec(ctx, lockLoteQuery, detalle.LoteProductoId)
+		lockLoteQuery := `SELECT estado::TEXT FROM lote_producto WHERE id = $1 FOR UPDATE`
+		var estadoLote string
+		err := tx.QueryRow(ctx, lockLoteQuery, detalle.LoteProductoId).Scan(&estadoLote)
 		if err != nil {
 			log.Printf("Error al bloquear lote_producto %d: %v", detalle.LoteProductoId, err)
 			return datatype.NewInternalServerErrorGeneric()
 		}
+		// Un lote retirado del mercado no puede recibir nuevo stock
+		if estadoLote == domain.LoteEstadoCuarentena {
+			return datatype.NewConflictError(fmt.Sprintf("El lote %d está en cuarentena por un retiro y no puede recibir stock", detalle.LoteProductoId))
+		}
 
 		// Lock de producto
 		lockProductoQuery := `SELECT stock FROM producto WHERE id = $1 FOR UPDATE`
@@ -262,25 +284,66 @@ func (c CompraRepository) RegistrarCompra(ctx context.Context, id *int) error {
 			return datatype.NewInternalServerErrorGeneric()
 		}
 
+		// La compra se registra por presentación y el stock se lleva en unidades base
+		unidadesBase := detalle.Cantidad * max(detalle.Factor, 1)
+
 		// Actualizar stock en lote_producto
 		updateLoteQuery := `UPDATE lote_producto SET stock = stock + $1 WHERE id = $2`
-		_, err = tx.Exec(ctx, updateLoteQuery, detalle.Cantidad, detalle.LoteProductoId)
+		_, err = tx.Exec(ctx, updateLoteQuery, unidadesBase, detalle.LoteProductoId)
 		if err != nil {
 			log.Printf("Error al actualizar stock del lote %d: %v", detalle.LoteProductoId, err)
 			return datatype.NewInternalServerErrorGeneric()
 		}
 
+		// Guardar el stock recibido en la ubicación indicada en la orden
+		if detalle.UbicacionId != nil {
+			if err := validarCondicionesUbicacion(ctx, tx, int(detalle.LoteProductoId), *detalle.UbicacionId); err != nil {
+				return err
+			}
+			if err := agregarStockUbicacion(ctx, tx, int(detalle.LoteProductoId), *detalle.UbicacionId, int64(unidadesBase)); err != nil {
+				return err
+			}
+		}
+
+		// Los precios del producto cambian con la compra y quedan en la bitácora
+		antesProducto, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, detalle.ProductoId)
+		if err != nil {
+			return err
+		}
+
+		preciosAnteriores, err := obtenerPreciosProducto(ctx, tx, detalle.ProductoId.String())
+		if err != nil {
+			return err
+		}
+
+		// Con propuesta el precio de venta queda pendiente de aprobación en lugar de sobrescribirse
+		precioVenta := detalle.PrecioVenta
+		if proponerPrecioVenta {
+			precioVenta = preciosAnteriores.venta
+			if detalle.PrecioVenta != preciosAnteriores.venta {
+				if err := proponerPrecioVentaCompra(ctx, tx, *id, detalle.ProductoId.String(), detalle.PrecioVenta); err != nil {
+					return err
+				}
+			}
+		}
+
 		// Actualizar producto con stock y precios nuevos
 		updateProductoQuery := `UPDATE producto 
 		                        SET stock = stock + $1, 
 		                            precio_compra = $2, 
 		                            precio_venta = $3 
 		                        WHERE id = $4`
-		_, err = tx.Exec(ctx, updateProductoQuery, detalle.Cantidad, detalle.PrecioCompra, detalle.PrecioVenta, detalle.ProductoId)
+		_, err = tx.Exec(ctx, updateProductoQuery, unidadesBase, detalle.PrecioCompra, precioVenta, detalle.ProductoId)
 		if err != nil {
-			log.Printf("Error al actualizar producto %d: %v", detalle.ProductoId, err)
+			log.Printf("Error al actualizar producto %s: %v", detalle.ProductoId, err)
 			return datatype.NewInternalServerErrorGeneric()
 		}
+		if err := registrarHistorialPrecio(ctx, tx, detalle.ProductoId.String(), preciosAnteriores, domain.PrecioOrigenCompra, codigoCompra, usuarioContexto(ctx)); err != nil {
+			return err
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaProducto, detalle.ProductoId, domain.AccionModificar, antesProducto); err != nil {
+			return err
+		}
 	}
 
 	// Actualizar estado de la compra
@@ -290,6 +353,9 @@ func (c CompraRepository) RegistrarCompra(ctx context.Context, id *int) error {
 		log.Println("Error al actualizar estado de la compra:", err)
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaCompra, *id, domain.AccionCompletar, antesCompra); err != nil {
+		return err
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -301,6 +367,27 @@ func (c CompraRepository) RegistrarCompra(ctx context.Context, id *int) error {
 	return nil
 }
 
+// proponerPrecioVentaCompra deja el precio de venta de la compra como propuesta; si el producto
+// aparece en varios detalles se conserva solo la última propuesta
+func proponerPrecioVentaCompra(ctx context.Context, tx pgx.Tx, compraId int, productoId string, precioVenta float64) error {
+	_, err := tx.Exec(ctx, `
+        DELETE FROM precio_programado WHERE compra_id = $1 AND producto_id::TEXT = $2 AND estado = 'Propuesto'
+    `, compraId, productoId)
+	if err != nil {
+		log.Println("Error al reemplazar precio propuesto:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	_, err = tx.Exec(ctx, `
+        INSERT INTO precio_programado (producto_id, precio_venta, estado, compra_id, usuario_id)
+        VALUES ($1, $2, 'Propuesto', $3, $4)
+    `, productoId, precioVenta, compraId, usuarioContexto(ctx))
+	if err != nil {
+		log.Println("Error al proponer precio de venta:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
 func (c CompraRepository) ObtenerListaCompras(ctx context.Context, filtros map[string]string) (*[]domain.CompraInfo, error) {
 	query := `SELECT c.id,c.codigo,c.comentario,c.estado,c.total,c.laboratorio,c.usuario,c.fecha FROM view_compras c`
 	var filters []string
@@ -391,6 +478,31 @@ func (c CompraRepository) ObtenerListaCompras(ctx context.Context, filtros map[s
 	return &list, nil
 }
 
+// registrarDetalleCompra guarda un detalle de compra con las unidades por presentación vigentes del producto y su ubicación de destino
+func registrarDetalleCompra(ctx context.Context, tx pgx.Tx, compraId int, detalle domain.DetalleCompraRequest) error {
+	// La ubicación de destino debe cumplir las condiciones de almacenamiento del producto
+	if detalle.UbicacionId != nil {
+		if err := validarCondicionesUbicacion(ctx, tx, int(detalle.LoteProductoId), *detalle.UbicacionId); err != nil {
+			return err
+		}
+	}
+	ct, err := tx.Exec(ctx, `
+        INSERT INTO detalle_compra (cantidad, precio_compra, precio_venta, compra_id, lote_producto_id, factor, ubicacion_id)
+        SELECT $1, $2, $3, $4, lp.id, COALESCE(NULLIF(p.unidades_presentacion, 0), 1), $6
+        FROM lote_producto lp
+        INNER JOIN producto p ON p.id = lp.producto_id
+        WHERE lp.id = $5
+    `, detalle.Cantidad, detalle.PrecioCompra, detalle.PrecioVenta, compraId, detalle.LoteProductoId, detalle.UbicacionId)
+	if err != nil {
+		log.Println("Ha ocurrido un error al insertar detalles de la compra:", err.Error())
+		return datatype.NewStatusServiceUnavailableErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError(fmt.Sprintf("El lote %d no existe", detalle.LoteProductoId))
+	}
+	return nil
+}
+
 func NewCompraRepository(pool *pgxpool.Pool) *CompraRepository {
 	return &CompraRepository{pool: pool}
 }
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Las cuentas de servicio son usuarios sin persona; sus permisos provienen de sus roles como en cualquier usuario
+const consultaCuentaServicio = `
+        SELECT u.id, u.username, u.descripcion, u.estado,
+               (SELECT COALESCE(jsonb_agg(jsonb_build_object('id', r.id, 'nombre', r.nombre, 'estado', r.estado)
+                                          ORDER BY r.nombre), '[]')
+                FROM usuario_rol ur
+                INNER JOIN rol r ON r.id = ur.rol_id AND r.deleted_at IS NULL
+                WHERE ur.usuario_id = u.id),
+               (SELECT COUNT(*) FROM api_key k
+                WHERE k.usuario_id = u.id AND k.revocada_at IS NULL AND (k.expira_at IS NULL OR k.expira_at > NOW())),
+               (SELECT MAX(k.ultimo_uso) FROM api_key k WHERE k.usuario_id = u.id),
+               u.created_at, u.deleted_at
+        FROM usuario u
+        WHERE u.es_servicio`
+
+const consultaApiKey = `
+        SELECT k.id, k.nombre, k.prefijo, k.permisos, k.expira_at, k.ultimo_uso, k.ultima_ip, k.created_at, k.revocada_at
+        FROM api_key k`
+
+type CuentaServicioRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (c CuentaServicioRepository) ListarCuentasServicio(ctx context.Context) (*[]domain.CuentaServicioInfo, error) {
+	rows, err := c.pool.Query(ctx, consultaCuentaServicio+" ORDER BY u.username")
+	if err != nil {
+		log.Println("Error al listar cuentas de servicio:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.CuentaServicioInfo, 0)
+	for rows.Next() {
+		var item domain.CuentaServicioInfo
+		if err := scanCuentaServicio(rows, &item); err != nil {
+			log.Println("Error al escanear cuenta de servicio:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (c CuentaServicioRepository) ObtenerCuentaServicioById(ctx context.Context, cuentaId *int) (*domain.CuentaServicioDetail, error) {
+	var detalle domain.CuentaServicioDetail
+	err := scanCuentaServicio(c.pool.QueryRow(ctx, consultaCuentaServicio+" AND u.id = $1", *cuentaId), &detalle.CuentaServicioInfo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Cuenta de servicio no encontrada")
+		}
+		log.Println("Error al obtener cuenta de servicio:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	rows, err := c.pool.Query(ctx, consultaApiKey+" WHERE k.usuario_id = $1 ORDER BY k.created_at DESC", *cuentaId)
+	if err != nil {
+		log.Println("Error al listar API keys:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	detalle.ApiKeys = make([]domain.ApiKeyInfo, 0)
+	for rows.Next() {
+		var item domain.ApiKeyInfo
+		if err := scanApiKey(rows, &item); err != nil {
+			log.Println("Error al escanear API key:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		detalle.ApiKeys = append(detalle.ApiKeys, item)
+	}
+	return &detalle, nil
+}
+
+func (c CuentaServicioRepository) RegistrarCuentaServicio(ctx context.Context, request *domain.CuentaServicioRequest, hashPassword string) (*int, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var id int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO usuario (username, password, es_servicio, descripcion)
+        VALUES ($1, $2, TRUE, $3)
+        RETURNING id
+    `, request.Nombre, hashPassword, request.Descripcion).Scan(&id)
+	if err != nil {
+		return nil, errorNombreCuentaServicio(err)
+	}
+	if err := asignarRolesCuentaServicio(ctx, tx, id, request.Roles); err != nil {
+		return nil, err
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, id, domain.AccionCrear, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &id, nil
+}
+
+func (c CuentaServicioRepository) ModificarCuentaServicio(ctx context.Context, cuentaId *int, request *domain.CuentaServicioRequest) error {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := bloquearCuentaServicio(ctx, tx, *cuentaId); err != nil {
+		return err
+	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *cuentaId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE usuario SET username = $1, descripcion = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3
+    `, request.Nombre, request.Descripcion, *cuentaId)
+	if err != nil {
+		return errorNombreCuentaServicio(err)
+	}
+	if err := asignarRolesCuentaServicio(ctx, tx, *cuentaId, request.Roles); err != nil {
+		return err
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *cuentaId, domain.AccionModificar, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (c CuentaServicioRepository) HabilitarCuentaServicio(ctx context.Context, cuentaId *int) error {
+	return c.cambiarEstadoCuentaServicio(ctx, *cuentaId, `deleted_at = NULL, estado = 'Activo'`, domain.AccionHabilitar)
+}
+
+// DeshabilitarCuentaServicio deja sin efecto todas sus API keys sin revocarlas, para poder volver a habilitarla
+func (c CuentaServicioRepository) DeshabilitarCuentaServicio(ctx context.Context, cuentaId *int) error {
+	return c.cambiarEstadoCuentaServicio(ctx, *cuentaId, `deleted_at = CURRENT_TIMESTAMP, estado = 'Inactivo'`, domain.AccionDeshabilitar)
+}
+
+func (c CuentaServicioRepository) RegistrarApiKey(ctx context.Context, cuentaId *int, request *domain.ApiKeyRequest, prefijo, hash string) (*domain.ApiKeyInfo, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	estado, err := bloquearCuentaServicio(ctx, tx, *cuentaId)
+	if err != nil {
+		return nil, err
+	}
+	if estado != "Activo" {
+		return nil, datatype.NewConflictError("La cuenta de servicio está deshabilitada")
+	}
+	if len(request.Permisos) > 0 {
+		var existentes int
+		err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM permiso WHERE codigo = ANY ($1)`, request.Permisos).Scan(&existentes)
+		if err != nil {
+			log.Println("Error al verificar permisos:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		if existentes != len(request.Permisos) {
+			return nil, datatype.NewBadRequestError("Algunos permisos no existen")
+		}
+	}
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *cuentaId)
+	if err != nil {
+		return nil, err
+	}
+	var apiKey domain.ApiKeyInfo
+	err = scanApiKey(tx.QueryRow(ctx, `
+        INSERT INTO api_key (usuario_id, nombre, prefijo, key_hash, permisos, expira_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, nombre, prefijo, permisos, expira_at, ultimo_uso, ultima_ip, created_at, revocada_at
+    `, *cuentaId, request.Nombre, prefijo, hash, request.Permisos, request.ExpiraAt, usuarioContexto(ctx)), &apiKey)
+	if err != nil {
+		log.Println("Error al registrar API key:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *cuentaId, domain.AccionGenerarApiKey, antes); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &apiKey, nil
+}
+
+func (c CuentaServicioRepository) RevocarApiKey(ctx context.Context, cuentaId *int, apiKeyId *int) error {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := bloquearCuentaServicio(ctx, tx, *cuentaId); err != nil {
+		return err
+	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *cuentaId)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(ctx, `
+        UPDATE api_key SET revocada_at = NOW()
+        WHERE id = $1 AND usuario_id = $2 AND revocada_at IS NULL
+    `, *apiKeyId, *cuentaId)
+	if err != nil {
+		log.Println("Error al revocar API key:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("API key no encontrada o ya revocada")
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *cuentaId, domain.AccionRevocarApiKey, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// ObtenerApiKeyVigente busca la API key por su prefijo si no está revocada ni vencida y su cuenta está activa
+func (c CuentaServicioRepository) ObtenerApiKeyVigente(ctx context.Context, prefijo string) (*domain.ApiKeyAutenticada, error) {
+	var apiKey domain.ApiKeyAutenticada
+	err := c.pool.QueryRow(ctx, `
+        SELECT k.id, k.key_hash, u.id, u.username, k.expira_at, k.permisos
+        FROM api_key k
+        INNER JOIN usuario u ON u.id = k.usuario_id
+        WHERE k.prefijo = $1 AND k.revocada_at IS NULL AND (k.expira_at IS NULL OR k.expira_at > NOW())
+          AND u.es_servicio AND u.estado = 'Activo'
+    `, prefijo).Scan(&apiKey.ApiKeyId, &apiKey.Hash, &apiKey.UsuarioId, &apiKey.Username, &apiKey.ExpiraAt, &apiKey.Permisos)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewStatusUnauthorizedError("API key no válida")
+		}
+		log.Println("Error al obtener API key:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &apiKey, nil
+}
+
+func (c CuentaServicioRepository) RegistrarUsoApiKey(ctx context.Context, apiKeyId int, ip string) error {
+	_, err := c.pool.Exec(ctx, `UPDATE api_key SET ultimo_uso = NOW(), ultima_ip = $2 WHERE id = $1`, apiKeyId, ip)
+	if err != nil {
+		log.Println("Error al registrar uso de API key:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (c CuentaServicioRepository) cambiarEstadoCuentaServicio(ctx context.Context, cuentaId int, cambios string, accion string) error {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := bloquearCuentaServicio(ctx, tx, cuentaId); err != nil {
+		return err
+	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, cuentaId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE usuario SET `+cambios+`, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, cuentaId)
+	if err != nil {
+		log.Println("Error al cambiar estado de cuenta de servicio:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, cuentaId, accion, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// bloquearCuentaServicio bloquea la fila de la cuenta dentro de la transacción y devuelve su estado
+func bloquearCuentaServicio(ctx context.Context, tx pgx.Tx, cuentaId int) (string, error) {
+	var estado string
+	err := tx.QueryRow(ctx, `SELECT estado FROM usuario WHERE id = $1 AND es_servicio FOR UPDATE`, cuentaId).Scan(&estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", datatype.NewNotFoundError("Cuenta de servicio no encontrada")
+		}
+		log.Println("Error al obtener cuenta de servicio:", err)
+		return "", datatype.NewInternalServerErrorGeneric()
+	}
+	return estado, nil
+}
+
+// asignarRolesCuentaServicio reemplaza los roles de la cuenta; el rol del sistema no se asigna a cuentas de servicio
+// porque tiene todos los permisos del catálogo
+func asignarRolesCuentaServicio(ctx context.Context, tx pgx.Tx, cuentaId int, roles []int32) error {
+	var sistema bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM rol WHERE id = ANY ($1) AND es_sistema)`, roles).Scan(&sistema)
+	if err != nil {
+		log.Println("Error al verificar roles:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if sistema {
+		return datatype.NewBadRequestError("Las cuentas de servicio no pueden tener el rol del sistema")
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM usuario_rol WHERE usuario_id = $1`, cuentaId); err != nil {
+		log.Println("Error al quitar roles:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO usuario_rol (usuario_id, rol_id) SELECT $1, unnest($2::INT[])`, cuentaId, roles)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return datatype.NewBadRequestError("Algunos roles no existen")
+		}
+		log.Println("Error al asignar roles:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func errorNombreCuentaServicio(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return datatype.NewConflictError("El nombre ya está registrado como usuario o cuenta de servicio")
+	}
+	log.Println("Error al guardar cuenta de servicio:", err)
+	return datatype.NewInternalServerErrorGeneric()
+}
+
+func scanCuentaServicio(row pgx.Row, item *domain.CuentaServicioInfo) error {
+	return row.Scan(&item.Id, &item.Nombre, &item.Descripcion, &item.Estado, &item.Roles, &item.ApiKeysActivas,
+		&item.UltimoUso, &item.CreatedAt, &item.DeletedAt)
+}
+
+func scanApiKey(row pgx.Row, item *domain.ApiKeyInfo) error {
+	return row.Scan(&item.Id, &item.Nombre, &item.Prefijo, &item.Permisos, &item.ExpiraAt, &item.UltimoUso,
+		&item.UltimaIp, &item.CreatedAt, &item.RevocadaAt)
+}
+
+func NewCuentaServicioRepository(pool *pgxpool.Pool) *CuentaServicioRepository {
+	return &CuentaServicioRepository{pool: pool}
+}
+
+var _ port.CuentaServicioRepository = (*CuentaServicioRepository)(nil)
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DobleFactorRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (d DobleFactorRepository) ObtenerDobleFactor(ctx context.Context, usuarioId int) (*domain.DobleFactorUsuario, error) {
+	var df domain.DobleFactorUsuario
+	err := d.pool.QueryRow(ctx, `
+        SELECT u.id, u.username, df.secreto, COALESCE(df.activo, FALSE), df.ultimo_paso, df.activado_at,
+               EXISTS (SELECT 1
+                       FROM usuario_rol ur
+                       INNER JOIN rol r ON r.id = ur.rol_id AND r.estado = 'Activo'
+                       WHERE ur.usuario_id = u.id AND r.requiere_doble_factor),
+               (SELECT COUNT(*) FROM codigo_recuperacion cr WHERE cr.usuario_id = u.id AND cr.usado_at IS NULL)
+        FROM usuario u
+        LEFT JOIN usuario_doble_factor df ON df.usuario_id = u.id
+        WHERE u.id = $1
+    `, usuarioId).Scan(&df.UsuarioId, &df.Username, &df.Secreto, &df.Activo, &df.UltimoPaso, &df.ActivadoAt, &df.Requerido, &df.CodigosRestantes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Usuario no encontrado")
+		}
+		log.Println("Error al obtener verificación en dos pasos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &df, nil
+}
+
+// GuardarSecretoDobleFactor reemplaza el secreto pendiente de confirmar; uno ya activo no se modifica
+func (d DobleFactorRepository) GuardarSecretoDobleFactor(ctx context.Context, usuarioId int, secreto string) error {
+	ct, err := d.pool.Exec(ctx, `
+        INSERT INTO usuario_doble_factor (usuario_id, secreto)
+        VALUES ($1, $2)
+        ON CONFLICT (usuario_id) DO UPDATE SET secreto = EXCLUDED.secreto, created_at = NOW()
+        WHERE NOT usuario_doble_factor.activo
+    `, usuarioId, secreto)
+	if err != nil {
+		log.Println("Error al guardar secreto de verificación en dos pasos:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewConflictError("La verificación en dos pasos ya está activa")
+	}
+	return nil
+}
+
+func (d DobleFactorRepository) ActivarDobleFactor(ctx context.Context, usuarioId int, paso int64, codigos []string) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, usuarioId)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(ctx, `
+        UPDATE usuario_doble_factor SET activo = TRUE, activado_at = NOW(), ultimo_paso = $2
+        WHERE usuario_id = $1 AND NOT activo
+    `, usuarioId, paso)
+	if err != nil {
+		log.Println("Error al activar verificación en dos pasos:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewConflictError("No hay una configuración pendiente de verificación en dos pasos")
+	}
+	if err := reemplazarCodigosRecuperacion(ctx, tx, usuarioId, codigos); err != nil {
+		return err
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, usuarioId, domain.AccionActivarDobleFactor, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// RegistrarPasoDobleFactor guarda el paso del código usado; falso si ese código o uno posterior ya se usó
+func (d DobleFactorRepository) RegistrarPasoDobleFactor(ctx context.Context, usuarioId int, paso int64) (bool, error) {
+	ct, err := d.pool.Exec(ctx, `
+        UPDATE usuario_doble_factor SET ultimo_paso = $2
+        WHERE usuario_id = $1 AND activo AND (ultimo_paso IS NULL OR ultimo_paso < $2)
+    `, usuarioId, paso)
+	if err != nil {
+		log.Println("Error al registrar código de verificación:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func (d DobleFactorRepository) UsarCodigoRecuperacion(ctx context.Context, usuarioId int, codigoHash string) (bool, error) {
+	ct, err := d.pool.Exec(ctx, `
+        UPDATE codigo_recuperacion SET usado_at = NOW()
+        WHERE usuario_id = $1 AND codigo_hash = $2 AND usado_at IS NULL
+    `, usuarioId, codigoHash)
+	if err != nil {
+		log.Println("Error al usar código de recuperación:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func (d DobleFactorRepository) ReemplazarCodigosRecuperacion(ctx context.Context, usuarioId int, codigos []string) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := reemplazarCodigosRecuperacion(ctx, tx, usuarioId, codigos); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// QuitarDobleFactor elimina el secreto y los códigos de recuperación del usuario
+func (d DobleFactorRepository) QuitarDobleFactor(ctx context.Context, usuarioId *int) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var existe bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM usuario WHERE id = $1)`, *usuarioId).Scan(&existe)
+	if err != nil {
+		log.Println("Error al obtener usuario:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if !existe {
+		return datatype.NewNotFoundError("Usuario no encontrado")
+	}
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(ctx, `DELETE FROM usuario_doble_factor WHERE usuario_id = $1`, *usuarioId)
+	if err != nil {
+		log.Println("Error al quitar verificación en dos pasos:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewConflictError("El usuario no tiene configurada la verificación en dos pasos")
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM codigo_recuperacion WHERE usuario_id = $1`, *usuarioId); err != nil {
+		log.Println("Error al eliminar códigos de recuperación:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId, domain.AccionQuitarDobleFactor, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// reemplazarCodigosRecuperacion invalida los códigos anteriores y guarda los nuevos hashes dentro de la transacción
+func reemplazarCodigosRecuperacion(ctx context.Context, tx pgx.Tx, usuarioId int, codigos []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM codigo_recuperacion WHERE usuario_id = $1`, usuarioId); err != nil {
+		log.Println("Error al eliminar códigos de recuperación:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	_, err := tx.Exec(ctx, `
+        INSERT INTO codigo_recuperacion (usuario_id, codigo_hash)
+        SELECT $1, UNNEST($2::TEXT[])
+    `, usuarioId, codigos)
+	if err != nil {
+		log.Println("Error al guardar códigos de recuperación:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// ConsumirDesafioLogin marca el token de desafío como usado; falso si ya se había usado
+func (d DobleFactorRepository) ConsumirDesafioLogin(ctx context.Context, desafioId uuid.UUID, usuarioId int, expiraAt time.Time) (bool, error) {
+	ct, err := d.pool.Exec(ctx, `
+        WITH vencidos AS (
+            DELETE FROM desafio_login_usado WHERE expira_at < NOW()
+        )
+        INSERT INTO desafio_login_usado (id, usuario_id, expira_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (id) DO NOTHING
+    `, desafioId, usuarioId, expiraAt)
+	if err != nil {
+		log.Println("Error al registrar token de desafío:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func NewDobleFactorRepository(pool *pgxpool.Pool) *DobleFactorRepository {
+	return &DobleFactorRepository{pool: pool}
+}
+
+var _ port.DobleFactorRepository = (*DobleFactorRepository)(nil)
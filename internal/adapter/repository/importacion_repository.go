@@ -0,0 +1,517 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
+)
+
+type ImportacionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// referenciaImportacion identifica en el historial de precios los cambios hechos por importación
+const referenciaImportacion = "Importación de productos"
+
+func (i ImportacionRepository) ImportarProductos(ctx context.Context, filas *[]domain.ProductoImportacion, dryRun bool) (*domain.ResultadoImportacion, error) {
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewStatusServiceUnavailableErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	resultado := domain.ResultadoImportacion{Total: len(*filas), Errores: make([]domain.ErrorImportacion, 0)}
+	catalogos := newCatalogosImportacion()
+	for _, fila := range *filas {
+		// Los catálogos se resuelven fuera del savepoint para que los creados sigan disponibles en las filas siguientes
+		refs, errores, err := catalogos.resolver(ctx, tx, &fila)
+		if err != nil {
+			return nil, err
+		}
+		if len(errores) > 0 {
+			resultado.Errores = append(resultado.Errores, errores...)
+			continue
+		}
+
+		// Cada fila en su propio savepoint: un error no invalida la transacción y se siguen validando las demás
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		creado, err := importarProducto(ctx, sp, &fila, refs)
+		if err != nil {
+			_ = sp.Rollback(ctx)
+			var errorResponse *datatype.ErrorResponse
+			if !errors.As(err, &errorResponse) || errorResponse.Code >= 500 {
+				return nil, err
+			}
+			resultado.Errores = append(resultado.Errores, domain.ErrorImportacion{Fila: fila.Fila, Mensaje: errorResponse.Message})
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		if creado {
+			resultado.Creados++
+		} else {
+			resultado.Actualizados++
+		}
+	}
+
+	if dryRun || len(resultado.Errores) > 0 {
+		return &resultado, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Error al confirmar importación de productos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	resultado.Aplicado = true
+	return &resultado, nil
+}
+
+func (i ImportacionRepository) ObtenerProductosExportacion(ctx context.Context) (*[]domain.ProductoImportacion, error) {
+	query := `
+        SELECT p.nombre_comercial,
+               l.nombre,
+               ff.nombre,
+               pre.nombre,
+               COALESCE(p.unidades_presentacion, 1),
+               COALESCE((SELECT array_agg(c.nombre ORDER BY c.nombre)
+                         FROM producto_categoria pc
+                         INNER JOIN categoria c ON c.id = pc.categoria_id
+                         WHERE pc.producto_id = p.id), '{}'),
+               COALESCE((SELECT jsonb_agg(jsonb_build_object('nombre', pa.nombre, 'concentracion', ppa.concentracion,
+                                                             'unidadMedida', um.abreviatura) ORDER BY ppa.id)
+                         FROM producto_principio_activo ppa
+                         INNER JOIN principio_activo pa ON pa.id = ppa.principio_activo_id
+                         INNER JOIN unidad_medida um ON um.id = ppa.unidad_medida_id
+                         WHERE ppa.producto_id = p.id), '[]'),
+               p.precio_compra,
+               p.precio_venta,
+               p.precio_venta_unidad,
+               p.stock_min,
+               p.nivel_control::TEXT,
+               p.refrigerado,
+               p.controlado,
+               COALESCE((SELECT jsonb_agg(jsonb_build_object('codigo', cb.codigo, 'tipo', cb.tipo) ORDER BY cb.id)
+                         FROM codigo_barra cb
+                         WHERE cb.producto_id = p.id), '[]')
+        FROM producto p
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        INNER JOIN forma_farmaceutica ff ON ff.id = p.forma_farmaceutica_id
+        LEFT JOIN presentacion pre ON pre.id = p.presentacion_id
+        ORDER BY p.nombre_comercial, l.nombre
+    `
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		log.Println("Error al obtener productos para exportar:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.ProductoImportacion, 0)
+	for rows.Next() {
+		var item domain.ProductoImportacion
+		if err := rows.Scan(&item.NombreComercial, &item.Laboratorio, &item.FormaFarmaceutica, &item.Presentacion,
+			&item.UnidadesPresentacion, &item.Categorias, &item.PrincipiosActivos, &item.PrecioCompra, &item.PrecioVenta,
+			&item.PrecioVentaUnidad, &item.StockMin, &item.NivelControl, &item.Refrigerado, &item.Controlado,
+			&item.CodigosBarra); err != nil {
+			log.Println("Error al escanear producto para exportar:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (i ImportacionRepository) ImportarStockInicial(ctx context.Context, filas *[]domain.StockInicialImportacion, usuarioId int, dryRun bool) (*domain.ResultadoImportacion, error) {
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewStatusServiceUnavailableErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	codigo, err := generarCodigoInventarioInicial(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	var inventarioId int
+	err = tx.QueryRow(ctx, `INSERT INTO inventario_inicial (codigo, usuario_id) VALUES ($1, $2) RETURNING id`, codigo, usuarioId).Scan(&inventarioId)
+	if err != nil {
+		log.Println("Error al registrar inventario inicial:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	resultado := domain.ResultadoImportacion{Total: len(*filas), Errores: make([]domain.ErrorImportacion, 0)}
+	for _, fila := range *filas {
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		if err := importarLoteInicial(ctx, sp, inventarioId, &fila); err != nil {
+			_ = sp.Rollback(ctx)
+			var errorResponse *datatype.ErrorResponse
+			if !errors.As(err, &errorResponse) || errorResponse.Code >= 500 {
+				return nil, err
+			}
+			resultado.Errores = append(resultado.Errores, domain.ErrorImportacion{Fila: fila.Fila, Mensaje: errorResponse.Message})
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		resultado.Creados++
+	}
+
+	if dryRun || len(resultado.Errores) > 0 {
+		return &resultado, nil
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE inventario_inicial
+        SET total = (SELECT COALESCE(SUM(cantidad * costo_unitario), 0) FROM detalle_inventario_inicial WHERE inventario_inicial_id = $1)
+        WHERE id = $1
+    `, inventarioId)
+	if err != nil {
+		log.Println("Error al calcular total del inventario inicial:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Error al confirmar inventario inicial:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	resultado.Aplicado = true
+	resultado.Documento = &codigo
+	return &resultado, nil
+}
+
+// importarLoteInicial crea el lote con su existencia en unidades base y lo agrega al documento de inventario inicial
+func importarLoteInicial(ctx context.Context, tx pgx.Tx, inventarioId int, fila *domain.StockInicialImportacion) error {
+	var productoId uuid.UUID
+	var factor int64
+	err := tx.QueryRow(ctx, `
+        SELECT p.id, COALESCE(p.unidades_presentacion, 1)
+        FROM producto p
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        WHERE UPPER(p.nombre_comercial) = $1 AND UPPER(l.nombre) = $2
+        FOR UPDATE OF p
+    `, fila.Producto, fila.Laboratorio).Scan(&productoId, &factor)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError(fmt.Sprintf("No existe el producto %s del laboratorio %s", fila.Producto, fila.Laboratorio))
+		}
+		log.Println("Error al buscar producto del inventario inicial:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	// El stock se lleva en unidades base; el costo del kardex es por unidad base
+	cantidad, costoUnitario := fila.Cantidad, fila.CostoUnitario
+	if fila.Unidad == domain.UnidadVentaPresentacion {
+		cantidad *= max(factor, 1)
+		costoUnitario /= float64(max(factor, 1))
+	}
+
+	var loteId int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO lote_producto (lote, fecha_vencimiento, producto_id, stock) VALUES ($1, $2, $3, $4) RETURNING id
+    `, fila.Lote, fila.FechaVencimiento.Format("2006-01-02"), productoId, cantidad).Scan(&loteId)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return datatype.NewConflictError("Ya existe un lote con ese código o fecha de vencimiento para este producto")
+			case "P0001":
+				// Excepción del trigger validar_fecha_vencimiento_lote
+				if strings.Contains(pgErr.Message, "fecha") {
+					return datatype.NewBadRequestError("La fecha de vencimiento no puede ser menor que la fecha actual")
+				}
+			}
+		}
+		log.Println("Error al registrar lote del inventario inicial:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO detalle_inventario_inicial (inventario_inicial_id, lote_id, cantidad, costo_unitario)
+        VALUES ($1, $2, $3, ROUND($4::NUMERIC, 2))
+    `, inventarioId, loteId, cantidad, costoUnitario)
+	if err != nil {
+		log.Println("Error al registrar detalle del inventario inicial:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if _, err = tx.Exec(ctx, `UPDATE producto SET stock = stock + $1 WHERE id = $2`, cantidad, productoId); err != nil {
+		log.Println("Error al actualizar stock del producto:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	if fila.Ubicacion != nil {
+		var ubicacionId int
+		err = tx.QueryRow(ctx, `SELECT id FROM ubicacion WHERE codigo = $1 AND deleted_at IS NULL`, *fila.Ubicacion).Scan(&ubicacionId)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return datatype.NewNotFoundError(fmt.Sprintf("No existe la ubicación %s", *fila.Ubicacion))
+			}
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if err := validarCondicionesUbicacion(ctx, tx, loteId, ubicacionId); err != nil {
+			return err
+		}
+		if err := agregarStockUbicacion(ctx, tx, loteId, ubicacionId, cantidad); err != nil {
+			return err
+		}
+	}
+	return registrarAuditoria(ctx, tx, domain.AuditoriaLoteProducto, loteId, domain.AccionCrear, nil)
+}
+
+// generarCodigoInventarioInicial genera el siguiente código correlativo de inventario inicial
+func generarCodigoInventarioInicial(ctx context.Context, tx pgx.Tx) (string, error) {
+	var nextNum int64
+	err := tx.QueryRow(ctx, `
+        SELECT COALESCE(
+            (SELECT MAX(CAST(SUBSTRING(codigo FROM 5) AS INTEGER)) + 1 FROM inventario_inicial WHERE codigo ~ '^INV-[0-9]+$'),
+            1
+        )
+    `).Scan(&nextNum)
+	if err != nil {
+		return "", datatype.NewInternalServerErrorGeneric()
+	}
+	return fmt.Sprintf("INV-%09d", nextNum), nil
+}
+
+// referenciasProducto son los ids de catálogo resueltos para una fila importada
+type referenciasProducto struct {
+	laboratorioId       int
+	formaFarmaceuticaId int
+	presentacionId      *int
+	categorias          []int
+	principiosActivos   []domain.ProductoPrincipioActivoRequest
+}
+
+// catalogosImportacion guarda los ids ya resueltos por tabla y nombre en minúsculas
+type catalogosImportacion map[string]map[string]int
+
+func newCatalogosImportacion() catalogosImportacion {
+	return catalogosImportacion{}
+}
+
+// resolver obtiene los ids de catálogo de la fila creando los que no existen; las unidades de medida
+// son un catálogo cerrado y solo se buscan por abreviatura o nombre
+func (c catalogosImportacion) resolver(ctx context.Context, tx pgx.Tx, fila *domain.ProductoImportacion) (*referenciasProducto, []domain.ErrorImportacion, error) {
+	var refs referenciasProducto
+	var errores []domain.ErrorImportacion
+	var err error
+
+	if refs.laboratorioId, err = c.obtenerOCrear(ctx, tx, "laboratorio", fila.Laboratorio); err != nil {
+		return nil, nil, err
+	}
+	if refs.formaFarmaceuticaId, err = c.obtenerOCrear(ctx, tx, "forma_farmaceutica", fila.FormaFarmaceutica); err != nil {
+		return nil, nil, err
+	}
+	if fila.Presentacion != nil {
+		id, err := c.obtenerOCrear(ctx, tx, "presentacion", *fila.Presentacion)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs.presentacionId = &id
+	}
+	for _, nombre := range fila.Categorias {
+		id, err := c.obtenerOCrear(ctx, tx, "categoria", nombre)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs.categorias = append(refs.categorias, id)
+	}
+	for _, pa := range fila.PrincipiosActivos {
+		principioId, err := c.obtenerOCrear(ctx, tx, "principio_activo", pa.Nombre)
+		if err != nil {
+			return nil, nil, err
+		}
+		unidadId, ok, err := c.obtenerUnidadMedida(ctx, tx, pa.UnidadMedida)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			errores = append(errores, domain.ErrorImportacion{Fila: fila.Fila, Columna: "principiosActivos",
+				Mensaje: fmt.Sprintf("La unidad de medida '%s' no está registrada", pa.UnidadMedida)})
+			continue
+		}
+		refs.principiosActivos = append(refs.principiosActivos, domain.ProductoPrincipioActivoRequest{
+			PrincipioActivoId: principioId,
+			Concentracion:     pa.Concentracion,
+			UnidadMedidaId:    unidadId,
+		})
+	}
+	return &refs, errores, nil
+}
+
+// obtenerOCrear busca un registro de catálogo por nombre sin distinguir mayúsculas y lo crea si no existe
+func (c catalogosImportacion) obtenerOCrear(ctx context.Context, tx pgx.Tx, tabla string, nombre string) (int, error) {
+	clave := strings.ToLower(nombre)
+	if id, ok := c[tabla][clave]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE LOWER(nombre) = LOWER($1) ORDER BY id LIMIT 1`, tabla), nombre).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(ctx, fmt.Sprintf(`INSERT INTO %s (nombre) VALUES ($1) RETURNING id`, tabla), nombre).Scan(&id)
+	}
+	if err != nil {
+		log.Printf("Error al resolver %s '%s': %v", tabla, nombre, err)
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if c[tabla] == nil {
+		c[tabla] = make(map[string]int)
+	}
+	c[tabla][clave] = id
+	return id, nil
+}
+
+func (c catalogosImportacion) obtenerUnidadMedida(ctx context.Context, tx pgx.Tx, unidad string) (int, bool, error) {
+	clave := strings.ToLower(unidad)
+	if id, ok := c["unidad_medida"][clave]; ok {
+		return id, true, nil
+	}
+
+	var id int
+	err := tx.QueryRow(ctx, `
+        SELECT id FROM unidad_medida
+        WHERE LOWER(abreviatura) = LOWER($1) OR LOWER(nombre) = LOWER($1)
+        ORDER BY (LOWER(abreviatura) = LOWER($1)) DESC
+        LIMIT 1
+    `, unidad).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		log.Println("Error al buscar unidad de medida:", err)
+		return 0, false, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if c["unidad_medida"] == nil {
+		c["unidad_medida"] = make(map[string]int)
+	}
+	c["unidad_medida"][clave] = id
+	return id, true, nil
+}
+
+// importarProducto registra el producto o lo actualiza si ya existe con el mismo nombre y laboratorio;
+// las fotos y el stock de un producto existente no se modifican
+func importarProducto(ctx context.Context, tx pgx.Tx, fila *domain.ProductoImportacion, refs *referenciasProducto) (bool, error) {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+        SELECT id FROM producto WHERE UPPER(nombre_comercial) = UPPER($1) AND laboratorio_id = $2 FOR UPDATE
+    `, fila.NombreComercial, refs.laboratorioId).Scan(&id)
+	creado := errors.Is(err, pgx.ErrNoRows)
+	if err != nil && !creado {
+		log.Println("Error al buscar producto a importar:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+
+	var antes map[string]interface{}
+	var preciosAnteriores *preciosProducto
+	if creado {
+		err = tx.QueryRow(ctx, `
+            INSERT INTO producto (nombre_comercial, forma_farmaceutica_id, precio_compra, precio_venta, estado, stock,
+                                  stock_min, laboratorio_id, presentacion_id, unidades_presentacion, nivel_control,
+                                  precio_venta_unidad, refrigerado, controlado, fotos)
+            VALUES ($1, $2, COALESCE($3, 0.0), $4, 'Activo', 0, $5, $6, $7, $8, $9, $10, $11, $12, '{}')
+            RETURNING id
+        `, fila.NombreComercial, refs.formaFarmaceuticaId, fila.PrecioCompra, fila.PrecioVenta, fila.StockMin,
+			refs.laboratorioId, refs.presentacionId, fila.UnidadesPresentacion, fila.NivelControl, fila.PrecioVentaUnidad,
+			fila.Refrigerado, fila.Controlado).Scan(&id)
+		if err != nil {
+			log.Println("Error al registrar producto importado:", err)
+			return false, errorImportacionProducto(err)
+		}
+	} else {
+		if antes, err = estadoAuditoria(ctx, tx, domain.AuditoriaProducto, id.String()); err != nil {
+			return false, err
+		}
+		if preciosAnteriores, err = obtenerPreciosProducto(ctx, tx, id.String()); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `
+            UPDATE producto
+            SET forma_farmaceutica_id = $1, precio_compra = COALESCE($2, precio_compra), precio_venta = $3,
+                stock_min = $4, presentacion_id = $5, unidades_presentacion = $6, nivel_control = $7,
+                precio_venta_unidad = $8, refrigerado = $9, controlado = $10
+            WHERE id = $11
+        `, refs.formaFarmaceuticaId, fila.PrecioCompra, fila.PrecioVenta, fila.StockMin, refs.presentacionId,
+			fila.UnidadesPresentacion, fila.NivelControl, fila.PrecioVentaUnidad, fila.Refrigerado, fila.Controlado, id)
+		if err != nil {
+			log.Println("Error al actualizar producto importado:", err)
+			return false, errorImportacionProducto(err)
+		}
+		if _, err = tx.Exec(ctx, `DELETE FROM producto_categoria WHERE producto_id = $1`, id); err != nil {
+			return false, datatype.NewInternalServerErrorGeneric()
+		}
+		if _, err = tx.Exec(ctx, `DELETE FROM producto_principio_activo WHERE producto_id = $1`, id); err != nil {
+			return false, datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO producto_categoria(producto_id, categoria_id) SELECT $1, unnest($2::int[]) ON CONFLICT DO NOTHING`, id, pq.Array(refs.categorias))
+	if err != nil {
+		log.Println("Error al guardar categorías importadas:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+	for _, pa := range refs.principiosActivos {
+		_, err = tx.Exec(ctx, `
+            INSERT INTO producto_principio_activo(producto_id, principio_activo_id, concentracion, unidad_medida_id)
+            VALUES ($1, $2, $3, $4)
+        `, id, pa.PrincipioActivoId, pa.Concentracion, pa.UnidadMedidaId)
+		if err != nil {
+			log.Println("Error al guardar principios activos importados:", err)
+			return false, datatype.NewInternalServerErrorGeneric()
+		}
+	}
+	if err := guardarCodigosBarra(ctx, tx, id, fila.CodigosBarra); err != nil {
+		return false, err
+	}
+
+	referencia := referenciaImportacion
+	if err := registrarHistorialPrecio(ctx, tx, id.String(), preciosAnteriores, domain.PrecioOrigenMasivo, &referencia, usuarioContexto(ctx)); err != nil {
+		return false, err
+	}
+	accion := domain.AccionModificar
+	if creado {
+		accion = domain.AccionCrear
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaProducto, id.String(), accion, antes); err != nil {
+		return false, err
+	}
+	return creado, nil
+}
+
+// errorImportacionProducto traduce las restricciones de la tabla producto a errores de la fila
+func errorImportacionProducto(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return datatype.NewConflictError("Ya existe ese producto")
+		case "23514", "22003":
+			return datatype.NewBadRequestError("Los valores del producto están fuera del rango permitido")
+		}
+	}
+	return datatype.NewInternalServerErrorGeneric()
+}
+
+func NewImportacionRepository(pool *pgxpool.Pool) *ImportacionRepository {
+	return &ImportacionRepository{pool: pool}
+}
+
+var _ port.ImportacionRepository = (*ImportacionRepository)(nil)
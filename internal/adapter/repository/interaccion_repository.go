@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InteraccionRepository struct {
+	pool *pgxpool.Pool
+}
+
+const queryInteraccionInfo = `
+SELECT i.id,
+       jsonb_build_object('id', pa.id, 'nombre', pa.nombre, 'descripcion', pa.descripcion) AS principio_activo_a,
+       jsonb_build_object('id', pb.id, 'nombre', pb.nombre, 'descripcion', pb.descripcion) AS principio_activo_b,
+       i.severidad::TEXT,
+       i.descripcion,
+       i.created_at
+FROM interaccion_principio_activo i
+INNER JOIN principio_activo pa ON pa.id = i.principio_activo_a_id
+INNER JOIN principio_activo pb ON pb.id = i.principio_activo_b_id
+`
+
+func (r InteraccionRepository) ObtenerListaInteracciones(ctx context.Context, filtros map[string]string) (*[]domain.InteraccionInfo, error) {
+	query := queryInteraccionInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	// Filtrar por principio activo involucrado
+	if principioStr := filtros["principioActivoId"]; principioStr != "" {
+		principioId, err := strconv.Atoi(principioStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de principioActivoId no es válido")
+		}
+		filters = append(filters, fmt.Sprintf("(i.principio_activo_a_id = $%d OR i.principio_activo_b_id = $%d)", i, i))
+		args = append(args, principioId)
+		i++
+	}
+
+	if severidad := filtros["severidad"]; severidad != "" {
+		filters = append(filters, fmt.Sprintf("i.severidad::TEXT = $%d", i))
+		args = append(args, severidad)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY pa.nombre, pb.nombre"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar interacciones:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.InteraccionInfo, 0)
+	for rows.Next() {
+		var item domain.InteraccionInfo
+		if err := rows.Scan(&item.Id, &item.PrincipioActivoA, &item.PrincipioActivoB, &item.Severidad, &item.Descripcion, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear interacción:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (r InteraccionRepository) ObtenerInteraccionById(ctx context.Context, id *int) (*domain.InteraccionInfo, error) {
+	var item domain.InteraccionInfo
+	err := r.pool.QueryRow(ctx, queryInteraccionInfo+` WHERE i.id = $1`, *id).
+		Scan(&item.Id, &item.PrincipioActivoA, &item.PrincipioActivoB, &item.Severidad, &item.Descripcion, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Interacción no encontrada")
+		}
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &item, nil
+}
+
+func (r InteraccionRepository) RegistrarInteraccion(ctx context.Context, request *domain.InteraccionRequest) (*int, error) {
+	var id int
+	err := r.pool.QueryRow(ctx, `
+        INSERT INTO interaccion_principio_activo (principio_activo_a_id, principio_activo_b_id, severidad, descripcion)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, request.PrincipioActivoAId, request.PrincipioActivoBId, request.Severidad, request.Descripcion).Scan(&id)
+	if err != nil {
+		return nil, errorInteraccion(err)
+	}
+	return &id, nil
+}
+
+func (r InteraccionRepository) ModificarInteraccion(ctx context.Context, id *int, request *domain.InteraccionRequest) error {
+	ct, err := r.pool.Exec(ctx, `
+        UPDATE interaccion_principio_activo
+        SET principio_activo_a_id = $1, principio_activo_b_id = $2, severidad = $3, descripcion = $4
+        WHERE id = $5
+    `, request.PrincipioActivoAId, request.PrincipioActivoBId, request.Severidad, request.Descripcion, *id)
+	if err != nil {
+		return errorInteraccion(err)
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Interacción no encontrada")
+	}
+	return nil
+}
+
+func (r InteraccionRepository) EliminarInteraccion(ctx context.Context, id *int) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM interaccion_principio_activo WHERE id = $1`, *id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return datatype.NewConflictError("La interacción tiene autorizaciones registradas en ventas y no puede eliminarse")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Interacción no encontrada")
+	}
+	return nil
+}
+
+func (r InteraccionRepository) ObtenerAdvertenciasProductos(ctx context.Context, productoIds []string) (*[]domain.Advertencia, error) {
+	list := make([]domain.Advertencia, 0)
+	if len(productoIds) == 0 {
+		return &list, nil
+	}
+
+	// Interacciones entre los principios activos de los productos, más graves primero
+	rows, err := r.pool.Query(ctx, `
+        WITH principios AS (
+            SELECT DISTINCT ppa.principio_activo_id
+            FROM producto_principio_activo ppa
+            WHERE ppa.producto_id::TEXT = ANY($1)
+        )
+        SELECT i.id, i.severidad::TEXT, pa.nombre, pb.nombre, i.descripcion
+        FROM interaccion_principio_activo i
+        INNER JOIN principios a ON a.principio_activo_id = i.principio_activo_a_id
+        INNER JOIN principios b ON b.principio_activo_id = i.principio_activo_b_id
+        INNER JOIN principio_activo pa ON pa.id = i.principio_activo_a_id
+        INNER JOIN principio_activo pb ON pb.id = i.principio_activo_b_id
+        ORDER BY i.severidad DESC, pa.nombre, pb.nombre
+    `, productoIds)
+	if err != nil {
+		log.Println("Error al obtener interacciones de productos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var severidad, principioA, principioB string
+		item := domain.Advertencia{Tipo: domain.AdvertenciaInteraccion}
+		if err := rows.Scan(&id, &severidad, &principioA, &principioB, &item.Descripcion); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.InteraccionId = &id
+		item.Severidad = &severidad
+		item.PrincipiosActivos = []string{principioA, principioB}
+		list = append(list, item)
+	}
+	rows.Close()
+
+	// Contraindicaciones de los principios activos presentes
+	rows, err = r.pool.Query(ctx, `
+        SELECT DISTINCT pa.nombre, pa.contraindicaciones
+        FROM producto_principio_activo ppa
+        INNER JOIN principio_activo pa ON pa.id = ppa.principio_activo_id
+        WHERE ppa.producto_id::TEXT = ANY($1)
+          AND NULLIF(TRIM(pa.contraindicaciones), '') IS NOT NULL
+        ORDER BY pa.nombre
+    `, productoIds)
+	if err != nil {
+		log.Println("Error al obtener contraindicaciones de productos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var principio string
+		item := domain.Advertencia{Tipo: domain.AdvertenciaContraindicacion}
+		if err := rows.Scan(&principio, &item.Descripcion); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.PrincipiosActivos = []string{principio}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+// registrarAutorizacionesVenta guarda las interacciones graves autorizadas por el farmacéutico en la venta
+func registrarAutorizacionesVenta(ctx context.Context, tx pgx.Tx, ventaId int64, request *domain.VentaRequest) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM venta_interaccion WHERE venta_id = $1`, ventaId); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if len(request.InteraccionesGraves) == 0 {
+		return nil
+	}
+	if request.AutorizacionInteraccion == nil {
+		return datatype.NewErrorDataResponse(http.StatusConflict, "La venta contiene interacciones graves que requieren autorización del farmacéutico", request.Advertencias)
+	}
+
+	// Solo un farmacéutico puede autorizar la dispensación
+	var esFarmaceutico bool
+	err := tx.QueryRow(ctx, `
+        SELECT EXISTS(SELECT 1
+                      FROM usuario_rol ur
+                      INNER JOIN rol r ON r.id = ur.rol_id
+                      WHERE ur.usuario_id = $1 AND r.nombre = 'FARMACEUTICO' AND r.estado = 'Activo')
+    `, request.UsuarioId).Scan(&esFarmaceutico)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if !esFarmaceutico {
+		return datatype.NewErrorResponse(http.StatusForbidden, "Solo un farmacéutico puede autorizar interacciones graves")
+	}
+
+	for _, interaccionId := range request.InteraccionesGraves {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO venta_interaccion (venta_id, interaccion_id, usuario_id, motivo)
+            VALUES ($1, $2, $3, $4)
+        `, ventaId, interaccionId, request.UsuarioId, request.AutorizacionInteraccion.Motivo)
+		if err != nil {
+			log.Println("Error al registrar autorización de interacción:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+	}
+	return nil
+}
+
+// errorInteraccion traduce los errores de restricciones de interaccion_principio_activo
+func errorInteraccion(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return datatype.NewConflictError("La interacción entre estos principios activos ya está registrada")
+		case "23503":
+			return datatype.NewBadRequestError("Uno de los principios activos no existe")
+		case "22P02":
+			return datatype.NewBadRequestError("Severidad de interacción no válida")
+		}
+	}
+	log.Println("Error al guardar interacción:", err)
+	return datatype.NewInternalServerErrorGeneric()
+}
+
+func NewInteraccionRepository(pool *pgxpool.Pool) *InteraccionRepository {
+	return &InteraccionRepository{pool: pool}
+}
+
+var _ port.InteraccionRepository = (*InteraccionRepository)(nil)
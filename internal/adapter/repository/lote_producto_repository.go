@@ -9,6 +9,7 @@ import (
 	"farma-santi_backend/internal/core/port"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,7 +38,7 @@ func (l LoteProductoRepository) ActualizarLotesVencidos(ctx context.Context) err
 	}()
 
 	// Actualizar lotes vencidos y retornar producto_id
-	query := `UPDATE lote_producto SET estado = 'Vencido' WHERE CURRENT_TIMESTAMP >= fecha_vencimiento AND estado != 'Vencido' RETURNING producto_id`
+	query := `UPDATE lote_producto SET estado = 'Vencido' WHERE CURRENT_TIMESTAMP >= fecha_vencimiento AND estado NOT IN ('Vencido', 'Cuarentena') RETURNING producto_id`
 	rows, err := tx.Query(ctx, query)
 	if err != nil {
 		log.Println("Error al actualizar lotes vencidos:", err)
@@ -122,6 +123,11 @@ func (l LoteProductoRepository) ModificarLoteProducto(ctx context.Context, id *i
 		}
 	}()
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaLoteProducto, *id)
+	if err != nil {
+		return err
+	}
+
 	query = `UPDATE lote_producto SET lote=$1,fecha_vencimiento=$2,producto_id=$3 WHERE id = $4`
 	fechaVencimiento := request.FechaVencimiento.Format("2006-01-02")
 	// Insertamos el nuevo lote del producto
@@ -149,6 +155,9 @@ func (l LoteProductoRepository) ModificarLoteProducto(ctx context.Context, id *i
 		// Si no es error de Postgres conocido
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaLoteProducto, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
 	// Confirmamos la transacción
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -225,7 +234,22 @@ func (l LoteProductoRepository) ObtenerListaLotesProductos(ctx context.Context,
 		i++
 	}
 
-	var query = `SELECT lp.id,lp.lote,lp.stock,lp.fecha_vencimiento,lp.estado,lp.producto FROM view_lotes_con_productos lp`
+	// Sí hay ubicacionId en filtros, el stock mostrado es el guardado en la ubicación y las que contiene
+	stock := "lp.stock"
+	if ubicacionStr := filtros["ubicacionId"]; ubicacionStr != "" {
+		ubicacionId, err := strconv.Atoi(ubicacionStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de ubicacionId no es válido")
+		}
+		stock = fmt.Sprintf(`(SELECT COALESCE(SUM(lu.cantidad), 0)::BIGINT
+		          FROM lote_ubicacion lu
+		          WHERE lu.lote_id = lp.id AND lu.ubicacion_id IN (SELECT ubicaciones_descendientes($%d)))`, i)
+		filters = append(filters, stock+" > 0")
+		args = append(args, ubicacionId)
+		i++
+	}
+
+	var query = `SELECT lp.id,lp.lote,` + stock + `,lp.fecha_vencimiento,lp.estado,lp.producto FROM view_lotes_con_productos lp`
 	if len(filters) > 0 {
 		query += " WHERE " + strings.Join(filters, " AND ")
 	}
@@ -267,10 +291,11 @@ func (l LoteProductoRepository) RegistrarLoteProducto(ctx context.Context, reque
 		}
 	}()
 
-	query := `INSERT INTO lote_producto(lote, fecha_vencimiento, producto_id) VALUES ($1, $2, $3)`
+	query := `INSERT INTO lote_producto(lote, fecha_vencimiento, producto_id) VALUES ($1, $2, $3) RETURNING id`
 	fechaVencimiento := request.FechaVencimiento.Format("2006-01-02")
 	// Insertamos el nuevo lote del producto
-	_, err = tx.Exec(ctx, query, request.Lote, fechaVencimiento, request.ProductoId.String())
+	var loteId int
+	err = tx.QueryRow(ctx, query, request.Lote, fechaVencimiento, request.ProductoId.String()).Scan(&loteId)
 	if err != nil {
 		// Detectar errores específicos de PostgreSQL
 		var pgErr *pgconn.PgError
@@ -294,6 +319,9 @@ func (l LoteProductoRepository) RegistrarLoteProducto(ctx context.Context, reque
 		// Si no es error de Postgres conocido
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaLoteProducto, loteId, domain.AccionCrear, nil); err != nil {
+		return err
+	}
 
 	// Confirmamos la transacción
 	if err := tx.Commit(ctx); err != nil {
@@ -317,6 +345,32 @@ func (l LoteProductoRepository) ObtenerLoteProductoById(ctx context.Context, id
 		return nil, datatype.NewInternalServerErrorGeneric()
 	}
 
+	// Ubicaciones donde está guardado el stock del lote
+	rows, err := l.pool.Query(ctx, `
+        SELECT jsonb_build_object('id', u.id, 'codigo', u.codigo, 'ruta', ruta_ubicacion(u.id)), lu.cantidad
+        FROM lote_ubicacion lu
+        INNER JOIN ubicacion u ON u.id = lu.ubicacion_id
+        WHERE lu.lote_id = $1 AND lu.cantidad > 0
+        ORDER BY u.codigo
+    `, *id)
+	if err != nil {
+		log.Println("Error al obtener ubicaciones del lote:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Ubicaciones = make([]domain.LoteUbicacion, 0)
+	item.StockSinUbicacion = int64(item.Stock)
+	for rows.Next() {
+		var ubicacion domain.LoteUbicacion
+		if err := rows.Scan(&ubicacion.Ubicacion, &ubicacion.Cantidad); err != nil {
+			log.Println("Error al escanear ubicación del lote:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.Ubicaciones = append(item.Ubicaciones, ubicacion)
+		item.StockSinUbicacion -= ubicacion.Cantidad
+	}
+
 	return &item, nil
 }
 
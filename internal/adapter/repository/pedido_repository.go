@@ -0,0 +1,522 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PedidoRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (p PedidoRepository) ObtenerCarrito(ctx context.Context, usuarioUid string) (*domain.Carrito, error) {
+	rows, err := p.pool.Query(ctx, `
+        SELECT jsonb_build_object('id', pr.id, 'nombreComercial', pr.nombre_comercial, 'laboratorio', l.nombre) AS producto,
+               ci.cantidad,
+               ci.unidad_venta,
+               CASE WHEN ci.unidad_venta = 'Unidad' THEN COALESCE(pr.precio_venta_unidad, 0) ELSE pr.precio_venta END,
+               COALESCE((
+                   SELECT SUM(GREATEST(lp.stock - COALESCE((
+                       SELECT SUM(r.cantidad) FROM reserva_lote r
+                       WHERE r.lote_id = lp.id AND r.fecha_expiracion > NOW()
+                   ), 0), 0) / CASE WHEN ci.unidad_venta = 'Unidad' THEN 1 ELSE COALESCE(NULLIF(pr.unidades_presentacion, 0), 1) END)
+                   FROM lote_producto lp
+                   WHERE lp.producto_id = pr.id AND lp.estado = 'Activo'
+               ), 0) AS disponible
+        FROM carrito_item ci
+        INNER JOIN producto pr ON pr.id = ci.producto_id
+        INNER JOIN laboratorio l ON l.id = pr.laboratorio_id
+        WHERE ci.usuario_uid = $1
+        ORDER BY ci.updated_at`, usuarioUid)
+	if err != nil {
+		log.Println("Error al obtener carrito:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	carrito := domain.Carrito{Items: make([]domain.CarritoItem, 0)}
+	for rows.Next() {
+		var item domain.CarritoItem
+		if err := rows.Scan(&item.Producto, &item.Cantidad, &item.UnidadVenta, &item.PrecioVenta, &item.Disponible); err != nil {
+			log.Println("Error al escanear carrito:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.Subtotal = float64(item.Cantidad) * item.PrecioVenta
+		carrito.Total += item.Subtotal
+		carrito.Items = append(carrito.Items, item)
+	}
+	return &carrito, nil
+}
+
+func (p PedidoRepository) GuardarItemCarrito(ctx context.Context, usuarioUid string, request *domain.CarritoItemRequest) error {
+	// Cantidad cero elimina el producto del carrito
+	if request.Cantidad == 0 {
+		_, err := p.pool.Exec(ctx, `DELETE FROM carrito_item WHERE usuario_uid = $1 AND producto_id = $2`, usuarioUid, request.ProductoId)
+		if err != nil {
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		return nil
+	}
+
+	// Solo se puede pedir por unidad un producto con precio por unidad
+	result, err := p.pool.Exec(ctx, `
+        INSERT INTO carrito_item (usuario_uid, producto_id, cantidad, unidad_venta)
+        SELECT $1, id, $3, $4 FROM producto
+        WHERE id = $2 AND estado = 'Activo' AND deleted_at IS NULL
+          AND ($4 = 'Presentacion' OR precio_venta_unidad IS NOT NULL)
+        ON CONFLICT (usuario_uid, producto_id) DO UPDATE SET cantidad = EXCLUDED.cantidad, unidad_venta = EXCLUDED.unidad_venta, updated_at = NOW()
+    `, usuarioUid, request.ProductoId, request.Cantidad, request.UnidadVenta)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return datatype.NewBadRequestError("El 'id' del producto no es válido")
+		}
+		log.Println("Error al guardar item de carrito:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if result.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Producto no disponible en la unidad de venta solicitada")
+	}
+	return nil
+}
+
+func (p PedidoRepository) VaciarCarrito(ctx context.Context, usuarioUid string) error {
+	_, err := p.pool.Exec(ctx, `DELETE FROM carrito_item WHERE usuario_uid = $1`, usuarioUid)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (p PedidoRepository) RegistrarPedido(ctx context.Context, request *domain.PedidoRequest, fechaLimite time.Time) (*int, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Productos del carrito, bloqueados para evitar pedidos duplicados
+	rows, err := tx.Query(ctx, `
+        SELECT producto_id, cantidad, unidad_venta FROM carrito_item
+        WHERE usuario_uid = $1
+        ORDER BY updated_at
+        FOR UPDATE`, request.UsuarioUid)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	var detalles []domain.DetalleVentaRequest
+	for rows.Next() {
+		var d domain.DetalleVentaRequest
+		if err := rows.Scan(&d.ProductoId, &d.Cantidad, &d.UnidadVenta); err != nil {
+			rows.Close()
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		detalles = append(detalles, d)
+	}
+	rows.Close()
+	if len(detalles) == 0 {
+		return nil, datatype.NewBadRequestError("El carrito está vacío")
+	}
+
+	codigo, err := generarCodigoPedido(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pedidoId int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO pedido (codigo, usuario_uid, email, telefono, comentario, fecha_limite)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+        RETURNING id
+    `, codigo, request.UsuarioUid, request.Email, request.Telefono, request.Comentario, fechaLimite).Scan(&pedidoId)
+	if err != nil {
+		log.Println("Error al registrar pedido:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Reservar stock por FEFO hasta la fecha límite de recojo
+	total := 0.0
+	for _, item := range detalles {
+		asignaciones, err := asignarStockVenta(ctx, tx, item)
+		if err != nil {
+			return nil, err
+		}
+		precio := asignaciones[0].Lote.PrecioVenta
+		var detalleId int
+		err = tx.QueryRow(ctx, `
+            INSERT INTO detalle_pedido (pedido_id, producto_id, cantidad, precio, unidad_venta)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id
+        `, pedidoId, item.ProductoId, item.Cantidad, precio, unidadVenta(item)).Scan(&detalleId)
+		if err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		// La reserva guarda unidades base y el detalle al que pertenece para entregar los mismos lotes
+		for _, asignacion := range asignaciones {
+			_, err = tx.Exec(ctx, `
+                INSERT INTO reserva_lote (lote_id, cantidad, pedido_id, detalle_pedido_id, fecha_expiracion)
+                VALUES ($1, $2, $3, $4, $5)
+            `, asignacion.Lote.Id, asignacion.Cantidad*asignacion.Lote.Factor, pedidoId, detalleId, fechaLimite)
+			if err != nil {
+				return nil, datatype.NewInternalServerErrorGeneric()
+			}
+		}
+		total += float64(item.Cantidad) * precio
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE pedido SET total = $1 WHERE id = $2`, total, pedidoId); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM carrito_item WHERE usuario_uid = $1`, request.UsuarioUid); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &pedidoId, nil
+}
+
+const queryPedidoInfo = `
+SELECT pe.id,
+       pe.codigo,
+       pe.estado::TEXT,
+       pe.usuario_uid,
+       cc.cliente_id,
+       pe.email,
+       pe.telefono,
+       pe.comentario,
+       pe.total,
+       pe.fecha_limite,
+       pe.venta_id,
+       pe.created_at,
+       pe.updated_at
+FROM pedido pe
+LEFT JOIN cliente_cuenta cc ON cc.usuario_uid = pe.usuario_uid
+`
+
+func (p PedidoRepository) ObtenerListaPedidos(ctx context.Context, filtros map[string]string) (*[]domain.PedidoInfo, error) {
+	query := queryPedidoInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	if estado := filtros["estado"]; estado != "" {
+		filters = append(filters, fmt.Sprintf("pe.estado::TEXT = $%d", i))
+		args = append(args, estado)
+		i++
+	}
+
+	if usuarioUid := filtros["usuarioUid"]; usuarioUid != "" {
+		filters = append(filters, fmt.Sprintf("pe.usuario_uid = $%d", i))
+		args = append(args, usuarioUid)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY pe.created_at DESC"
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar pedidos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var list = make([]domain.PedidoInfo, 0)
+	for rows.Next() {
+		var item domain.PedidoInfo
+		if err := rows.Scan(&item.Id, &item.Codigo, &item.Estado, &item.UsuarioUid, &item.ClienteId, &item.Email, &item.Telefono, &item.Comentario,
+			&item.Total, &item.FechaLimite, &item.VentaId, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			log.Println("Error al escanear pedido:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PedidoRepository) ObtenerPedidoById(ctx context.Context, id *int) (*domain.PedidoDetail, error) {
+	var item domain.PedidoDetail
+	err := p.pool.QueryRow(ctx, queryPedidoInfo+` WHERE pe.id = $1`, *id).
+		Scan(&item.Id, &item.Codigo, &item.Estado, &item.UsuarioUid, &item.ClienteId, &item.Email, &item.Telefono, &item.Comentario,
+			&item.Total, &item.FechaLimite, &item.VentaId, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Pedido no encontrado")
+		}
+		log.Println("Error al obtener pedido:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	rows, err := p.pool.Query(ctx, `
+        SELECT jsonb_build_object('id', pr.id, 'nombreComercial', pr.nombre_comercial, 'laboratorio', l.nombre) AS producto,
+               dp.cantidad, dp.unidad_venta, dp.precio
+        FROM detalle_pedido dp
+        INNER JOIN producto pr ON pr.id = dp.producto_id
+        INNER JOIN laboratorio l ON l.id = pr.laboratorio_id
+        WHERE dp.pedido_id = $1
+        ORDER BY dp.id`, *id)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Detalles = make([]domain.PedidoDetalle, 0)
+	for rows.Next() {
+		var d domain.PedidoDetalle
+		if err := rows.Scan(&d.Producto, &d.Cantidad, &d.UnidadVenta, &d.Precio); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		d.Subtotal = float64(d.Cantidad) * d.Precio
+		item.Detalles = append(item.Detalles, d)
+	}
+	return &item, nil
+}
+
+func (p PedidoRepository) ActualizarEstadoPedido(ctx context.Context, id *int, estado string, estadosPrevios []string) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := bloquearPedido(ctx, tx, *id, estadosPrevios); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE pedido SET estado = $1, updated_at = NOW() WHERE id = $2`, estado, *id); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Un pedido cancelado libera su reserva de stock
+	if estado == domain.PedidoCancelado {
+		if _, err := tx.Exec(ctx, `DELETE FROM reserva_lote WHERE pedido_id = $1`, *id); err != nil {
+			return datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (p PedidoRepository) EntregarPedido(ctx context.Context, id *int, request *domain.VentaRequest) (*int64, error) {
+	if len(request.Detalles) == 0 {
+		return nil, datatype.NewBadRequestError("El pedido debe tener al menos un detalle")
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := bloquearPedido(ctx, tx, *id, []string{domain.PedidoListo}); err != nil {
+		return nil, err
+	}
+
+	// Entregar los lotes reservados para el pedido
+	detalles, err := detallesReservadosPedido(ctx, tx, *id)
+	if err != nil {
+		return nil, err
+	}
+	request.Detalles = detalles
+
+	// Liberar la reserva y registrar la venta con salida definitiva de stock
+	if _, err := tx.Exec(ctx, `DELETE FROM reserva_lote WHERE pedido_id = $1`, *id); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	ventaId, err := registrarVenta(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE pedido SET estado = 'Entregado', venta_id = $1, updated_at = NOW()
+        WHERE id = $2
+    `, ventaId, *id)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &ventaId, nil
+}
+
+func (p PedidoRepository) CancelarPedidosVencidos(ctx context.Context) (int64, error) {
+	var cancelados int64
+	err := p.pool.QueryRow(ctx, `
+        WITH vencidos AS (
+            UPDATE pedido SET estado = 'Cancelado', updated_at = NOW()
+            WHERE estado IN ('Recibido', 'Preparando', 'Listo') AND fecha_limite <= NOW()
+            RETURNING id
+        ), liberadas AS (
+            DELETE FROM reserva_lote r USING vencidos v WHERE r.pedido_id = v.id
+        )
+        SELECT COUNT(*) FROM vencidos
+    `).Scan(&cancelados)
+	if err != nil {
+		log.Println("Error al cancelar pedidos vencidos:", err)
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	return cancelados, nil
+}
+
+// bloquearPedido bloquea el pedido y verifica que su estado actual permita la operación
+func bloquearPedido(ctx context.Context, tx pgx.Tx, pedidoId int, estadosPrevios []string) error {
+	var estado string
+	err := tx.QueryRow(ctx, `SELECT estado::TEXT FROM pedido WHERE id = $1 FOR UPDATE`, pedidoId).Scan(&estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Pedido no encontrado")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	for _, previo := range estadosPrevios {
+		if estado == previo {
+			return nil
+		}
+	}
+	return datatype.NewConflictError(fmt.Sprintf("No se puede cambiar un pedido en estado '%s'", estado))
+}
+
+// detallesReservadosPedido arma los detalles del pedido con sus precios y los lotes de su reserva, bloqueados; si la reserva
+// ya no cubre un detalle (lote inactivo o sin stock) ese detalle se vuelve a asignar por FEFO
+func detallesReservadosPedido(ctx context.Context, tx pgx.Tx, pedidoId int) ([]domain.DetalleVentaRequest, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT id, producto_id::TEXT, cantidad, unidad_venta, precio FROM detalle_pedido
+        WHERE pedido_id = $1
+        ORDER BY id`, pedidoId)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	var detalleIds []int
+	var detalles []domain.DetalleVentaRequest
+	for rows.Next() {
+		var detalleId int
+		var precio float64
+		var d domain.DetalleVentaRequest
+		if err := rows.Scan(&detalleId, &d.ProductoId, &d.Cantidad, &d.UnidadVenta, &precio); err != nil {
+			rows.Close()
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		// Se cobra el precio confirmado en el pedido
+		d.Precio = &precio
+		detalleIds = append(detalleIds, detalleId)
+		detalles = append(detalles, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if len(detalles) == 0 {
+		return nil, datatype.NewBadRequestError("El pedido debe tener al menos un detalle")
+	}
+
+	rows, err = tx.Query(ctx, `
+        SELECT r.detalle_pedido_id, r.cantidad,
+               lp.id, lp.lote, lp.fecha_vencimiento, lp.estado = 'Activo' AND lp.stock >= r.cantidad,
+               p.precio_venta,
+               COALESCE(NULLIF(p.unidades_presentacion, 0), 1),
+               p.precio_venta_unidad
+        FROM reserva_lote r
+        JOIN lote_producto lp ON lp.id = r.lote_id
+        JOIN producto p ON p.id = lp.producto_id
+        WHERE r.pedido_id = $1 AND r.detalle_pedido_id IS NOT NULL
+        ORDER BY lp.fecha_vencimiento ASC, lp.id ASC
+        FOR UPDATE OF lp`, pedidoId)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	reservados := make(map[int][]domain.VentaLoteAsignacion)
+	invalidos := make(map[int]bool)
+	for rows.Next() {
+		var detalleId int
+		var cantidad uint
+		var vigente bool
+		var lote domain.VentaLoteProductoDAO
+		if err := rows.Scan(&detalleId, &cantidad, &lote.Id, &lote.Lote, &lote.FechaVencimiento, &vigente,
+			&lote.PrecioVenta, &lote.UnidadesPresentacion, &lote.PrecioVentaUnidad); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		i := slices.Index(detalleIds, detalleId)
+		if i < 0 {
+			continue
+		}
+		// La reserva está en unidades base; se convierte a la unidad de venta del detalle
+		lotes, err := convertirLotesUnidadVenta([]domain.VentaLoteProductoDAO{{
+			Id: lote.Id, Lote: lote.Lote, FechaVencimiento: lote.FechaVencimiento, Stock: cantidad,
+			PrecioVenta: lote.PrecioVenta, UnidadesPresentacion: lote.UnidadesPresentacion, PrecioVentaUnidad: lote.PrecioVentaUnidad,
+		}}, detalles[i])
+		if err != nil || len(lotes) == 0 || !vigente || cantidad%lotes[0].Factor != 0 {
+			invalidos[detalleId] = true
+			continue
+		}
+		reservados[detalleId] = append(reservados[detalleId], domain.VentaLoteAsignacion{Lote: lotes[0], Cantidad: lotes[0].Stock})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	for i, detalleId := range detalleIds {
+		if invalidos[detalleId] {
+			continue
+		}
+		var cubierto uint
+		for _, asignacion := range reservados[detalleId] {
+			cubierto += asignacion.Cantidad
+		}
+		if cubierto == detalles[i].Cantidad {
+			detalles[i].Lotes = reservados[detalleId]
+		}
+	}
+	return detalles, nil
+}
+
+// generarCodigoPedido genera el siguiente código correlativo de pedido
+func generarCodigoPedido(ctx context.Context, tx pgx.Tx) (string, error) {
+	var nextNum int64
+	err := tx.QueryRow(ctx, `
+        SELECT COALESCE(
+            (SELECT MAX(CAST(SUBSTRING(codigo FROM 5) AS INTEGER)) + 1 FROM pedido WHERE codigo ~ '^PED-[0-9]+$'),
+            1
+        )
+    `).Scan(&nextNum)
+	if err != nil {
+		return "", datatype.NewInternalServerErrorGeneric()
+	}
+	return fmt.Sprintf("PED-%09d", nextNum), nil
+}
+
+func NewPedidoRepository(pool *pgxpool.Pool) *PedidoRepository {
+	return &PedidoRepository{pool: pool}
+}
+
+var _ port.PedidoRepository = (*PedidoRepository)(nil)
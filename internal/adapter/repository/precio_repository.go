@@ -0,0 +1,688 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PrecioRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (p PrecioRepository) ObtenerHistorialPrecios(ctx context.Context, productoId *uuid.UUID) (*[]domain.HistorialPrecio, error) {
+	var existe bool
+	err := p.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM producto WHERE id = $1)`, productoId.String()).Scan(&existe)
+	if err != nil {
+		log.Println("Error al verificar producto:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if !existe {
+		return nil, datatype.NewNotFoundError("Producto no encontrado")
+	}
+
+	rows, err := p.pool.Query(ctx, `
+        SELECT h.id, h.origen, h.referencia,
+               h.precio_compra_anterior, h.precio_compra,
+               h.precio_venta_anterior, h.precio_venta,
+               h.precio_venta_unidad_anterior, h.precio_venta_unidad,
+               CASE WHEN u.id IS NULL THEN NULL ELSE jsonb_build_object('id', u.id, 'username', u.username) END,
+               h.created_at
+        FROM historial_precio h
+        LEFT JOIN usuario u ON u.id = h.usuario_id
+        WHERE h.producto_id = $1
+        ORDER BY h.created_at DESC, h.id DESC
+    `, productoId.String())
+	if err != nil {
+		log.Println("Error al obtener historial de precios:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.HistorialPrecio, 0)
+	for rows.Next() {
+		var item domain.HistorialPrecio
+		if err := rows.Scan(&item.Id, &item.Origen, &item.Referencia, &item.PrecioCompraAnterior, &item.PrecioCompra,
+			&item.PrecioVentaAnterior, &item.PrecioVenta, &item.PrecioVentaUnidadAnterior, &item.PrecioVentaUnidad,
+			&item.Usuario, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear historial de precios:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PrecioRepository) ObtenerListaPreciosProgramados(ctx context.Context, filtros map[string]string) (*[]domain.PrecioProgramadoInfo, error) {
+	query := `
+        SELECT pp.id,
+               jsonb_build_object('id', pr.id, 'nombreComercial', pr.nombre_comercial, 'laboratorio', l.nombre,
+                                  'presentacion', jsonb_build_object('id', pre.id, 'nombre', pre.nombre),
+                                  'unidadesPresentacion', pr.unidades_presentacion),
+               pr.precio_venta,
+               pp.precio_venta,
+               pp.precio_venta_unidad,
+               pp.fecha_efectiva,
+               pp.estado,
+               pp.compra_id,
+               c.codigo,
+               CASE WHEN u.id IS NULL THEN NULL ELSE jsonb_build_object('id', u.id, 'username', u.username) END,
+               pp.created_at,
+               pp.aplicado_at
+        FROM precio_programado pp
+        INNER JOIN producto pr ON pr.id = pp.producto_id
+        INNER JOIN laboratorio l ON l.id = pr.laboratorio_id
+        LEFT JOIN presentacion pre ON pre.id = pr.presentacion_id
+        LEFT JOIN compra c ON c.id = pp.compra_id
+        LEFT JOIN usuario u ON u.id = pp.usuario_id
+    `
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	if estado := filtros["estado"]; estado != "" {
+		filters = append(filters, fmt.Sprintf("pp.estado = $%d", i))
+		args = append(args, estado)
+		i++
+	}
+
+	if productoId := filtros["productoId"]; productoId != "" {
+		filters = append(filters, fmt.Sprintf("pp.producto_id::TEXT = $%d", i))
+		args = append(args, productoId)
+		i++
+	}
+
+	if compraId := filtros["compraId"]; compraId != "" {
+		filters = append(filters, fmt.Sprintf("pp.compra_id::TEXT = $%d", i))
+		args = append(args, compraId)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY pp.created_at DESC, pp.id DESC"
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar precios programados:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.PrecioProgramadoInfo, 0)
+	for rows.Next() {
+		var item domain.PrecioProgramadoInfo
+		if err := rows.Scan(&item.Id, &item.Producto, &item.PrecioVentaActual, &item.PrecioVenta, &item.PrecioVentaUnidad,
+			&item.FechaEfectiva, &item.Estado, &item.CompraId, &item.CompraCodigo, &item.Usuario, &item.CreatedAt, &item.AplicadoAt); err != nil {
+			log.Println("Error al escanear precio programado:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PrecioRepository) ProgramarPrecio(ctx context.Context, request *domain.PrecioProgramadoRequest) (*int, error) {
+	var id int
+	err := p.pool.QueryRow(ctx, `
+        INSERT INTO precio_programado (producto_id, precio_venta, precio_venta_unidad, fecha_efectiva, estado, usuario_id)
+        VALUES ($1, $2, $3, $4, 'Pendiente', $5)
+        RETURNING id
+    `, request.ProductoId.String(), request.PrecioVenta, request.PrecioVentaUnidad, request.FechaEfectiva, request.UsuarioId).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, datatype.NewNotFoundError("Producto no encontrado")
+		}
+		log.Println("Error al programar precio:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &id, nil
+}
+
+func (p PrecioRepository) AprobarPrecioProgramado(ctx context.Context, id *int, fechaEfectiva *time.Time) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	programado, err := bloquearPrecioProgramado(ctx, tx, *id)
+	if err != nil {
+		return err
+	}
+	if programado.estado != domain.PrecioProgramadoPropuesto {
+		return datatype.NewConflictError("Solo se pueden aprobar precios propuestos por una compra")
+	}
+
+	usuarioId := usuarioContexto(ctx)
+	if fechaEfectiva != nil && fechaEfectiva.After(time.Now()) {
+		_, err = tx.Exec(ctx, `
+            UPDATE precio_programado SET estado = 'Pendiente', fecha_efectiva = $1, usuario_id = $2 WHERE id = $3
+        `, *fechaEfectiva, usuarioId, *id)
+		if err != nil {
+			log.Println("Error al programar precio propuesto:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+	} else {
+		programado.usuarioId = usuarioId
+		if err := aplicarPrecioProgramado(ctx, tx, programado); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (p PrecioRepository) CancelarPrecioProgramado(ctx context.Context, id *int) error {
+	ct, err := p.pool.Exec(ctx, `
+        UPDATE precio_programado SET estado = 'Cancelado'
+        WHERE id = $1 AND estado IN ('Propuesto', 'Pendiente')
+    `, *id)
+	if err != nil {
+		log.Println("Error al cancelar precio programado:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewConflictError("El precio programado no existe o ya fue aplicado o cancelado")
+	}
+	return nil
+}
+
+func (p PrecioRepository) AplicarPreciosProgramados(ctx context.Context) (int64, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, `
+        SELECT id, producto_id::TEXT, precio_venta, precio_venta_unidad, estado, usuario_id
+        FROM precio_programado
+        WHERE estado = 'Pendiente' AND fecha_efectiva <= NOW()
+        ORDER BY fecha_efectiva, id
+        FOR UPDATE SKIP LOCKED
+    `)
+	if err != nil {
+		log.Println("Error al obtener precios programados:", err)
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	var pendientes []precioProgramado
+	for rows.Next() {
+		var item precioProgramado
+		if err := rows.Scan(&item.id, &item.productoId, &item.precioVenta, &item.precioVentaUnidad, &item.estado, &item.usuarioId); err != nil {
+			rows.Close()
+			return 0, datatype.NewInternalServerErrorGeneric()
+		}
+		pendientes = append(pendientes, item)
+	}
+	rows.Close()
+
+	for _, item := range pendientes {
+		if err := aplicarPrecioProgramado(ctx, tx, item); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	return int64(len(pendientes)), nil
+}
+
+func (p PrecioRepository) ObtenerProductosCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*[]domain.CambioPrecioMasivo, error) {
+	query := `
+        SELECT jsonb_build_object('id', p.id, 'nombreComercial', p.nombre_comercial, 'laboratorio', l.nombre,
+                                  'presentacion', jsonb_build_object('id', pre.id, 'nombre', pre.nombre),
+                                  'unidadesPresentacion', p.unidades_presentacion),
+               p.precio_compra,
+               p.precio_venta,
+               p.precio_venta_unidad
+        FROM producto p
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        LEFT JOIN presentacion pre ON pre.id = p.presentacion_id
+        WHERE p.deleted_at IS NULL
+    `
+
+	var args []interface{}
+	i := 1
+
+	if request.LaboratorioId != nil {
+		query += fmt.Sprintf(" AND p.laboratorio_id = $%d", i)
+		args = append(args, *request.LaboratorioId)
+		i++
+	}
+
+	if request.CategoriaId != nil {
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM producto_categoria pc WHERE pc.producto_id = p.id AND pc.categoria_id = $%d)", i)
+		args = append(args, *request.CategoriaId)
+		i++
+	}
+	query += " ORDER BY p.nombre_comercial, l.nombre"
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al obtener productos para cambio masivo:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.CambioPrecioMasivo, 0)
+	for rows.Next() {
+		var item domain.CambioPrecioMasivo
+		if err := rows.Scan(&item.Producto, &item.PrecioCompra, &item.PrecioVentaAnterior, &item.PrecioVentaUnidadAnterior); err != nil {
+			log.Println("Error al escanear producto para cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PrecioRepository) AplicarCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest, cambios *[]domain.CambioPrecioMasivo) (*int, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	filtros := make(map[string]interface{})
+	if request.LaboratorioId != nil {
+		filtros["laboratorioId"] = *request.LaboratorioId
+	}
+	if request.CategoriaId != nil {
+		filtros["categoriaId"] = *request.CategoriaId
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO cambio_precio_masivo (tipo, valor, redondeo, modo_redondeo, filtros, comentario, usuario_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `, request.Tipo, request.Valor, request.Redondeo, request.ModoRedondeo, filtros, request.Comentario, request.UsuarioId).Scan(&id)
+	if err != nil {
+		log.Println("Error al registrar cambio masivo:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	usuarioId := int(request.UsuarioId)
+	referencia := fmt.Sprintf("Cambio masivo #%d", id)
+	for _, cambio := range *cambios {
+		productoId := cambio.Producto.Id.String()
+		antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, productoId)
+		if err != nil {
+			return nil, err
+		}
+		anteriores, err := obtenerPreciosProducto(ctx, tx, productoId)
+		if err != nil {
+			return nil, err
+		}
+		if anteriores.venta != cambio.PrecioVentaAnterior || !mismoPrecio(anteriores.ventaUnidad, cambio.PrecioVentaUnidadAnterior) {
+			return nil, datatype.NewConflictError(fmt.Sprintf("El precio de %s cambió desde la vista previa, vuelva a previsualizar", cambio.Producto.NombreComercial))
+		}
+
+		_, err = tx.Exec(ctx, `
+            UPDATE producto SET precio_venta = $1, precio_venta_unidad = $2 WHERE id::TEXT = $3
+        `, cambio.PrecioVenta, cambio.PrecioVentaUnidad, productoId)
+		if err != nil {
+			log.Println("Error al actualizar precio en cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO detalle_cambio_precio_masivo (cambio_precio_masivo_id, producto_id, precio_venta_anterior, precio_venta,
+                                                      precio_venta_unidad_anterior, precio_venta_unidad)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, id, productoId, cambio.PrecioVentaAnterior, cambio.PrecioVenta, cambio.PrecioVentaUnidadAnterior, cambio.PrecioVentaUnidad)
+		if err != nil {
+			log.Println("Error al registrar detalle de cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+
+		if err := registrarHistorialPrecio(ctx, tx, productoId, anteriores, domain.PrecioOrigenMasivo, &referencia, &usuarioId); err != nil {
+			return nil, err
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaProducto, productoId, domain.AccionModificar, antes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &id, nil
+}
+
+// consultaCambioMasivo obtiene la cabecera de los cambios masivos de precios con la cantidad de productos afectados
+const consultaCambioMasivo = `
+    SELECT c.id, c.tipo, c.valor, c.redondeo, c.modo_redondeo, c.filtros, c.comentario, c.estado,
+           (SELECT COUNT(*) FROM detalle_cambio_precio_masivo d WHERE d.cambio_precio_masivo_id = c.id),
+           jsonb_build_object('id', u.id, 'username', u.username, 'estado', u.estado),
+           c.created_at,
+           CASE WHEN r.id IS NULL THEN NULL ELSE jsonb_build_object('id', r.id, 'username', r.username, 'estado', r.estado) END,
+           c.revertido_at
+    FROM cambio_precio_masivo c
+    INNER JOIN usuario u ON u.id = c.usuario_id
+    LEFT JOIN usuario r ON r.id = c.revertido_por
+`
+
+func escanearCambioMasivo(row pgx.Row, item *domain.CambioMasivoInfo) error {
+	return row.Scan(&item.Id, &item.Tipo, &item.Valor, &item.Redondeo, &item.ModoRedondeo, &item.Filtros, &item.Comentario,
+		&item.Estado, &item.Productos, &item.Usuario, &item.CreatedAt, &item.RevertidoPor, &item.RevertidoAt)
+}
+
+func (p PrecioRepository) ObtenerListaCambiosMasivos(ctx context.Context) (*[]domain.CambioMasivoInfo, error) {
+	rows, err := p.pool.Query(ctx, consultaCambioMasivo+" ORDER BY c.created_at DESC, c.id DESC")
+	if err != nil {
+		log.Println("Error al listar cambios masivos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.CambioMasivoInfo, 0)
+	for rows.Next() {
+		var item domain.CambioMasivoInfo
+		if err := escanearCambioMasivo(rows, &item); err != nil {
+			log.Println("Error al escanear cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PrecioRepository) ObtenerCambioMasivoById(ctx context.Context, id *int) (*domain.CambioMasivoDetail, error) {
+	var detail domain.CambioMasivoDetail
+	err := escanearCambioMasivo(p.pool.QueryRow(ctx, consultaCambioMasivo+" WHERE c.id = $1", *id), &detail.CambioMasivoInfo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Cambio masivo no encontrado")
+		}
+		log.Println("Error al obtener cambio masivo:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	rows, err := p.pool.Query(ctx, `
+        SELECT jsonb_build_object('id', pr.id, 'nombreComercial', pr.nombre_comercial, 'laboratorio', l.nombre,
+                                  'presentacion', jsonb_build_object('id', pre.id, 'nombre', pre.nombre),
+                                  'unidadesPresentacion', pr.unidades_presentacion),
+               d.precio_venta_anterior, d.precio_venta, d.precio_venta_unidad_anterior, d.precio_venta_unidad, d.revertido
+        FROM detalle_cambio_precio_masivo d
+        INNER JOIN producto pr ON pr.id = d.producto_id
+        INNER JOIN laboratorio l ON l.id = pr.laboratorio_id
+        LEFT JOIN presentacion pre ON pre.id = pr.presentacion_id
+        WHERE d.cambio_precio_masivo_id = $1
+        ORDER BY pr.nombre_comercial, l.nombre
+    `, *id)
+	if err != nil {
+		log.Println("Error al obtener detalle de cambio masivo:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	detail.Detalles = make([]domain.DetalleCambioMasivo, 0)
+	for rows.Next() {
+		var item domain.DetalleCambioMasivo
+		if err := rows.Scan(&item.Producto, &item.PrecioVentaAnterior, &item.PrecioVenta, &item.PrecioVentaUnidadAnterior,
+			&item.PrecioVentaUnidad, &item.Revertido); err != nil {
+			log.Println("Error al escanear detalle de cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		detail.Detalles = append(detail.Detalles, item)
+	}
+	return &detail, nil
+}
+
+func (p PrecioRepository) RevertirCambioMasivo(ctx context.Context, id *int) (*domain.ReversionCambioMasivo, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var estado string
+	err = tx.QueryRow(ctx, `SELECT estado FROM cambio_precio_masivo WHERE id = $1 FOR UPDATE`, *id).Scan(&estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Cambio masivo no encontrado")
+		}
+		log.Println("Error al bloquear cambio masivo:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if estado != domain.CambioMasivoAplicado {
+		return nil, datatype.NewConflictError("El cambio masivo ya fue revertido")
+	}
+
+	rows, err := tx.Query(ctx, `
+        SELECT d.id,
+               jsonb_build_object('id', pr.id, 'nombreComercial', pr.nombre_comercial, 'laboratorio', l.nombre,
+                                  'presentacion', jsonb_build_object('id', pre.id, 'nombre', pre.nombre),
+                                  'unidadesPresentacion', pr.unidades_presentacion),
+               d.precio_venta_anterior, d.precio_venta, d.precio_venta_unidad_anterior, d.precio_venta_unidad
+        FROM detalle_cambio_precio_masivo d
+        INNER JOIN producto pr ON pr.id = d.producto_id
+        INNER JOIN laboratorio l ON l.id = pr.laboratorio_id
+        LEFT JOIN presentacion pre ON pre.id = pr.presentacion_id
+        WHERE d.cambio_precio_masivo_id = $1
+        ORDER BY pr.nombre_comercial, l.nombre
+    `, *id)
+	if err != nil {
+		log.Println("Error al obtener detalle de cambio masivo:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	type detalleReversion struct {
+		id int
+		domain.DetalleCambioMasivo
+	}
+	var detalles []detalleReversion
+	for rows.Next() {
+		var item detalleReversion
+		if err := rows.Scan(&item.id, &item.Producto, &item.PrecioVentaAnterior, &item.PrecioVenta,
+			&item.PrecioVentaUnidadAnterior, &item.PrecioVentaUnidad); err != nil {
+			rows.Close()
+			log.Println("Error al escanear detalle de cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		detalles = append(detalles, item)
+	}
+	rows.Close()
+
+	usuarioId := usuarioContexto(ctx)
+	referencia := fmt.Sprintf("Reversión de cambio masivo #%d", *id)
+	resultado := domain.ReversionCambioMasivo{Omitidos: make([]domain.ProductoSimple, 0)}
+	for _, detalle := range detalles {
+		productoId := detalle.Producto.Id.String()
+		antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, productoId)
+		if err != nil {
+			return nil, err
+		}
+		anteriores, err := obtenerPreciosProducto(ctx, tx, productoId)
+		if err != nil {
+			return nil, err
+		}
+		// Los productos cuyo precio se modificó después del cambio masivo conservan su precio actual
+		if anteriores.venta != detalle.PrecioVenta || !mismoPrecio(anteriores.ventaUnidad, detalle.PrecioVentaUnidad) {
+			resultado.Omitidos = append(resultado.Omitidos, detalle.Producto)
+			continue
+		}
+
+		_, err = tx.Exec(ctx, `
+            UPDATE producto SET precio_venta = $1, precio_venta_unidad = $2 WHERE id::TEXT = $3
+        `, detalle.PrecioVentaAnterior, detalle.PrecioVentaUnidadAnterior, productoId)
+		if err != nil {
+			log.Println("Error al revertir precio de cambio masivo:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		_, err = tx.Exec(ctx, `UPDATE detalle_cambio_precio_masivo SET revertido = TRUE WHERE id = $1`, detalle.id)
+		if err != nil {
+			log.Println("Error al marcar detalle de cambio masivo como revertido:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+
+		if err := registrarHistorialPrecio(ctx, tx, productoId, anteriores, domain.PrecioOrigenMasivo, &referencia, usuarioId); err != nil {
+			return nil, err
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaProducto, productoId, domain.AccionModificar, antes); err != nil {
+			return nil, err
+		}
+		resultado.Revertidos++
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE cambio_precio_masivo SET estado = 'Revertido', revertido_por = $1, revertido_at = NOW() WHERE id = $2
+    `, usuarioId, *id)
+	if err != nil {
+		log.Println("Error al marcar cambio masivo como revertido:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &resultado, nil
+}
+
+// precioProgramado es un precio de venta pendiente de aplicar al producto
+type precioProgramado struct {
+	id                int
+	productoId        string
+	precioVenta       float64
+	precioVentaUnidad *float64
+	estado            string
+	usuarioId         *int
+}
+
+// bloquearPrecioProgramado obtiene un precio programado bloqueándolo hasta el fin de la transacción
+func bloquearPrecioProgramado(ctx context.Context, tx pgx.Tx, id int) (precioProgramado, error) {
+	var item precioProgramado
+	err := tx.QueryRow(ctx, `
+        SELECT id, producto_id::TEXT, precio_venta, precio_venta_unidad, estado, usuario_id
+        FROM precio_programado WHERE id = $1 FOR UPDATE
+    `, id).Scan(&item.id, &item.productoId, &item.precioVenta, &item.precioVentaUnidad, &item.estado, &item.usuarioId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return item, datatype.NewNotFoundError("Precio programado no encontrado")
+		}
+		log.Println("Error al bloquear precio programado:", err)
+		return item, datatype.NewInternalServerErrorGeneric()
+	}
+	return item, nil
+}
+
+// aplicarPrecioProgramado actualiza el precio de venta del producto y lo registra en el historial
+func aplicarPrecioProgramado(ctx context.Context, tx pgx.Tx, item precioProgramado) error {
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, item.productoId)
+	if err != nil {
+		return err
+	}
+	anteriores, err := obtenerPreciosProducto(ctx, tx, item.productoId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE producto SET precio_venta = $1, precio_venta_unidad = COALESCE($2, precio_venta_unidad)
+        WHERE id::TEXT = $3
+    `, item.precioVenta, item.precioVentaUnidad, item.productoId)
+	if err != nil {
+		log.Println("Error al aplicar precio programado:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	referencia := fmt.Sprintf("Precio programado #%d", item.id)
+	if err := registrarHistorialPrecio(ctx, tx, item.productoId, anteriores, domain.PrecioOrigenProgramado, &referencia, item.usuarioId); err != nil {
+		return err
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaProducto, item.productoId, domain.AccionModificar, antes); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE precio_programado SET estado = 'Aplicado', aplicado_at = NOW() WHERE id = $1`, item.id)
+	if err != nil {
+		log.Println("Error al marcar precio programado como aplicado:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// preciosProducto son los precios de un producto en un momento dado
+type preciosProducto struct {
+	compra      float64
+	venta       float64
+	ventaUnidad *float64
+}
+
+// obtenerPreciosProducto obtiene los precios vigentes de un producto bloqueándolo para el cambio
+func obtenerPreciosProducto(ctx context.Context, tx pgx.Tx, productoId string) (*preciosProducto, error) {
+	var precios preciosProducto
+	err := tx.QueryRow(ctx, `
+        SELECT precio_compra, precio_venta, precio_venta_unidad FROM producto WHERE id::TEXT = $1 FOR UPDATE
+    `, productoId).Scan(&precios.compra, &precios.venta, &precios.ventaUnidad)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Producto no encontrado")
+		}
+		log.Println("Error al obtener precios del producto:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &precios, nil
+}
+
+// registrarHistorialPrecio guarda los precios vigentes si cambiaron respecto a los anteriores;
+// sin precios anteriores se registran como precios iniciales del producto
+func registrarHistorialPrecio(ctx context.Context, tx pgx.Tx, productoId string, anteriores *preciosProducto, origen string, referencia *string, usuarioId *int) error {
+	actuales, err := obtenerPreciosProducto(ctx, tx, productoId)
+	if err != nil {
+		return err
+	}
+
+	var compraAnterior, ventaAnterior, ventaUnidadAnterior *float64
+	if anteriores != nil {
+		if anteriores.compra == actuales.compra && anteriores.venta == actuales.venta && mismoPrecio(anteriores.ventaUnidad, actuales.ventaUnidad) {
+			return nil
+		}
+		compraAnterior, ventaAnterior, ventaUnidadAnterior = &anteriores.compra, &anteriores.venta, anteriores.ventaUnidad
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO historial_precio (producto_id, origen, referencia, precio_compra_anterior, precio_compra,
+                                      precio_venta_anterior, precio_venta, precio_venta_unidad_anterior,
+                                      precio_venta_unidad, usuario_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `, productoId, origen, referencia, compraAnterior, actuales.compra, ventaAnterior, actuales.venta,
+		ventaUnidadAnterior, actuales.ventaUnidad, usuarioId)
+	if err != nil {
+		log.Println("Error al registrar historial de precios:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func mismoPrecio(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func NewPrecioRepository(pool *pgxpool.Pool) *PrecioRepository {
+	return &PrecioRepository{pool: pool}
+}
+
+var _ port.PrecioRepository = (*PrecioRepository)(nil)
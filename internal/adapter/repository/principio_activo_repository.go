@@ -16,7 +16,7 @@ type PrincipioActivoRepository struct {
 }
 
 func (p PrincipioActivoRepository) RegistrarPrincipioActivo(ctx context.Context, request *domain.PrincipioActivoRequest) (*int, error) {
-	query := `INSERT INTO principio_activo(nombre, descripcion) VALUES ($1, $2) RETURNING id`
+	query := `INSERT INTO principio_activo(nombre, descripcion, nivel_control, contraindicaciones) VALUES ($1, $2, $3, $4) RETURNING id`
 
 	tx, err := p.pool.Begin(ctx)
 	if err != nil {
@@ -29,7 +29,7 @@ func (p PrincipioActivoRepository) RegistrarPrincipioActivo(ctx context.Context,
 		}
 	}()
 	var id int
-	err = tx.QueryRow(ctx, query, request.Nombre, request.Descripcion).Scan(&id)
+	err = tx.QueryRow(ctx, query, request.Nombre, request.Descripcion, request.NivelControl, request.Contraindicaciones).Scan(&id)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -59,9 +59,9 @@ func (p PrincipioActivoRepository) ModificarPrincipioActivo(ctx context.Context,
 		}
 	}()
 
-	query := `UPDATE principio_activo SET nombre = $1, descripcion = $2 WHERE id = $3`
+	query := `UPDATE principio_activo SET nombre = $1, descripcion = $2, nivel_control = $3, contraindicaciones = $4 WHERE id = $5`
 
-	result, err := tx.Exec(ctx, query, request.Nombre, request.Descripcion, *id)
+	result, err := tx.Exec(ctx, query, request.Nombre, request.Descripcion, request.NivelControl, request.Contraindicaciones, *id)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -84,7 +84,7 @@ func (p PrincipioActivoRepository) ModificarPrincipioActivo(ctx context.Context,
 }
 
 func (p PrincipioActivoRepository) ListarPrincipioActivo(ctx context.Context) (*[]domain.PrincipioActivoInfo, error) {
-	query := `SELECT id, nombre, descripcion FROM principio_activo ORDER BY nombre`
+	query := `SELECT id, nombre, descripcion, nivel_control::TEXT, contraindicaciones FROM principio_activo ORDER BY nombre`
 	rows, err := p.pool.Query(ctx, query)
 	if err != nil {
 		return nil, datatype.NewInternalServerErrorGeneric()
@@ -94,7 +94,7 @@ func (p PrincipioActivoRepository) ListarPrincipioActivo(ctx context.Context) (*
 	var lista = make([]domain.PrincipioActivoInfo, 0)
 	for rows.Next() {
 		var pa domain.PrincipioActivoInfo
-		if err := rows.Scan(&pa.Id, &pa.Nombre, &pa.Descripcion); err != nil {
+		if err := rows.Scan(&pa.Id, &pa.Nombre, &pa.Descripcion, &pa.NivelControl, &pa.Contraindicaciones); err != nil {
 			return nil, err
 		}
 		lista = append(lista, pa)
@@ -108,11 +108,11 @@ func (p PrincipioActivoRepository) ListarPrincipioActivo(ctx context.Context) (*
 }
 
 func (p PrincipioActivoRepository) ObtenerPrincipioActivoById(ctx context.Context, id *int) (*domain.PrincipioActivoDetail, error) {
-	query := `SELECT id, nombre, descripcion FROM principio_activo WHERE id = $1`
+	query := `SELECT id, nombre, descripcion, nivel_control::TEXT, contraindicaciones FROM principio_activo WHERE id = $1`
 	row := p.pool.QueryRow(ctx, query, *id)
 
 	var detalle domain.PrincipioActivoDetail
-	err := row.Scan(&detalle.Id, &detalle.Nombre, &detalle.Descripcion)
+	err := row.Scan(&detalle.Id, &detalle.Nombre, &detalle.Descripcion, &detalle.NivelControl, &detalle.Contraindicaciones)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, datatype.NewNotFoundError("El principio activo no existe")
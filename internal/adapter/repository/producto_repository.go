@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lib/pq"
@@ -28,17 +29,32 @@ type ProductoRepository struct {
 func (p ProductoRepository) ObtenerProductoById(ctx context.Context, id *uuid.UUID) (*domain.ProductoDetail, error) {
 	fullHostname := ctx.Value("fullHostname").(string)
 	fullHostname = fmt.Sprintf("%s%s", fullHostname, "/uploads/productos")
-	query := `SELECT p.id,p.nombre_comercial,p.forma_farmaceutica,p.laboratorio,p.precio_venta,p.stock_min,p.stock,p.fotos,p.created_at,p.deleted_at,p.estado,p.categorias,p.principio_activos,p.precio_compra,p.presentacion,p.unidades_presentacion FROM obtener_producto_detalle_by_id($1,$2) p;`
+	query := `SELECT p.id,p.nombre_comercial,p.forma_farmaceutica,p.laboratorio,p.precio_venta,p.stock_min,p.stock,p.fotos,p.created_at,p.deleted_at,p.estado,p.categorias,p.principio_activos,p.precio_compra,p.presentacion,p.unidades_presentacion,p.nivel_control,p.nivel_control_efectivo,p.precio_venta_unidad,p.refrigerado,p.controlado FROM obtener_producto_detalle_by_id($1,$2) p;`
 	var item domain.ProductoDetail
 	err := p.pool.QueryRow(ctx, query, id.String(), fullHostname).Scan(&item.Id, &item.NombreComercial, &item.FormaFarmaceutica,
 		&item.Laboratorio, &item.PrecioVenta, &item.StockMin, &item.Stock, &item.UrlFotos, &item.CreatedAt, &item.DeletedAt, &item.Estado, &item.Categorias,
-		&item.PrincipiosActivos, &item.PrecioCompra, &item.Presentacion, &item.UnidadesPresentacion)
+		&item.PrincipiosActivos, &item.PrecioCompra, &item.Presentacion, &item.UnidadesPresentacion, &item.NivelControl, &item.NivelControlEfectivo, &item.PrecioVentaUnidad, &item.Refrigerado, &item.Controlado)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datatype.NewNotFoundError("Producto no encontrado")
 		}
 		return nil, datatype.NewInternalServerErrorGeneric()
 	}
+
+	// Códigos de barras del producto
+	rows, err := p.pool.Query(ctx, `SELECT id, codigo, tipo FROM codigo_barra WHERE producto_id = $1 ORDER BY id`, id.String())
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+	item.CodigosBarra = make([]domain.CodigoBarra, 0)
+	for rows.Next() {
+		var codigo domain.CodigoBarra
+		if err := rows.Scan(&codigo.Id, &codigo.Codigo, &codigo.Tipo); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.CodigosBarra = append(item.CodigosBarra, codigo)
+	}
 	return &item, nil
 }
 
@@ -56,6 +72,10 @@ func (p ProductoRepository) HabilitarProducto(ctx context.Context, id *uuid.UUID
 		}
 	}()
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, id.String())
+	if err != nil {
+		return err
+	}
 	query := `UPDATE producto SET estado='Activo',deleted_at=NULL WHERE id=$1`
 	ct, err := tx.Exec(ctx, query, id.String())
 	if err != nil {
@@ -64,6 +84,9 @@ func (p ProductoRepository) HabilitarProducto(ctx context.Context, id *uuid.UUID
 	if ct.RowsAffected() == 0 {
 		return datatype.NewNotFoundError("Producto no encontrado")
 	}
+	if err = registrarAuditoria(ctx, tx, domain.AuditoriaProducto, id.String(), domain.AccionHabilitar, antes); err != nil {
+		return err
+	}
 	// Confirmar la transacción
 	if err = tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -84,6 +107,10 @@ func (p ProductoRepository) DeshabilitarProducto(ctx context.Context, id *uuid.U
 			_ = tx.Rollback(ctx)
 		}
 	}()
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, id.String())
+	if err != nil {
+		return err
+	}
 	query := `UPDATE producto SET estado='Inactivo',deleted_at=CURRENT_TIMESTAMP WHERE id=$1`
 	ct, err := tx.Exec(ctx, query, id.String())
 	if err != nil {
@@ -92,6 +119,9 @@ func (p ProductoRepository) DeshabilitarProducto(ctx context.Context, id *uuid.U
 	if ct.RowsAffected() == 0 {
 		return datatype.NewNotFoundError("Producto no encontrado")
 	}
+	if err = registrarAuditoria(ctx, tx, domain.AuditoriaProducto, id.String(), domain.AccionDeshabilitar, antes); err != nil {
+		return err
+	}
 
 	// Confirmar la transacción
 	if err = tx.Commit(ctx); err != nil {
@@ -113,11 +143,11 @@ func (p ProductoRepository) RegistrarProducto(ctx context.Context, request *doma
 			_ = tx.Rollback(ctx)
 		}
 	}()
-	query := `INSERT INTO producto(nombre_comercial,forma_farmaceutica_id,precio_compra,precio_venta,estado,stock,stock_min,laboratorio_id,presentacion_id,unidades_presentacion) 
-				VALUES ($1,$2,0.0,$3,'Activo',0,$4,$5,$6,$7) RETURNING id`
+	query := `INSERT INTO producto(nombre_comercial,forma_farmaceutica_id,precio_compra,precio_venta,estado,stock,stock_min,laboratorio_id,presentacion_id,unidades_presentacion,nivel_control,precio_venta_unidad,refrigerado,controlado) 
+				VALUES ($1,$2,0.0,$3,'Activo',0,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING id`
 
 	var id uuid.UUID
-	err = tx.QueryRow(ctx, query, request.NombreComercial, request.FormaFarmaceuticaId, request.PrecioVenta, request.StockMin, request.LaboratorioId, request.PresentacionId, request.UnidadesPresentacion).Scan(&id)
+	err = tx.QueryRow(ctx, query, request.NombreComercial, request.FormaFarmaceuticaId, request.PrecioVenta, request.StockMin, request.LaboratorioId, request.PresentacionId, request.UnidadesPresentacion, request.NivelControl, request.PrecioVentaUnidad, request.Refrigerado, request.Controlado).Scan(&id)
 	if err != nil {
 		_ = tx.Rollback(ctx)
 		var pgErr *pgconn.PgError
@@ -169,11 +199,23 @@ func (p ProductoRepository) RegistrarProducto(ctx context.Context, request *doma
 			return datatype.NewStatusServiceUnavailableErrorGeneric()
 		}
 	}
+	err = guardarCodigosBarra(ctx, tx, id, request.CodigosBarra)
+	if err != nil {
+		return err
+	}
 	query = `UPDATE producto SET fotos = $1 WHERE id = $2`
 	_, err = tx.Exec(ctx, query, pq.Array(fotos), id)
 	if err != nil {
 		return datatype.NewStatusServiceUnavailableErrorGeneric()
 	}
+	err = registrarHistorialPrecio(ctx, tx, id.String(), nil, domain.PrecioOrigenManual, nil, usuarioContexto(ctx))
+	if err != nil {
+		return err
+	}
+	err = registrarAuditoria(ctx, tx, domain.AuditoriaProducto, id.String(), domain.AccionCrear, nil)
+	if err != nil {
+		return err
+	}
 
 	// Confirmar la transacción
 	if err = tx.Commit(ctx); err != nil {
@@ -204,9 +246,21 @@ func (p ProductoRepository) ModificarProducto(ctx context.Context, id *uuid.UUID
 		return datatype.NewInternalServerErrorGeneric()
 	}
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaProducto, id.String())
+	if err != nil {
+		return err
+	}
+	if antes == nil {
+		return datatype.NewNotFoundError("No existe el producto")
+	}
+	preciosAnteriores, err := obtenerPreciosProducto(ctx, tx, id.String())
+	if err != nil {
+		return err
+	}
+
 	// Ejecutar SQL update
-	query := `UPDATE producto SET nombre_comercial=$1,forma_farmaceutica_id=$2,stock_min=$3,laboratorio_id=$4,presentacion_id=$5,precio_venta=$6,unidades_presentacion=$7 WHERE id=$8`
-	ct, err := tx.Exec(ctx, query, request.NombreComercial, request.FormaFarmaceuticaId, request.StockMin, request.LaboratorioId, request.PresentacionId, request.PrecioVenta, request.UnidadesPresentacion, id.String())
+	query := `UPDATE producto SET nombre_comercial=$1,forma_farmaceutica_id=$2,stock_min=$3,laboratorio_id=$4,presentacion_id=$5,precio_venta=$6,unidades_presentacion=$7,nivel_control=$8,precio_venta_unidad=$9,refrigerado=$10,controlado=$11 WHERE id=$12`
+	ct, err := tx.Exec(ctx, query, request.NombreComercial, request.FormaFarmaceuticaId, request.StockMin, request.LaboratorioId, request.PresentacionId, request.PrecioVenta, request.UnidadesPresentacion, request.NivelControl, request.PrecioVentaUnidad, request.Refrigerado, request.Controlado, id.String())
 	if err != nil {
 		log.Println(err)
 		var pgErr *pgconn.PgError
@@ -273,6 +327,20 @@ func (p ProductoRepository) ModificarProducto(ctx context.Context, id *uuid.UUID
 			return datatype.NewStatusServiceUnavailableErrorGeneric()
 		}
 	}
+
+	// Reemplazar códigos de barras
+	err = guardarCodigosBarra(ctx, tx, *id, request.CodigosBarra)
+	if err != nil {
+		return err
+	}
+	err = registrarHistorialPrecio(ctx, tx, id.String(), preciosAnteriores, domain.PrecioOrigenManual, nil, usuarioContexto(ctx))
+	if err != nil {
+		return err
+	}
+	err = registrarAuditoria(ctx, tx, domain.AuditoriaProducto, id.String(), domain.AccionModificar, antes)
+	if err != nil {
+		return err
+	}
 	// Confirmar transacción
 	if err = tx.Commit(ctx); err != nil {
 		util.File.DeleteFiles(route, nuevosArchivos)
@@ -446,6 +514,22 @@ func (p ProductoRepository) ObtenerListaProductos(ctx context.Context, filtros m
 		i++
 	}
 
+	// Filtro: ubicacionId, el stock mostrado pasa a ser el guardado en la ubicación y las que contiene
+	stock := "p.stock"
+	if ubicacionStr := filtros["ubicacionId"]; ubicacionStr != "" {
+		ubicacionId, err := strconv.Atoi(strings.TrimSpace(ubicacionStr))
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de ubicacionId no es válido")
+		}
+		stock = fmt.Sprintf(`(SELECT COALESCE(SUM(lu.cantidad), 0)::BIGINT
+		          FROM lote_ubicacion lu
+		          INNER JOIN lote_producto lp ON lp.id = lu.lote_id
+		          WHERE lp.producto_id = p.id AND lu.ubicacion_id IN (SELECT ubicaciones_descendientes($%d)))`, i)
+		filters = append(filters, stock+" > 0")
+		args = append(args, ubicacionId)
+		i++
+	}
+
 	query := `
 		SELECT DISTINCT ON (p.id)
 			p.id,
@@ -453,14 +537,17 @@ func (p ProductoRepository) ObtenerListaProductos(ctx context.Context, filtros m
 			p.forma_farmaceutica,
 			p.laboratorio,
 			p.precio_venta,
-			p.stock,
+			` + stock + `,
 			p.stock_min,
 			p.url_foto,
 			p.estado,
 			p.deleted_at,
 			p.precio_compra,
 			p.presentacion,
-			p.unidades_presentacion
+			p.unidades_presentacion,
+			p.precio_venta_unidad,
+			p.refrigerado,
+			p.controlado
 		FROM listar_productos_info($1) p
 		LEFT JOIN producto_categoria pc ON pc.producto_id = p.id
 	`
@@ -496,10 +583,117 @@ func (p ProductoRepository) ObtenerListaProductos(ctx context.Context, filtros m
 			&item.PrecioCompra,
 			&item.Presentacion,
 			&item.UnidadesPresentacion,
+			&item.PrecioVentaUnidad,
+			&item.Refrigerado,
+			&item.Controlado,
+		)
+		if err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	return &list, nil
+}
+
+func (p ProductoRepository) ObtenerEquivalentesProducto(ctx context.Context, id *uuid.UUID) (*[]domain.ProductoEquivalente, error) {
+	fullHostname := ctx.Value("fullHostname").(string)
+	fullHostname = fmt.Sprintf("%s%s", fullHostname, "/uploads/productos")
+
+	var existe bool
+	if err := p.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM producto WHERE id = $1)`, *id).Scan(&existe); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if !existe {
+		return nil, datatype.NewNotFoundError("Producto no encontrado")
+	}
+
+	// Coincidencia exacta: mismos principios activos con igual concentración y unidad, y misma forma farmacéutica.
+	// El resto de productos que comparten algún principio activo se listan como coincidencia parcial.
+	query := `
+        WITH base AS (
+            SELECT principio_activo_id, concentracion, unidad_medida_id
+            FROM producto_principio_activo
+            WHERE producto_id = $2
+        ),
+        candidatos AS (
+            SELECT ppa.producto_id,
+                   COUNT(b.principio_activo_id) AS coinciden_principio,
+                   COUNT(*) FILTER (WHERE b.concentracion = ppa.concentracion AND b.unidad_medida_id = ppa.unidad_medida_id) AS coinciden_exacto,
+                   COUNT(*) AS total
+            FROM producto_principio_activo ppa
+            LEFT JOIN base b ON b.principio_activo_id = ppa.principio_activo_id
+            WHERE ppa.producto_id <> $2
+            GROUP BY ppa.producto_id
+            HAVING COUNT(b.principio_activo_id) > 0
+        )
+        SELECT p.id,
+               p.nombre_comercial,
+               p.forma_farmaceutica,
+               p.laboratorio,
+               p.precio_venta,
+               p.stock,
+               p.stock_min,
+               p.url_foto,
+               p.estado,
+               p.deleted_at,
+               p.precio_compra,
+               p.presentacion,
+               p.unidades_presentacion,
+               p.precio_venta_unidad,
+               p.forma_farmaceutica_id = pb.forma_farmaceutica_id AS misma_forma,
+               c.coinciden_exacto = c.total AND c.total = (SELECT COUNT(*) FROM base) AS exacto,
+               c.coinciden_principio,
+               c.total
+        FROM candidatos c
+        INNER JOIN listar_productos_info($1) p ON p.id = c.producto_id
+        INNER JOIN producto pb ON pb.id = $2
+        WHERE p.estado = 'Activo' AND p.stock > 0
+        ORDER BY exacto DESC, misma_forma DESC, c.coinciden_exacto DESC, c.coinciden_principio DESC, p.precio_venta
+    `
+	rows, err := p.pool.Query(ctx, query, fullHostname, *id)
+	if err != nil {
+		log.Println("Error al obtener productos equivalentes:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var list = make([]domain.ProductoEquivalente, 0)
+	for rows.Next() {
+		var item domain.ProductoEquivalente
+		var exacto bool
+		err := rows.Scan(
+			&item.Id,
+			&item.NombreComercial,
+			&item.FormaFarmaceutica,
+			&item.Laboratorio,
+			&item.PrecioVenta,
+			&item.Stock,
+			&item.StockMin,
+			&item.UrlFoto,
+			&item.Estado,
+			&item.DeletedAt,
+			&item.PrecioCompra,
+			&item.Presentacion,
+			&item.UnidadesPresentacion,
+			&item.PrecioVentaUnidad,
+			&item.MismaForma,
+			&exacto,
+			&item.PrincipiosCoincidentes,
+			&item.PrincipiosTotales,
 		)
 		if err != nil {
+			log.Println("Error al escanear producto equivalente:", err)
 			return nil, datatype.NewInternalServerErrorGeneric()
 		}
+		item.Coincidencia = domain.EquivalenteParcial
+		if exacto && item.MismaForma {
+			item.Coincidencia = domain.EquivalenteExacto
+		}
 		list = append(list, item)
 	}
 
@@ -510,6 +704,203 @@ func (p ProductoRepository) ObtenerListaProductos(ctx context.Context, filtros m
 	return &list, nil
 }
 
+func (p ProductoRepository) EscanearCodigo(ctx context.Context, codigo string, gs1 *domain.DatosGS1) (*domain.ResultadoEscaneo, error) {
+	fullHostname := ctx.Value("fullHostname").(string)
+	fullHostname = fmt.Sprintf("%s%s", fullHostname, "/uploads/productos")
+
+	resultado := domain.ResultadoEscaneo{Codigo: codigo, Gs1: gs1}
+	busqueda := codigo
+	if gs1 != nil {
+		busqueda = gs1.Gtin
+	}
+	normalizado := busqueda
+	if util.Barcode.ValidarGTIN(busqueda) {
+		normalizado = util.Barcode.NormalizarGTIN(busqueda)
+	}
+
+	query := `
+		SELECT p.id,
+			p.nombre_comercial,
+			p.forma_farmaceutica,
+			p.laboratorio,
+			p.precio_venta,
+			p.stock,
+			p.stock_min,
+			p.url_foto,
+			p.estado,
+			p.deleted_at,
+			p.precio_compra,
+			p.presentacion,
+			p.unidades_presentacion,
+			p.precio_venta_unidad
+		FROM codigo_barra cb
+		INNER JOIN listar_productos_info($1) p ON p.id = cb.producto_id
+		WHERE cb.codigo_normalizado = $2 OR cb.codigo = $3
+		LIMIT 1
+	`
+	item := &resultado.Producto
+	err := p.pool.QueryRow(ctx, query, fullHostname, normalizado, busqueda).Scan(
+		&item.Id,
+		&item.NombreComercial,
+		&item.FormaFarmaceutica,
+		&item.Laboratorio,
+		&item.PrecioVenta,
+		&item.Stock,
+		&item.StockMin,
+		&item.UrlFoto,
+		&item.Estado,
+		&item.DeletedAt,
+		&item.PrecioCompra,
+		&item.Presentacion,
+		&item.UnidadesPresentacion,
+		&item.PrecioVentaUnidad,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("No existe un producto con ese código de barras")
+		}
+		log.Println("Error al buscar código de barras:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Resolver el lote por su número o, en su defecto, por la fecha de vencimiento
+	if gs1 == nil || (gs1.Lote == nil && gs1.FechaVencimiento == nil) {
+		return &resultado, nil
+	}
+	var lote domain.LoteEscaneo
+	query = `SELECT id, lote, fecha_vencimiento, stock, estado::TEXT FROM lote_producto WHERE producto_id = $1 AND `
+	var arg interface{}
+	if gs1.Lote != nil {
+		query += `lote = $2`
+		arg = *gs1.Lote
+	} else {
+		query += `fecha_vencimiento = $2`
+		arg = *gs1.FechaVencimiento
+	}
+	err = p.pool.QueryRow(ctx, query, item.Id, arg).Scan(&lote.Id, &lote.Lote, &lote.FechaVencimiento, &lote.Stock, &lote.Estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &resultado, nil
+		}
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	resultado.Lote = &lote
+	return &resultado, nil
+}
+
+// Datos comunes de una etiqueta; el código preferido es el GTIN del producto
+const queryEtiqueta = `
+SELECT p.nombre_comercial,
+       TRIM(ff.nombre || ' - ' || COALESCE(pr.nombre || ' x ' || p.unidades_presentacion, '')),
+       l.nombre,
+       p.precio_venta,
+       cb.codigo,
+       cb.tipo,
+       lp.lote,
+       lp.fecha_vencimiento::TIMESTAMPTZ,
+       %s AS cantidad
+FROM %s
+INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+INNER JOIN forma_farmaceutica ff ON ff.id = p.forma_farmaceutica_id
+LEFT JOIN presentacion pr ON pr.id = p.presentacion_id
+LEFT JOIN LATERAL (SELECT c.codigo, c.tipo
+                   FROM codigo_barra c
+                   WHERE c.producto_id = p.id
+                   ORDER BY c.tipo = 'GTIN' DESC, c.id
+                   LIMIT 1) cb ON TRUE
+`
+
+func (p ProductoRepository) ObtenerEtiquetas(ctx context.Context, request *domain.EtiquetaRequest) (*[]domain.Etiqueta, error) {
+	list := make([]domain.Etiqueta, 0)
+	for _, item := range request.Productos {
+		query := fmt.Sprintf(queryEtiqueta, "$2::INT", "producto p LEFT JOIN lote_producto lp ON FALSE") + ` WHERE p.id::TEXT = $1`
+		etiquetas, err := p.obtenerEtiquetas(ctx, query, item.ProductoId, item.Cantidad)
+		if err != nil {
+			return nil, err
+		}
+		if len(etiquetas) == 0 {
+			return nil, datatype.NewNotFoundErrorWithData("Producto no encontrado", domain.ProductoId{Id: item.ProductoId})
+		}
+		list = append(list, etiquetas...)
+	}
+	for _, item := range request.Lotes {
+		query := fmt.Sprintf(queryEtiqueta, "$2::INT", "lote_producto lp INNER JOIN producto p ON p.id = lp.producto_id") + ` WHERE lp.id = $1`
+		etiquetas, err := p.obtenerEtiquetas(ctx, query, item.LoteId, item.Cantidad)
+		if err != nil {
+			return nil, err
+		}
+		if len(etiquetas) == 0 {
+			return nil, datatype.NewNotFoundError(fmt.Sprintf("Lote %d no encontrado", item.LoteId))
+		}
+		list = append(list, etiquetas...)
+	}
+	return &list, nil
+}
+
+func (p ProductoRepository) ObtenerEtiquetasCompra(ctx context.Context, compraId *int) (*[]domain.Etiqueta, error) {
+	var estado string
+	err := p.pool.QueryRow(ctx, `SELECT estado::TEXT FROM compra WHERE id = $1`, *compraId).Scan(&estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Compra no encontrada")
+		}
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if estado != "Completado" {
+		return nil, datatype.NewConflictError("Las etiquetas se generan para compras completadas")
+	}
+
+	// Una etiqueta por unidad recibida de cada lote
+	query := fmt.Sprintf(queryEtiqueta, "dc.cantidad", `detalle_compra dc
+INNER JOIN lote_producto lp ON lp.id = dc.lote_producto_id
+INNER JOIN producto p ON p.id = lp.producto_id`) + ` WHERE dc.compra_id = $1 ORDER BY dc.id`
+	list, err := p.obtenerEtiquetas(ctx, query, *compraId)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (p ProductoRepository) obtenerEtiquetas(ctx context.Context, query string, args ...interface{}) ([]domain.Etiqueta, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al obtener datos de etiquetas:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.Etiqueta, 0)
+	for rows.Next() {
+		var item domain.Etiqueta
+		if err := rows.Scan(&item.NombreComercial, &item.Presentacion, &item.Laboratorio, &item.PrecioVenta, &item.CodigoBarra,
+			&item.TipoCodigo, &item.Lote, &item.FechaVencimiento, &item.Cantidad); err != nil {
+			log.Println("Error al escanear etiqueta:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// guardarCodigosBarra reemplaza los códigos de barras de un producto
+func guardarCodigosBarra(ctx context.Context, tx pgx.Tx, productoId uuid.UUID, codigos []domain.CodigoBarraRequest) error {
+	_, err := tx.Exec(ctx, `DELETE FROM codigo_barra WHERE producto_id = $1`, productoId)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	for _, c := range codigos {
+		_, err := tx.Exec(ctx, `INSERT INTO codigo_barra (producto_id, codigo, tipo) VALUES ($1, $2, $3)`, productoId, c.Codigo, c.Tipo)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return datatype.NewConflictError(fmt.Sprintf("El código de barras %s ya está asignado a otro producto", c.Codigo))
+			}
+			return datatype.NewInternalServerErrorGeneric()
+		}
+	}
+	return nil
+}
+
 func NewProductoRepository(pool *pgxpool.Pool) *ProductoRepository {
 	return &ProductoRepository{pool: pool}
 }
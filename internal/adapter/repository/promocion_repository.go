@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PromocionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (p PromocionRepository) ObtenerListaPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionInfo, error) {
+	query := `SELECT pr.id, pr.nombre, pr.tipo, pr.estado, pr.fecha_inicio, pr.fecha_fin, pr.created_at, pr.deleted_at FROM promocion pr`
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	// Filtrar por estado
+	if estado := filtros["estado"]; estado != "" {
+		filters = append(filters, fmt.Sprintf("pr.estado = $%d", i))
+		args = append(args, estado)
+		i++
+	}
+
+	// Filtrar por tipo
+	if tipo := filtros["tipo"]; tipo != "" {
+		filters = append(filters, fmt.Sprintf("pr.tipo = $%d", i))
+		args = append(args, tipo)
+		i++
+	}
+
+	// Filtrar solo promociones vigentes
+	if filtros["vigente"] == "true" {
+		filters = append(filters, "pr.estado = 'Activo' AND NOW() BETWEEN pr.fecha_inicio AND pr.fecha_fin")
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY pr.fecha_inicio DESC, pr.id DESC"
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar promociones:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var list = make([]domain.PromocionInfo, 0)
+	for rows.Next() {
+		var item domain.PromocionInfo
+		if err := rows.Scan(&item.Id, &item.Nombre, &item.Tipo, &item.Estado, &item.FechaInicio, &item.FechaFin, &item.CreatedAt, &item.DeletedAt); err != nil {
+			log.Println("Error al escanear promoción:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PromocionRepository) ObtenerPromocionById(ctx context.Context, id *int) (*domain.PromocionDetail, error) {
+	query := `
+	SELECT pr.id, pr.nombre, pr.tipo, pr.estado, pr.fecha_inicio, pr.fecha_fin, pr.created_at, pr.deleted_at,
+	       pr.descripcion, pr.cantidad_requerida, pr.cantidad_gratis, pr.precio_paquete, pr.porcentaje,
+	       CASE WHEN c.id IS NULL THEN NULL ELSE jsonb_build_object('id', c.id, 'nombre', c.nombre) END AS categoria,
+	       COALESCE((
+	           SELECT jsonb_agg(jsonb_build_object(
+	                   'producto', jsonb_build_object('id', p.id, 'nombreComercial', p.nombre_comercial),
+	                   'cantidad', pp.cantidad
+	                  ) ORDER BY p.nombre_comercial)
+	           FROM promocion_producto pp
+	           INNER JOIN producto p ON p.id = pp.producto_id
+	           WHERE pp.promocion_id = pr.id
+	       ), '[]'::jsonb) AS productos
+	FROM promocion pr
+	LEFT JOIN categoria c ON c.id = pr.categoria_id
+	WHERE pr.id = $1`
+
+	var item domain.PromocionDetail
+	err := p.pool.QueryRow(ctx, query, *id).Scan(
+		&item.Id, &item.Nombre, &item.Tipo, &item.Estado, &item.FechaInicio, &item.FechaFin, &item.CreatedAt, &item.DeletedAt,
+		&item.Descripcion, &item.CantidadRequerida, &item.CantidadGratis, &item.PrecioPaquete, &item.Porcentaje,
+		&item.Categoria, &item.Productos,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Promoción no encontrada")
+		}
+		log.Println("Error al obtener promoción:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &item, nil
+}
+
+func (p PromocionRepository) RegistrarPromocion(ctx context.Context, request *domain.PromocionRequest) (*int, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewStatusServiceUnavailableErrorGeneric()
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var id int
+	query := `
+	INSERT INTO promocion (nombre, descripcion, tipo, fecha_inicio, fecha_fin, cantidad_requerida, cantidad_gratis, precio_paquete, porcentaje, categoria_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING id`
+	err = tx.QueryRow(ctx, query, request.Nombre, request.Descripcion, request.Tipo, request.FechaInicio, request.FechaFin,
+		request.CantidadRequerida, request.CantidadGratis, request.PrecioPaquete, request.Porcentaje, request.CategoriaId).Scan(&id)
+	if err != nil {
+		return nil, promocionError(err)
+	}
+
+	if err := insertarPromocionProductos(ctx, tx, id, request.Productos); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	committed = true
+	return &id, nil
+}
+
+func (p PromocionRepository) ModificarPromocion(ctx context.Context, id *int, request *domain.PromocionRequest) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewStatusServiceUnavailableErrorGeneric()
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	query := `
+	UPDATE promocion SET nombre = $1, descripcion = $2, tipo = $3, fecha_inicio = $4, fecha_fin = $5,
+	       cantidad_requerida = $6, cantidad_gratis = $7, precio_paquete = $8, porcentaje = $9, categoria_id = $10
+	WHERE id = $11`
+	ct, err := tx.Exec(ctx, query, request.Nombre, request.Descripcion, request.Tipo, request.FechaInicio, request.FechaFin,
+		request.CantidadRequerida, request.CantidadGratis, request.PrecioPaquete, request.Porcentaje, request.CategoriaId, *id)
+	if err != nil {
+		return promocionError(err)
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Promoción no encontrada")
+	}
+
+	// Reemplazar productos asociados
+	if _, err := tx.Exec(ctx, `DELETE FROM promocion_producto WHERE promocion_id = $1`, *id); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := insertarPromocionProductos(ctx, tx, *id, request.Productos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	committed = true
+	return nil
+}
+
+func (p PromocionRepository) HabilitarPromocion(ctx context.Context, id *int) error {
+	ct, err := p.pool.Exec(ctx, `UPDATE promocion SET deleted_at = NULL, estado = 'Activo' WHERE id = $1`, *id)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Promoción no encontrada")
+	}
+	return nil
+}
+
+func (p PromocionRepository) DeshabilitarPromocion(ctx context.Context, id *int) error {
+	ct, err := p.pool.Exec(ctx, `UPDATE promocion SET deleted_at = CURRENT_TIMESTAMP, estado = 'Inactivo' WHERE id = $1`, *id)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Promoción no encontrada")
+	}
+	return nil
+}
+
+func (p PromocionRepository) ObtenerPromocionesVigentes(ctx context.Context) (*[]domain.PromocionDetail, error) {
+	query := `
+	SELECT pr.id, pr.nombre, pr.tipo, pr.cantidad_requerida, pr.cantidad_gratis, pr.precio_paquete, pr.porcentaje,
+	       CASE WHEN pr.categoria_id IS NULL THEN NULL ELSE jsonb_build_object('id', pr.categoria_id) END AS categoria,
+	       COALESCE((
+	           SELECT jsonb_agg(jsonb_build_object('producto', jsonb_build_object('id', pp.producto_id), 'cantidad', pp.cantidad))
+	           FROM promocion_producto pp
+	           WHERE pp.promocion_id = pr.id
+	       ), '[]'::jsonb) AS productos
+	FROM promocion pr
+	WHERE pr.estado = 'Activo' AND NOW() BETWEEN pr.fecha_inicio AND pr.fecha_fin
+	ORDER BY pr.id`
+
+	rows, err := p.pool.Query(ctx, query)
+	if err != nil {
+		log.Println("Error al obtener promociones vigentes:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var list = make([]domain.PromocionDetail, 0)
+	for rows.Next() {
+		var item domain.PromocionDetail
+		if err := rows.Scan(&item.Id, &item.Nombre, &item.Tipo, &item.CantidadRequerida, &item.CantidadGratis,
+			&item.PrecioPaquete, &item.Porcentaje, &item.Categoria, &item.Productos); err != nil {
+			log.Println("Error al escanear promoción vigente:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PromocionRepository) ObtenerItemsPromocion(ctx context.Context, detalles []domain.DetalleVentaRequest) (*[]domain.PromocionItem, error) {
+	// Las promociones se aplican sobre presentaciones completas, no sobre unidades sueltas
+	var ids []uuid.UUID
+	for _, d := range detalles {
+		if d.UnidadVenta == domain.UnidadVentaUnidad {
+			continue
+		}
+		id, err := uuid.Parse(d.ProductoId)
+		if err != nil {
+			return nil, datatype.NewNotFoundErrorWithData("Producto no encontrado", domain.ProductoId{Id: d.ProductoId})
+		}
+		ids = append(ids, id)
+	}
+	var list = make([]domain.PromocionItem, 0, len(ids))
+	if len(ids) == 0 {
+		return &list, nil
+	}
+
+	rows, err := p.pool.Query(ctx, `
+	SELECT p.id, p.precio_venta,
+	       COALESCE(ARRAY(SELECT pc.categoria_id FROM producto_categoria pc WHERE pc.producto_id = p.id), '{}')
+	FROM producto p
+	WHERE p.id = ANY($1)`, ids)
+	if err != nil {
+		log.Println("Error al obtener productos para promoción:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	productos := make(map[uuid.UUID]domain.PromocionItem, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var item domain.PromocionItem
+		if err := rows.Scan(&id, &item.PrecioVenta, &item.Categorias); err != nil {
+			log.Println("Error al escanear producto para promoción:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.ProductoId = id.String()
+		productos[id] = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	i := 0
+	for _, d := range detalles {
+		if d.UnidadVenta == domain.UnidadVentaUnidad {
+			continue
+		}
+		item, ok := productos[ids[i]]
+		i++
+		if !ok {
+			return nil, datatype.NewNotFoundErrorWithData("Producto no encontrado", domain.ProductoId{Id: d.ProductoId})
+		}
+		item.Cantidad = d.Cantidad
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (p PromocionRepository) ObtenerEfectividadPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionEfectividad, error) {
+	var filters = []string{"v.estado = 'Realizada'"}
+	var args []interface{}
+	i := 1
+
+	// Filtrar por fechaInicio
+	if fechaInicioStr := filtros["fechaInicio"]; fechaInicioStr != "" {
+		fechaInicio, err := time.Parse("2006-01-02", fechaInicioStr)
+		if err != nil {
+			fechaInicio, err = time.Parse(time.RFC3339, fechaInicioStr)
+			if err != nil {
+				return nil, datatype.NewBadRequestError("El valor de fechaInicio no es válido, formatos esperados: YYYY-MM-DD o RFC3339")
+			}
+		}
+		filters = append(filters, fmt.Sprintf("v.fecha >= $%d", i))
+		args = append(args, fechaInicio)
+		i++
+	}
+
+	// Filtrar por fechaFin
+	if fechaFinStr := filtros["fechaFin"]; fechaFinStr != "" {
+		fechaFin, err := time.Parse("2006-01-02", fechaFinStr)
+		if err != nil {
+			fechaFin, err = time.Parse(time.RFC3339, fechaFinStr)
+			if err != nil {
+				return nil, datatype.NewBadRequestError("El valor de fechaFin no es válido, formatos esperados: YYYY-MM-DD o RFC3339")
+			}
+		}
+		filters = append(filters, fmt.Sprintf("v.fecha <= $%d", i))
+		args = append(args, fechaFin)
+		i++
+	}
+
+	query := `
+	SELECT pr.id, pr.nombre, pr.tipo,
+	       CAST(COUNT(DISTINCT v.id) AS INTEGER) AS cantidad_ventas,
+	       CAST(COALESCE(SUM(vp.cantidad), 0) AS INTEGER) AS unidades,
+	       COALESCE(SUM(vp.subtotal), 0) AS subtotal,
+	       COALESCE(SUM(vp.descuento), 0) AS descuento,
+	       COALESCE(SUM(vp.subtotal - vp.descuento), 0) AS ingreso
+	FROM promocion pr
+	INNER JOIN venta_promocion vp ON vp.promocion_id = pr.id
+	INNER JOIN venta v ON v.id = vp.venta_id
+	WHERE ` + strings.Join(filters, " AND ") + `
+	GROUP BY pr.id, pr.nombre, pr.tipo
+	ORDER BY ingreso DESC`
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al obtener efectividad de promociones:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var list = make([]domain.PromocionEfectividad, 0)
+	for rows.Next() {
+		var item domain.PromocionEfectividad
+		if err := rows.Scan(&item.PromocionId, &item.Nombre, &item.Tipo, &item.CantidadVentas, &item.Unidades,
+			&item.Subtotal, &item.Descuento, &item.Ingreso); err != nil {
+			log.Println("Error al escanear efectividad de promoción:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func insertarPromocionProductos(ctx context.Context, tx pgx.Tx, promocionId int, productos []domain.PromocionProductoRequest) error {
+	for _, pp := range productos {
+		cantidad := pp.Cantidad
+		if cantidad <= 0 {
+			cantidad = 1
+		}
+		_, err := tx.Exec(ctx, `INSERT INTO promocion_producto (promocion_id, producto_id, cantidad) VALUES ($1, $2, $3)`,
+			promocionId, pp.ProductoId, cantidad)
+		if err != nil {
+			return promocionError(err)
+		}
+	}
+	return nil
+}
+
+func promocionError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return datatype.NewConflictError("Ya existe una promoción con el mismo nombre o producto repetido")
+		case "23503":
+			return datatype.NewBadRequestError("La categoría o producto de la promoción no existe")
+		case "23514":
+			return datatype.NewBadRequestError("Los datos de la promoción no son válidos")
+		case "22P02":
+			return datatype.NewBadRequestError("Formato de id de producto no válido")
+		}
+	}
+	log.Println("Error en promoción:", err)
+	return datatype.NewInternalServerErrorGeneric()
+}
+
+func NewPromocionRepository(pool *pgxpool.Pool) *PromocionRepository {
+	return &PromocionRepository{pool: pool}
+}
+
+var _ port.PromocionRepository = (*PromocionRepository)(nil)
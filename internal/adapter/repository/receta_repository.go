@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Las imágenes de recetas contienen datos del paciente, por eso no se guardan en ./public
+const rutaImagenesReceta = "./uploads/recetas"
+
+// Días de vigencia de una receta desde su emisión
+const diasVigenciaReceta = 30
+
+type RecetaRepository struct {
+	pool *pgxpool.Pool
+}
+
+const queryRecetaInfo = `
+SELECT r.id,
+       r.medico_nombre,
+       r.medico_matricula,
+       r.paciente_nombre,
+       r.paciente_ci,
+       r.fecha_emision,
+       r.imagen,
+       jsonb_build_object('id', u.id, 'username', u.username) AS usuario,
+       r.created_at
+FROM receta r
+INNER JOIN usuario u ON u.id = r.usuario_id
+`
+
+func (r RecetaRepository) ObtenerListaRecetas(ctx context.Context, filtros map[string]string) (*[]domain.RecetaInfo, error) {
+	query := queryRecetaInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	// Buscar por nombre o CI del paciente
+	if paciente := strings.TrimSpace(filtros["paciente"]); paciente != "" {
+		filters = append(filters, fmt.Sprintf("(r.paciente_nombre ILIKE $%d OR r.paciente_ci ILIKE $%d)", i, i))
+		args = append(args, "%"+paciente+"%")
+		i++
+	}
+
+	// Buscar por matrícula del médico
+	if matricula := strings.TrimSpace(filtros["matricula"]); matricula != "" {
+		filters = append(filters, fmt.Sprintf("r.medico_matricula = $%d", i))
+		args = append(args, matricula)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY r.created_at DESC"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar recetas:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.RecetaInfo, 0)
+	for rows.Next() {
+		var item domain.RecetaInfo
+		if err := rows.Scan(&item.Id, &item.MedicoNombre, &item.MedicoMatricula, &item.PacienteNombre, &item.PacienteCi,
+			&item.FechaEmision, &item.Imagen, &item.Usuario, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear receta:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (r RecetaRepository) ObtenerRecetaById(ctx context.Context, id *int) (*domain.RecetaDetail, error) {
+	var item domain.RecetaDetail
+	err := r.pool.QueryRow(ctx, queryRecetaInfo+` WHERE r.id = $1`, *id).
+		Scan(&item.Id, &item.MedicoNombre, &item.MedicoMatricula, &item.PacienteNombre, &item.PacienteCi,
+			&item.FechaEmision, &item.Imagen, &item.Usuario, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Receta no encontrada")
+		}
+		log.Println("Error al obtener receta:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	rows, err := r.pool.Query(ctx, `
+        SELECT jsonb_build_object('id', p.id, 'nombreComercial', p.nombre_comercial, 'laboratorio', l.nombre) AS producto,
+               nivel_control_producto(p.id)::TEXT,
+               dr.cantidad_prescrita,
+               dr.cantidad_dispensada
+        FROM detalle_receta dr
+        INNER JOIN producto p ON p.id = dr.producto_id
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        WHERE dr.receta_id = $1
+        ORDER BY dr.id`, *id)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Detalles = make([]domain.RecetaDetalle, 0)
+	for rows.Next() {
+		var d domain.RecetaDetalle
+		if err := rows.Scan(&d.Producto, &d.NivelControl, &d.CantidadPrescrita, &d.CantidadDispensada); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.Detalles = append(item.Detalles, d)
+	}
+	return &item, nil
+}
+
+func (r RecetaRepository) RegistrarReceta(ctx context.Context, request *domain.RecetaRequest, imagen *multipart.FileHeader) (*int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var recetaId int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO receta (medico_nombre, medico_matricula, paciente_nombre, paciente_ci, fecha_emision, usuario_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, request.MedicoNombre, request.MedicoMatricula, request.PacienteNombre, request.PacienteCi, request.FechaEmision, request.UsuarioId).Scan(&recetaId)
+	if err != nil {
+		log.Println("Error al registrar receta:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	for _, d := range request.Detalles {
+		_, err = tx.Exec(ctx, `
+            INSERT INTO detalle_receta (receta_id, producto_id, cantidad_prescrita)
+            VALUES ($1, $2, $3)
+        `, recetaId, d.ProductoId, d.Cantidad)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				switch pgErr.Code {
+				case "23505":
+					return nil, datatype.NewConflictError("Un producto está repetido en la receta")
+				case "23503", "22P02":
+					return nil, datatype.NewBadRequestError("Uno de los productos de la receta no existe")
+				}
+			}
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	// Guardar la imagen de la receta
+	if imagen != nil {
+		route := fmt.Sprintf("%s/%d", rutaImagenesReceta, recetaId)
+		if err := util.File.MakeDir(route); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		file, err := imagen.Open()
+		if err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		nameFile := "receta" + strings.ToLower(filepath.Ext(imagen.Filename))
+		if err := util.File.SaveFile(route, nameFile, file); err != nil {
+			_ = util.File.DeleteAllFiles(route)
+			return nil, datatype.NewInternalServerError("Error al guardar la imagen de la receta")
+		}
+		if _, err := tx.Exec(ctx, `UPDATE receta SET imagen = $1 WHERE id = $2`, nameFile, recetaId); err != nil {
+			_ = util.File.DeleteAllFiles(route)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &recetaId, nil
+}
+
+func (r RecetaRepository) ObtenerRegistroControlados(ctx context.Context, filtros map[string]string) (*[]domain.RegistroControlado, error) {
+	query := `
+        SELECT v.fecha,
+               v.codigo,
+               p.nombre_comercial,
+               l.nombre,
+               lp.lote,
+               dv.cantidad * dv.factor,
+               r.id,
+               r.paciente_nombre,
+               r.paciente_ci,
+               r.medico_nombre,
+               r.medico_matricula,
+               u.username
+        FROM detalle_venta dv
+        INNER JOIN venta v ON v.id = dv.venta_id
+        INNER JOIN receta r ON r.id = v.receta_id
+        INNER JOIN usuario u ON u.id = v.usuario_id
+        INNER JOIN lote_producto lp ON lp.id = dv.lote_id
+        INNER JOIN producto p ON p.id = lp.producto_id
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        WHERE v.estado = 'Realizada'
+          AND nivel_control_producto(p.id) = 'Controlado'`
+
+	var args []interface{}
+	i := 1
+
+	if fechaInicioStr := filtros["fechaInicio"]; fechaInicioStr != "" {
+		fechaInicio, err := time.Parse("2006-01-02", fechaInicioStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de fechaInicio no es válido, formato esperado: YYYY-MM-DD")
+		}
+		query += fmt.Sprintf(" AND v.fecha >= $%d", i)
+		args = append(args, fechaInicio)
+		i++
+	}
+
+	if fechaFinStr := filtros["fechaFin"]; fechaFinStr != "" {
+		fechaFin, err := time.Parse("2006-01-02", fechaFinStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de fechaFin no es válido, formato esperado: YYYY-MM-DD")
+		}
+		query += fmt.Sprintf(" AND v.fecha < $%d", i)
+		args = append(args, fechaFin.AddDate(0, 0, 1))
+		i++
+	}
+	query += " ORDER BY v.fecha, v.codigo, dv.id"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al obtener registro de controlados:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.RegistroControlado, 0)
+	for rows.Next() {
+		var item domain.RegistroControlado
+		if err := rows.Scan(&item.Fecha, &item.VentaCodigo, &item.Producto, &item.Laboratorio, &item.Lote, &item.Cantidad, &item.RecetaId,
+			&item.PacienteNombre, &item.PacienteCi, &item.MedicoNombre, &item.MedicoMatricula, &item.Usuario); err != nil {
+			log.Println("Error al escanear registro de controlados:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+// validarRecetaVenta exige receta vigente para los productos bajo control y descuenta lo dispensado de lo prescrito
+func validarRecetaVenta(ctx context.Context, tx pgx.Tx, recetaId *int, detalles []domain.DetalleVentaRequest) error {
+	if recetaId != nil {
+		var vigente bool
+		err := tx.QueryRow(ctx, `
+            SELECT fecha_emision >= CURRENT_DATE - $2::INT FROM receta WHERE id = $1 FOR UPDATE
+        `, *recetaId, diasVigenciaReceta).Scan(&vigente)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return datatype.NewNotFoundError("Receta no encontrada")
+			}
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if !vigente {
+			return datatype.NewBadRequestError("La receta está vencida")
+		}
+	}
+
+	for _, item := range detalles {
+		var nivel string
+		var unidadesPresentacion uint
+		err := tx.QueryRow(ctx, `
+            SELECT nivel_control_producto(p.id)::TEXT, COALESCE(NULLIF(p.unidades_presentacion, 0), 1)
+            FROM producto p WHERE p.id = $1
+        `, item.ProductoId).Scan(&nivel, &unidadesPresentacion)
+		if err != nil {
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if recetaId == nil {
+			if nivel != domain.ControlLibre {
+				return datatype.NewErrorDataResponse(http.StatusBadRequest, "El producto requiere receta médica", domain.ProductoId{Id: item.ProductoId})
+			}
+			continue
+		}
+
+		// Las cantidades de la receta se llevan en unidades base
+		cantidad := item.Cantidad
+		if unidadVenta(item) == domain.UnidadVentaPresentacion {
+			cantidad *= unidadesPresentacion
+		}
+		ct, err := tx.Exec(ctx, `
+            UPDATE detalle_receta SET cantidad_dispensada = cantidad_dispensada + $3
+            WHERE receta_id = $1 AND producto_id = $2 AND cantidad_dispensada + $3 <= cantidad_prescrita
+        `, *recetaId, item.ProductoId, cantidad)
+		if err != nil {
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if ct.RowsAffected() == 0 && nivel != domain.ControlLibre {
+			return datatype.NewErrorDataResponse(http.StatusBadRequest, "La cantidad excede lo prescrito en la receta o el producto no figura en ella", domain.ProductoId{Id: item.ProductoId})
+		}
+	}
+	return nil
+}
+
+// revertirRecetaVenta devuelve a la receta las cantidades dispensadas en una venta anulada
+func revertirRecetaVenta(ctx context.Context, tx pgx.Tx, ventaId int) error {
+	_, err := tx.Exec(ctx, `
+        UPDATE detalle_receta dr
+        SET cantidad_dispensada = GREATEST(dr.cantidad_dispensada - x.cantidad, 0)
+        FROM (SELECT v.receta_id, lp.producto_id, SUM(dv.cantidad * dv.factor) AS cantidad
+              FROM detalle_venta dv
+              INNER JOIN venta v ON v.id = dv.venta_id
+              INNER JOIN lote_producto lp ON lp.id = dv.lote_id
+              WHERE v.id = $1 AND v.receta_id IS NOT NULL
+              GROUP BY v.receta_id, lp.producto_id) x
+        WHERE dr.receta_id = x.receta_id AND dr.producto_id = x.producto_id
+    `, ventaId)
+	if err != nil {
+		log.Println("Error al revertir receta de venta:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func NewRecetaRepository(pool *pgxpool.Pool) *RecetaRepository {
+	return &RecetaRepository{pool: pool}
+}
+
+var _ port.RecetaRepository = (*RecetaRepository)(nil)
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RetiroLoteRepository struct {
+	pool *pgxpool.Pool
+}
+
+const queryRetiroLoteInfo = `
+SELECT r.id,
+       r.codigo,
+       r.origen,
+       r.referencia,
+       r.motivo,
+       r.stock_cuarentena,
+       jsonb_build_object('id', lp.id, 'lote', lp.lote, 'fechaVencimiento', lp.fecha_vencimiento::timestamptz) AS lote,
+       jsonb_build_object('id', p.id, 'nombreComercial', p.nombre_comercial, 'laboratorio', l.nombre,
+                          'presentacion', jsonb_build_object('id', pr.id, 'nombre', pr.nombre),
+                          'unidadesPresentacion', p.unidades_presentacion) AS producto,
+       jsonb_build_object('id', u.id, 'username', u.username) AS usuario,
+       r.created_at
+FROM retiro_lote r
+INNER JOIN lote_producto lp ON lp.id = r.lote_id
+INNER JOIN producto p ON p.id = lp.producto_id
+INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+LEFT JOIN presentacion pr ON pr.id = p.presentacion_id
+INNER JOIN usuario u ON u.id = r.usuario_id
+`
+
+func (r RetiroLoteRepository) ObtenerListaRetiros(ctx context.Context, filtros map[string]string) (*[]domain.RetiroLoteInfo, error) {
+	query := queryRetiroLoteInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	if origen := filtros["origen"]; origen != "" {
+		filters = append(filters, fmt.Sprintf("r.origen = $%d", i))
+		args = append(args, origen)
+		i++
+	}
+
+	if productoId := filtros["productoId"]; productoId != "" {
+		filters = append(filters, fmt.Sprintf("p.id::TEXT = $%d", i))
+		args = append(args, productoId)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY r.created_at DESC"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar retiros de lotes:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.RetiroLoteInfo, 0)
+	for rows.Next() {
+		var item domain.RetiroLoteInfo
+		if err := rows.Scan(&item.Id, &item.Codigo, &item.Origen, &item.Referencia, &item.Motivo, &item.StockCuarentena,
+			&item.Lote, &item.Producto, &item.Usuario, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear retiro de lote:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (r RetiroLoteRepository) ObtenerRetiroById(ctx context.Context, id *int) (*domain.RetiroLoteDetail, error) {
+	var item domain.RetiroLoteDetail
+	err := r.pool.QueryRow(ctx, queryRetiroLoteInfo+` WHERE r.id = $1`, *id).
+		Scan(&item.Id, &item.Codigo, &item.Origen, &item.Referencia, &item.Motivo, &item.StockCuarentena,
+			&item.Lote, &item.Producto, &item.Usuario, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Retiro de lote no encontrado")
+		}
+		log.Println("Error al obtener retiro de lote:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	ventas, err := r.ObtenerVentasLote(ctx, &item.Lote.Id)
+	if err != nil {
+		return nil, err
+	}
+	item.Ventas = *ventas
+	return &item, nil
+}
+
+func (r RetiroLoteRepository) RegistrarRetiro(ctx context.Context, request *domain.RetiroLoteRequest) (*int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Bloquear el lote para que ninguna venta lo tome mientras se registra el retiro
+	var estado string
+	var stock int64
+	var productoId string
+	err = tx.QueryRow(ctx, `SELECT estado::TEXT, stock, producto_id::TEXT FROM lote_producto WHERE id = $1 FOR UPDATE`, request.LoteId).
+		Scan(&estado, &stock, &productoId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Lote no encontrado")
+		}
+		log.Println("Error al bloquear lote para retiro:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if estado == domain.LoteEstadoCuarentena {
+		return nil, datatype.NewConflictError("El lote ya se encuentra en cuarentena por un retiro")
+	}
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaLoteProducto, request.LoteId)
+	if err != nil {
+		return nil, err
+	}
+
+	codigo, err := generarCodigoRetiro(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO retiro_lote (codigo, lote_id, origen, referencia, motivo, stock_cuarentena, usuario_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `, codigo, request.LoteId, request.Origen, request.Referencia, request.Motivo, stock, request.UsuarioId).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, datatype.NewConflictError("El lote ya tiene un retiro registrado")
+		}
+		log.Println("Error al registrar retiro de lote:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	// El stock queda en el lote como cantidad en cuarentena y deja de estar disponible para la venta
+	if _, err := tx.Exec(ctx, `UPDATE lote_producto SET estado = 'Cuarentena' WHERE id = $1`, request.LoteId); err != nil {
+		log.Println("Error al poner el lote en cuarentena:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaLoteProducto, request.LoteId, domain.AccionRetirar, antes); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE producto
+        SET stock = (SELECT COALESCE(SUM(lp.stock), 0) FROM lote_producto lp WHERE lp.producto_id = producto.id AND lp.estado = 'Activo')
+        WHERE id::TEXT = $1
+    `, productoId)
+	if err != nil {
+		log.Println("Error al actualizar stock del producto retirado:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Error al confirmar retiro de lote:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &id, nil
+}
+
+func (r RetiroLoteRepository) ObtenerVentasLote(ctx context.Context, loteId *int) (*[]domain.VentaLoteRetirado, error) {
+	// Ventas realizadas que dispensaron el lote; los pedidos en línea aportan su contacto si el cliente no tiene uno
+	rows, err := r.pool.Query(ctx, `
+        SELECT v.id,
+               v.codigo,
+               v.fecha,
+               SUM(dv.cantidad * dv.factor)::BIGINT,
+               c.id,
+               c.razon_social,
+               c.nit_ci,
+               COALESCE(NULLIF(TRIM(c.email), ''), MAX(pe.email)),
+               COALESCE(c.telefono::TEXT, MAX(pe.telefono))
+        FROM detalle_venta dv
+        INNER JOIN venta v ON v.id = dv.venta_id
+        INNER JOIN cliente c ON c.id = v.cliente_id
+        LEFT JOIN pedido pe ON pe.venta_id = v.id
+        WHERE dv.lote_id = $1 AND v.estado = 'Realizada'
+        GROUP BY v.id, c.id
+        ORDER BY v.fecha DESC
+    `, *loteId)
+	if err != nil {
+		log.Println("Error al obtener ventas del lote:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.VentaLoteRetirado, 0)
+	for rows.Next() {
+		var item domain.VentaLoteRetirado
+		if err := rows.Scan(&item.VentaId, &item.Codigo, &item.Fecha, &item.Cantidad, &item.Cliente.Id, &item.Cliente.RazonSocial,
+			&item.Cliente.NitCi, &item.Cliente.Email, &item.Cliente.Telefono); err != nil {
+			log.Println("Error al escanear venta del lote:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+// generarCodigoRetiro genera el siguiente código correlativo de retiro de lote
+func generarCodigoRetiro(ctx context.Context, tx pgx.Tx) (string, error) {
+	var nextNum int64
+	err := tx.QueryRow(ctx, `
+        SELECT COALESCE(
+            (SELECT MAX(CAST(SUBSTRING(codigo FROM 5) AS INTEGER)) + 1 FROM retiro_lote WHERE codigo ~ '^RET-[0-9]+$'),
+            1
+        )
+    `).Scan(&nextNum)
+	if err != nil {
+		return "", datatype.NewInternalServerErrorGeneric()
+	}
+	return fmt.Sprintf("RET-%09d", nextNum), nil
+}
+
+func NewRetiroLoteRepository(pool *pgxpool.Pool) *RetiroLoteRepository {
+	return &RetiroLoteRepository{pool: pool}
+}
+
+var _ port.RetiroLoteRepository = (*RetiroLoteRepository)(nil)
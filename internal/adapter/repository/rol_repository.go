@@ -9,6 +9,7 @@ import (
 	"farma-santi_backend/internal/core/port"
 	"log"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -30,10 +31,17 @@ func (r RolRepository) HabilitarRol(ctx context.Context, id *int) error {
 		}
 	}()
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaRol, *id)
+	if err != nil {
+		return err
+	}
 	_, err = tx.Exec(ctx, query, *id)
 	if err != nil {
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaRol, *id, domain.AccionHabilitar, antes); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -46,7 +54,7 @@ func (r RolRepository) DeshabilitarRol(ctx context.Context, id *int) error {
 	query := `
 		UPDATE rol r 
 		SET deleted_at = CURRENT_TIMESTAMP, estado= 'Inactivo'
-		WHERE r.id = $1 AND r.nombre != 'ADMIN'
+		WHERE r.id = $1 AND NOT r.es_sistema
 	`
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
@@ -59,6 +67,10 @@ func (r RolRepository) DeshabilitarRol(ctx context.Context, id *int) error {
 		}
 	}()
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaRol, *id)
+	if err != nil {
+		return err
+	}
 	ct, err := tx.Exec(ctx, query, *id)
 	if err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -66,6 +78,9 @@ func (r RolRepository) DeshabilitarRol(ctx context.Context, id *int) error {
 	if ct.RowsAffected() == 0 {
 		return datatype.NewConflictError("Conflicto al actualizar rol")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaRol, *id, domain.AccionDeshabilitar, antes); err != nil {
+		return err
+	}
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
 	}
@@ -94,7 +109,11 @@ func (r RolRepository) ModificarRol(ctx context.Context, id *int, rolRequestUpda
 			_ = tx.Rollback(ctx)
 		}
 	}()
-	query = `UPDATE rol SET nombre = $1 WHERE id = $2`
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaRol, *id)
+	if err != nil {
+		return err
+	}
+	query = `UPDATE rol SET nombre = $1 WHERE id = $2 AND NOT es_sistema`
 	ct, err := tx.Exec(ctx, query, rolRequestUpdate.Nombre, id)
 	if err != nil {
 		// Si el nombre ya existe (violación de restricción única)
@@ -108,6 +127,9 @@ func (r RolRepository) ModificarRol(ctx context.Context, id *int, rolRequestUpda
 	if ct.RowsAffected() == 0 {
 		return datatype.NewNotFoundError("No existe el rol")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaRol, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -150,6 +172,9 @@ func (r RolRepository) RegistrarRol(ctx context.Context, rolRequest *domain.RolR
 		}
 		return datatype.NewInternalServerErrorGeneric()
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaRol, rolId, domain.AccionCrear, nil); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -159,7 +184,7 @@ func (r RolRepository) RegistrarRol(ctx context.Context, rolRequest *domain.RolR
 }
 
 func (r RolRepository) ListarRoles(ctx context.Context) (*[]domain.Rol, error) {
-	query := "SELECT r.id, r.nombre,r.estado, r.created_at, r.deleted_at FROM rol r ORDER BY created_at DESC "
+	query := "SELECT r.id, r.nombre,r.estado, r.requiere_doble_factor, r.es_sistema, r.created_at, r.deleted_at FROM rol r ORDER BY created_at DESC "
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
 		return nil, datatype.NewInternalServerErrorGeneric()
@@ -169,7 +194,7 @@ func (r RolRepository) ListarRoles(ctx context.Context) (*[]domain.Rol, error) {
 	var roles = make([]domain.Rol, 0)
 	for rows.Next() {
 		var rol domain.Rol
-		if err := rows.Scan(&rol.Id, &rol.Nombre, &rol.Estado, &rol.CreatedAt, &rol.DeletedAt); err != nil {
+		if err := rows.Scan(&rol.Id, &rol.Nombre, &rol.Estado, &rol.RequiereDobleFactor, &rol.EsSistema, &rol.CreatedAt, &rol.DeletedAt); err != nil {
 			return nil, datatype.NewInternalServerErrorGeneric()
 		}
 		roles = append(roles, rol)
@@ -182,11 +207,11 @@ func (r RolRepository) ListarRoles(ctx context.Context) (*[]domain.Rol, error) {
 }
 
 func (r RolRepository) ObtenerRolById(ctx context.Context, id *int) (*domain.Rol, error) {
-	query := "SELECT r.id, r.nombre, r.created_at, r.deleted_at FROM rol r WHERE r.id = $1 ORDER BY r.id"
+	query := "SELECT r.id, r.nombre, r.requiere_doble_factor, r.es_sistema, r.created_at, r.deleted_at FROM rol r WHERE r.id = $1 ORDER BY r.id"
 	row := r.pool.QueryRow(ctx, query, id)
 
 	var rol domain.Rol
-	if err := row.Scan(&rol.Id, &rol.Nombre, &rol.CreatedAt, &rol.DeletedAt); err != nil {
+	if err := row.Scan(&rol.Id, &rol.Nombre, &rol.RequiereDobleFactor, &rol.EsSistema, &rol.CreatedAt, &rol.DeletedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, datatype.NewNotFoundError("Rol no encontrado")
 		}
@@ -196,6 +221,146 @@ func (r RolRepository) ObtenerRolById(ctx context.Context, id *int) (*domain.Rol
 	return &rol, nil
 }
 
+func (r RolRepository) ListarPermisos(ctx context.Context) (*[]domain.Permiso, error) {
+	rows, err := r.pool.Query(ctx, `SELECT p.id, p.codigo, p.modulo, p.descripcion FROM permiso p ORDER BY p.modulo, p.codigo`)
+	if err != nil {
+		log.Println("Error al listar permisos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var permisos = make([]domain.Permiso, 0)
+	for rows.Next() {
+		var permiso domain.Permiso
+		if err := rows.Scan(&permiso.Id, &permiso.Codigo, &permiso.Modulo, &permiso.Descripcion); err != nil {
+			log.Println("Error al escanear permiso:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		permisos = append(permisos, permiso)
+	}
+	return &permisos, nil
+}
+
+func (r RolRepository) ObtenerPermisosRol(ctx context.Context, id *int) (*[]domain.Permiso, error) {
+	var existe bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM rol WHERE id = $1)`, *id).Scan(&existe)
+	if err != nil {
+		log.Println("Error al obtener rol:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if !existe {
+		return nil, datatype.NewNotFoundError("Rol no encontrado")
+	}
+
+	rows, err := r.pool.Query(ctx, `
+        SELECT p.id, p.codigo, p.modulo, p.descripcion
+        FROM permiso p
+        INNER JOIN rol_permiso rp ON rp.permiso_id = p.id
+        WHERE rp.rol_id = $1
+        ORDER BY p.modulo, p.codigo
+    `, *id)
+	if err != nil {
+		log.Println("Error al obtener permisos del rol:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var permisos = make([]domain.Permiso, 0)
+	for rows.Next() {
+		var permiso domain.Permiso
+		if err := rows.Scan(&permiso.Id, &permiso.Codigo, &permiso.Modulo, &permiso.Descripcion); err != nil {
+			log.Println("Error al escanear permiso:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		permisos = append(permisos, permiso)
+	}
+	return &permisos, nil
+}
+
+// AsignarPermisosRol reemplaza los permisos asignados al rol
+func (r RolRepository) AsignarPermisosRol(ctx context.Context, id *int, permisos []int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var esSistema bool
+	err = tx.QueryRow(ctx, `SELECT es_sistema FROM rol WHERE id = $1 FOR UPDATE`, *id).Scan(&esSistema)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Rol no encontrado")
+		}
+		log.Println("Error al bloquear rol:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if esSistema {
+		return datatype.NewBadRequestError("El rol del sistema tiene todos los permisos y no se puede modificar")
+	}
+
+	var existentes int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM permiso WHERE id = ANY($1)`, permisos).Scan(&existentes)
+	if err != nil {
+		log.Println("Error al verificar permisos:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if existentes != len(permisos) {
+		return datatype.NewBadRequestError("Uno o más permisos no existen")
+	}
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaRol, *id)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM rol_permiso WHERE rol_id = $1`, *id); err != nil {
+		log.Println("Error al quitar permisos del rol:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO rol_permiso (rol_id, permiso_id) SELECT $1, UNNEST($2::INT[])`, *id, permisos)
+	if err != nil {
+		log.Println("Error al asignar permisos al rol:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaRol, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// ModificarDobleFactorRol indica si los usuarios del rol deben usar verificación en dos pasos
+func (r RolRepository) ModificarDobleFactorRol(ctx context.Context, id *int, requerido bool) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaRol, *id)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(ctx, `UPDATE rol SET requiere_doble_factor = $1 WHERE id = $2`, requerido, *id)
+	if err != nil {
+		log.Println("Error al modificar verificación en dos pasos del rol:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Rol no encontrado")
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaRol, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
 func NewRolRepository(pool *pgxpool.Pool) *RolRepository {
 	return &RolRepository{pool: pool}
 }
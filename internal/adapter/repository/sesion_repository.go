@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// graciaRotacion es el tiempo en que el token de actualización anterior se acepta sin considerarse reutilizado,
+// para las peticiones concurrentes de varias pestañas
+const graciaRotacion = 30 * time.Second
+
+type SesionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (s SesionRepository) RegistrarSesion(ctx context.Context, usuarioId int, refreshTokenId uuid.UUID, expiraAt time.Time, cliente *domain.SesionCliente) (*uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.pool.QueryRow(ctx, `
+        INSERT INTO sesion (usuario_id, refresh_token_id, dispositivo, ip, user_agent, expira_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, usuarioId, refreshTokenId, cliente.Dispositivo, cliente.Ip, cliente.UserAgent, expiraAt).Scan(&id)
+	if err != nil {
+		log.Println("Error al registrar sesión:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &id, nil
+}
+
+// RotarSesion reemplaza el token de actualización de la sesión; presentar un token ya rotado fuera del
+// tiempo de gracia se considera robo del token y revoca la sesión
+func (s SesionRepository) RotarSesion(ctx context.Context, sesionId, refreshTokenId, nuevoRefreshTokenId uuid.UUID, expiraAt time.Time, cliente *domain.SesionCliente) (*domain.RotacionSesion, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var rotacion domain.RotacionSesion
+	var actual uuid.UUID
+	var anterior *uuid.UUID
+	var rotadoAt, revocadaAt *time.Time
+	var sesionExpira time.Time
+	var estado string
+	err = tx.QueryRow(ctx, `
+        SELECT s.usuario_id, u.username, u.estado, s.refresh_token_id, s.refresh_token_anterior, s.rotado_at, s.revocada_at, s.expira_at
+        FROM sesion s
+        INNER JOIN usuario u ON u.id = s.usuario_id
+        WHERE s.id = $1
+        FOR UPDATE OF s
+    `, sesionId).Scan(&rotacion.UsuarioId, &rotacion.Username, &estado, &actual, &anterior, &rotadoAt, &revocadaAt, &sesionExpira)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewStatusUnauthorizedError("Sesión no válida")
+		}
+		log.Println("Error al obtener sesión:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if revocadaAt != nil || time.Now().After(sesionExpira) || estado != "Activo" {
+		return nil, datatype.NewStatusUnauthorizedError("Sesión no válida")
+	}
+
+	switch {
+	case refreshTokenId == actual:
+		_, err = tx.Exec(ctx, `
+            UPDATE sesion
+            SET refresh_token_anterior = refresh_token_id, refresh_token_id = $1, rotado_at = NOW(),
+                ultimo_acceso = NOW(), ip = $2, user_agent = $3, expira_at = $4
+            WHERE id = $5
+        `, nuevoRefreshTokenId, cliente.Ip, cliente.UserAgent, expiraAt, sesionId)
+		if err != nil {
+			log.Println("Error al rotar sesión:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		rotacion.RefreshTokenId = &nuevoRefreshTokenId
+	case anterior != nil && refreshTokenId == *anterior && rotadoAt != nil && time.Since(*rotadoAt) < graciaRotacion:
+		_, err = tx.Exec(ctx, `UPDATE sesion SET ultimo_acceso = NOW() WHERE id = $1`, sesionId)
+		if err != nil {
+			log.Println("Error al actualizar sesión:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+	default:
+		// La revocación se confirma aunque la petición sea rechazada
+		if err := revocarSesiones(ctx, tx, `id = $1`, sesionId, domain.SesionMotivoReutilizacion); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		log.Printf("Reutilización de token de actualización detectada en la sesión %s", sesionId)
+		return nil, datatype.NewStatusUnauthorizedError("Sesión revocada, vuelva a iniciar sesión")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &rotacion, nil
+}
+
+// VerificarSesionActiva indica si la sesión sigue vigente y registra el último acceso
+func (s SesionRepository) VerificarSesionActiva(ctx context.Context, sesionId uuid.UUID) (bool, error) {
+	ct, err := s.pool.Exec(ctx, `
+        UPDATE sesion SET ultimo_acceso = NOW()
+        WHERE id = $1 AND revocada_at IS NULL AND expira_at > NOW()
+    `, sesionId)
+	if err != nil {
+		log.Println("Error al verificar sesión:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func (s SesionRepository) ObtenerListaSesiones(ctx context.Context, usuarioId *int, soloActivas bool) (*[]domain.SesionInfo, error) {
+	query := `
+        SELECT s.id, jsonb_build_object('id', u.id, 'username', u.username, 'estado', u.estado),
+               s.dispositivo, s.ip, s.user_agent, s.created_at, s.ultimo_acceso, s.expira_at, s.revocada_at, s.motivo_revocacion
+        FROM sesion s
+        INNER JOIN usuario u ON u.id = s.usuario_id
+        WHERE s.usuario_id = $1
+    `
+	if soloActivas {
+		query += " AND s.revocada_at IS NULL AND s.expira_at > NOW()"
+	}
+	query += " ORDER BY s.ultimo_acceso DESC"
+
+	rows, err := s.pool.Query(ctx, query, *usuarioId)
+	if err != nil {
+		log.Println("Error al listar sesiones:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.SesionInfo, 0)
+	for rows.Next() {
+		var item domain.SesionInfo
+		if err := rows.Scan(&item.Id, &item.Usuario, &item.Dispositivo, &item.Ip, &item.UserAgent, &item.CreatedAt,
+			&item.UltimoAcceso, &item.ExpiraAt, &item.RevocadaAt, &item.MotivoRevocacion); err != nil {
+			log.Println("Error al escanear sesión:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+// RevocarSesion revoca una sesión; con usuarioId solo si pertenece a ese usuario
+func (s SesionRepository) RevocarSesion(ctx context.Context, sesionId uuid.UUID, usuarioId *int, motivo string) error {
+	ct, err := s.pool.Exec(ctx, `
+        UPDATE sesion SET revocada_at = NOW(), motivo_revocacion = $1
+        WHERE id = $2 AND ($3::INT IS NULL OR usuario_id = $3) AND revocada_at IS NULL
+    `, motivo, sesionId, usuarioId)
+	if err != nil {
+		log.Println("Error al revocar sesión:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Sesión no encontrada o ya revocada")
+	}
+	return nil
+}
+
+func (s SesionRepository) RevocarSesionesUsuario(ctx context.Context, usuarioId int, motivo string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := revocarSesiones(ctx, tx, `usuario_id = $1`, usuarioId, motivo); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// revocarSesiones revoca las sesiones vigentes que cumplen la condición dentro de la transacción
+func revocarSesiones(ctx context.Context, tx pgx.Tx, condicion string, valor interface{}, motivo string) error {
+	_, err := tx.Exec(ctx, `
+        UPDATE sesion SET revocada_at = NOW(), motivo_revocacion = $2
+        WHERE `+condicion+` AND revocada_at IS NULL
+    `, valor, motivo)
+	if err != nil {
+		log.Println("Error al revocar sesiones:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func NewSesionRepository(pool *pgxpool.Pool) *SesionRepository {
+	return &SesionRepository{pool: pool}
+}
+
+var _ port.SesionRepository = (*SesionRepository)(nil)
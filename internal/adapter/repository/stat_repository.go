@@ -84,7 +84,7 @@ func (s StatRepository) ObtenerTopProductosVendidos(ctx context.Context, _ map[s
 				SELECT $1 || '/' || p.id || '/' || foto
 				FROM unnest(p.fotos) AS foto
 			) AS fotos,
-			CAST(COALESCE(SUM(dv.cantidad), 0) AS INTEGER) as total_vendido
+			CAST(COALESCE(SUM(dv.cantidad * dv.factor), 0) AS INTEGER) as total_vendido
 		FROM detalle_venta dv
 		INNER JOIN venta v ON v.id = dv.venta_id
 		INNER JOIN lote_producto lp ON dv.lote_id = lp.id
@@ -0,0 +1,696 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UbicacionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// Tipo de ubicación que debe tener el padre de cada tipo
+var tipoPadreUbicacion = map[string]string{
+	domain.UbicacionEstante: domain.UbicacionAlmacen,
+	domain.UbicacionNivel:   domain.UbicacionEstante,
+}
+
+const queryUbicacionInfo = `
+SELECT u.id,
+       u.codigo,
+       u.nombre,
+       u.tipo,
+       u.padre_id,
+       ruta_ubicacion(u.id),
+       u.refrigerado,
+       u.controlado,
+       (SELECT COALESCE(SUM(lu.cantidad), 0)::BIGINT
+        FROM lote_ubicacion lu
+        WHERE lu.ubicacion_id IN (SELECT ubicaciones_descendientes(u.id))) AS stock,
+       u.created_at,
+       u.deleted_at
+FROM ubicacion u
+`
+
+// jsonUbicacionSimple arma el objeto UbicacionSimple de la ubicación con alias dado
+func jsonUbicacionSimple(alias string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s.id IS NOT NULL THEN jsonb_build_object('id', %[1]s.id, 'codigo', %[1]s.codigo, 'ruta', ruta_ubicacion(%[1]s.id)) END`, alias)
+}
+
+const jsonProductoUbicacion = `jsonb_build_object('id', p.id, 'nombreComercial', p.nombre_comercial, 'laboratorio', l.nombre,
+                          'presentacion', jsonb_build_object('id', pr.id, 'nombre', pr.nombre),
+                          'unidadesPresentacion', p.unidades_presentacion)`
+
+func (r UbicacionRepository) ObtenerListaUbicaciones(ctx context.Context, filtros map[string]string) (*[]domain.UbicacionInfo, error) {
+	query := queryUbicacionInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	if tipo := filtros["tipo"]; tipo != "" {
+		filters = append(filters, fmt.Sprintf("u.tipo = $%d", i))
+		args = append(args, tipo)
+		i++
+	}
+
+	if padreStr := filtros["padreId"]; padreStr != "" {
+		padreId, err := strconv.Atoi(padreStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de padreId no es válido")
+		}
+		filters = append(filters, fmt.Sprintf("u.padre_id = $%d", i))
+		args = append(args, padreId)
+		i++
+	}
+
+	if search := strings.TrimSpace(filtros["search"]); search != "" {
+		filters = append(filters, fmt.Sprintf("(u.codigo ILIKE $%d OR u.nombre ILIKE $%d)", i, i))
+		args = append(args, "%"+search+"%")
+		i++
+	}
+
+	// Por defecto solo las ubicaciones vigentes
+	if filtros["eliminados"] != "true" {
+		filters = append(filters, "u.deleted_at IS NULL")
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY u.codigo"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar ubicaciones:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.UbicacionInfo, 0)
+	for rows.Next() {
+		var item domain.UbicacionInfo
+		if err := rows.Scan(&item.Id, &item.Codigo, &item.Nombre, &item.Tipo, &item.PadreId, &item.Ruta, &item.Refrigerado,
+			&item.Controlado, &item.Stock, &item.CreatedAt, &item.DeletedAt); err != nil {
+			log.Println("Error al escanear ubicación:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (r UbicacionRepository) ObtenerUbicacionById(ctx context.Context, id *int) (*domain.UbicacionInfo, error) {
+	var item domain.UbicacionInfo
+	err := r.pool.QueryRow(ctx, queryUbicacionInfo+` WHERE u.id = $1`, *id).
+		Scan(&item.Id, &item.Codigo, &item.Nombre, &item.Tipo, &item.PadreId, &item.Ruta, &item.Refrigerado,
+			&item.Controlado, &item.Stock, &item.CreatedAt, &item.DeletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Ubicación no encontrada")
+		}
+		log.Println("Error al obtener ubicación:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &item, nil
+}
+
+func (r UbicacionRepository) RegistrarUbicacion(ctx context.Context, request *domain.UbicacionRequest) (*int, error) {
+	if err := r.validarPadreUbicacion(ctx, request); err != nil {
+		return nil, err
+	}
+	var id int
+	err := r.pool.QueryRow(ctx, `
+        INSERT INTO ubicacion (codigo, nombre, tipo, padre_id, refrigerado, controlado)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, request.Codigo, request.Nombre, request.Tipo, request.PadreId, request.Refrigerado, request.Controlado).Scan(&id)
+	if err != nil {
+		return nil, errorUbicacion(err)
+	}
+	return &id, nil
+}
+
+func (r UbicacionRepository) ModificarUbicacion(ctx context.Context, id *int, request *domain.UbicacionRequest) error {
+	if err := r.validarPadreUbicacion(ctx, request); err != nil {
+		return err
+	}
+
+	// Cambiar el tipo dejaría a las ubicaciones hijas fuera de la jerarquía
+	var tipoActual string
+	var tieneHijas bool
+	err := r.pool.QueryRow(ctx, `
+        SELECT u.tipo, EXISTS(SELECT 1 FROM ubicacion h WHERE h.padre_id = u.id AND h.deleted_at IS NULL)
+        FROM ubicacion u
+        WHERE u.id = $1 AND u.deleted_at IS NULL
+    `, *id).Scan(&tipoActual, &tieneHijas)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Ubicación no encontrada")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if tipoActual != request.Tipo && tieneHijas {
+		return datatype.NewConflictError("No se puede cambiar el tipo de una ubicación que contiene otras ubicaciones")
+	}
+	if request.PadreId != nil && *request.PadreId == *id {
+		return datatype.NewBadRequestError("Una ubicación no puede contenerse a sí misma")
+	}
+
+	_, err = r.pool.Exec(ctx, `
+        UPDATE ubicacion
+        SET codigo = $1, nombre = $2, tipo = $3, padre_id = $4, refrigerado = $5, controlado = $6
+        WHERE id = $7
+    `, request.Codigo, request.Nombre, request.Tipo, request.PadreId, request.Refrigerado, request.Controlado, *id)
+	if err != nil {
+		return errorUbicacion(err)
+	}
+	return nil
+}
+
+func (r UbicacionRepository) EliminarUbicacion(ctx context.Context, id *int) error {
+	var tieneHijas, tieneStock bool
+	err := r.pool.QueryRow(ctx, `
+        SELECT EXISTS(SELECT 1 FROM ubicacion h WHERE h.padre_id = u.id AND h.deleted_at IS NULL),
+               EXISTS(SELECT 1 FROM lote_ubicacion lu WHERE lu.ubicacion_id = u.id AND lu.cantidad > 0)
+        FROM ubicacion u
+        WHERE u.id = $1 AND u.deleted_at IS NULL
+    `, *id).Scan(&tieneHijas, &tieneStock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Ubicación no encontrada")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if tieneHijas {
+		return datatype.NewConflictError("La ubicación contiene otras ubicaciones y no puede eliminarse")
+	}
+	if tieneStock {
+		return datatype.NewConflictError("La ubicación tiene stock asignado y no puede eliminarse")
+	}
+
+	if _, err := r.pool.Exec(ctx, `UPDATE ubicacion SET deleted_at = NOW() WHERE id = $1`, *id); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func (r UbicacionRepository) ObtenerStockUbicacion(ctx context.Context, id *int) (*[]domain.StockUbicacion, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT `+jsonUbicacionSimple("u")+`,
+               jsonb_build_object('id', lp.id, 'lote', lp.lote, 'fechaVencimiento', lp.fecha_vencimiento::timestamptz),
+               `+jsonProductoUbicacion+`,
+               lu.cantidad
+        FROM lote_ubicacion lu
+        INNER JOIN ubicacion u ON u.id = lu.ubicacion_id
+        INNER JOIN lote_producto lp ON lp.id = lu.lote_id
+        INNER JOIN producto p ON p.id = lp.producto_id
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        LEFT JOIN presentacion pr ON pr.id = p.presentacion_id
+        WHERE lu.ubicacion_id IN (SELECT ubicaciones_descendientes($1)) AND lu.cantidad > 0
+        ORDER BY u.codigo, p.nombre_comercial, lp.fecha_vencimiento
+    `, *id)
+	if err != nil {
+		log.Println("Error al obtener stock de la ubicación:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.StockUbicacion, 0)
+	for rows.Next() {
+		var item domain.StockUbicacion
+		if err := rows.Scan(&item.Ubicacion, &item.Lote, &item.Producto, &item.Cantidad); err != nil {
+			log.Println("Error al escanear stock de la ubicación:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+const queryTrasladoInfo = `
+SELECT t.id,
+       t.codigo,
+       t.comentario,
+       jsonb_build_object('id', u.id, 'username', u.username) AS usuario,
+       t.created_at
+FROM traslado t
+INNER JOIN usuario u ON u.id = t.usuario_id
+`
+
+func (r UbicacionRepository) ObtenerListaTraslados(ctx context.Context, filtros map[string]string) (*[]domain.TrasladoInfo, error) {
+	query := queryTrasladoInfo
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	// Traslados que involucran la ubicación como origen o destino
+	if ubicacionStr := filtros["ubicacionId"]; ubicacionStr != "" {
+		ubicacionId, err := strconv.Atoi(ubicacionStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de ubicacionId no es válido")
+		}
+		filters = append(filters, fmt.Sprintf("EXISTS(SELECT 1 FROM detalle_traslado dt WHERE dt.traslado_id = t.id AND (dt.origen_id = $%d OR dt.destino_id = $%d))", i, i))
+		args = append(args, ubicacionId)
+		i++
+	}
+
+	if loteStr := filtros["loteId"]; loteStr != "" {
+		loteId, err := strconv.Atoi(loteStr)
+		if err != nil {
+			return nil, datatype.NewBadRequestError("El valor de loteId no es válido")
+		}
+		filters = append(filters, fmt.Sprintf("EXISTS(SELECT 1 FROM detalle_traslado dt WHERE dt.traslado_id = t.id AND dt.lote_id = $%d)", i))
+		args = append(args, loteId)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY t.created_at DESC"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar traslados:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	list := make([]domain.TrasladoInfo, 0)
+	for rows.Next() {
+		var item domain.TrasladoInfo
+		if err := rows.Scan(&item.Id, &item.Codigo, &item.Comentario, &item.Usuario, &item.CreatedAt); err != nil {
+			log.Println("Error al escanear traslado:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (r UbicacionRepository) ObtenerTrasladoById(ctx context.Context, id *int) (*domain.TrasladoDetail, error) {
+	var item domain.TrasladoDetail
+	err := r.pool.QueryRow(ctx, queryTrasladoInfo+` WHERE t.id = $1`, *id).
+		Scan(&item.Id, &item.Codigo, &item.Comentario, &item.Usuario, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Traslado no encontrado")
+		}
+		log.Println("Error al obtener traslado:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	rows, err := r.pool.Query(ctx, `
+        SELECT dt.id,
+               jsonb_build_object('id', lp.id, 'lote', lp.lote, 'fechaVencimiento', lp.fecha_vencimiento::timestamptz),
+               `+jsonProductoUbicacion+`,
+               `+jsonUbicacionSimple("o")+`,
+               `+jsonUbicacionSimple("d")+`,
+               dt.cantidad
+        FROM detalle_traslado dt
+        INNER JOIN lote_producto lp ON lp.id = dt.lote_id
+        INNER JOIN producto p ON p.id = lp.producto_id
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        LEFT JOIN presentacion pr ON pr.id = p.presentacion_id
+        LEFT JOIN ubicacion o ON o.id = dt.origen_id
+        INNER JOIN ubicacion d ON d.id = dt.destino_id
+        WHERE dt.traslado_id = $1
+        ORDER BY o.codigo NULLS LAST, p.nombre_comercial, dt.id
+    `, *id)
+	if err != nil {
+		log.Println("Error al obtener detalles del traslado:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Detalles = make([]domain.DetalleTrasladoDetail, 0)
+	for rows.Next() {
+		var detalle domain.DetalleTrasladoDetail
+		if err := rows.Scan(&detalle.Id, &detalle.Lote, &detalle.Producto, &detalle.Origen, &detalle.Destino, &detalle.Cantidad); err != nil {
+			log.Println("Error al escanear detalle del traslado:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.Detalles = append(item.Detalles, detalle)
+	}
+	return &item, nil
+}
+
+func (r UbicacionRepository) RegistrarTraslado(ctx context.Context, request *domain.TrasladoRequest) (*int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	codigo, err := generarCodigoTraslado(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO traslado (codigo, comentario, usuario_id)
+        VALUES ($1, $2, $3)
+        RETURNING id
+    `, codigo, request.Comentario, request.UsuarioId).Scan(&id)
+	if err != nil {
+		log.Println("Error al registrar traslado:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	for _, detalle := range request.Detalles {
+		if err := validarCondicionesUbicacion(ctx, tx, detalle.LoteId, detalle.DestinoId); err != nil {
+			return nil, err
+		}
+
+		if detalle.OrigenId != nil {
+			if err := quitarStockUbicacion(ctx, tx, detalle.LoteId, *detalle.OrigenId, int64(detalle.Cantidad)); err != nil {
+				return nil, err
+			}
+		} else {
+			// Solo se puede ubicar el stock del lote que aún no está en ninguna ubicación
+			sinUbicacion, err := stockSinUbicacion(ctx, tx, detalle.LoteId)
+			if err != nil {
+				return nil, err
+			}
+			if sinUbicacion < int64(detalle.Cantidad) {
+				return nil, datatype.NewConflictError(fmt.Sprintf("El lote %d solo tiene %d unidades sin ubicación", detalle.LoteId, sinUbicacion))
+			}
+		}
+
+		if err := agregarStockUbicacion(ctx, tx, detalle.LoteId, detalle.DestinoId, int64(detalle.Cantidad)); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO detalle_traslado (traslado_id, lote_id, origen_id, destino_id, cantidad)
+            VALUES ($1, $2, $3, $4, $5)
+        `, id, detalle.LoteId, detalle.OrigenId, detalle.DestinoId, detalle.Cantidad)
+		if err != nil {
+			log.Println("Error al registrar detalle de traslado:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Error al confirmar traslado:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &id, nil
+}
+
+func (r UbicacionRepository) ObtenerPickingVenta(ctx context.Context, ventaId *int) (*domain.PickingVenta, error) {
+	var item domain.PickingVenta
+	var estado string
+	err := r.pool.QueryRow(ctx, `SELECT id, codigo, fecha, estado::TEXT FROM venta WHERE id = $1`, *ventaId).
+		Scan(&item.VentaId, &item.Codigo, &item.Fecha, &estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Venta no encontrada")
+		}
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if estado != "Realizada" {
+		return nil, datatype.NewConflictError("La lista de recolección solo está disponible para ventas realizadas")
+	}
+
+	// Lo retirado de cada ubicación y, sin ubicación, lo que se descontó de stock no ubicado
+	rows, err := r.pool.Query(ctx, `
+        WITH recoleccion AS (SELECT dv.lote_id, dvu.ubicacion_id, dvu.cantidad::BIGINT AS cantidad
+                             FROM detalle_venta dv
+                                      INNER JOIN detalle_venta_ubicacion dvu ON dvu.detalle_venta_id = dv.id
+                             WHERE dv.venta_id = $1
+                             UNION ALL
+                             SELECT dv.lote_id, NULL, dv.cantidad * dv.factor - COALESCE((SELECT SUM(dvu.cantidad)
+                                                                                          FROM detalle_venta_ubicacion dvu
+                                                                                          WHERE dvu.detalle_venta_id = dv.id), 0)
+                             FROM detalle_venta dv
+                             WHERE dv.venta_id = $1)
+        SELECT `+jsonUbicacionSimple("u")+`,
+               `+jsonProductoUbicacion+`,
+               jsonb_build_object('id', lp.id, 'lote', lp.lote, 'fechaVencimiento', lp.fecha_vencimiento::timestamptz),
+               SUM(rc.cantidad)::BIGINT
+        FROM recoleccion rc
+        INNER JOIN lote_producto lp ON lp.id = rc.lote_id
+        INNER JOIN producto p ON p.id = lp.producto_id
+        INNER JOIN laboratorio l ON l.id = p.laboratorio_id
+        LEFT JOIN presentacion pr ON pr.id = p.presentacion_id
+        LEFT JOIN ubicacion u ON u.id = rc.ubicacion_id
+        WHERE rc.cantidad > 0
+        GROUP BY u.id, p.id, l.id, pr.id, lp.id
+        ORDER BY u.codigo NULLS LAST, p.nombre_comercial, lp.fecha_vencimiento
+    `, *ventaId)
+	if err != nil {
+		log.Println("Error al obtener lista de recolección de la venta:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Items = make([]domain.PickingItem, 0)
+	for rows.Next() {
+		var picking domain.PickingItem
+		if err := rows.Scan(&picking.Ubicacion, &picking.Producto, &picking.Lote, &picking.Cantidad); err != nil {
+			log.Println("Error al escanear lista de recolección:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.Items = append(item.Items, picking)
+	}
+	return &item, nil
+}
+
+// validarPadreUbicacion verifica que el padre exista y corresponda al nivel superior de la jerarquía
+func (r UbicacionRepository) validarPadreUbicacion(ctx context.Context, request *domain.UbicacionRequest) error {
+	tipoPadre, requierePadre := tipoPadreUbicacion[request.Tipo]
+	if !requierePadre {
+		if request.PadreId != nil {
+			return datatype.NewBadRequestError("Un almacén no puede estar dentro de otra ubicación")
+		}
+		return nil
+	}
+	if request.PadreId == nil {
+		return datatype.NewBadRequestError(fmt.Sprintf("Un %s debe pertenecer a un %s", strings.ToLower(request.Tipo), strings.ToLower(tipoPadre)))
+	}
+
+	var tipo string
+	err := r.pool.QueryRow(ctx, `SELECT tipo FROM ubicacion WHERE id = $1 AND deleted_at IS NULL`, *request.PadreId).Scan(&tipo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("La ubicación padre no existe")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if tipo != tipoPadre {
+		return datatype.NewBadRequestError(fmt.Sprintf("Un %s debe pertenecer a un %s", strings.ToLower(request.Tipo), strings.ToLower(tipoPadre)))
+	}
+	return nil
+}
+
+// validarCondicionesUbicacion comprueba que la ubicación, o alguna que la contenga, cumpla la cadena de frío
+// y el resguardo de controlados que requiere el producto del lote
+func validarCondicionesUbicacion(ctx context.Context, tx pgx.Tx, loteId int, ubicacionId int) error {
+	var producto, codigo string
+	var requiereFrio, requiereControl, refrigerada, controlada bool
+	err := tx.QueryRow(ctx, `
+        WITH RECURSIVE ascendentes AS (SELECT u.id, u.padre_id, u.refrigerado, u.controlado
+                                       FROM ubicacion u
+                                       WHERE u.id = $2 AND u.deleted_at IS NULL
+                                       UNION ALL
+                                       SELECT u.id, u.padre_id, u.refrigerado, u.controlado
+                                       FROM ubicacion u
+                                                INNER JOIN ascendentes a ON a.padre_id = u.id)
+        SELECT p.nombre_comercial,
+               p.refrigerado,
+               p.controlado OR nivel_control_producto(p.id) = 'Controlado',
+               (SELECT codigo FROM ubicacion WHERE id = $2),
+               (SELECT bool_or(a.refrigerado) FROM ascendentes a),
+               (SELECT bool_or(a.controlado) FROM ascendentes a)
+        FROM lote_producto lp
+        INNER JOIN producto p ON p.id = lp.producto_id
+        WHERE lp.id = $1
+          AND EXISTS(SELECT 1 FROM ascendentes)
+    `, loteId, ubicacionId).Scan(&producto, &requiereFrio, &requiereControl, &codigo, &refrigerada, &controlada)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError(fmt.Sprintf("El lote %d o la ubicación %d no existen", loteId, ubicacionId))
+		}
+		log.Println("Error al validar condiciones de la ubicación:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if requiereFrio && !refrigerada {
+		return datatype.NewConflictError(fmt.Sprintf("%s requiere cadena de frío y la ubicación %s no es refrigerada", producto, codigo))
+	}
+	if requiereControl && !controlada {
+		return datatype.NewConflictError(fmt.Sprintf("%s es de resguardo controlado y la ubicación %s no es controlada", producto, codigo))
+	}
+	return nil
+}
+
+// stockSinUbicacion bloquea el lote y devuelve las unidades que aún no están asignadas a ninguna ubicación
+func stockSinUbicacion(ctx context.Context, tx pgx.Tx, loteId int) (int64, error) {
+	var stock int64
+	err := tx.QueryRow(ctx, `SELECT stock FROM lote_producto WHERE id = $1 FOR UPDATE`, loteId).Scan(&stock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, datatype.NewNotFoundError(fmt.Sprintf("El lote %d no existe", loteId))
+		}
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	var ubicado int64
+	err = tx.QueryRow(ctx, `SELECT COALESCE(SUM(cantidad), 0)::BIGINT FROM lote_ubicacion WHERE lote_id = $1`, loteId).Scan(&ubicado)
+	if err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	return stock - ubicado, nil
+}
+
+// agregarStockUbicacion suma unidades base de un lote a una ubicación
+func agregarStockUbicacion(ctx context.Context, tx pgx.Tx, loteId int, ubicacionId int, cantidad int64) error {
+	_, err := tx.Exec(ctx, `
+        INSERT INTO lote_ubicacion (lote_id, ubicacion_id, cantidad)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (lote_id, ubicacion_id) DO UPDATE SET cantidad = lote_ubicacion.cantidad + EXCLUDED.cantidad
+    `, loteId, ubicacionId, cantidad)
+	if err != nil {
+		log.Println("Error al asignar stock a la ubicación:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// quitarStockUbicacion descuenta unidades base de un lote en una ubicación
+func quitarStockUbicacion(ctx context.Context, tx pgx.Tx, loteId int, ubicacionId int, cantidad int64) error {
+	ct, err := tx.Exec(ctx, `
+        UPDATE lote_ubicacion SET cantidad = cantidad - $3
+        WHERE lote_id = $1 AND ubicacion_id = $2 AND cantidad >= $3
+    `, loteId, ubicacionId, cantidad)
+	if err != nil {
+		log.Println("Error al descontar stock de la ubicación:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewConflictError(fmt.Sprintf("La ubicación %d no tiene %d unidades del lote %d", ubicacionId, cantidad, loteId))
+	}
+	_, err = tx.Exec(ctx, `DELETE FROM lote_ubicacion WHERE lote_id = $1 AND ubicacion_id = $2 AND cantidad = 0`, loteId, ubicacionId)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// retirarUbicacionesVenta descuenta de las ubicaciones del lote las unidades vendidas y registra de dónde se retiraron;
+// si las ubicaciones no alcanzan, el resto sale del stock aún no ubicado
+func retirarUbicacionesVenta(ctx context.Context, tx pgx.Tx, detalleVentaId int64, loteId int, unidades int64) error {
+	rows, err := tx.Query(ctx, `
+        SELECT lu.ubicacion_id, lu.cantidad
+        FROM lote_ubicacion lu
+        INNER JOIN ubicacion u ON u.id = lu.ubicacion_id
+        WHERE lu.lote_id = $1 AND lu.cantidad > 0
+        ORDER BY u.codigo
+        FOR UPDATE OF lu
+    `, loteId)
+	if err != nil {
+		log.Println("Error al obtener ubicaciones del lote:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	type existencia struct {
+		ubicacionId int
+		cantidad    int64
+	}
+	var existencias []existencia
+	for rows.Next() {
+		var e existencia
+		if err := rows.Scan(&e.ubicacionId, &e.cantidad); err != nil {
+			rows.Close()
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		existencias = append(existencias, e)
+	}
+	rows.Close()
+
+	for _, e := range existencias {
+		if unidades == 0 {
+			break
+		}
+		retirar := min(unidades, e.cantidad)
+		if err := quitarStockUbicacion(ctx, tx, loteId, e.ubicacionId, retirar); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+            INSERT INTO detalle_venta_ubicacion (detalle_venta_id, ubicacion_id, cantidad)
+            VALUES ($1, $2, $3)
+        `, detalleVentaId, e.ubicacionId, retirar)
+		if err != nil {
+			log.Println("Error al registrar ubicación de venta:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		unidades -= retirar
+	}
+	return nil
+}
+
+// restaurarUbicacionesVenta devuelve a sus ubicaciones las unidades de una venta anulada
+func restaurarUbicacionesVenta(ctx context.Context, tx pgx.Tx, ventaId int64) error {
+	_, err := tx.Exec(ctx, `
+        INSERT INTO lote_ubicacion (lote_id, ubicacion_id, cantidad)
+        SELECT dv.lote_id, dvu.ubicacion_id, SUM(dvu.cantidad)
+        FROM detalle_venta_ubicacion dvu
+        INNER JOIN detalle_venta dv ON dv.id = dvu.detalle_venta_id
+        WHERE dv.venta_id = $1
+        GROUP BY dv.lote_id, dvu.ubicacion_id
+        ON CONFLICT (lote_id, ubicacion_id) DO UPDATE SET cantidad = lote_ubicacion.cantidad + EXCLUDED.cantidad
+    `, ventaId)
+	if err != nil {
+		log.Println("Error al restaurar ubicaciones de la venta:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+// generarCodigoTraslado genera el siguiente código correlativo de traslado
+func generarCodigoTraslado(ctx context.Context, tx pgx.Tx) (string, error) {
+	var nextNum int64
+	err := tx.QueryRow(ctx, `
+        SELECT COALESCE(
+            (SELECT MAX(CAST(SUBSTRING(codigo FROM 5) AS INTEGER)) + 1 FROM traslado WHERE codigo ~ '^TRA-[0-9]+$'),
+            1
+        )
+    `).Scan(&nextNum)
+	if err != nil {
+		return "", datatype.NewInternalServerErrorGeneric()
+	}
+	return fmt.Sprintf("TRA-%09d", nextNum), nil
+}
+
+// errorUbicacion traduce los errores de restricciones de ubicacion
+func errorUbicacion(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return datatype.NewConflictError("Ya existe una ubicación con ese código")
+		case "23514":
+			return datatype.NewBadRequestError("Tipo de ubicación no válido")
+		}
+	}
+	log.Println("Error al guardar ubicación:", err)
+	return datatype.NewInternalServerErrorGeneric()
+}
+
+func NewUbicacionRepository(pool *pgxpool.Pool) *UbicacionRepository {
+	return &UbicacionRepository{pool: pool}
+}
+
+var _ port.UbicacionRepository = (*UbicacionRepository)(nil)
@@ -12,6 +12,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -29,7 +30,8 @@ func (u UsuarioRepository) RestablecerPassword(ctx context.Context, usuarioId *i
 	if err != nil {
 		return nil, datatype.NewStatusServiceUnavailableErrorGeneric()
 	}
-	query := `UPDATE usuario SET password = $1,updated_at=CURRENT_TIMESTAMP WHERE id = $2 `
+	// La contraseña asignada por el administrador es temporal
+	query := `UPDATE usuario SET password = $1,updated_at=CURRENT_TIMESTAMP, debe_cambiar_password = TRUE WHERE id = $2 `
 	tx, err := u.pool.Begin(ctx)
 	if err != nil {
 		return nil, datatype.NewStatusServiceUnavailableErrorGeneric()
@@ -41,6 +43,10 @@ func (u UsuarioRepository) RestablecerPassword(ctx context.Context, usuarioId *i
 			_ = tx.Rollback(ctx)
 		}
 	}()
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId)
+	if err != nil {
+		return nil, err
+	}
 	ct, err := tx.Exec(ctx, query, hashPassword, *usuarioId)
 	if err != nil {
 		return nil, datatype.NewInternalServerErrorGeneric()
@@ -49,6 +55,15 @@ func (u UsuarioRepository) RestablecerPassword(ctx context.Context, usuarioId *i
 		log.Println("Usuario no encontrado con id:", *usuarioId)
 		return nil, datatype.NewNotFoundError("Usuario no encontrado")
 	}
+	if err := registrarHistorialPassword(ctx, tx, *usuarioId, hashPassword); err != nil {
+		return nil, err
+	}
+	if err := revocarSesiones(ctx, tx, `usuario_id = $1`, *usuarioId, domain.SesionMotivoCambioPassword); err != nil {
+		return nil, err
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId, domain.AccionRestablecerPassword, antes); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(ctx); err != nil {
 		return nil, datatype.NewInternalServerErrorGeneric()
 	}
@@ -77,6 +92,10 @@ func (u UsuarioRepository) HabilitarUsuarioById(ctx context.Context, usuarioId *
 		}
 	}()
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId)
+	if err != nil {
+		return err
+	}
 	ct, err := tx.Exec(ctx, query, *usuarioId)
 	if err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -86,6 +105,9 @@ func (u UsuarioRepository) HabilitarUsuarioById(ctx context.Context, usuarioId *
 		log.Println("Usuario no encontrado con id:", *usuarioId)
 		return datatype.NewNotFoundError("Usuario no encontrado")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId, domain.AccionHabilitar, antes); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -108,6 +130,10 @@ func (u UsuarioRepository) DeshabilitarUsuarioById(ctx context.Context, usuarioI
 		}
 	}()
 
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId)
+	if err != nil {
+		return err
+	}
 	ct, err := tx.Exec(ctx, query, *usuarioId)
 	if err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -117,6 +143,12 @@ func (u UsuarioRepository) DeshabilitarUsuarioById(ctx context.Context, usuarioI
 		log.Println("Usuario no encontrado con id:", *usuarioId)
 		return datatype.NewNotFoundError("Usuario no encontrado")
 	}
+	if err := revocarSesiones(ctx, tx, `usuario_id = $1`, *usuarioId, domain.SesionMotivoDeshabilitado); err != nil {
+		return err
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId, domain.AccionDeshabilitar, antes); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return datatype.NewInternalServerErrorGeneric()
@@ -142,6 +174,61 @@ func (u UsuarioRepository) ObtenerUsuarioDetalleByUsername(ctx context.Context,
 	return &usuarioDetalle, nil
 }
 
+// ObtenerPermisosUsuario devuelve los códigos de permiso efectivos de un usuario activo según sus roles activos
+func (u UsuarioRepository) ObtenerPermisosUsuario(ctx context.Context, usuarioId *int) ([]string, error) {
+	rows, err := u.pool.Query(ctx, `
+        SELECT p.codigo
+        FROM permiso p
+        WHERE EXISTS (SELECT 1
+                      FROM usuario us
+                      INNER JOIN usuario_rol ur ON ur.usuario_id = us.id
+                      INNER JOIN rol r ON r.id = ur.rol_id AND r.estado = 'Activo'
+                      WHERE us.id = $1
+                        AND us.estado = 'Activo'
+                        AND EXISTS (SELECT 1 FROM rol_permiso rp WHERE rp.rol_id = r.id AND rp.permiso_id = p.id))
+        ORDER BY p.codigo
+    `, *usuarioId)
+	if err != nil {
+		log.Println("Error al obtener permisos del usuario:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	permisos := make([]string, 0)
+	for rows.Next() {
+		var codigo string
+		if err := rows.Scan(&codigo); err != nil {
+			log.Println("Error al escanear permiso:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		permisos = append(permisos, codigo)
+	}
+	return permisos, nil
+}
+
+// EscucharCambiosPermisos recibe las notificaciones de cambios de permisos hasta que falle la conexión o termine el contexto
+func (u UsuarioRepository) EscucharCambiosPermisos(ctx context.Context, notificar func(payload string)) error {
+	conn, err := u.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("error al obtener conexión para escuchar permisos: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN cambio_permisos"); err != nil {
+		return fmt.Errorf("error al escuchar cambios de permisos: %w", err)
+	}
+	// La conexión vuelve al pool, no debe seguir recibiendo notificaciones
+	defer func() { _, _ = conn.Exec(context.Background(), "UNLISTEN cambio_permisos") }()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		notificar(notification.Payload)
+	}
+}
+
 func (u UsuarioRepository) ListarUsuarios(ctx context.Context, filtros map[string]string) (*[]domain.UsuarioInfo, error) {
 	var filters []string
 	var args []interface{}
@@ -213,6 +300,10 @@ func (u UsuarioRepository) ModificarUsuario(ctx context.Context, usuarioId *int,
 	if err != nil {
 		return err
 	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId)
+	if err != nil {
+		return err
+	}
 
 	query := `UPDATE usuario SET username = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
 	ct, err := tx.Exec(ctx, query, usuarioRequest.Username, *usuarioId)
@@ -262,6 +353,9 @@ func (u UsuarioRepository) ModificarUsuario(ctx context.Context, usuarioId *int,
 		}
 		return datatype.NewInternalServerError("Error al registrar roles")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, *usuarioId, domain.AccionModificar, antes); err != nil {
+		return err
+	}
 
 	// Confirmar la transacción
 	if err = tx.Commit(ctx); err != nil {
@@ -325,7 +419,7 @@ func (u UsuarioRepository) RegistrarUsuario(ctx context.Context, usuarioRequest
 	}
 
 	// Insertar el usuario en la tabla `usuario`, relacionado con la persona
-	query = `INSERT INTO usuario(username, password,persona_id) VALUES ($1, $2, $3) RETURNING id, username`
+	query = `INSERT INTO usuario(username, password,persona_id, debe_cambiar_password) VALUES ($1, $2, $3, TRUE) RETURNING id, username`
 
 	var usuarioId uint
 	var usuarioEmail string
@@ -352,6 +446,9 @@ func (u UsuarioRepository) RegistrarUsuario(ctx context.Context, usuarioRequest
 		// Otro tipo de error imprevisto
 		return nil, datatype.NewInternalServerError("Error al registrar roles")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, usuarioId, domain.AccionCrear, nil); err != nil {
+		return nil, err
+	}
 
 	query = `SELECT oud.id, oud.username, oud.persona, oud.roles,oud.created_at,oud.updated_at,oud.deleted_at FROM obtener_usuario_detalle_by_id($1) oud;`
 	var usuarioDetalle domain.UsuarioDetail
@@ -372,10 +469,10 @@ func (u UsuarioRepository) RegistrarUsuario(ctx context.Context, usuarioRequest
 }
 
 func (u UsuarioRepository) ObtenerUsuario(ctx context.Context, username *string) (*domain.Usuario, error) {
-	query := `SELECT u.id, u.username, u.password, u.deleted_at FROM usuario u WHERE u.username = $1 LIMIT 1`
+	query := `SELECT u.id, u.username, u.password, u.deleted_at, u.intentos_fallidos, u.bloqueado_hasta, u.debe_cambiar_password FROM usuario u WHERE u.username = $1 AND NOT u.es_servicio LIMIT 1`
 
 	var usuario domain.Usuario
-	err := u.pool.QueryRow(ctx, query, *username).Scan(&usuario.Id, &usuario.Username, &usuario.Password, &usuario.DeletedAt)
+	err := u.pool.QueryRow(ctx, query, *username).Scan(&usuario.Id, &usuario.Username, &usuario.Password, &usuario.DeletedAt, &usuario.IntentosFallidos, &usuario.BloqueadoHasta, &usuario.DebeCambiarPassword)
 	if err != nil {
 		// Si no hay registros
 		if errors.Is(err, sql.ErrNoRows) {
@@ -394,6 +491,85 @@ func (u UsuarioRepository) ObtenerUsuario(ctx context.Context, username *string)
 	return &usuario, nil
 }
 
+// ObtenerHistorialPassword devuelve los hashes de las últimas contraseñas del usuario, de la más reciente a la más antigua
+func (u UsuarioRepository) ObtenerHistorialPassword(ctx context.Context, usuarioId int, cantidad int) ([]string, error) {
+	rows, err := u.pool.Query(ctx, `
+        SELECT hp.password FROM historial_password hp
+        WHERE hp.usuario_id = $1
+        ORDER BY hp.created_at DESC, hp.id DESC
+        LIMIT $2
+    `, usuarioId, cantidad)
+	if err != nil {
+		log.Println("Error al obtener historial de contraseñas:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	historial := make([]string, 0, cantidad)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			log.Println("Error al escanear historial de contraseñas:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		historial = append(historial, hash)
+	}
+	return historial, nil
+}
+
+// CambiarPassword guarda la contraseña elegida por el propio usuario y cierra sus otras sesiones
+func (u UsuarioRepository) CambiarPassword(ctx context.Context, usuarioId int, hashPassword string, sesionActual *uuid.UUID) error {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaUsuario, usuarioId)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(ctx, `
+        UPDATE usuario SET password = $1, updated_at = CURRENT_TIMESTAMP, debe_cambiar_password = FALSE
+        WHERE id = $2
+    `, hashPassword, usuarioId)
+	if err != nil {
+		log.Println("Error al cambiar contraseña:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if ct.RowsAffected() == 0 {
+		return datatype.NewNotFoundError("Usuario no encontrado")
+	}
+	if err := registrarHistorialPassword(ctx, tx, usuarioId, hashPassword); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE sesion SET revocada_at = NOW(), motivo_revocacion = $3
+        WHERE usuario_id = $1 AND ($2::UUID IS NULL OR id <> $2) AND revocada_at IS NULL
+    `, usuarioId, sesionActual, domain.SesionMotivoCambioPassword)
+	if err != nil {
+		log.Println("Error al revocar sesiones:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaUsuario, usuarioId, domain.AccionCambiarPassword, antes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
+func registrarHistorialPassword(ctx context.Context, tx pgx.Tx, usuarioId int, hashPassword string) error {
+	_, err := tx.Exec(ctx, `INSERT INTO historial_password (usuario_id, password) VALUES ($1, $2)`, usuarioId, hashPassword)
+	if err != nil {
+		log.Println("Error al registrar historial de contraseñas:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
 func NewUsuarioRepository(pool *pgxpool.Pool) *UsuarioRepository {
 	return &UsuarioRepository{pool: pool}
 }
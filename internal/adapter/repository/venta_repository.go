@@ -8,11 +8,14 @@ import (
 	"farma-santi_backend/internal/core/port"
 	"fmt"
 	"log"
+	"math"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -92,7 +95,8 @@ SELECT
 		WHERE d.venta_id = v.id
 	) AS detalles_info,
     v.tipo_pago,
-    v.descuento
+    v.descuento,
+    v.descuento_promocion
 FROM view_venta_info v
 LEFT JOIN factura f ON v.id = f.venta_id
 LEFT JOIN public.cliente c on c.id = v.cliente_id
@@ -109,6 +113,13 @@ LEFT JOIN public.cliente c on c.id = v.cliente_id
 		i++
 	}
 
+	// Filtrar por cuenta de la tienda en línea, solo si el vínculo con el cliente fue verificado
+	if usuarioUid := filtros["usuarioUid"]; usuarioUid != "" {
+		filters = append(filters, fmt.Sprintf("v.cliente_id IN (SELECT cc.cliente_id FROM cliente_cuenta cc WHERE cc.usuario_uid = $%d AND cc.verificado)", i))
+		args = append(args, usuarioUid)
+		i++
+	}
+
 	// Filtrar por estado
 	if estadoStr := filtros["estado"]; estadoStr != "" {
 		filters = append(filters, fmt.Sprintf("v.estado = $%d", i))
@@ -196,6 +207,7 @@ LEFT JOIN public.cliente c on c.id = v.cliente_id
 			&item.DetallesInfo,
 			&item.TipoPago,
 			&item.Descuento,
+			&item.DescuentoPromocion,
 		)
 		if err != nil {
 			return nil, datatype.NewInternalServerErrorGeneric()
@@ -221,152 +233,235 @@ func (v VentaRepository) RegistraVenta(ctx context.Context, request *domain.Vent
 		_ = tx.Rollback(ctx)
 	}()
 
-	// Generar código de venta de forma más eficiente
-	var nextNum int64
-	err = tx.QueryRow(ctx, `
-        SELECT COALESCE(
-            (SELECT MAX(CAST(SUBSTRING(codigo FROM 6) AS INTEGER)) + 1 FROM venta WHERE codigo ~ '^VENT-[0-9]+$'),
-            1
-        )
-    `).Scan(&nextNum)
+	ventaId, err := registrarVenta(ctx, tx, request)
 	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return nil, datatype.NewInternalServerErrorGeneric()
 	}
-	codigo := fmt.Sprintf("VENT-%09d", nextNum)
 
-	// Crear la venta
+	return &ventaId, nil
+}
+
+func (v VentaRepository) RegistrarVentaEnEspera(ctx context.Context, request *domain.VentaRequest) (*int64, error) {
+	if len(request.Detalles) == 0 {
+		return nil, datatype.NewBadRequestError("La venta debe tener al menos un detalle")
+	}
+
+	tx, err := v.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Crear la venta pendiente, el código se asigna al finalizarla
 	var ventaId int64
 	err = tx.QueryRow(ctx, `
-        INSERT INTO venta (cliente_id, usuario_id, total, codigo,tipo_pago,descuento)
-        VALUES ($1, $2, 0, $3, $4, $5)
+        INSERT INTO venta (cliente_id, usuario_id, total, tipo_pago, descuento, estado, receta_id)
+        VALUES ($1, $2, 0, $3, $4, 'Pendiente', $5)
         RETURNING id
-    `, request.ClienteId, request.UsuarioId, codigo, request.TipoPago, request.Descuento).Scan(&ventaId)
+    `, request.ClienteId, request.UsuarioId, request.TipoPago, request.Descuento, request.RecetaId).Scan(&ventaId)
 	if err != nil {
 		return nil, datatype.NewInternalServerErrorGeneric()
 	}
 
-	totalVenta := 0.0
+	totalVenta, err := reservarDetallesVenta(ctx, tx, ventaId, request.Detalles, request.ReservaHasta)
+	if err != nil {
+		return nil, err
+	}
 
-	// Procesar cada detalle de venta
-	for _, item := range request.Detalles {
-		if item.Cantidad <= 0 {
-			return nil, datatype.NewBadRequestError("La cantidad debe ser mayor a cero")
-		}
+	if err := registrarAutorizacionesVenta(ctx, tx, ventaId, request); err != nil {
+		return nil, err
+	}
 
-		// Obtener lotes disponibles ordenados por FEFO (First Expired, First Out) con bloqueo
-		rows, err := tx.Query(
-			ctx,
-			`
-            SELECT lp.id, lp.stock, p.precio_venta
-            FROM lote_producto lp
-            JOIN producto p ON p.id = lp.producto_id
-            WHERE lp.producto_id = $1 
-              AND lp.stock > 0 
-              AND lp.estado = 'Activo'
-            ORDER BY lp.fecha_vencimiento ASC, lp.id ASC
-            FOR UPDATE OF lp
-        `,
-			item.ProductoId,
-		)
-		if err != nil {
-			return nil, datatype.NewInternalServerErrorGeneric()
-		}
+	_, err = tx.Exec(ctx, `UPDATE venta SET total = $1 WHERE id = $2`, totalVenta, ventaId)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, ventaId, domain.AccionCrear, nil); err != nil {
+		return nil, err
+	}
 
-		var lotes []domain.VentaLoteProductoDAO
-		for rows.Next() {
-			var lote domain.VentaLoteProductoDAO
-			if err := rows.Scan(&lote.Id, &lote.Stock, &lote.PrecioVenta); err != nil {
-				rows.Close()
-				return nil, datatype.NewInternalServerErrorGeneric()
-			}
-			lotes = append(lotes, lote)
-		}
-		rows.Close()
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &ventaId, nil
+}
 
-		if len(lotes) == 0 {
-			return nil, datatype.NewNotFoundErrorWithData("Producto sin stock disponible",
-				domain.ProductoId{Id: item.ProductoId})
-		}
+func (v VentaRepository) ModificarVentaEnEspera(ctx context.Context, id *int, request *domain.VentaRequest) error {
+	if len(request.Detalles) == 0 {
+		return datatype.NewBadRequestError("La venta debe tener al menos un detalle")
+	}
 
-		// Verificar stock total disponible
-		stockTotal := uint(0)
-		for _, lote := range lotes {
-			stockTotal += lote.Stock
-		}
+	tx, err := v.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
-		if stockTotal < item.Cantidad {
-			return nil, datatype.NewNotFoundErrorWithData(
-				fmt.Sprintf("Stock insuficiente. Disponible: %d, Solicitado: %d", stockTotal, item.Cantidad),
-				domain.ProductoId{Id: item.ProductoId})
-		}
+	if err := bloquearVentaPendiente(ctx, tx, *id); err != nil {
+		return err
+	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaVenta, *id)
+	if err != nil {
+		return err
+	}
 
-		// Asignar stock desde los lotes usando FEFO
-		cantidadRestante := item.Cantidad
-		subtotalItem := 0.0
+	// Liberar la reserva anterior y volver a asignar lotes
+	if err := eliminarDetallesVentaPendiente(ctx, tx, *id); err != nil {
+		return err
+	}
+	totalVenta, err := reservarDetallesVenta(ctx, tx, int64(*id), request.Detalles, request.ReservaHasta)
+	if err != nil {
+		return err
+	}
 
-		for _, lote := range lotes {
-			if cantidadRestante <= 0 {
-				break
-			}
+	if err := registrarAutorizacionesVenta(ctx, tx, int64(*id), request); err != nil {
+		return err
+	}
 
-			cantidadUsar := cantidadRestante
-			if lote.Stock < cantidadUsar {
-				cantidadUsar = lote.Stock
-			}
+	_, err = tx.Exec(ctx, `
+        UPDATE venta SET cliente_id = $1, tipo_pago = $2, descuento = $3, total = $4, receta_id = $5
+        WHERE id = $6
+    `, request.ClienteId, request.TipoPago, request.Descuento, totalVenta, request.RecetaId, *id)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, *id, domain.AccionModificar, antes); err != nil {
+		return err
+	}
 
-			// Crear detalle de venta
-			_, err = tx.Exec(ctx, `
-                INSERT INTO detalle_venta (venta_id, lote_id, cantidad, precio)
-                VALUES ($1, $2, $3, $4)
-            `, ventaId, lote.Id, cantidadUsar, lote.PrecioVenta)
-			if err != nil {
-				return nil, datatype.NewInternalServerErrorGeneric()
-			}
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
 
-			// Actualizar stock del lote con verificación
-			result, err := tx.Exec(ctx, `
-                UPDATE lote_producto 
-                SET stock = stock - $1
-                WHERE id = $2 AND stock >= $1
-            `, cantidadUsar, lote.Id)
-			if err != nil {
-				return nil, datatype.NewInternalServerErrorGeneric()
-			}
-			if result.RowsAffected() == 0 {
-				return nil, datatype.NewConflictError("Stock insuficiente en el lote")
-			}
+func (v VentaRepository) FinalizarVentaEnEspera(ctx context.Context, id *int, promociones []domain.VentaPromocion) error {
+	tx, err := v.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
-			// Actualizar stock del producto principal con verificación
-			result, err = tx.Exec(ctx, `
-                UPDATE producto 
-                SET stock = stock - $1
-                WHERE id = $2 AND stock >= $1
-            `, cantidadUsar, item.ProductoId)
-			if err != nil {
-				return nil, datatype.NewInternalServerErrorGeneric()
-			}
-			if result.RowsAffected() == 0 {
-				return nil, datatype.NewConflictError("Stock insuficiente en el producto")
-			}
+	if err := bloquearVentaPendiente(ctx, tx, *id); err != nil {
+		return err
+	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaVenta, *id)
+	if err != nil {
+		return err
+	}
 
-			subtotalItem += float64(cantidadUsar) * lote.PrecioVenta
-			cantidadRestante -= cantidadUsar
-		}
+	// Lotes y precios reservados de la venta en espera
+	detalles, err := detallesVentaEnEspera(ctx, tx, *id)
+	if err != nil {
+		return err
+	}
 
-		totalVenta += subtotalItem
+	// Validar receta de los productos bajo control
+	var recetaId *int
+	if err := tx.QueryRow(ctx, `SELECT receta_id FROM venta WHERE id = $1`, *id).Scan(&recetaId); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := validarRecetaVenta(ctx, tx, recetaId, detalles); err != nil {
+		return err
 	}
 
-	// Actualizar total de la venta
-	_, err = tx.Exec(ctx, `UPDATE venta SET total = $1,fecha = NOW() WHERE id = $2 `, totalVenta, ventaId)
+	// Liberar la reserva y registrar la salida definitiva de stock
+	if err := eliminarDetallesVentaPendiente(ctx, tx, *id); err != nil {
+		return err
+	}
+	totalVenta, err := registrarDetallesVenta(ctx, tx, int64(*id), detalles)
 	if err != nil {
-		return nil, datatype.NewInternalServerErrorGeneric()
+		return err
+	}
+	descuentoPromocion, err := registrarPromocionesVenta(ctx, tx, int64(*id), promociones)
+	if err != nil {
+		return err
+	}
+
+	codigo, err := generarCodigoVenta(ctx, tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE venta SET estado = 'Realizada', codigo = $1, total = $2, descuento_promocion = $3, fecha = NOW()
+        WHERE id = $4
+    `, codigo, totalVenta, descuentoPromocion, *id)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, *id, domain.AccionCompletar, antes); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return nil, datatype.NewInternalServerErrorGeneric()
+		return datatype.NewInternalServerErrorGeneric()
 	}
+	return nil
+}
 
-	return &ventaId, nil
+// LiberarReservasVencidas anula las ventas en espera cuya reserva venció y libera su stock; las reservas de pedidos
+// se liberan al cancelar los pedidos vencidos
+func (v VentaRepository) LiberarReservasVencidas(ctx context.Context) (int64, error) {
+	tx, err := v.pool.Begin(ctx)
+	if err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	rows, err := tx.Query(ctx, `
+        SELECT v.id FROM venta v
+        WHERE v.estado = 'Pendiente'
+          AND EXISTS (SELECT 1 FROM reserva_lote r WHERE r.venta_id = v.id AND r.fecha_expiracion <= NOW())
+        FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		log.Println("Error al obtener ventas en espera vencidas:", err)
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	var ventaIds []int64
+	for rows.Next() {
+		var ventaId int64
+		if err := rows.Scan(&ventaId); err != nil {
+			rows.Close()
+			return 0, datatype.NewInternalServerErrorGeneric()
+		}
+		ventaIds = append(ventaIds, ventaId)
+	}
+	rows.Close()
+
+	for _, ventaId := range ventaIds {
+		antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaVenta, ventaId)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM reserva_lote WHERE venta_id = $1`, ventaId); err != nil {
+			log.Println("Error al liberar reserva de venta:", err)
+			return 0, datatype.NewInternalServerErrorGeneric()
+		}
+		if _, err := tx.Exec(ctx, `UPDATE venta SET estado = 'Anulado', deleted_at = CURRENT_TIMESTAMP WHERE id = $1`, ventaId); err != nil {
+			log.Println("Error al anular venta en espera vencida:", err)
+			return 0, datatype.NewInternalServerErrorGeneric()
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, ventaId, domain.AccionAnular, antes); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	return int64(len(ventaIds)), nil
 }
 
 func (v VentaRepository) ObtenerVentaById(ctx context.Context, id *int) (*domain.VentaDetail, error) {
@@ -387,7 +482,24 @@ func (v VentaRepository) ObtenerVentaById(ctx context.Context, id *int) (*domain
 		) AS detalles,
 	    f.url AS url_factura,
 	    v.tipo_pago,
-	    v.descuento
+	    v.descuento,
+	    v.descuento_promocion,
+	    COALESCE((
+	        SELECT jsonb_agg(jsonb_build_object(
+	                'id', vp.id,
+	                'promocionId', vp.promocion_id,
+	                'nombre', pr.nombre,
+	                'tipo', pr.tipo,
+	                'productoId', vp.producto_id,
+	                'cantidad', vp.cantidad,
+	                'subtotal', vp.subtotal,
+	                'descuento', vp.descuento
+	               ) ORDER BY vp.id)
+	        FROM venta_promocion vp
+	        INNER JOIN promocion pr ON pr.id = vp.promocion_id
+	        WHERE vp.venta_id = v.id
+	    ), '[]'::jsonb) AS promociones,
+	    (SELECT vr.receta_id FROM venta vr WHERE vr.id = v.id) AS receta_id
 	FROM view_venta_info v
 	LEFT JOIN factura f ON v.id = f.venta_id
 	WHERE v.id = $1
@@ -396,7 +508,7 @@ func (v VentaRepository) ObtenerVentaById(ctx context.Context, id *int) (*domain
 
 	var venta domain.VentaDetail
 	err := v.pool.QueryRow(ctx, query, *id).
-		Scan(&venta.Id, &venta.Codigo, &venta.Fecha, &venta.Estado, &venta.DeletedAt, &venta.Total, &venta.Usuario, &venta.Cliente, &venta.Detalles, &venta.UrlFactura, &venta.TipoPago, &venta.Descuento)
+		Scan(&venta.Id, &venta.Codigo, &venta.Fecha, &venta.Estado, &venta.DeletedAt, &venta.Total, &venta.Usuario, &venta.Cliente, &venta.Detalles, &venta.UrlFactura, &venta.TipoPago, &venta.Descuento, &venta.DescuentoPromocion, &venta.Promociones, &venta.RecetaId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, datatype.NewNotFoundError("Venta no encontrada")
@@ -433,6 +545,30 @@ func (v VentaRepository) AnularVentaById(ctx context.Context, id *int) error {
 	if estadoActual == "Anulado" {
 		return datatype.NewBadRequestError("La venta ya está anulada")
 	}
+	antes, err := estadoAuditoria(ctx, tx, domain.AuditoriaVenta, *id)
+	if err != nil {
+		return err
+	}
+
+	// Una venta en espera no descontó stock, solo se libera su reserva
+	if estadoActual == "Pendiente" {
+		if _, err := tx.Exec(ctx, `DELETE FROM reserva_lote WHERE venta_id = $1`, *id); err != nil {
+			log.Println("Error al liberar reserva de venta:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if _, err := tx.Exec(ctx, `UPDATE venta SET estado = 'Anulado', deleted_at = CURRENT_TIMESTAMP WHERE id = $1`, *id); err != nil {
+			log.Println("Error marcando venta como anulada:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, *id, domain.AccionAnular, antes); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Println("Error al hacer commit:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+		return nil
+	}
 
 	// Verificar que existen detalles de venta
 	var tieneDetalles bool
@@ -448,7 +584,7 @@ func (v VentaRepository) AnularVentaById(ctx context.Context, id *int) error {
 
 	// Obtener los lotes y productos involucrados en la venta CON BLOQUEO
 	query = `
-        SELECT dv.lote_id, dv.cantidad, lp.producto_id
+        SELECT dv.lote_id, dv.cantidad * dv.factor, lp.producto_id
         FROM detalle_venta dv 
         INNER JOIN lote_producto lp ON dv.lote_id = lp.id
         WHERE dv.venta_id = $1
@@ -548,6 +684,16 @@ func (v VentaRepository) AnularVentaById(ctx context.Context, id *int) error {
 		log.Printf("Producto %s: stock actualizado a %d", productoId, nuevoStockTotal)
 	}
 
+	// Devolver el stock a las ubicaciones de donde se retiró
+	if err := restaurarUbicacionesVenta(ctx, tx, int64(*id)); err != nil {
+		return err
+	}
+
+	// Devolver a la receta las cantidades dispensadas
+	if err := revertirRecetaVenta(ctx, tx, *id); err != nil {
+		return err
+	}
+
 	// Marcar el estado de la venta como 'Anulado'
 	query = `UPDATE venta SET estado = 'Anulado', deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND estado != 'Anulado'`
 	result, err := tx.Exec(ctx, query, *id)
@@ -559,6 +705,9 @@ func (v VentaRepository) AnularVentaById(ctx context.Context, id *int) error {
 	if result.RowsAffected() == 0 {
 		return datatype.NewConflictError("No se pudo anular la venta, posiblemente ya está anulada")
 	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, *id, domain.AccionAnular, antes); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		log.Println("Error al hacer commit:", err)
@@ -569,6 +718,707 @@ func (v VentaRepository) AnularVentaById(ctx context.Context, id *int) error {
 	return nil
 }
 
+func (v VentaRepository) CotizarVenta(ctx context.Context, detalles []domain.DetalleVentaRequest) (*domain.Cotizacion, error) {
+	if len(detalles) == 0 {
+		return nil, datatype.NewBadRequestError("La cotización debe tener al menos un detalle")
+	}
+
+	cotizacion := domain.Cotizacion{
+		Detalles:  make([]domain.CotizacionDetalle, 0, len(detalles)),
+		Faltantes: make([]domain.ProductoFaltante, 0),
+	}
+
+	for _, item := range detalles {
+		if item.Cantidad <= 0 {
+			return nil, datatype.NewBadRequestError("La cantidad debe ser mayor a cero")
+		}
+
+		detalle := domain.CotizacionDetalle{Cantidad: item.Cantidad, UnidadVenta: unidadVenta(item), Lotes: make([]domain.VentaLote, 0)}
+		var precioUnidad *float64
+		err := v.pool.QueryRow(ctx, `SELECT p.id, p.nombre_comercial, p.precio_venta, p.precio_venta_unidad FROM producto p WHERE p.id = $1`, item.ProductoId).
+			Scan(&detalle.ProductoId, &detalle.NombreComercial, &detalle.Precio, &precioUnidad)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, datatype.NewNotFoundErrorWithData("Producto no encontrado", domain.ProductoId{Id: item.ProductoId})
+			}
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		if detalle.UnidadVenta == domain.UnidadVentaUnidad {
+			if precioUnidad == nil {
+				return nil, datatype.NewErrorDataResponse(http.StatusBadRequest, "El producto no se vende por unidad", domain.ProductoId{Id: item.ProductoId})
+			}
+			detalle.Precio = *precioUnidad
+		}
+
+		// Misma asignación FEFO que en el registro de venta, sin bloquear ni descontar stock
+		lotes, err := obtenerLotesFEFO(ctx, v.pool, item.ProductoId, false)
+		if err != nil {
+			return nil, err
+		}
+		lotes, err = convertirLotesUnidadVenta(lotes, item)
+		if err != nil {
+			return nil, err
+		}
+		asignaciones, faltante := asignarLotesFEFO(lotes, item.Cantidad)
+		for _, asignacion := range asignaciones {
+			detalle.Lotes = append(detalle.Lotes, domain.VentaLote{
+				Id:               int(asignacion.Lote.Id),
+				Lote:             asignacion.Lote.Lote,
+				Cantidad:         int(asignacion.Cantidad),
+				FechaVencimiento: asignacion.Lote.FechaVencimiento.Format(time.RFC3339),
+			})
+			detalle.CantidadAsignada += asignacion.Cantidad
+			detalle.Subtotal += float64(asignacion.Cantidad) * asignacion.Lote.PrecioVenta
+		}
+
+		if faltante > 0 {
+			cotizacion.Faltantes = append(cotizacion.Faltantes, domain.ProductoFaltante{
+				ProductoId:      detalle.ProductoId,
+				NombreComercial: detalle.NombreComercial,
+				Solicitado:      item.Cantidad,
+				Disponible:      detalle.CantidadAsignada,
+				Faltante:        faltante,
+			})
+		}
+
+		cotizacion.Subtotal += detalle.Subtotal
+		cotizacion.Detalles = append(cotizacion.Detalles, detalle)
+	}
+
+	return &cotizacion, nil
+}
+
+func (v VentaRepository) RegistrarCotizacion(ctx context.Context, request *domain.CotizacionRequest, cotizacion *domain.Cotizacion) error {
+	tx, err := v.pool.Begin(ctx)
+	if err != nil {
+		return datatype.NewStatusServiceUnavailableErrorGeneric()
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Generar código de cotización
+	var nextNum int64
+	err = tx.QueryRow(ctx, `
+        SELECT COALESCE(
+            (SELECT MAX(CAST(SUBSTRING(codigo FROM 5) AS INTEGER)) + 1 FROM cotizacion WHERE codigo ~ '^COT-[0-9]+$'),
+            1
+        )
+    `).Scan(&nextNum)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	codigo := fmt.Sprintf("COT-%09d", nextNum)
+
+	var id int
+	var fechaExpiracion time.Time
+	err = tx.QueryRow(ctx, `
+        INSERT INTO cotizacion (codigo, cliente_id, usuario_id, fecha_expiracion, subtotal, descuento_promocion, descuento, total)
+        VALUES ($1, $2, $3, NOW() + make_interval(days => $4), $5, $6, $7, $8)
+        RETURNING id, fecha_expiracion
+    `, codigo, request.ClienteId, request.UsuarioId, request.DiasVigencia, cotizacion.Subtotal,
+		cotizacion.DescuentoPromocion, cotizacion.Descuento, cotizacion.Total).Scan(&id, &fechaExpiracion)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return datatype.NewBadRequestError("El cliente no existe")
+		}
+		log.Println("Error al registrar cotización:", err)
+		return datatype.NewInternalServerErrorGeneric()
+	}
+
+	for _, d := range cotizacion.Detalles {
+		_, err = tx.Exec(ctx, `
+            INSERT INTO detalle_cotizacion (cotizacion_id, producto_id, cantidad, precio, subtotal, unidad_venta)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, id, d.ProductoId, d.Cantidad, d.Precio, d.Subtotal, d.UnidadVenta)
+		if err != nil {
+			log.Println("Error al registrar detalle de cotización:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	for _, promo := range cotizacion.Promociones {
+		_, err = tx.Exec(ctx, `
+            INSERT INTO cotizacion_promocion (cotizacion_id, promocion_id, producto_id, cantidad, subtotal, descuento)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, id, promo.PromocionId, promo.ProductoId, promo.Cantidad, promo.Subtotal, promo.Descuento)
+		if err != nil {
+			log.Println("Error al registrar promoción de cotización:", err)
+			return datatype.NewInternalServerErrorGeneric()
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	committed = true
+
+	cotizacion.Id = &id
+	cotizacion.Codigo = &codigo
+	cotizacion.FechaExpiracion = &fechaExpiracion
+	return nil
+}
+
+const queryCotizacionInfo = `
+SELECT ct.id,
+       ct.codigo,
+       CASE WHEN ct.estado = 'Vigente' AND ct.fecha_expiracion < NOW() THEN 'Vencida' ELSE ct.estado::TEXT END AS estado,
+       jsonb_build_object('id', u.id, 'username', u.username) AS usuario,
+       CASE WHEN c.id IS NULL THEN NULL ELSE jsonb_build_object(
+               'id', c.id,
+               'razonSocial', c.razon_social,
+               'tipo', c.tipo,
+               'nitCi', c.nit_ci,
+               'complemento', c.complemento
+       ) END AS cliente,
+       ct.fecha,
+       ct.fecha_expiracion,
+       ct.subtotal,
+       ct.descuento_promocion,
+       ct.descuento,
+       ct.total,
+       ct.venta_id
+FROM cotizacion ct
+INNER JOIN usuario u ON u.id = ct.usuario_id
+LEFT JOIN cliente c ON c.id = ct.cliente_id
+`
+
+func (v VentaRepository) ObtenerListaCotizaciones(ctx context.Context, filtros map[string]string) (*[]domain.CotizacionInfo, error) {
+	query := `SELECT * FROM (` + queryCotizacionInfo + `) ct`
+
+	var filters []string
+	var args []interface{}
+	i := 1
+
+	// Filtrar por estado (Vigente, Vencida, Convertida)
+	if estado := filtros["estado"]; estado != "" {
+		filters = append(filters, fmt.Sprintf("ct.estado = $%d", i))
+		args = append(args, estado)
+		i++
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+	query += " ORDER BY ct.codigo DESC"
+
+	rows, err := v.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Println("Error al listar cotizaciones:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var list = make([]domain.CotizacionInfo, 0)
+	for rows.Next() {
+		var item domain.CotizacionInfo
+		if err := rows.Scan(&item.Id, &item.Codigo, &item.Estado, &item.Usuario, &item.Cliente, &item.Fecha, &item.FechaExpiracion,
+			&item.Subtotal, &item.DescuentoPromocion, &item.Descuento, &item.Total, &item.VentaId); err != nil {
+			log.Println("Error al escanear cotización:", err)
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		list = append(list, item)
+	}
+	return &list, nil
+}
+
+func (v VentaRepository) ObtenerCotizacionById(ctx context.Context, id *int) (*domain.CotizacionDetail, error) {
+	var item domain.CotizacionDetail
+	err := v.pool.QueryRow(ctx, queryCotizacionInfo+` WHERE ct.id = $1`, *id).
+		Scan(&item.Id, &item.Codigo, &item.Estado, &item.Usuario, &item.Cliente, &item.Fecha, &item.FechaExpiracion,
+			&item.Subtotal, &item.DescuentoPromocion, &item.Descuento, &item.Total, &item.VentaId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Cotización no encontrada")
+		}
+		log.Println("Error al obtener cotización:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	rows, err := v.pool.Query(ctx, `
+        SELECT dc.producto_id, p.nombre_comercial, dc.cantidad, dc.unidad_venta, dc.precio, dc.subtotal
+        FROM detalle_cotizacion dc
+        INNER JOIN producto p ON p.id = dc.producto_id
+        WHERE dc.cotizacion_id = $1
+        ORDER BY dc.id`, *id)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Detalles = make([]domain.CotizacionDetalle, 0)
+	for rows.Next() {
+		var d domain.CotizacionDetalle
+		if err := rows.Scan(&d.ProductoId, &d.NombreComercial, &d.Cantidad, &d.UnidadVenta, &d.Precio, &d.Subtotal); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		// El subtotal cotizado solo incluye la cantidad que tenía stock
+		d.CantidadAsignada = d.Cantidad
+		if d.Precio > 0 {
+			d.CantidadAsignada = uint(math.Round(d.Subtotal / d.Precio))
+		}
+		d.Lotes = make([]domain.VentaLote, 0)
+		item.Detalles = append(item.Detalles, d)
+	}
+	rows.Close()
+
+	rows, err = v.pool.Query(ctx, `
+        SELECT cp.id, cp.promocion_id, pr.nombre, pr.tipo::TEXT, cp.producto_id::TEXT, cp.cantidad, cp.subtotal, cp.descuento
+        FROM cotizacion_promocion cp
+        INNER JOIN promocion pr ON pr.id = cp.promocion_id
+        WHERE cp.cotizacion_id = $1
+        ORDER BY cp.id`, *id)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	item.Promociones = make([]domain.VentaPromocion, 0)
+	for rows.Next() {
+		var promo domain.VentaPromocion
+		if err := rows.Scan(&promo.Id, &promo.PromocionId, &promo.Nombre, &promo.Tipo, &promo.ProductoId, &promo.Cantidad, &promo.Subtotal, &promo.Descuento); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		item.Promociones = append(item.Promociones, promo)
+	}
+	return &item, nil
+}
+
+// ConvertirCotizacion registra la venta de una cotización vigente y la marca como convertida en una sola transacción
+func (v VentaRepository) ConvertirCotizacion(ctx context.Context, id *int, request *domain.VentaRequest) (*int64, error) {
+	if len(request.Detalles) == 0 {
+		return nil, datatype.NewBadRequestError("La venta debe tener al menos un detalle")
+	}
+
+	tx, err := v.pool.Begin(ctx)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Bloquear la cotización para evitar conversiones duplicadas
+	var vigente bool
+	err = tx.QueryRow(ctx, `
+        SELECT estado = 'Vigente' AND fecha_expiracion >= NOW()
+        FROM cotizacion WHERE id = $1 FOR UPDATE`, *id).Scan(&vigente)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, datatype.NewNotFoundError("Cotización no encontrada")
+		}
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if !vigente {
+		return nil, datatype.NewConflictError("La cotización no está vigente o ya fue convertida")
+	}
+
+	ventaId, err := registrarVenta(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE cotizacion SET estado = 'Convertida', venta_id = $1 WHERE id = $2`, ventaId, *id)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &ventaId, nil
+}
+
+// consultor permite ejecutar consultas tanto en el pool como dentro de una transacción
+type consultor interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// obtenerLotesFEFO obtiene los lotes activos con stock disponible de un producto ordenados por FEFO (First Expired, First Out).
+// El stock disponible, en unidades base, descuenta las reservas vigentes de ventas en espera.
+func obtenerLotesFEFO(ctx context.Context, q consultor, productoId string, bloquear bool) ([]domain.VentaLoteProductoDAO, error) {
+	query := `
+            SELECT lp.id, lp.lote, lp.fecha_vencimiento,
+                   GREATEST(lp.stock - COALESCE((
+                       SELECT SUM(r.cantidad) FROM reserva_lote r
+                       WHERE r.lote_id = lp.id AND r.fecha_expiracion > NOW()
+                   ), 0), 0) AS disponible,
+                   p.precio_venta,
+                   COALESCE(NULLIF(p.unidades_presentacion, 0), 1),
+                   p.precio_venta_unidad
+            FROM lote_producto lp
+            JOIN producto p ON p.id = lp.producto_id
+            WHERE lp.producto_id = $1 
+              AND lp.stock > 0 
+              AND lp.estado = 'Activo'
+            ORDER BY lp.fecha_vencimiento ASC, lp.id ASC`
+	if bloquear {
+		query += " FOR UPDATE OF lp"
+	}
+
+	rows, err := q.Query(ctx, query, productoId)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var lotes []domain.VentaLoteProductoDAO
+	for rows.Next() {
+		var lote domain.VentaLoteProductoDAO
+		if err := rows.Scan(&lote.Id, &lote.Lote, &lote.FechaVencimiento, &lote.Stock, &lote.PrecioVenta, &lote.UnidadesPresentacion, &lote.PrecioVentaUnidad); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		if lote.Stock == 0 {
+			continue
+		}
+		lotes = append(lotes, lote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return lotes, nil
+}
+
+// unidadVenta devuelve la unidad de venta del detalle, por defecto la presentación completa
+func unidadVenta(item domain.DetalleVentaRequest) string {
+	if item.UnidadVenta == "" {
+		return domain.UnidadVentaPresentacion
+	}
+	return item.UnidadVenta
+}
+
+// convertirLotesUnidadVenta expresa el stock y el precio de los lotes en la unidad de venta del detalle
+func convertirLotesUnidadVenta(lotes []domain.VentaLoteProductoDAO, item domain.DetalleVentaRequest) ([]domain.VentaLoteProductoDAO, error) {
+	unidad := unidadVenta(item)
+	if unidad != domain.UnidadVentaPresentacion && unidad != domain.UnidadVentaUnidad {
+		return nil, datatype.NewBadRequestError("Unidad de venta no válida, valores permitidos: Presentacion, Unidad")
+	}
+
+	convertidos := make([]domain.VentaLoteProductoDAO, 0, len(lotes))
+	for _, lote := range lotes {
+		if unidad == domain.UnidadVentaUnidad {
+			if lote.PrecioVentaUnidad == nil {
+				return nil, datatype.NewErrorDataResponse(http.StatusBadRequest, "El producto no se vende por unidad", domain.ProductoId{Id: item.ProductoId})
+			}
+			lote.Factor = 1
+			lote.PrecioVenta = *lote.PrecioVentaUnidad
+		} else {
+			lote.Factor = max(lote.UnidadesPresentacion, 1)
+		}
+		// Una presentación se entrega completa desde un mismo lote
+		lote.Stock /= lote.Factor
+		if lote.Stock == 0 {
+			continue
+		}
+		convertidos = append(convertidos, lote)
+	}
+	return convertidos, nil
+}
+
+// asignarLotesFEFO reparte la cantidad solicitada entre los lotes y devuelve la cantidad que no pudo cubrirse
+func asignarLotesFEFO(lotes []domain.VentaLoteProductoDAO, cantidad uint) ([]domain.VentaLoteAsignacion, uint) {
+	var asignaciones []domain.VentaLoteAsignacion
+	cantidadRestante := cantidad
+	for _, lote := range lotes {
+		if cantidadRestante == 0 {
+			break
+		}
+		cantidadUsar := min(cantidadRestante, lote.Stock)
+		asignaciones = append(asignaciones, domain.VentaLoteAsignacion{Lote: lote, Cantidad: cantidadUsar})
+		cantidadRestante -= cantidadUsar
+	}
+	return asignaciones, cantidadRestante
+}
+
+// asignarStockVenta bloquea los lotes del producto y asigna la cantidad solicitada por FEFO
+func asignarStockVenta(ctx context.Context, tx pgx.Tx, item domain.DetalleVentaRequest) ([]domain.VentaLoteAsignacion, error) {
+	if item.Cantidad <= 0 {
+		return nil, datatype.NewBadRequestError("La cantidad debe ser mayor a cero")
+	}
+
+	// Obtener lotes disponibles ordenados por FEFO (First Expired, First Out) con bloqueo
+	lotes, err := obtenerLotesFEFO(ctx, tx, item.ProductoId, true)
+	if err != nil {
+		return nil, err
+	}
+	lotes, err = convertirLotesUnidadVenta(lotes, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lotes) == 0 {
+		return nil, datatype.NewNotFoundErrorWithData("Producto sin stock disponible",
+			domain.ProductoId{Id: item.ProductoId})
+	}
+
+	asignaciones, faltante := asignarLotesFEFO(lotes, item.Cantidad)
+	if faltante > 0 {
+		return nil, datatype.NewNotFoundErrorWithData(
+			fmt.Sprintf("Stock insuficiente. Disponible: %d, Solicitado: %d", item.Cantidad-faltante, item.Cantidad),
+			domain.ProductoId{Id: item.ProductoId})
+	}
+	return asignaciones, nil
+}
+
+// registrarDetallesVenta registra los detalles de la venta y descuenta el stock de lotes y productos
+func registrarDetallesVenta(ctx context.Context, tx pgx.Tx, ventaId int64, detalles []domain.DetalleVentaRequest) (float64, error) {
+	totalVenta := 0.0
+	for _, item := range detalles {
+		// Los lotes reservados de pedidos y ventas en espera se entregan tal cual; el resto se asigna por FEFO
+		asignaciones := item.Lotes
+		var err error
+		if len(asignaciones) == 0 {
+			asignaciones, err = asignarStockVenta(ctx, tx, item)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		for _, asignacion := range asignaciones {
+			lote := asignacion.Lote
+			if item.Precio != nil {
+				lote.PrecioVenta = *item.Precio
+			}
+			cantidadUsar := asignacion.Cantidad
+			unidadesBase := cantidadUsar * lote.Factor
+
+			// Crear detalle de venta
+			var detalleId int64
+			err = tx.QueryRow(ctx, `
+                INSERT INTO detalle_venta (venta_id, lote_id, cantidad, precio, unidad_venta, factor)
+                VALUES ($1, $2, $3, $4, $5, $6)
+                RETURNING id
+            `, ventaId, lote.Id, cantidadUsar, lote.PrecioVenta, unidadVenta(item), lote.Factor).Scan(&detalleId)
+			if err != nil {
+				return 0, datatype.NewInternalServerErrorGeneric()
+			}
+
+			// Retirar de las ubicaciones del lote para la lista de recolección
+			if err := retirarUbicacionesVenta(ctx, tx, detalleId, int(lote.Id), int64(unidadesBase)); err != nil {
+				return 0, err
+			}
+
+			// Actualizar stock del lote con verificación
+			result, err := tx.Exec(ctx, `
+                UPDATE lote_producto 
+                SET stock = stock - $1
+                WHERE id = $2 AND stock >= $1
+            `, unidadesBase, lote.Id)
+			if err != nil {
+				return 0, datatype.NewInternalServerErrorGeneric()
+			}
+			if result.RowsAffected() == 0 {
+				return 0, datatype.NewConflictError("Stock insuficiente en el lote")
+			}
+
+			// Actualizar stock del producto principal con verificación
+			result, err = tx.Exec(ctx, `
+                UPDATE producto 
+                SET stock = stock - $1
+                WHERE id = $2 AND stock >= $1
+            `, unidadesBase, item.ProductoId)
+			if err != nil {
+				return 0, datatype.NewInternalServerErrorGeneric()
+			}
+			if result.RowsAffected() == 0 {
+				return 0, datatype.NewConflictError("Stock insuficiente en el producto")
+			}
+
+			totalVenta += float64(cantidadUsar) * lote.PrecioVenta
+		}
+	}
+	return totalVenta, nil
+}
+
+// reservarDetallesVenta registra los detalles de una venta en espera y reserva el stock de los lotes sin descontarlo
+func reservarDetallesVenta(ctx context.Context, tx pgx.Tx, ventaId int64, detalles []domain.DetalleVentaRequest, reservaHasta time.Time) (float64, error) {
+	totalVenta := 0.0
+	for _, item := range detalles {
+		asignaciones, err := asignarStockVenta(ctx, tx, item)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, asignacion := range asignaciones {
+			_, err = tx.Exec(ctx, `
+                INSERT INTO detalle_venta (venta_id, lote_id, cantidad, precio, unidad_venta, factor)
+                VALUES ($1, $2, $3, $4, $5, $6)
+            `, ventaId, asignacion.Lote.Id, asignacion.Cantidad, asignacion.Lote.PrecioVenta, unidadVenta(item), asignacion.Lote.Factor)
+			if err != nil {
+				return 0, datatype.NewInternalServerErrorGeneric()
+			}
+
+			_, err = tx.Exec(ctx, `
+                INSERT INTO reserva_lote (lote_id, cantidad, venta_id, fecha_expiracion)
+                VALUES ($1, $2, $3, $4)
+            `, asignacion.Lote.Id, asignacion.Cantidad*asignacion.Lote.Factor, ventaId, reservaHasta)
+			if err != nil {
+				return 0, datatype.NewInternalServerErrorGeneric()
+			}
+
+			totalVenta += float64(asignacion.Cantidad) * asignacion.Lote.PrecioVenta
+		}
+	}
+	return totalVenta, nil
+}
+
+// registrarPromocionesVenta registra las líneas de descuento por promoción y devuelve el descuento total
+func registrarPromocionesVenta(ctx context.Context, tx pgx.Tx, ventaId int64, promociones []domain.VentaPromocion) (float64, error) {
+	descuentoPromocion := 0.0
+	for _, promo := range promociones {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO venta_promocion (venta_id, promocion_id, producto_id, cantidad, subtotal, descuento)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, ventaId, promo.PromocionId, promo.ProductoId, promo.Cantidad, promo.Subtotal, promo.Descuento)
+		if err != nil {
+			return 0, datatype.NewInternalServerErrorGeneric()
+		}
+		descuentoPromocion += promo.Descuento
+	}
+	return descuentoPromocion, nil
+}
+
+// registrarVenta crea la venta con su código, descuenta el stock por FEFO y registra las promociones dentro de la transacción
+func registrarVenta(ctx context.Context, tx pgx.Tx, request *domain.VentaRequest) (int64, error) {
+	// Generar código de venta
+	codigo, err := generarCodigoVenta(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Crear la venta
+	var ventaId int64
+	err = tx.QueryRow(ctx, `
+        INSERT INTO venta (cliente_id, usuario_id, total, codigo,tipo_pago,descuento,receta_id)
+        VALUES ($1, $2, 0, $3, $4, $5, $6)
+        RETURNING id
+    `, request.ClienteId, request.UsuarioId, codigo, request.TipoPago, request.Descuento, request.RecetaId).Scan(&ventaId)
+	if err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Validar receta de los productos bajo control
+	if err := validarRecetaVenta(ctx, tx, request.RecetaId, request.Detalles); err != nil {
+		return 0, err
+	}
+
+	// Registrar autorización de interacciones graves
+	if err := registrarAutorizacionesVenta(ctx, tx, ventaId, request); err != nil {
+		return 0, err
+	}
+
+	// Procesar cada detalle de venta
+	totalVenta, err := registrarDetallesVenta(ctx, tx, ventaId, request.Detalles)
+	if err != nil {
+		return 0, err
+	}
+
+	// Registrar líneas de descuento por promoción
+	descuentoPromocion, err := registrarPromocionesVenta(ctx, tx, ventaId, request.Promociones)
+	if err != nil {
+		return 0, err
+	}
+
+	// Actualizar total de la venta
+	_, err = tx.Exec(ctx, `UPDATE venta SET total = $1,descuento_promocion = $2,fecha = NOW() WHERE id = $3 `, totalVenta, descuentoPromocion, ventaId)
+	if err != nil {
+		return 0, datatype.NewInternalServerErrorGeneric()
+	}
+	if err := registrarAuditoria(ctx, tx, domain.AuditoriaVenta, ventaId, domain.AccionCrear, nil); err != nil {
+		return 0, err
+	}
+	return ventaId, nil
+}
+
+// generarCodigoVenta genera el siguiente código correlativo de venta
+func generarCodigoVenta(ctx context.Context, tx pgx.Tx) (string, error) {
+	var nextNum int64
+	err := tx.QueryRow(ctx, `
+        SELECT COALESCE(
+            (SELECT MAX(CAST(SUBSTRING(codigo FROM 6) AS INTEGER)) + 1 FROM venta WHERE codigo ~ '^VENT-[0-9]+$'),
+            1
+        )
+    `).Scan(&nextNum)
+	if err != nil {
+		return "", datatype.NewInternalServerErrorGeneric()
+	}
+	return fmt.Sprintf("VENT-%09d", nextNum), nil
+}
+
+// bloquearVentaPendiente bloquea la venta y verifica que siga en espera
+func bloquearVentaPendiente(ctx context.Context, tx pgx.Tx, ventaId int) error {
+	var estado string
+	err := tx.QueryRow(ctx, `SELECT estado FROM venta WHERE id = $1 FOR UPDATE`, ventaId).Scan(&estado)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datatype.NewNotFoundError("Venta no encontrada")
+		}
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if estado != "Pendiente" {
+		return datatype.NewConflictError("La venta no está en espera")
+	}
+	return nil
+}
+
+// eliminarDetallesVentaPendiente elimina los detalles y la reserva de una venta en espera
+// detallesVentaEnEspera arma un detalle por cada lote reservado de la venta, bloqueado y con el precio reservado;
+// si el lote ya no está activo o no tiene el stock reservado, esa cantidad se vuelve a asignar por FEFO al mismo precio
+func detallesVentaEnEspera(ctx context.Context, tx pgx.Tx, ventaId int) ([]domain.DetalleVentaRequest, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT lp.producto_id::TEXT, dv.unidad_venta, dv.cantidad, dv.precio, dv.factor,
+               lp.id, lp.lote, lp.fecha_vencimiento, lp.estado = 'Activo' AND lp.stock >= dv.cantidad * dv.factor
+        FROM detalle_venta dv
+        INNER JOIN lote_producto lp ON lp.id = dv.lote_id
+        WHERE dv.venta_id = $1
+        ORDER BY dv.id
+        FOR UPDATE OF lp
+    `, ventaId)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	defer rows.Close()
+
+	var detalles []domain.DetalleVentaRequest
+	for rows.Next() {
+		var d domain.DetalleVentaRequest
+		var precio float64
+		var vigente bool
+		var lote domain.VentaLoteProductoDAO
+		if err := rows.Scan(&d.ProductoId, &d.UnidadVenta, &d.Cantidad, &precio, &lote.Factor,
+			&lote.Id, &lote.Lote, &lote.FechaVencimiento, &vigente); err != nil {
+			return nil, datatype.NewInternalServerErrorGeneric()
+		}
+		d.Precio = &precio
+		if vigente {
+			lote.PrecioVenta = precio
+			d.Lotes = []domain.VentaLoteAsignacion{{Lote: lote, Cantidad: d.Cantidad}}
+		}
+		detalles = append(detalles, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if len(detalles) == 0 {
+		return nil, datatype.NewBadRequestError("La venta debe tener al menos un detalle")
+	}
+	return detalles, nil
+}
+
+func eliminarDetallesVentaPendiente(ctx context.Context, tx pgx.Tx, ventaId int) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM reserva_lote WHERE venta_id = $1`, ventaId); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM detalle_venta WHERE venta_id = $1`, ventaId); err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return nil
+}
+
 func NewVentaRepository(pool *pgxpool.Pool) *VentaRepository {
 	return &VentaRepository{pool: pool}
 }
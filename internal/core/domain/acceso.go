@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Motivos de un intento de inicio de sesión fallido
+const (
+	AccesoMotivoCredenciales = "Usuario o contraseña incorrecta"
+	AccesoMotivoBloqueado    = "Cuenta bloqueada"
+	AccesoMotivoDobleFactor  = "Código de verificación incorrecto"
+)
+
+// IntentoLogin es un intento de inicio de sesión en el panel administrativo
+type IntentoLogin struct {
+	Username  string
+	UsuarioId *int
+	Exitoso   bool
+	Motivo    *string
+	Ip        string
+	UserAgent string
+}
+
+type IntentoLoginInfo struct {
+	Id        int64          `json:"id"`
+	Username  string         `json:"username"`
+	Usuario   *UsuarioSimple `json:"usuario"`
+	Exitoso   bool           `json:"exitoso"`
+	Motivo    *string        `json:"motivo"`
+	Ip        *string        `json:"ip"`
+	UserAgent *string        `json:"userAgent"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Tipos de actividad sospechosa: intentos fallidos agrupados por IP o por nombre de usuario
+const (
+	SospechosoIp       = "IP"
+	SospechosoUsername = "Usuario"
+)
+
+type ActividadSospechosa struct {
+	Tipo              string     `json:"tipo"`
+	Valor             string     `json:"valor"`
+	Fallidos          int        `json:"fallidos"`
+	UsuariosDistintos int        `json:"usuariosDistintos"`
+	IpsDistintas      int        `json:"ipsDistintas"`
+	PrimerIntento     time.Time  `json:"primerIntento"`
+	UltimoIntento     time.Time  `json:"ultimoIntento"`
+	BloqueadoHasta    *time.Time `json:"bloqueadoHasta"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Entidades registradas en la bitácora de auditoría
+const (
+	AuditoriaProducto     = "Producto"
+	AuditoriaLoteProducto = "LoteProducto"
+	AuditoriaCompra       = "Compra"
+	AuditoriaVenta        = "Venta"
+	AuditoriaUsuario      = "Usuario"
+	AuditoriaRol          = "Rol"
+	AuditoriaCliente      = "Cliente"
+)
+
+// Acciones registradas en la bitácora de auditoría
+const (
+	AccionCrear               = "Crear"
+	AccionModificar           = "Modificar"
+	AccionHabilitar           = "Habilitar"
+	AccionDeshabilitar        = "Deshabilitar"
+	AccionAnular              = "Anular"
+	AccionCompletar           = "Completar"
+	AccionRetirar             = "Retirar"
+	AccionRestablecerPassword = "RestablecerPassword"
+	AccionCambiarPassword     = "CambiarPassword"
+	AccionDesbloquear         = "Desbloquear"
+	AccionActivarDobleFactor  = "ActivarDobleFactor"
+	AccionQuitarDobleFactor   = "QuitarDobleFactor"
+	AccionGenerarApiKey       = "GenerarApiKey"
+	AccionRevocarApiKey       = "RevocarApiKey"
+)
+
+// AuditoriaInfo es un cambio registrado; antes y después solo incluyen los campos modificados
+type AuditoriaInfo struct {
+	Id        int64                  `json:"id"`
+	Usuario   *UsuarioSimple         `json:"usuario"`
+	Ip        *string                `json:"ip"`
+	Entidad   string                 `json:"entidad"`
+	EntidadId string                 `json:"entidadId"`
+	Accion    string                 `json:"accion"`
+	Antes     map[string]interface{} `json:"antes"`
+	Despues   map[string]interface{} `json:"despues"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
@@ -50,3 +50,25 @@ type ClienteSimple struct {
 type ClienteId struct {
 	Id int `json:"id"`
 }
+
+// ClienteCuenta es el vínculo entre un usuario de la tienda en línea (UID de Firebase) y un cliente
+type ClienteCuenta struct {
+	UsuarioUid   string         `json:"usuarioUid"`
+	Email        *string        `json:"email"`
+	Verificado   bool           `json:"verificado"`
+	VerificadoAt *time.Time     `json:"verificadoAt"`
+	Cliente      *ClienteDetail `json:"cliente"`
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+type PerfilClienteRequest struct {
+	NitCi       *uint   `json:"nitCi"`
+	Complemento *string `json:"complemento"`
+	Tipo        string  `json:"tipo"`
+	RazonSocial string  `json:"razonSocial"`
+	Telefono    *uint   `json:"telefono"`
+}
+
+type VerificarCuentaRequest struct {
+	CodigoVenta string `json:"codigoVenta"`
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// Tipos de código de barras de un producto
+const (
+	CodigoGTIN    = "GTIN"
+	CodigoInterno = "Interno"
+)
+
+type CodigoBarraRequest struct {
+	Codigo string `json:"codigo"`
+	Tipo   string `json:"tipo"`
+}
+
+type CodigoBarra struct {
+	Id     int    `json:"id"`
+	Codigo string `json:"codigo"`
+	Tipo   string `json:"tipo"`
+}
+
+// DatosGS1 son los datos leídos de un código GS1-128 o GS1 DataMatrix
+type DatosGS1 struct {
+	Gtin             string     `json:"gtin"`
+	Lote             *string    `json:"lote,omitempty"`
+	Serie            *string    `json:"serie,omitempty"`
+	FechaVencimiento *time.Time `json:"fechaVencimiento,omitempty"`
+}
+
+type LoteEscaneo struct {
+	Id               int       `json:"id"`
+	Lote             string    `json:"lote"`
+	FechaVencimiento time.Time `json:"fechaVencimiento"`
+	Stock            int       `json:"stock"`
+	Estado           string    `json:"estado"`
+}
+
+// ResultadoEscaneo es el producto y, si el código lo incluye, el lote correspondiente a un código leído
+type ResultadoEscaneo struct {
+	Codigo   string       `json:"codigo"`
+	Producto ProductoInfo `json:"producto"`
+	Lote     *LoteEscaneo `json:"lote"`
+	Gs1      *DatosGS1    `json:"gs1,omitempty"`
+}
@@ -7,11 +7,13 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// DetalleCompraRequest se registra por presentación: cantidad y precios corresponden a cajas completas
 type DetalleCompraRequest struct {
 	Cantidad       uint    `json:"cantidad"`
 	PrecioCompra   float64 `json:"precioCompra"`
 	PrecioVenta    float64 `json:"precioVenta"`
 	LoteProductoId uint    `json:"loteProductoId"`
+	UbicacionId    *int    `json:"ubicacionId"`
 }
 
 type CompraRequest struct {
@@ -35,10 +37,12 @@ type CompraInfo struct {
 type DetalleCompraDAO struct {
 	Id             uint      `json:"id"`
 	Cantidad       uint      `json:"cantidad"`
+	Factor         uint      `json:"factor"`
 	PrecioCompra   float64   `json:"precioCompra"`
 	PrecioVenta    float64   `json:"precioVenta"`
 	LoteProductoId uint      `json:"loteProductoId"`
 	ProductoId     uuid.UUID `json:"productoId"`
+	UbicacionId    *int      `json:"ubicacionId"`
 }
 
 type CompraDAO struct {
@@ -54,9 +58,11 @@ type CompraDAO struct {
 type DetalleCompraDetail struct {
 	Id           uint             `json:"id"`
 	Cantidad     uint             `json:"cantidad"`
+	Factor       uint             `json:"factor"`
 	PrecioCompra float64          `json:"precioCompra"`
 	PrecioVenta  float64          `json:"precioVenta"`
 	LoteProducto LoteProductoInfo `json:"loteProducto"`
+	Ubicacion    *UbicacionSimple `json:"ubicacion"`
 }
 
 type CompraDetail struct {
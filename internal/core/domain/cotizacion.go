@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"time"
+)
+
+type CotizacionRequest struct {
+	UsuarioId    uint                  `json:"-"`
+	ClienteId    *uint                 `json:"clienteId"`
+	Descuento    float64               `json:"descuento"`
+	Detalles     []DetalleVentaRequest `json:"detalles"`
+	Guardar      bool                  `json:"guardar"`
+	DiasVigencia int                   `json:"diasVigencia"`
+}
+
+type Cotizacion struct {
+	Id                 *int                `json:"id,omitempty"`
+	Codigo             *string             `json:"codigo,omitempty"`
+	FechaExpiracion    *time.Time          `json:"fechaExpiracion,omitempty"`
+	Detalles           []CotizacionDetalle `json:"detalles"`
+	Promociones        []VentaPromocion    `json:"promociones"`
+	Faltantes          []ProductoFaltante  `json:"faltantes"`
+	Advertencias       []Advertencia       `json:"advertencias"`
+	Subtotal           float64             `json:"subtotal"`
+	DescuentoPromocion float64             `json:"descuentoPromocion"`
+	Descuento          float64             `json:"descuento"`
+	Total              float64             `json:"total"`
+}
+
+type CotizacionDetalle struct {
+	ProductoId       string      `json:"productoId"`
+	NombreComercial  string      `json:"nombreComercial"`
+	Cantidad         uint        `json:"cantidad"`
+	UnidadVenta      string      `json:"unidadVenta"`
+	CantidadAsignada uint        `json:"cantidadAsignada"`
+	Precio           float64     `json:"precio"`
+	Subtotal         float64     `json:"subtotal"`
+	Lotes            []VentaLote `json:"lotes"`
+}
+
+type ProductoFaltante struct {
+	ProductoId      string `json:"productoId"`
+	NombreComercial string `json:"nombreComercial"`
+	Solicitado      uint   `json:"solicitado"`
+	Disponible      uint   `json:"disponible"`
+	Faltante        uint   `json:"faltante"`
+}
+
+type CotizacionInfo struct {
+	Id                 int            `json:"id"`
+	Codigo             string         `json:"codigo"`
+	Estado             string         `json:"estado"`
+	Usuario            UsuarioSimple  `json:"usuario"`
+	Cliente            *ClienteSimple `json:"cliente"`
+	Fecha              time.Time      `json:"fecha"`
+	FechaExpiracion    time.Time      `json:"fechaExpiracion"`
+	Subtotal           float64        `json:"subtotal"`
+	DescuentoPromocion float64        `json:"descuentoPromocion"`
+	Descuento          float64        `json:"descuento"`
+	Total              float64        `json:"total"`
+	VentaId            *int           `json:"ventaId"`
+}
+
+type CotizacionDetail struct {
+	CotizacionInfo
+	Detalles    []CotizacionDetalle `json:"detalles"`
+	Promociones []VentaPromocion    `json:"promociones"`
+}
+
+type ConvertirCotizacionRequest struct {
+	ClienteId *uint  `json:"clienteId"`
+	TipoPago  string `json:"tipoPago"`
+	RecetaId  *int   `json:"recetaId"`
+	// Autorización del farmacéutico para interacciones graves
+	AutorizacionInteraccion *AutorizacionInteraccion `json:"autorizacionInteraccion"`
+}
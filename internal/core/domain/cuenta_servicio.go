@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// CuentaServicioRequest registra o modifica una cuenta de servicio; los permisos provienen de sus roles
+type CuentaServicioRequest struct {
+	Nombre      string  `json:"nombre"`
+	Descripcion *string `json:"descripcion"`
+	Roles       []int32 `json:"roles"`
+}
+
+type CuentaServicioInfo struct {
+	Id             int        `json:"id"`
+	Nombre         string     `json:"nombre"`
+	Descripcion    *string    `json:"descripcion"`
+	Estado         string     `json:"estado"`
+	Roles          []RolInfo  `json:"roles"`
+	ApiKeysActivas int        `json:"apiKeysActivas"`
+	UltimoUso      *time.Time `json:"ultimoUso"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeletedAt      *time.Time `json:"deletedAt"`
+}
+
+type CuentaServicioDetail struct {
+	CuentaServicioInfo
+	ApiKeys []ApiKeyInfo `json:"apiKeys"`
+}
+
+// ApiKeyRequest genera una API key; sin permisos la key tiene todos los permisos de los roles de la cuenta
+type ApiKeyRequest struct {
+	Nombre   string     `json:"nombre"`
+	Permisos []string   `json:"permisos"`
+	ExpiraAt *time.Time `json:"expiraAt"`
+}
+
+type ApiKeyInfo struct {
+	Id         int        `json:"id"`
+	Nombre     string     `json:"nombre"`
+	Prefijo    string     `json:"prefijo"`
+	Permisos   []string   `json:"permisos"`
+	ExpiraAt   *time.Time `json:"expiraAt"`
+	UltimoUso  *time.Time `json:"ultimoUso"`
+	UltimaIp   *string    `json:"ultimaIp"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevocadaAt *time.Time `json:"revocadaAt"`
+}
+
+// ApiKeyCreada incluye la API key completa, que se muestra una sola vez
+type ApiKeyCreada struct {
+	ApiKeyInfo
+	ApiKey string `json:"apiKey"`
+}
+
+// ApiKeyAutenticada es la identidad con la que opera una petición autenticada con API key
+type ApiKeyAutenticada struct {
+	ApiKeyId  int
+	Hash      string
+	UsuarioId int
+	Username  string
+	ExpiraAt  *time.Time
+	// Permisos limita los permisos de los roles de la cuenta; nil no agrega restricciones
+	Permisos []string
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// CantidadCodigosRecuperacion es la cantidad de códigos de un solo uso que se entregan al activar
+const CantidadCodigosRecuperacion = 10
+
+// DobleFactorUsuario es la configuración de verificación en dos pasos guardada para un usuario
+type DobleFactorUsuario struct {
+	UsuarioId  int
+	Username   string
+	Secreto    *string
+	Activo     bool
+	UltimoPaso *int64
+	ActivadoAt *time.Time
+	// Requerido indica que alguno de sus roles activos exige la verificación en dos pasos
+	Requerido        bool
+	CodigosRestantes int
+}
+
+type DobleFactorEstado struct {
+	Activo           bool       `json:"activo"`
+	Requerido        bool       `json:"requerido"`
+	ActivadoAt       *time.Time `json:"activadoAt"`
+	CodigosRestantes int        `json:"codigosRestantes"`
+}
+
+// DobleFactorConfiguracion se muestra una sola vez para registrar la cuenta en la app autenticadora
+type DobleFactorConfiguracion struct {
+	Secreto string `json:"secreto"`
+	Uri     string `json:"uri"`
+	Qr      string `json:"qr"`
+}
+
+type DobleFactorCodigoRequest struct {
+	Codigo string `json:"codigo"`
+}
+
+// DesafioDobleFactorRequest completa el inicio de sesión con el token recibido en el primer paso
+type DesafioDobleFactorRequest struct {
+	TokenDesafio string `json:"tokenDesafio"`
+	Codigo       string `json:"codigo"`
+}
+
+type CodigosRecuperacion struct {
+	Codigos []string `json:"codigosRecuperacion"`
+}
+
+type RolDobleFactorRequest struct {
+	Requerido bool `json:"requerido"`
+}
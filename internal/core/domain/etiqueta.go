@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type EtiquetaRequest struct {
+	Formato   string                    `json:"formato"`
+	Productos []EtiquetaProductoRequest `json:"productos"`
+	Lotes     []EtiquetaLoteRequest     `json:"lotes"`
+}
+
+type EtiquetaProductoRequest struct {
+	ProductoId string `json:"productoId"`
+	Cantidad   int    `json:"cantidad"`
+}
+
+type EtiquetaLoteRequest struct {
+	LoteId   int `json:"loteId"`
+	Cantidad int `json:"cantidad"`
+}
+
+// Etiqueta son los datos impresos en una etiqueta de góndola o de producto
+type Etiqueta struct {
+	NombreComercial  string     `json:"nombreComercial"`
+	Presentacion     string     `json:"presentacion"`
+	Laboratorio      string     `json:"laboratorio"`
+	PrecioVenta      float64    `json:"precioVenta"`
+	CodigoBarra      *string    `json:"codigoBarra"`
+	TipoCodigo       *string    `json:"tipoCodigo"`
+	Lote             *string    `json:"lote"`
+	FechaVencimiento *time.Time `json:"fechaVencimiento"`
+	Cantidad         int        `json:"cantidad"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// ProductoImportacion es una fila del catálogo de productos; los catálogos relacionados se referencian por nombre
+type ProductoImportacion struct {
+	Fila                 int
+	NombreComercial      string
+	Laboratorio          string
+	FormaFarmaceutica    string
+	Presentacion         *string
+	UnidadesPresentacion int
+	Categorias           []string
+	PrincipiosActivos    []PrincipioActivoImportacion
+	PrecioCompra         *float64
+	PrecioVenta          float64
+	PrecioVentaUnidad    *float64
+	StockMin             int64
+	NivelControl         string
+	Refrigerado          bool
+	Controlado           bool
+	CodigosBarra         []CodigoBarraRequest
+}
+
+type PrincipioActivoImportacion struct {
+	Nombre        string  `json:"nombre"`
+	Concentracion float64 `json:"concentracion"`
+	UnidadMedida  string  `json:"unidadMedida"`
+}
+
+// StockInicialImportacion es un lote con su existencia inicial; la cantidad y el costo son por presentación o por unidad
+type StockInicialImportacion struct {
+	Fila             int
+	Producto         string
+	Laboratorio      string
+	Lote             string
+	FechaVencimiento time.Time
+	Cantidad         int64
+	Unidad           string
+	CostoUnitario    float64
+	Ubicacion        *string
+}
+
+// ErrorImportacion es un problema encontrado en una fila del archivo importado
+type ErrorImportacion struct {
+	Fila    int    `json:"fila"`
+	Columna string `json:"columna,omitempty"`
+	Mensaje string `json:"mensaje"`
+}
+
+// ResultadoImportacion resume una importación; con errores o en modo de prueba no se aplica ningún cambio
+type ResultadoImportacion struct {
+	DryRun       bool               `json:"dryRun"`
+	Aplicado     bool               `json:"aplicado"`
+	Total        int                `json:"total"`
+	Creados      int                `json:"creados"`
+	Actualizados int                `json:"actualizados"`
+	Documento    *string            `json:"documento,omitempty"`
+	Errores      []ErrorImportacion `json:"errores"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Severidades de una interacción entre principios activos
+const (
+	SeveridadLeve     = "Leve"
+	SeveridadModerada = "Moderada"
+	SeveridadGrave    = "Grave"
+)
+
+// Tipos de advertencia mostrados al vender o cotizar
+const (
+	AdvertenciaInteraccion      = "Interaccion"
+	AdvertenciaContraindicacion = "Contraindicacion"
+)
+
+type InteraccionRequest struct {
+	PrincipioActivoAId int    `json:"principioActivoAId"`
+	PrincipioActivoBId int    `json:"principioActivoBId"`
+	Severidad          string `json:"severidad"`
+	Descripcion        string `json:"descripcion"`
+}
+
+type InteraccionId struct {
+	Id int `json:"id"`
+}
+
+type InteraccionInfo struct {
+	Id               int             `json:"id"`
+	PrincipioActivoA PrincipioActivo `json:"principioActivoA"`
+	PrincipioActivoB PrincipioActivo `json:"principioActivoB"`
+	Severidad        string          `json:"severidad"`
+	Descripcion      string          `json:"descripcion"`
+	CreatedAt        time.Time       `json:"createdAt"`
+}
+
+// Advertencia es una interacción o contraindicación detectada entre los productos de una venta
+type Advertencia struct {
+	Tipo              string   `json:"tipo"`
+	InteraccionId     *int     `json:"interaccionId,omitempty"`
+	Severidad         *string  `json:"severidad,omitempty"`
+	PrincipiosActivos []string `json:"principiosActivos"`
+	Descripcion       string   `json:"descripcion"`
+}
+
+// AutorizacionInteraccion es la confirmación del farmacéutico para vender pese a interacciones graves
+type AutorizacionInteraccion struct {
+	Motivo string `json:"motivo"`
+}
@@ -27,6 +27,9 @@ type LoteProductoDetail struct {
 	Stock            int          `json:"stock"`
 	Estado           string       `json:"estado"`
 	Producto         ProductoInfo `json:"producto"`
+	// Ubicaciones donde está guardado el stock; el resto aún no tiene ubicación asignada
+	Ubicaciones       []LoteUbicacion `json:"ubicaciones"`
+	StockSinUbicacion int64           `json:"stockSinUbicacion"`
 }
 
 type LoteProductoSimple struct {
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+)
+
+// Estados del pedido en línea
+const (
+	PedidoRecibido   = "Recibido"
+	PedidoPreparando = "Preparando"
+	PedidoListo      = "Listo"
+	PedidoEntregado  = "Entregado"
+	PedidoCancelado  = "Cancelado"
+)
+
+type CarritoItemRequest struct {
+	ProductoId string `json:"productoId"`
+	Cantidad   uint   `json:"cantidad"`
+	// Presentacion (por defecto) o Unidad
+	UnidadVenta string `json:"unidadVenta"`
+}
+
+type CarritoItem struct {
+	Producto    ProductoSimple `json:"producto"`
+	Cantidad    uint           `json:"cantidad"`
+	UnidadVenta string         `json:"unidadVenta"`
+	PrecioVenta float64        `json:"precioVenta"`
+	Subtotal    float64        `json:"subtotal"`
+	Disponible  uint           `json:"disponible"`
+}
+
+type Carrito struct {
+	Items []CarritoItem `json:"items"`
+	Total float64       `json:"total"`
+}
+
+type PedidoRequest struct {
+	UsuarioUid string  `json:"-"`
+	Email      string  `json:"-"`
+	Telefono   *string `json:"telefono"`
+	Comentario *string `json:"comentario"`
+}
+
+type PedidoId struct {
+	Id int `json:"id"`
+}
+
+type PedidoInfo struct {
+	Id          int       `json:"id"`
+	Codigo      string    `json:"codigo"`
+	Estado      string    `json:"estado"`
+	UsuarioUid  string    `json:"usuarioUid"`
+	ClienteId   *uint     `json:"clienteId"`
+	Email       *string   `json:"email"`
+	Telefono    *string   `json:"telefono"`
+	Comentario  *string   `json:"comentario"`
+	Total       float64   `json:"total"`
+	FechaLimite time.Time `json:"fechaLimite"`
+	VentaId     *int      `json:"ventaId"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type PedidoDetail struct {
+	PedidoInfo
+	Detalles []PedidoDetalle `json:"detalles"`
+}
+
+type PedidoDetalle struct {
+	Producto    ProductoSimple `json:"producto"`
+	Cantidad    uint           `json:"cantidad"`
+	UnidadVenta string         `json:"unidadVenta"`
+	Precio      float64        `json:"precio"`
+	Subtotal    float64        `json:"subtotal"`
+}
+
+type PedidoEstadoRequest struct {
+	Estado string `json:"estado"`
+}
+
+type EntregarPedidoRequest struct {
+	ClienteId uint    `json:"clienteId"`
+	TipoPago  string  `json:"tipoPago"`
+	Descuento float64 `json:"descuento"`
+	RecetaId  *int    `json:"recetaId"`
+	// Autorización del farmacéutico para interacciones graves
+	AutorizacionInteraccion *AutorizacionInteraccion `json:"autorizacionInteraccion"`
+}
@@ -0,0 +1,54 @@
+package domain
+
+// Códigos del catálogo de permisos; se asignan a los roles y se verifican en las rutas
+const (
+	PermisoRolesGestionar             = "roles.gestionar"
+	PermisoUsuariosGestionar          = "usuarios.gestionar"
+	PermisoAccesosVer                 = "accesos.ver"
+	PermisoCuentasServicioGestionar   = "cuentas_servicio.gestionar"
+	PermisoCategoriasGestionar        = "categorias.gestionar"
+	PermisoPrincipiosActivosGestionar = "principios_activos.gestionar"
+	PermisoLaboratoriosGestionar      = "laboratorios.gestionar"
+	PermisoProductosGestionar         = "productos.gestionar"
+	PermisoProductosImportar          = "productos.importar"
+	PermisoProductosExportar          = "productos.exportar"
+	PermisoPreciosGestionar           = "precios.gestionar"
+	PermisoLotesVer                   = "lotes.ver"
+	PermisoLotesGestionar             = "lotes.gestionar"
+	PermisoInventarioImportar         = "inventario.importar"
+	PermisoComprasVer                 = "compras.ver"
+	PermisoComprasGestionar           = "compras.gestionar"
+	PermisoComprasCompletar           = "compras.completar"
+	PermisoComprasAnular              = "compras.anular"
+	PermisoClientesGestionar          = "clientes.gestionar"
+	PermisoVentasVer                  = "ventas.ver"
+	PermisoVentasCotizar              = "ventas.cotizar"
+	PermisoVentasRegistrar            = "ventas.registrar"
+	PermisoVentasAnular               = "ventas.anular"
+	PermisoPromocionesVer             = "promociones.ver"
+	PermisoPromocionesGestionar       = "promociones.gestionar"
+	PermisoPedidosGestionar           = "pedidos.gestionar"
+	PermisoRecetasGestionar           = "recetas.gestionar"
+	PermisoInteraccionesVer           = "interacciones.ver"
+	PermisoInteraccionesGestionar     = "interacciones.gestionar"
+	PermisoRetirosGestionar           = "retiros.gestionar"
+	PermisoUbicacionesVer             = "ubicaciones.ver"
+	PermisoUbicacionesGestionar       = "ubicaciones.gestionar"
+	PermisoTrasladosGestionar         = "traslados.gestionar"
+	PermisoAuditoriaVer               = "auditoria.ver"
+	PermisoBackupsGestionar           = "backups.gestionar"
+	PermisoReportesVer                = "reportes.ver"
+	PermisoReportesKardex             = "reportes.kardex"
+	PermisoEstadisticasVer            = "estadisticas.ver"
+)
+
+type Permiso struct {
+	Id          int    `json:"id"`
+	Codigo      string `json:"codigo"`
+	Modulo      string `json:"modulo"`
+	Descripcion string `json:"descripcion"`
+}
+
+type RolPermisosRequest struct {
+	Permisos []int `json:"permisos"`
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Origen de un cambio de precios en el historial
+const (
+	PrecioOrigenManual     = "Manual"
+	PrecioOrigenCompra     = "Compra"
+	PrecioOrigenMasivo     = "Masivo"
+	PrecioOrigenProgramado = "Programado"
+)
+
+// Estados de un precio programado; los propuestos por una compra esperan aprobación
+const (
+	PrecioProgramadoPropuesto = "Propuesto"
+	PrecioProgramadoPendiente = "Pendiente"
+	PrecioProgramadoAplicado  = "Aplicado"
+	PrecioProgramadoCancelado = "Cancelado"
+)
+
+type HistorialPrecio struct {
+	Id                        int64          `json:"id"`
+	Origen                    string         `json:"origen"`
+	Referencia                *string        `json:"referencia"`
+	PrecioCompraAnterior      *float64       `json:"precioCompraAnterior"`
+	PrecioCompra              float64        `json:"precioCompra"`
+	PrecioVentaAnterior       *float64       `json:"precioVentaAnterior"`
+	PrecioVenta               float64        `json:"precioVenta"`
+	PrecioVentaUnidadAnterior *float64       `json:"precioVentaUnidadAnterior"`
+	PrecioVentaUnidad         *float64       `json:"precioVentaUnidad"`
+	Usuario                   *UsuarioSimple `json:"usuario"`
+	CreatedAt                 time.Time      `json:"createdAt"`
+}
+
+// PrecioProgramadoRequest programa un precio de venta; sin precio por unidad se conserva el vigente
+type PrecioProgramadoRequest struct {
+	ProductoId        uuid.UUID  `json:"productoId"`
+	PrecioVenta       float64    `json:"precioVenta"`
+	PrecioVentaUnidad *float64   `json:"precioVentaUnidad"`
+	FechaEfectiva     *time.Time `json:"fechaEfectiva"`
+	UsuarioId         uint       `json:"-"`
+}
+
+// AprobarPrecioRequest aprueba un precio propuesto; sin fecha se aplica de inmediato
+type AprobarPrecioRequest struct {
+	FechaEfectiva *time.Time `json:"fechaEfectiva"`
+}
+
+type PrecioProgramadoId struct {
+	Id int `json:"id"`
+}
+
+type PrecioProgramadoInfo struct {
+	Id                int            `json:"id"`
+	Producto          ProductoSimple `json:"producto"`
+	PrecioVentaActual float64        `json:"precioVentaActual"`
+	PrecioVenta       float64        `json:"precioVenta"`
+	PrecioVentaUnidad *float64       `json:"precioVentaUnidad"`
+	FechaEfectiva     *time.Time     `json:"fechaEfectiva"`
+	Estado            string         `json:"estado"`
+	CompraId          *int           `json:"compraId"`
+	CompraCodigo      *string        `json:"compraCodigo"`
+	Usuario           *UsuarioSimple `json:"usuario"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	AplicadoAt        *time.Time     `json:"aplicadoAt"`
+}
+
+// Tipos de cambio masivo: porcentaje sobre el precio de venta actual o margen sobre el precio de compra
+const (
+	CambioMasivoPorcentaje = "Porcentaje"
+	CambioMasivoMargen     = "Margen"
+)
+
+// Modos de redondeo de los precios calculados en un cambio masivo
+const (
+	RedondeoCercano = "Cercano"
+	RedondeoArriba  = "Arriba"
+)
+
+// Estados de un cambio masivo de precios
+const (
+	CambioMasivoAplicado  = "Aplicado"
+	CambioMasivoRevertido = "Revertido"
+)
+
+// CambioMasivoRequest selecciona los productos por laboratorio y/o categoría y describe el ajuste a aplicar
+type CambioMasivoRequest struct {
+	LaboratorioId       *int     `json:"laboratorioId"`
+	CategoriaId         *int     `json:"categoriaId"`
+	Tipo                string   `json:"tipo"`
+	Valor               float64  `json:"valor"`
+	Redondeo            *float64 `json:"redondeo"`
+	ModoRedondeo        string   `json:"modoRedondeo"`
+	IncluirPrecioUnidad bool     `json:"incluirPrecioUnidad"`
+	Comentario          *string  `json:"comentario"`
+	UsuarioId           uint     `json:"-"`
+}
+
+// CambioPrecioMasivo es el cambio calculado para un producto, usado en la vista previa y al aplicar
+type CambioPrecioMasivo struct {
+	Producto                  ProductoSimple `json:"producto"`
+	PrecioCompra              float64        `json:"precioCompra"`
+	PrecioVentaAnterior       float64        `json:"precioVentaAnterior"`
+	PrecioVenta               float64        `json:"precioVenta"`
+	PrecioVentaUnidadAnterior *float64       `json:"precioVentaUnidadAnterior"`
+	PrecioVentaUnidad         *float64       `json:"precioVentaUnidad"`
+	Variacion                 float64        `json:"variacion"`
+}
+
+type CambioMasivoId struct {
+	Id int `json:"id"`
+}
+
+type CambioMasivoInfo struct {
+	Id           int                    `json:"id"`
+	Tipo         string                 `json:"tipo"`
+	Valor        float64                `json:"valor"`
+	Redondeo     *float64               `json:"redondeo"`
+	ModoRedondeo string                 `json:"modoRedondeo"`
+	Filtros      map[string]interface{} `json:"filtros"`
+	Comentario   *string                `json:"comentario"`
+	Estado       string                 `json:"estado"`
+	Productos    int                    `json:"productos"`
+	Usuario      UsuarioSimple          `json:"usuario"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	RevertidoPor *UsuarioSimple         `json:"revertidoPor"`
+	RevertidoAt  *time.Time             `json:"revertidoAt"`
+}
+
+type DetalleCambioMasivo struct {
+	Producto                  ProductoSimple `json:"producto"`
+	PrecioVentaAnterior       float64        `json:"precioVentaAnterior"`
+	PrecioVenta               float64        `json:"precioVenta"`
+	PrecioVentaUnidadAnterior *float64       `json:"precioVentaUnidadAnterior"`
+	PrecioVentaUnidad         *float64       `json:"precioVentaUnidad"`
+	Revertido                 bool           `json:"revertido"`
+}
+
+type CambioMasivoDetail struct {
+	CambioMasivoInfo
+	Detalles []DetalleCambioMasivo `json:"detalles"`
+}
+
+// ReversionCambioMasivo indica los productos revertidos; se omiten los que cambiaron de precio después del cambio masivo
+type ReversionCambioMasivo struct {
+	Revertidos int              `json:"revertidos"`
+	Omitidos   []ProductoSimple `json:"omitidos"`
+}
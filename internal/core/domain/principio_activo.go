@@ -7,19 +7,25 @@ type PrincipioActivo struct {
 }
 
 type PrincipioActivoInfo struct {
-	Id          int    `json:"id"`
-	Nombre      string `json:"nombre"`
-	Descripcion string `json:"descripcion,omitempty"`
+	Id                 int     `json:"id"`
+	Nombre             string  `json:"nombre"`
+	Descripcion        string  `json:"descripcion,omitempty"`
+	NivelControl       string  `json:"nivelControl"`
+	Contraindicaciones *string `json:"contraindicaciones,omitempty"`
 }
 type PrincipioActivoRequest struct {
-	Nombre      string `json:"nombre"`
-	Descripcion string `json:"descripcion"`
+	Nombre             string  `json:"nombre"`
+	Descripcion        string  `json:"descripcion"`
+	NivelControl       string  `json:"nivelControl"`
+	Contraindicaciones *string `json:"contraindicaciones"`
 }
 
 type PrincipioActivoDetail struct {
-	Id          int    `json:"id"`
-	Nombre      string `json:"nombre"`
-	Descripcion string `json:"descripcion"`
+	Id                 int     `json:"id"`
+	Nombre             string  `json:"nombre"`
+	Descripcion        string  `json:"descripcion"`
+	NivelControl       string  `json:"nivelControl"`
+	Contraindicaciones *string `json:"contraindicaciones"`
 }
 
 type PrincipioActivoId struct {
@@ -37,11 +37,16 @@ type ProductRequest struct {
 	PrincipiosActivos    []ProductoPrincipioActivoRequest `json:"principiosActivos"`
 	FormaFarmaceuticaId  int                              `json:"formaFarmaceuticaId"`
 	PrecioVenta          float64                          `json:"precioVenta"`
+	PrecioVentaUnidad    *float64                         `json:"precioVentaUnidad"`
 	StockMin             int64                            `json:"stockMin"`
 	PresentacionId       int                              `json:"presentacionId"`
 	UnidadesPresentacion int                              `json:"unidadesPresentacion"`
 	Categorias           []int                            `json:"categorias"`
 	LaboratorioId        int                              `json:"laboratorioId"`
+	NivelControl         string                           `json:"nivelControl"`
+	Refrigerado          bool                             `json:"refrigerado"`
+	Controlado           bool                             `json:"controlado"`
+	CodigosBarra         []CodigoBarraRequest             `json:"codigosBarra"`
 }
 
 type ProductoInfo struct {
@@ -51,15 +56,27 @@ type ProductoInfo struct {
 	Laboratorio          string       `json:"laboratorio"`
 	PrecioCompra         float64      `json:"precioCompra"`
 	PrecioVenta          float64      `json:"precioVenta"`
+	PrecioVentaUnidad    *float64     `json:"precioVentaUnidad"`
 	Stock                int64        `json:"stock"`
 	StockMin             int64        `json:"stockMin"`
 	Estado               string       `json:"estado"`
 	Presentacion         Presentacion `json:"presentacion"`
 	UnidadesPresentacion int          `json:"unidadesPresentacion"`
+	Refrigerado          bool         `json:"refrigerado"`
+	Controlado           bool         `json:"controlado"`
 	UrlFoto              string       `json:"urlFoto,omitempty"`
 	DeletedAt            *time.Time   `json:"deletedAt"`
 }
 
+// ProductoEquivalente es un producto alternativo con los mismos principios activos
+type ProductoEquivalente struct {
+	ProductoInfo
+	Coincidencia           string `json:"coincidencia"`
+	MismaForma             bool   `json:"mismaForma"`
+	PrincipiosCoincidentes int    `json:"principiosCoincidentes"`
+	PrincipiosTotales      int    `json:"principiosTotales"`
+}
+
 type UnidadMedida struct {
 	Id          int    `json:"id"`
 	Nombre      string `json:"nombre"`
@@ -85,12 +102,18 @@ type ProductoDetail struct {
 	PrincipiosActivos    []ProductoPrincipioActivo `json:"principiosActivos"`
 	PrecioCompra         float64                   `json:"precioCompra"`
 	PrecioVenta          float64                   `json:"precioVenta"`
+	PrecioVentaUnidad    *float64                  `json:"precioVentaUnidad"`
 	StockMin             int64                     `json:"stockMin"`
 	Stock                int64                     `json:"stock"`
 	Estado               string                    `json:"estado"`
 	Presentacion         Presentacion              `json:"presentacion"`
 	UnidadesPresentacion int                       `json:"unidadesPresentacion"`
 	UrlFotos             []string                  `json:"urlFotos"`
+	NivelControl         string                    `json:"nivelControl"`
+	NivelControlEfectivo string                    `json:"nivelControlEfectivo"`
+	Refrigerado          bool                      `json:"refrigerado"`
+	Controlado           bool                      `json:"controlado"`
+	CodigosBarra         []CodigoBarra             `json:"codigosBarra"`
 	CreatedAt            time.Time                 `json:"createdAt"`
 	DeletedAt            *time.Time                `json:"deletedAt"`
 }
@@ -104,10 +127,17 @@ type ProductoSimple struct {
 	UnidadesPresentacion int          `json:"unidadesPresentacion,omitempty"`
 }
 
+// Tipos de coincidencia de un producto equivalente
+const (
+	EquivalenteExacto  = "Exacta"
+	EquivalenteParcial = "Parcial"
+)
+
 type ProductoId struct {
 	Id string `json:"id"`
 }
 
 type VentaResponse struct {
-	VentaId int64 `json:"ventaId"`
+	VentaId      int64         `json:"ventaId"`
+	Advertencias []Advertencia `json:"advertencias,omitempty"`
 }
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"time"
+)
+
+// Tipos de promoción soportados
+const (
+	PromocionCompraXLlevaY      = "CompraXLlevaY"
+	PromocionPrecioPaquete      = "PrecioPaquete"
+	PromocionDescuentoCategoria = "DescuentoCategoria"
+)
+
+type PromocionRequest struct {
+	Nombre            string                     `json:"nombre"`
+	Descripcion       *string                    `json:"descripcion"`
+	Tipo              string                     `json:"tipo"`
+	FechaInicio       time.Time                  `json:"fechaInicio"`
+	FechaFin          time.Time                  `json:"fechaFin"`
+	CantidadRequerida int                        `json:"cantidadRequerida"`
+	CantidadGratis    int                        `json:"cantidadGratis"`
+	PrecioPaquete     float64                    `json:"precioPaquete"`
+	Porcentaje        float64                    `json:"porcentaje"`
+	CategoriaId       *int                       `json:"categoriaId"`
+	Productos         []PromocionProductoRequest `json:"productos"`
+}
+
+type PromocionProductoRequest struct {
+	ProductoId string `json:"productoId"`
+	Cantidad   int    `json:"cantidad"`
+}
+
+type PromocionId struct {
+	Id int `json:"id"`
+}
+
+type PromocionInfo struct {
+	Id          int        `json:"id"`
+	Nombre      string     `json:"nombre"`
+	Tipo        string     `json:"tipo"`
+	Estado      string     `json:"estado"`
+	FechaInicio time.Time  `json:"fechaInicio"`
+	FechaFin    time.Time  `json:"fechaFin"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DeletedAt   *time.Time `json:"deletedAt"`
+}
+
+type PromocionDetail struct {
+	PromocionInfo
+	Descripcion       *string             `json:"descripcion"`
+	CantidadRequerida int                 `json:"cantidadRequerida"`
+	CantidadGratis    int                 `json:"cantidadGratis"`
+	PrecioPaquete     float64             `json:"precioPaquete"`
+	Porcentaje        float64             `json:"porcentaje"`
+	Categoria         *CategoriaSimple    `json:"categoria"`
+	Productos         []PromocionProducto `json:"productos"`
+}
+
+type PromocionProducto struct {
+	Producto ProductoSimple `json:"producto"`
+	Cantidad int            `json:"cantidad"`
+}
+
+// PromocionItem representa un producto de la venta con los datos necesarios para evaluar promociones
+type PromocionItem struct {
+	ProductoId  string
+	Cantidad    uint
+	PrecioVenta float64
+	Categorias  []int
+}
+
+// VentaPromocion es una línea de descuento aplicada a una venta
+type VentaPromocion struct {
+	Id          uint    `json:"id,omitempty"`
+	PromocionId int     `json:"promocionId"`
+	Nombre      string  `json:"nombre"`
+	Tipo        string  `json:"tipo"`
+	ProductoId  string  `json:"productoId"`
+	Cantidad    uint    `json:"cantidad"`
+	Subtotal    float64 `json:"subtotal"`
+	Descuento   float64 `json:"descuento"`
+}
+
+type PromocionEfectividad struct {
+	PromocionId    int     `json:"promocionId"`
+	Nombre         string  `json:"nombre"`
+	Tipo           string  `json:"tipo"`
+	CantidadVentas int     `json:"cantidadVentas"`
+	Unidades       int     `json:"unidades"`
+	Subtotal       float64 `json:"subtotal"`
+	Descuento      float64 `json:"descuento"`
+	Ingreso        float64 `json:"ingreso"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+)
+
+// Niveles de control de dispensación de productos y principios activos
+const (
+	ControlLibre      = "Libre"
+	ControlReceta     = "Receta"
+	ControlControlado = "Controlado"
+)
+
+type RecetaRequest struct {
+	UsuarioId       uint                   `json:"-"`
+	MedicoNombre    string                 `json:"medicoNombre"`
+	MedicoMatricula string                 `json:"medicoMatricula"`
+	PacienteNombre  string                 `json:"pacienteNombre"`
+	PacienteCi      *string                `json:"pacienteCi"`
+	FechaEmision    time.Time              `json:"fechaEmision"`
+	Detalles        []RecetaDetalleRequest `json:"detalles"`
+}
+
+// RecetaDetalleRequest indica la cantidad prescrita en unidades base (comprimidos, ampollas)
+type RecetaDetalleRequest struct {
+	ProductoId string `json:"productoId"`
+	Cantidad   uint   `json:"cantidad"`
+}
+
+type RecetaId struct {
+	Id int `json:"id"`
+}
+
+type RecetaInfo struct {
+	Id              int           `json:"id"`
+	MedicoNombre    string        `json:"medicoNombre"`
+	MedicoMatricula string        `json:"medicoMatricula"`
+	PacienteNombre  string        `json:"pacienteNombre"`
+	PacienteCi      *string       `json:"pacienteCi"`
+	FechaEmision    time.Time     `json:"fechaEmision"`
+	Imagen          *string       `json:"imagen"`
+	Usuario         UsuarioSimple `json:"usuario"`
+	CreatedAt       time.Time     `json:"createdAt"`
+}
+
+type RecetaDetail struct {
+	RecetaInfo
+	Detalles []RecetaDetalle `json:"detalles"`
+}
+
+type RecetaDetalle struct {
+	Producto           ProductoSimple `json:"producto"`
+	NivelControl       string         `json:"nivelControl"`
+	CantidadPrescrita  uint           `json:"cantidadPrescrita"`
+	CantidadDispensada uint           `json:"cantidadDispensada"`
+}
+
+// RegistroControlado es una línea del libro de registro de sustancias controladas
+type RegistroControlado struct {
+	Fecha           time.Time `json:"fecha"`
+	VentaCodigo     string    `json:"ventaCodigo"`
+	Producto        string    `json:"producto"`
+	Laboratorio     string    `json:"laboratorio"`
+	Lote            string    `json:"lote"`
+	Cantidad        uint      `json:"cantidad"`
+	RecetaId        int       `json:"recetaId"`
+	PacienteNombre  string    `json:"pacienteNombre"`
+	PacienteCi      *string   `json:"pacienteCi"`
+	MedicoNombre    string    `json:"medicoNombre"`
+	MedicoMatricula string    `json:"medicoMatricula"`
+	Usuario         string    `json:"usuario"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// Origen del retiro de un lote
+const (
+	RetiroOrigenLaboratorio = "Laboratorio"
+	RetiroOrigenRegulador   = "Regulador"
+	RetiroOrigenInterno     = "Interno"
+)
+
+// Estado en que queda un lote retirado del mercado, excluido de la venta
+const LoteEstadoCuarentena = "Cuarentena"
+
+type RetiroLoteRequest struct {
+	LoteId     int     `json:"loteId"`
+	Origen     string  `json:"origen"`
+	Referencia *string `json:"referencia"`
+	Motivo     string  `json:"motivo"`
+	UsuarioId  uint    `json:"-"`
+}
+
+type RetiroLoteId struct {
+	Id int `json:"id"`
+}
+
+type RetiroLoteInfo struct {
+	Id              int                `json:"id"`
+	Codigo          string             `json:"codigo"`
+	Origen          string             `json:"origen"`
+	Referencia      *string            `json:"referencia"`
+	Motivo          string             `json:"motivo"`
+	StockCuarentena int64              `json:"stockCuarentena"`
+	Lote            LoteProductoSimple `json:"lote"`
+	Producto        ProductoSimple     `json:"producto"`
+	Usuario         UsuarioSimple      `json:"usuario"`
+	CreatedAt       time.Time          `json:"createdAt"`
+}
+
+type RetiroLoteDetail struct {
+	RetiroLoteInfo
+	Ventas []VentaLoteRetirado `json:"ventas"`
+}
+
+// VentaLoteRetirado es una venta que dispensó el lote, con los datos de contacto del cliente
+type VentaLoteRetirado struct {
+	VentaId  int             `json:"ventaId"`
+	Codigo   *string         `json:"codigo"`
+	Fecha    time.Time       `json:"fecha"`
+	Cantidad int64           `json:"cantidad"`
+	Cliente  ClienteContacto `json:"cliente"`
+}
+
+type ClienteContacto struct {
+	Id          uint    `json:"id"`
+	RazonSocial string  `json:"razonSocial"`
+	NitCi       *uint   `json:"nitCi"`
+	Email       *string `json:"email"`
+	Telefono    *string `json:"telefono"`
+}
@@ -5,11 +5,13 @@ import (
 )
 
 type Rol struct {
-	Id        int32      `json:"id"`
-	Nombre    string     `json:"nombre"`
-	Estado    string     `json:"estado"`
-	CreatedAt time.Time  `json:"createdAt"`
-	DeletedAt *time.Time `json:"deletedAt"`
+	Id                  int32      `json:"id"`
+	Nombre              string     `json:"nombre"`
+	Estado              string     `json:"estado"`
+	RequiereDobleFactor bool       `json:"requiereDobleFactor"`
+	EsSistema           bool       `json:"esSistema"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	DeletedAt           *time.Time `json:"deletedAt"`
 }
 
 type RolRequest struct {
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Motivos de revocación de una sesión
+const (
+	SesionMotivoLogout         = "Cierre de sesión"
+	SesionMotivoLogoutTodas    = "Cierre de todas las sesiones"
+	SesionMotivoAdministrador  = "Revocada por un administrador"
+	SesionMotivoDeshabilitado  = "Usuario deshabilitado"
+	SesionMotivoCambioPassword = "Cambio de contraseña"
+	SesionMotivoReutilizacion  = "Reutilización del token de actualización"
+)
+
+// SesionCliente identifica el dispositivo desde el que se inicia o renueva una sesión
+type SesionCliente struct {
+	Dispositivo *string
+	Ip          string
+	UserAgent   string
+}
+
+type SesionInfo struct {
+	Id               uuid.UUID     `json:"id"`
+	Usuario          UsuarioSimple `json:"usuario"`
+	Dispositivo      *string       `json:"dispositivo"`
+	Ip               *string       `json:"ip"`
+	UserAgent        *string       `json:"userAgent"`
+	CreatedAt        time.Time     `json:"createdAt"`
+	UltimoAcceso     time.Time     `json:"ultimoAcceso"`
+	ExpiraAt         time.Time     `json:"expiraAt"`
+	RevocadaAt       *time.Time    `json:"revocadaAt"`
+	MotivoRevocacion *string       `json:"motivoRevocacion"`
+	Actual           bool          `json:"actual"`
+}
+
+// RotacionSesion es el resultado de renovar una sesión con su token de actualización;
+// sin RefreshTokenId el token ya fue rotado por una petición concurrente y se conserva el vigente
+type RotacionSesion struct {
+	UsuarioId      int
+	Username       string
+	RefreshTokenId *uuid.UUID
+}
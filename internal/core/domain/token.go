@@ -2,10 +2,23 @@ package domain
 
 import "time"
 
+// Pasos pendientes del inicio de sesión antes de crear la sesión
+const (
+	PasoVerificarDobleFactor  = "VerificarDobleFactor"
+	PasoConfigurarDobleFactor = "ConfigurarDobleFactor"
+	PasoCambiarPassword       = "CambiarPassword"
+)
+
 type TokenResponse struct {
 	Message         string    `json:"message"`
 	AccessToken     string    `json:"-"`
 	RefreshToken    string    `json:"-"`
 	ExpAccessToken  time.Time `json:"-"`
 	ExpRefreshToken time.Time `json:"-"`
+	// Falso si el token de actualización vigente no cambió
+	RefreshRotado bool `json:"-"`
+	// Si no está vacío el inicio de sesión continúa con ese paso usando el token de desafío
+	Paso                string   `json:"paso,omitempty"`
+	TokenDesafio        string   `json:"tokenDesafio,omitempty"`
+	CodigosRecuperacion []string `json:"codigosRecuperacion,omitempty"`
 }
@@ -0,0 +1,112 @@
+package domain
+
+import "time"
+
+// Tipos de ubicación según su nivel en la jerarquía almacén -> estante -> nivel
+const (
+	UbicacionAlmacen = "Almacen"
+	UbicacionEstante = "Estante"
+	UbicacionNivel   = "Nivel"
+)
+
+type UbicacionRequest struct {
+	Codigo      string `json:"codigo"`
+	Nombre      string `json:"nombre"`
+	Tipo        string `json:"tipo"`
+	PadreId     *int   `json:"padreId"`
+	Refrigerado bool   `json:"refrigerado"`
+	Controlado  bool   `json:"controlado"`
+}
+
+type UbicacionId struct {
+	Id int `json:"id"`
+}
+
+type UbicacionInfo struct {
+	Id          int        `json:"id"`
+	Codigo      string     `json:"codigo"`
+	Nombre      string     `json:"nombre"`
+	Tipo        string     `json:"tipo"`
+	PadreId     *int       `json:"padreId"`
+	Ruta        string     `json:"ruta"`
+	Refrigerado bool       `json:"refrigerado"`
+	Controlado  bool       `json:"controlado"`
+	Stock       int64      `json:"stock"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DeletedAt   *time.Time `json:"deletedAt"`
+}
+
+type UbicacionSimple struct {
+	Id     int    `json:"id"`
+	Codigo string `json:"codigo"`
+	Ruta   string `json:"ruta,omitempty"`
+}
+
+// StockUbicacion es la cantidad en unidades base de un lote guardada en una ubicación
+type StockUbicacion struct {
+	Ubicacion UbicacionSimple    `json:"ubicacion"`
+	Lote      LoteProductoSimple `json:"lote"`
+	Producto  ProductoSimple     `json:"producto"`
+	Cantidad  int64              `json:"cantidad"`
+}
+
+// LoteUbicacion es la cantidad de un lote guardada en una ubicación
+type LoteUbicacion struct {
+	Ubicacion UbicacionSimple `json:"ubicacion"`
+	Cantidad  int64           `json:"cantidad"`
+}
+
+// DetalleTrasladoRequest mueve unidades base de un lote; sin origen se ubica stock que aún no tiene ubicación
+type DetalleTrasladoRequest struct {
+	LoteId    int  `json:"loteId"`
+	OrigenId  *int `json:"origenId"`
+	DestinoId int  `json:"destinoId"`
+	Cantidad  int  `json:"cantidad"`
+}
+
+type TrasladoRequest struct {
+	Comentario *string                  `json:"comentario"`
+	UsuarioId  uint                     `json:"-"`
+	Detalles   []DetalleTrasladoRequest `json:"detalles"`
+}
+
+type TrasladoId struct {
+	Id int `json:"id"`
+}
+
+type TrasladoInfo struct {
+	Id         int           `json:"id"`
+	Codigo     string        `json:"codigo"`
+	Comentario *string       `json:"comentario"`
+	Usuario    UsuarioSimple `json:"usuario"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
+type DetalleTrasladoDetail struct {
+	Id       int                `json:"id"`
+	Lote     LoteProductoSimple `json:"lote"`
+	Producto ProductoSimple     `json:"producto"`
+	Origen   *UbicacionSimple   `json:"origen"`
+	Destino  UbicacionSimple    `json:"destino"`
+	Cantidad int64              `json:"cantidad"`
+}
+
+type TrasladoDetail struct {
+	TrasladoInfo
+	Detalles []DetalleTrasladoDetail `json:"detalles"`
+}
+
+// PickingItem es una línea de la lista de recolección; sin ubicación corresponde a stock no ubicado
+type PickingItem struct {
+	Ubicacion *UbicacionSimple   `json:"ubicacion"`
+	Producto  ProductoSimple     `json:"producto"`
+	Lote      LoteProductoSimple `json:"lote"`
+	Cantidad  int64              `json:"cantidad"`
+}
+
+type PickingVenta struct {
+	VentaId int           `json:"ventaId"`
+	Codigo  *string       `json:"codigo"`
+	Fecha   time.Time     `json:"fecha"`
+	Items   []PickingItem `json:"items"`
+}
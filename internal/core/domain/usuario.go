@@ -17,6 +17,11 @@ type Usuario struct {
 	PersonaId uint       `json:"-"`
 	Persona   Persona    `json:"-"`
 	Roles     []Rol      `json:"-"`
+	// Intentos fallidos consecutivos y bloqueo temporal por fuerza bruta
+	IntentosFallidos int        `json:"-"`
+	BloqueadoHasta   *time.Time `json:"-"`
+	// Tras un restablecimiento o el registro la contraseña debe cambiarse al iniciar sesión
+	DebeCambiarPassword bool `json:"-"`
 }
 
 // LoginRequest se usa para las peticiones de autenticación de los usuarios.
@@ -56,6 +61,7 @@ type UsuarioDetail struct {
 	DeletedAt *time.Time `json:"deletedAt"`
 	Persona   Persona    `json:"persona"`
 	Roles     []RolInfo  `json:"roles"`
+	Permisos  []string   `json:"permisos,omitempty"`
 }
 
 // UsuarioRequest se usa para las peticiones de creación o modificación de un usuario.
@@ -75,3 +81,25 @@ type FirebaseLogin struct {
 type UsuarioResetPassword struct {
 	NewPassword string `json:"newPassword"`
 }
+
+type CambiarPasswordRequest struct {
+	PasswordActual string `json:"passwordActual"`
+	PasswordNueva  string `json:"passwordNueva"`
+}
+
+// DesafioPasswordRequest completa el inicio de sesión de un usuario que debe cambiar su contraseña
+type DesafioPasswordRequest struct {
+	TokenDesafio  string `json:"tokenDesafio"`
+	PasswordNueva string `json:"passwordNueva"`
+}
+
+// PoliticaPassword son los requisitos que debe cumplir una contraseña nueva
+type PoliticaPassword struct {
+	LongitudMinima    int  `json:"longitudMinima"`
+	RequiereMayuscula bool `json:"requiereMayuscula"`
+	RequiereMinuscula bool `json:"requiereMinuscula"`
+	RequiereNumero    bool `json:"requiereNumero"`
+	RequiereSimbolo   bool `json:"requiereSimbolo"`
+	// Cantidad de contraseñas anteriores que no se pueden reutilizar
+	Historial int `json:"historial"`
+}
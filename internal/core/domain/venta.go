@@ -12,43 +12,71 @@ type VentaRequest struct {
 	TipoPago  string                `json:"tipoPago"`
 	Descuento float64               `json:"descuento"`
 	Detalles  []DetalleVentaRequest `json:"detalles"`
+	// Receta médica para productos bajo control
+	RecetaId *int `json:"recetaId"`
+	// Autorización del farmacéutico para interacciones graves
+	AutorizacionInteraccion *AutorizacionInteraccion `json:"autorizacionInteraccion"`
+	// Interacciones y contraindicaciones detectadas entre los productos
+	Advertencias        []Advertencia `json:"-"`
+	InteraccionesGraves []int         `json:"-"`
+	// Líneas de descuento calculadas a partir de las promociones vigentes
+	Promociones []VentaPromocion `json:"-"`
+	// Vencimiento de la reserva de stock de una venta en espera
+	ReservaHasta time.Time `json:"-"`
 }
 
+// Unidades de venta de un detalle: presentación completa (caja, frasco) o unidad suelta
+const (
+	UnidadVentaPresentacion = "Presentacion"
+	UnidadVentaUnidad       = "Unidad"
+)
+
 type DetalleVentaRequest struct {
 	ProductoId string `json:"productoId"`
 	Cantidad   uint   `json:"cantidad"`
+	// Presentacion (por defecto) o Unidad
+	UnidadVenta string `json:"unidadVenta"`
+	// Lotes reservados que se entregan; sin lotes se asignan por FEFO
+	Lotes []VentaLoteAsignacion `json:"-"`
+	// Precio acordado al cotizar, reservar o pedir; sin precio se cobra el precio vigente
+	Precio *float64 `json:"-"`
 }
 
 type VentaInfo struct {
-	Id           uint                 `json:"id"`
-	Codigo       pgtype.Text          `json:"codigo"`
-	Usuario      UsuarioSimple        `json:"usuario"`
-	Cliente      ClienteSimple        `json:"cliente"`
-	Fecha        time.Time            `json:"fecha"`
-	Estado       string               `json:"estado"`
-	Total        float64              `json:"total"`
-	TipoPago     string               `json:"tipoPago"`
-	Descuento    float64              `json:"descuento"`
-	DeletedAt    *time.Time           `json:"deletedAt"`
-	UrlFactura   *string              `json:"url"`
-	DetallesInfo []DetalleVentaDetail `json:"-"`
+	Id                 uint                 `json:"id"`
+	Codigo             pgtype.Text          `json:"codigo"`
+	Usuario            UsuarioSimple        `json:"usuario"`
+	Cliente            ClienteSimple        `json:"cliente"`
+	Fecha              time.Time            `json:"fecha"`
+	Estado             string               `json:"estado"`
+	Total              float64              `json:"total"`
+	TipoPago           string               `json:"tipoPago"`
+	Descuento          float64              `json:"descuento"`
+	DescuentoPromocion float64              `json:"descuentoPromocion"`
+	DeletedAt          *time.Time           `json:"deletedAt"`
+	UrlFactura         *string              `json:"url"`
+	DetallesInfo       []DetalleVentaDetail `json:"-"`
 }
 
 type VentaDetail struct {
 	VentaInfo
 
-	Detalles []DetalleVentaDetail `json:"detalles"`
+	Detalles    []DetalleVentaDetail `json:"detalles"`
+	Promociones []VentaPromocion     `json:"promociones"`
+	RecetaId    *int                 `json:"recetaId"`
 }
 
 type DetalleVentaDetail struct {
-	Id        uint           `json:"id"`
-	Producto  ProductoSimple `json:"producto"`
-	Lotes     []VentaLote    `json:"lotes"`
-	Cantidad  uint           `json:"cantidad"`
-	Precio    float64        `json:"precio"`
-	Total     float64        `json:"total"`
-	TipoPago  string         `json:"tipoPago"`
-	Descuento float64        `json:"descuento"`
+	Id               uint           `json:"id"`
+	Producto         ProductoSimple `json:"producto"`
+	Lotes            []VentaLote    `json:"lotes"`
+	Cantidad         uint           `json:"cantidad"`
+	UnidadVenta      string         `json:"unidadVenta"`
+	CantidadUnidades uint           `json:"cantidadUnidades"`
+	Precio           float64        `json:"precio"`
+	Total            float64        `json:"total"`
+	TipoPago         string         `json:"tipoPago"`
+	Descuento        float64        `json:"descuento"`
 }
 
 type VentaLoteProducto struct {
@@ -65,7 +93,19 @@ type VentaLote struct {
 }
 
 type VentaLoteProductoDAO struct {
-	Id          uint
-	Stock       uint
-	PrecioVenta float64
+	Id                   uint
+	Lote                 string
+	FechaVencimiento     time.Time
+	Stock                uint
+	PrecioVenta          float64
+	UnidadesPresentacion uint
+	PrecioVentaUnidad    *float64
+	// Unidades base que representa cada unidad vendida del lote
+	Factor uint
+}
+
+// VentaLoteAsignacion es la cantidad tomada de un lote al asignar stock por FEFO
+type VentaLoteAsignacion struct {
+	Lote     VentaLoteProductoDAO
+	Cantidad uint
 }
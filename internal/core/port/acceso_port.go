@@ -0,0 +1,30 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AccesoRepository interface {
+	RegistrarIntentoLogin(ctx context.Context, intento *domain.IntentoLogin) error
+	RegistrarFalloLogin(ctx context.Context, usuarioId int, maxIntentos int, bloqueo, bloqueoMaximo time.Duration) (int, *time.Time, error)
+	ReiniciarIntentosLogin(ctx context.Context, usuarioId int) error
+	DesbloquearUsuario(ctx context.Context, usuarioId *int) error
+	ObtenerListaIntentosLogin(ctx context.Context, filtros map[string]string) (*[]domain.IntentoLoginInfo, error)
+	ObtenerActividadSospechosa(ctx context.Context, desde time.Time, minimoFallidos int) (*[]domain.ActividadSospechosa, error)
+}
+
+type AccesoService interface {
+	ObtenerListaIntentosLogin(ctx context.Context, filtros map[string]string) (*[]domain.IntentoLoginInfo, error)
+	ObtenerActividadSospechosa(ctx context.Context, filtros map[string]string) (*[]domain.ActividadSospechosa, error)
+	DesbloquearUsuario(ctx context.Context, usuarioId *int) error
+}
+
+type AccesoHandler interface {
+	ObtenerListaIntentosLogin(c *fiber.Ctx) error
+	ObtenerActividadSospechosa(c *fiber.Ctx) error
+	DesbloquearUsuario(c *fiber.Ctx) error
+}
@@ -0,0 +1,23 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditoriaRepository interface {
+	ObtenerListaAuditoria(ctx context.Context, filtros map[string]string) (*[]domain.AuditoriaInfo, error)
+	ObtenerAuditoriaById(ctx context.Context, id *int64) (*domain.AuditoriaInfo, error)
+}
+
+type AuditoriaService interface {
+	ObtenerListaAuditoria(ctx context.Context, filtros map[string]string) (*[]domain.AuditoriaInfo, error)
+	ObtenerAuditoriaById(ctx context.Context, id *int64) (*domain.AuditoriaInfo, error)
+}
+
+type AuditoriaHandler interface {
+	ObtenerListaAuditoria(c *fiber.Ctx) error
+	ObtenerAuditoriaById(c *fiber.Ctx) error
+}
@@ -8,7 +8,14 @@ import (
 )
 
 type AuthService interface {
-	ObtenerTokenByCredencial(ctx context.Context, credentials *domain.LoginRequest) (*domain.TokenResponse, error)
+	ObtenerTokenByCredencial(ctx context.Context, credentials *domain.LoginRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error)
+	RenovarToken(ctx context.Context, refreshToken string, cliente *domain.SesionCliente) (*domain.TokenResponse, error)
+	VerificarDobleFactor(ctx context.Context, request *domain.DesafioDobleFactorRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error)
+	IniciarDobleFactorDesafio(ctx context.Context, tokenDesafio string, cliente *domain.SesionCliente) (*domain.DobleFactorConfiguracion, error)
+	ConfirmarDobleFactorDesafio(ctx context.Context, request *domain.DesafioDobleFactorRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error)
+	CambiarPasswordDesafio(ctx context.Context, request *domain.DesafioPasswordRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error)
+	CerrarSesion(ctx context.Context, refreshToken string) error
+	RegistrarCuentaCliente(ctx context.Context, usuarioUid string, email string) error
 }
 
 type AuthHandler interface {
@@ -16,6 +23,10 @@ type AuthHandler interface {
 	LoginWithGoogle(c *fiber.Ctx) error
 	LoginWithEmail(c *fiber.Ctx) error
 	Login(c *fiber.Ctx) error
+	VerificarDobleFactor(c *fiber.Ctx) error
+	IniciarDobleFactorDesafio(c *fiber.Ctx) error
+	ConfirmarDobleFactorDesafio(c *fiber.Ctx) error
+	CambiarPasswordDesafio(c *fiber.Ctx) error
 	Logout(c *fiber.Ctx) error
 	RefreshOrVerify(c *fiber.Ctx) error
 }
@@ -14,6 +14,11 @@ type ClienteRepository interface {
 	ModificarClienteById(ctx context.Context, id *int, request *domain.ClienteRequest) error
 	HabilitarCliente(ctx context.Context, id *int) error
 	DeshabilitarCliente(ctx context.Context, id *int) error
+	RegistrarCuentaCliente(ctx context.Context, usuarioUid string, email string) error
+	ObtenerCuentaCliente(ctx context.Context, usuarioUid string) (*domain.ClienteCuenta, error)
+	ObtenerListaCuentasCliente(ctx context.Context, filtros map[string]string) (*[]domain.ClienteCuenta, error)
+	GuardarPerfilCliente(ctx context.Context, usuarioUid string, request *domain.PerfilClienteRequest) error
+	VerificarCuentaCliente(ctx context.Context, usuarioUid string, codigoVenta *string) error
 }
 
 type ClienteService interface {
@@ -23,6 +28,11 @@ type ClienteService interface {
 	ModificarClienteById(ctx context.Context, id *int, request *domain.ClienteRequest) error
 	HabilitarCliente(ctx context.Context, id *int) error
 	DeshabilitarCliente(ctx context.Context, id *int) error
+	ObtenerPerfil(ctx context.Context) (*domain.ClienteCuenta, error)
+	GuardarPerfil(ctx context.Context, request *domain.PerfilClienteRequest) error
+	VerificarPerfil(ctx context.Context, request *domain.VerificarCuentaRequest) error
+	ObtenerListaCuentasCliente(ctx context.Context, filtros map[string]string) (*[]domain.ClienteCuenta, error)
+	VerificarCuentaCliente(ctx context.Context, usuarioUid string) error
 }
 
 type ClienteHandler interface {
@@ -32,4 +42,9 @@ type ClienteHandler interface {
 	ModificarClienteById(c *fiber.Ctx) error
 	HabilitarCliente(c *fiber.Ctx) error
 	DeshabilitarCliente(c *fiber.Ctx) error
+	ObtenerPerfil(c *fiber.Ctx) error
+	GuardarPerfil(c *fiber.Ctx) error
+	VerificarPerfil(c *fiber.Ctx) error
+	ObtenerListaCuentasCliente(c *fiber.Ctx) error
+	VerificarCuentaCliente(c *fiber.Ctx) error
 }
@@ -11,7 +11,7 @@ type CompraRepository interface {
 	RegistrarOrdenCompra(ctx context.Context, request *domain.CompraRequest) (*uint, error)
 	ModificarOrdenCompra(ctx context.Context, id *int, request *domain.CompraRequest) error
 	AnularOrdenCompra(ctx context.Context, id *int) error
-	RegistrarCompra(ctx context.Context, id *int) error
+	RegistrarCompra(ctx context.Context, id *int, proponerPrecioVenta bool) error
 	ObtenerListaCompras(ctx context.Context, filtros map[string]string) (*[]domain.CompraInfo, error)
 	ObtenerCompraById(ctx context.Context, id *int) (*domain.CompraDetail, error)
 }
@@ -20,7 +20,7 @@ type CompraService interface {
 	RegistrarOrdenCompra(ctx context.Context, request *domain.CompraRequest) (*uint, error)
 	ModificarOrdenCompra(ctx context.Context, id *int, request *domain.CompraRequest) error
 	AnularOrdenCompra(ctx context.Context, id *int) error
-	RegistrarCompra(ctx context.Context, id *int) error
+	RegistrarCompra(ctx context.Context, id *int, proponerPrecioVenta bool) error
 	ObtenerListaCompras(ctx context.Context, filtros map[string]string) (*[]domain.CompraInfo, error)
 	ObtenerCompraById(ctx context.Context, id *int) (*domain.CompraDetail, error)
 }
@@ -0,0 +1,44 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CuentaServicioRepository interface {
+	ListarCuentasServicio(ctx context.Context) (*[]domain.CuentaServicioInfo, error)
+	ObtenerCuentaServicioById(ctx context.Context, cuentaId *int) (*domain.CuentaServicioDetail, error)
+	RegistrarCuentaServicio(ctx context.Context, request *domain.CuentaServicioRequest, hashPassword string) (*int, error)
+	ModificarCuentaServicio(ctx context.Context, cuentaId *int, request *domain.CuentaServicioRequest) error
+	HabilitarCuentaServicio(ctx context.Context, cuentaId *int) error
+	DeshabilitarCuentaServicio(ctx context.Context, cuentaId *int) error
+	RegistrarApiKey(ctx context.Context, cuentaId *int, request *domain.ApiKeyRequest, prefijo, hash string) (*domain.ApiKeyInfo, error)
+	RevocarApiKey(ctx context.Context, cuentaId *int, apiKeyId *int) error
+	ObtenerApiKeyVigente(ctx context.Context, prefijo string) (*domain.ApiKeyAutenticada, error)
+	RegistrarUsoApiKey(ctx context.Context, apiKeyId int, ip string) error
+}
+
+type CuentaServicioService interface {
+	ListarCuentasServicio(ctx context.Context) (*[]domain.CuentaServicioInfo, error)
+	ObtenerCuentaServicioById(ctx context.Context, cuentaId *int) (*domain.CuentaServicioDetail, error)
+	RegistrarCuentaServicio(ctx context.Context, request *domain.CuentaServicioRequest) (*domain.CuentaServicioDetail, error)
+	ModificarCuentaServicio(ctx context.Context, cuentaId *int, request *domain.CuentaServicioRequest) error
+	HabilitarCuentaServicio(ctx context.Context, cuentaId *int) error
+	DeshabilitarCuentaServicio(ctx context.Context, cuentaId *int) error
+	GenerarApiKey(ctx context.Context, cuentaId *int, request *domain.ApiKeyRequest) (*domain.ApiKeyCreada, error)
+	RevocarApiKey(ctx context.Context, cuentaId *int, apiKeyId *int) error
+	AutenticarApiKey(ctx context.Context, key string, ip string) (*domain.ApiKeyAutenticada, error)
+}
+
+type CuentaServicioHandler interface {
+	ListarCuentasServicio(c *fiber.Ctx) error
+	ObtenerCuentaServicioById(c *fiber.Ctx) error
+	RegistrarCuentaServicio(c *fiber.Ctx) error
+	ModificarCuentaServicio(c *fiber.Ctx) error
+	HabilitarCuentaServicio(c *fiber.Ctx) error
+	DeshabilitarCuentaServicio(c *fiber.Ctx) error
+	GenerarApiKey(c *fiber.Ctx) error
+	RevocarApiKey(c *fiber.Ctx) error
+}
@@ -0,0 +1,39 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DobleFactorRepository interface {
+	ObtenerDobleFactor(ctx context.Context, usuarioId int) (*domain.DobleFactorUsuario, error)
+	GuardarSecretoDobleFactor(ctx context.Context, usuarioId int, secreto string) error
+	ActivarDobleFactor(ctx context.Context, usuarioId int, paso int64, codigos []string) error
+	RegistrarPasoDobleFactor(ctx context.Context, usuarioId int, paso int64) (bool, error)
+	UsarCodigoRecuperacion(ctx context.Context, usuarioId int, codigoHash string) (bool, error)
+	ReemplazarCodigosRecuperacion(ctx context.Context, usuarioId int, codigos []string) error
+	QuitarDobleFactor(ctx context.Context, usuarioId *int) error
+	ConsumirDesafioLogin(ctx context.Context, desafioId uuid.UUID, usuarioId int, expiraAt time.Time) (bool, error)
+}
+
+type DobleFactorService interface {
+	ObtenerEstado(ctx context.Context) (*domain.DobleFactorEstado, error)
+	IniciarConfiguracion(ctx context.Context) (*domain.DobleFactorConfiguracion, error)
+	ActivarDobleFactor(ctx context.Context, request *domain.DobleFactorCodigoRequest) (*domain.CodigosRecuperacion, error)
+	DesactivarDobleFactor(ctx context.Context, request *domain.DobleFactorCodigoRequest) error
+	RegenerarCodigosRecuperacion(ctx context.Context, request *domain.DobleFactorCodigoRequest) (*domain.CodigosRecuperacion, error)
+	RestablecerDobleFactor(ctx context.Context, usuarioId *int) error
+}
+
+type DobleFactorHandler interface {
+	ObtenerEstado(c *fiber.Ctx) error
+	IniciarConfiguracion(c *fiber.Ctx) error
+	ActivarDobleFactor(c *fiber.Ctx) error
+	DesactivarDobleFactor(c *fiber.Ctx) error
+	RegenerarCodigosRecuperacion(c *fiber.Ctx) error
+	RestablecerDobleFactor(c *fiber.Ctx) error
+}
@@ -0,0 +1,27 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportacionRepository interface {
+	ImportarProductos(ctx context.Context, filas *[]domain.ProductoImportacion, dryRun bool) (*domain.ResultadoImportacion, error)
+	ObtenerProductosExportacion(ctx context.Context) (*[]domain.ProductoImportacion, error)
+	ImportarStockInicial(ctx context.Context, filas *[]domain.StockInicialImportacion, usuarioId int, dryRun bool) (*domain.ResultadoImportacion, error)
+}
+
+type ImportacionService interface {
+	ImportarProductos(ctx context.Context, fileHeader *multipart.FileHeader, dryRun bool) (*domain.ResultadoImportacion, error)
+	ExportarProductos(ctx context.Context, formato string) ([]byte, string, error)
+	ImportarStockInicial(ctx context.Context, fileHeader *multipart.FileHeader, dryRun bool) (*domain.ResultadoImportacion, error)
+}
+
+type ImportacionHandler interface {
+	ImportarProductos(c *fiber.Ctx) error
+	ExportarProductos(c *fiber.Ctx) error
+	ImportarStockInicial(c *fiber.Ctx) error
+}
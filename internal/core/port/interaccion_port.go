@@ -0,0 +1,33 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InteraccionRepository interface {
+	ObtenerListaInteracciones(ctx context.Context, filtros map[string]string) (*[]domain.InteraccionInfo, error)
+	ObtenerInteraccionById(ctx context.Context, id *int) (*domain.InteraccionInfo, error)
+	RegistrarInteraccion(ctx context.Context, request *domain.InteraccionRequest) (*int, error)
+	ModificarInteraccion(ctx context.Context, id *int, request *domain.InteraccionRequest) error
+	EliminarInteraccion(ctx context.Context, id *int) error
+	ObtenerAdvertenciasProductos(ctx context.Context, productoIds []string) (*[]domain.Advertencia, error)
+}
+
+type InteraccionService interface {
+	ObtenerListaInteracciones(ctx context.Context, filtros map[string]string) (*[]domain.InteraccionInfo, error)
+	ObtenerInteraccionById(ctx context.Context, id *int) (*domain.InteraccionInfo, error)
+	RegistrarInteraccion(ctx context.Context, request *domain.InteraccionRequest) (*int, error)
+	ModificarInteraccion(ctx context.Context, id *int, request *domain.InteraccionRequest) error
+	EliminarInteraccion(ctx context.Context, id *int) error
+}
+
+type InteraccionHandler interface {
+	ObtenerListaInteracciones(c *fiber.Ctx) error
+	ObtenerInteraccionById(c *fiber.Ctx) error
+	RegistrarInteraccion(c *fiber.Ctx) error
+	ModificarInteraccion(c *fiber.Ctx) error
+	EliminarInteraccion(c *fiber.Ctx) error
+}
@@ -0,0 +1,50 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PedidoRepository interface {
+	ObtenerCarrito(ctx context.Context, usuarioUid string) (*domain.Carrito, error)
+	GuardarItemCarrito(ctx context.Context, usuarioUid string, request *domain.CarritoItemRequest) error
+	VaciarCarrito(ctx context.Context, usuarioUid string) error
+	RegistrarPedido(ctx context.Context, request *domain.PedidoRequest, fechaLimite time.Time) (*int, error)
+	ObtenerListaPedidos(ctx context.Context, filtros map[string]string) (*[]domain.PedidoInfo, error)
+	ObtenerPedidoById(ctx context.Context, id *int) (*domain.PedidoDetail, error)
+	ActualizarEstadoPedido(ctx context.Context, id *int, estado string, estadosPrevios []string) error
+	EntregarPedido(ctx context.Context, id *int, request *domain.VentaRequest) (*int64, error)
+	CancelarPedidosVencidos(ctx context.Context) (int64, error)
+}
+
+type PedidoService interface {
+	ObtenerCarrito(ctx context.Context) (*domain.Carrito, error)
+	GuardarItemCarrito(ctx context.Context, request *domain.CarritoItemRequest) error
+	VaciarCarrito(ctx context.Context) error
+	RegistrarPedido(ctx context.Context, request *domain.PedidoRequest) (*int, error)
+	ObtenerMisPedidos(ctx context.Context) (*[]domain.PedidoInfo, error)
+	ObtenerMiPedidoById(ctx context.Context, id *int) (*domain.PedidoDetail, error)
+	CancelarMiPedido(ctx context.Context, id *int) error
+	ObtenerListaPedidos(ctx context.Context, filtros map[string]string) (*[]domain.PedidoInfo, error)
+	ObtenerPedidoById(ctx context.Context, id *int) (*domain.PedidoDetail, error)
+	ActualizarEstadoPedido(ctx context.Context, id *int, request *domain.PedidoEstadoRequest) error
+	EntregarPedido(ctx context.Context, id *int, request *domain.EntregarPedidoRequest) (*int64, error)
+	CancelarPedidosVencidos(ctx context.Context) error
+}
+
+type PedidoHandler interface {
+	ObtenerCarrito(c *fiber.Ctx) error
+	GuardarItemCarrito(c *fiber.Ctx) error
+	VaciarCarrito(c *fiber.Ctx) error
+	RegistrarPedido(c *fiber.Ctx) error
+	ObtenerMisPedidos(c *fiber.Ctx) error
+	ObtenerMiPedidoById(c *fiber.Ctx) error
+	CancelarMiPedido(c *fiber.Ctx) error
+	ObtenerListaPedidos(c *fiber.Ctx) error
+	ObtenerPedidoById(c *fiber.Ctx) error
+	ActualizarEstadoPedido(c *fiber.Ctx) error
+	EntregarPedido(c *fiber.Ctx) error
+}
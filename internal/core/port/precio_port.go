@@ -0,0 +1,51 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PrecioRepository interface {
+	ObtenerHistorialPrecios(ctx context.Context, productoId *uuid.UUID) (*[]domain.HistorialPrecio, error)
+	ObtenerListaPreciosProgramados(ctx context.Context, filtros map[string]string) (*[]domain.PrecioProgramadoInfo, error)
+	ProgramarPrecio(ctx context.Context, request *domain.PrecioProgramadoRequest) (*int, error)
+	AprobarPrecioProgramado(ctx context.Context, id *int, fechaEfectiva *time.Time) error
+	CancelarPrecioProgramado(ctx context.Context, id *int) error
+	AplicarPreciosProgramados(ctx context.Context) (int64, error)
+	ObtenerProductosCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*[]domain.CambioPrecioMasivo, error)
+	AplicarCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest, cambios *[]domain.CambioPrecioMasivo) (*int, error)
+	ObtenerListaCambiosMasivos(ctx context.Context) (*[]domain.CambioMasivoInfo, error)
+	ObtenerCambioMasivoById(ctx context.Context, id *int) (*domain.CambioMasivoDetail, error)
+	RevertirCambioMasivo(ctx context.Context, id *int) (*domain.ReversionCambioMasivo, error)
+}
+
+type PrecioService interface {
+	ObtenerHistorialPrecios(ctx context.Context, productoId *uuid.UUID) (*[]domain.HistorialPrecio, error)
+	ObtenerListaPreciosProgramados(ctx context.Context, filtros map[string]string) (*[]domain.PrecioProgramadoInfo, error)
+	ProgramarPrecio(ctx context.Context, request *domain.PrecioProgramadoRequest) (*int, error)
+	AprobarPrecioProgramado(ctx context.Context, id *int, request *domain.AprobarPrecioRequest) error
+	CancelarPrecioProgramado(ctx context.Context, id *int) error
+	AplicarPreciosProgramados(ctx context.Context) error
+	PrevisualizarCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*[]domain.CambioPrecioMasivo, error)
+	AplicarCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*int, error)
+	ObtenerListaCambiosMasivos(ctx context.Context) (*[]domain.CambioMasivoInfo, error)
+	ObtenerCambioMasivoById(ctx context.Context, id *int) (*domain.CambioMasivoDetail, error)
+	RevertirCambioMasivo(ctx context.Context, id *int) (*domain.ReversionCambioMasivo, error)
+}
+
+type PrecioHandler interface {
+	ObtenerHistorialPrecios(c *fiber.Ctx) error
+	ObtenerListaPreciosProgramados(c *fiber.Ctx) error
+	ProgramarPrecio(c *fiber.Ctx) error
+	AprobarPrecioProgramado(c *fiber.Ctx) error
+	CancelarPrecioProgramado(c *fiber.Ctx) error
+	PrevisualizarCambioMasivo(c *fiber.Ctx) error
+	AplicarCambioMasivo(c *fiber.Ctx) error
+	ObtenerListaCambiosMasivos(c *fiber.Ctx) error
+	ObtenerCambioMasivoById(c *fiber.Ctx) error
+	RevertirCambioMasivo(c *fiber.Ctx) error
+}
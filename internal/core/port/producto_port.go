@@ -18,6 +18,10 @@ type ProductoRepository interface {
 	HabilitarProducto(ctx context.Context, id *uuid.UUID) error
 	DeshabilitarProducto(ctx context.Context, id *uuid.UUID) error
 	ObtenerProductoById(ctx context.Context, id *uuid.UUID) (*domain.ProductoDetail, error)
+	ObtenerEquivalentesProducto(ctx context.Context, id *uuid.UUID) (*[]domain.ProductoEquivalente, error)
+	EscanearCodigo(ctx context.Context, codigo string, gs1 *domain.DatosGS1) (*domain.ResultadoEscaneo, error)
+	ObtenerEtiquetas(ctx context.Context, request *domain.EtiquetaRequest) (*[]domain.Etiqueta, error)
+	ObtenerEtiquetasCompra(ctx context.Context, compraId *int) (*[]domain.Etiqueta, error)
 }
 
 type ProductoService interface {
@@ -29,6 +33,8 @@ type ProductoService interface {
 	HabilitarProducto(ctx context.Context, id *uuid.UUID) error
 	DeshabilitarProducto(ctx context.Context, id *uuid.UUID) error
 	ObtenerProductoById(ctx context.Context, id *uuid.UUID) (*domain.ProductoDetail, error)
+	ObtenerEquivalentesProducto(ctx context.Context, id *uuid.UUID) (*[]domain.ProductoEquivalente, error)
+	EscanearCodigo(ctx context.Context, codigo string) (*domain.ResultadoEscaneo, error)
 }
 
 type ProductoHandler interface {
@@ -42,4 +48,6 @@ type ProductoHandler interface {
 	DeshabilitarProducto(c *fiber.Ctx) error
 	ObtenerProductoById(c *fiber.Ctx) error
 	ObtenerProductoByIdShared(c *fiber.Ctx) error
+	ObtenerEquivalentesProducto(c *fiber.Ctx) error
+	EscanearCodigo(c *fiber.Ctx) error
 }
@@ -0,0 +1,40 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromocionRepository interface {
+	ObtenerListaPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionInfo, error)
+	ObtenerPromocionById(ctx context.Context, id *int) (*domain.PromocionDetail, error)
+	RegistrarPromocion(ctx context.Context, request *domain.PromocionRequest) (*int, error)
+	ModificarPromocion(ctx context.Context, id *int, request *domain.PromocionRequest) error
+	HabilitarPromocion(ctx context.Context, id *int) error
+	DeshabilitarPromocion(ctx context.Context, id *int) error
+	ObtenerPromocionesVigentes(ctx context.Context) (*[]domain.PromocionDetail, error)
+	ObtenerItemsPromocion(ctx context.Context, detalles []domain.DetalleVentaRequest) (*[]domain.PromocionItem, error)
+	ObtenerEfectividadPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionEfectividad, error)
+}
+
+type PromocionService interface {
+	ObtenerListaPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionInfo, error)
+	ObtenerPromocionById(ctx context.Context, id *int) (*domain.PromocionDetail, error)
+	RegistrarPromocion(ctx context.Context, request *domain.PromocionRequest) (*int, error)
+	ModificarPromocion(ctx context.Context, id *int, request *domain.PromocionRequest) error
+	HabilitarPromocion(ctx context.Context, id *int) error
+	DeshabilitarPromocion(ctx context.Context, id *int) error
+	ObtenerEfectividadPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionEfectividad, error)
+}
+
+type PromocionHandler interface {
+	ObtenerListaPromociones(c *fiber.Ctx) error
+	ObtenerPromocionById(c *fiber.Ctx) error
+	RegistrarPromocion(c *fiber.Ctx) error
+	ModificarPromocion(c *fiber.Ctx) error
+	HabilitarPromocion(c *fiber.Ctx) error
+	DeshabilitarPromocion(c *fiber.Ctx) error
+	ObtenerEfectividadPromociones(c *fiber.Ctx) error
+}
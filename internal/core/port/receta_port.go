@@ -0,0 +1,32 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RecetaRepository interface {
+	ObtenerListaRecetas(ctx context.Context, filtros map[string]string) (*[]domain.RecetaInfo, error)
+	ObtenerRecetaById(ctx context.Context, id *int) (*domain.RecetaDetail, error)
+	RegistrarReceta(ctx context.Context, request *domain.RecetaRequest, imagen *multipart.FileHeader) (*int, error)
+	ObtenerRegistroControlados(ctx context.Context, filtros map[string]string) (*[]domain.RegistroControlado, error)
+}
+
+type RecetaService interface {
+	ObtenerListaRecetas(ctx context.Context, filtros map[string]string) (*[]domain.RecetaInfo, error)
+	ObtenerRecetaById(ctx context.Context, id *int) (*domain.RecetaDetail, error)
+	ObtenerImagenReceta(ctx context.Context, id *int) (string, error)
+	RegistrarReceta(ctx context.Context, request *domain.RecetaRequest, imagen *multipart.FileHeader) (*int, error)
+	ObtenerRegistroControlados(ctx context.Context, filtros map[string]string) (*[]domain.RegistroControlado, error)
+}
+
+type RecetaHandler interface {
+	ObtenerListaRecetas(c *fiber.Ctx) error
+	ObtenerRecetaById(c *fiber.Ctx) error
+	ObtenerImagenReceta(c *fiber.Ctx) error
+	RegistrarReceta(c *fiber.Ctx) error
+	ObtenerRegistroControlados(c *fiber.Ctx) error
+}
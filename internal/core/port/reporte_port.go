@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"farma-santi_backend/internal/core/domain"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -18,6 +19,14 @@ type ReporteService interface {
 	ReporteMovimientosPDF(ctx context.Context, filtros map[string]string) (core.Document, error)
 	ReporteKardexProductoPDF(ctx context.Context, productoId *uuid.UUID) (core.Document, error)
 	ReporteComprasDetallePDF(ctx context.Context, compraId *int) (core.Document, error)
+	ReportePromocionesPDF(ctx context.Context, filtros map[string]string) (core.Document, error)
+	ReporteControladosPDF(ctx context.Context, filtros map[string]string) (core.Document, error)
+	ReporteEtiquetasPDF(ctx context.Context, request *domain.EtiquetaRequest) (core.Document, error)
+	ReporteEtiquetasCompraPDF(ctx context.Context, compraId *int, filtros map[string]string) (core.Document, error)
+	ReporteRetiroLotePDF(ctx context.Context, retiroId *int) (core.Document, error)
+	ReportePickingVentaPDF(ctx context.Context, ventaId *int) (core.Document, error)
+	ReportePickingTrasladoPDF(ctx context.Context, trasladoId *int) (core.Document, error)
+	ReporteAuditoriaPDF(ctx context.Context, filtros map[string]string) (core.Document, error)
 }
 
 type ReporteHandler interface {
@@ -30,4 +39,12 @@ type ReporteHandler interface {
 	ReporteMovimientosPDF(c *fiber.Ctx) error
 	ReporteKardexProductoPDF(c *fiber.Ctx) error
 	ReporteComprasDetallePDF(c *fiber.Ctx) error
+	ReportePromocionesPDF(c *fiber.Ctx) error
+	ReporteControladosPDF(c *fiber.Ctx) error
+	ReporteEtiquetasPDF(c *fiber.Ctx) error
+	ReporteEtiquetasCompraPDF(c *fiber.Ctx) error
+	ReporteRetiroLotePDF(c *fiber.Ctx) error
+	ReportePickingVentaPDF(c *fiber.Ctx) error
+	ReportePickingTrasladoPDF(c *fiber.Ctx) error
+	ReporteAuditoriaPDF(c *fiber.Ctx) error
 }
@@ -0,0 +1,29 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RetiroLoteRepository interface {
+	ObtenerListaRetiros(ctx context.Context, filtros map[string]string) (*[]domain.RetiroLoteInfo, error)
+	ObtenerRetiroById(ctx context.Context, id *int) (*domain.RetiroLoteDetail, error)
+	RegistrarRetiro(ctx context.Context, request *domain.RetiroLoteRequest) (*int, error)
+	ObtenerVentasLote(ctx context.Context, loteId *int) (*[]domain.VentaLoteRetirado, error)
+}
+
+type RetiroLoteService interface {
+	ObtenerListaRetiros(ctx context.Context, filtros map[string]string) (*[]domain.RetiroLoteInfo, error)
+	ObtenerRetiroById(ctx context.Context, id *int) (*domain.RetiroLoteDetail, error)
+	RegistrarRetiro(ctx context.Context, request *domain.RetiroLoteRequest) (*int, error)
+	ObtenerVentasLote(ctx context.Context, loteId *int) (*[]domain.VentaLoteRetirado, error)
+}
+
+type RetiroLoteHandler interface {
+	ObtenerListaRetiros(c *fiber.Ctx) error
+	ObtenerRetiroById(c *fiber.Ctx) error
+	RegistrarRetiro(c *fiber.Ctx) error
+	ObtenerVentasLote(c *fiber.Ctx) error
+}
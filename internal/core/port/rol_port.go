@@ -13,6 +13,10 @@ type RolRepository interface {
 	ModificarRol(ctx context.Context, id *int, rolRequest *domain.RolRequest) error
 	HabilitarRol(ctx context.Context, id *int) error
 	DeshabilitarRol(ctx context.Context, id *int) error
+	ListarPermisos(ctx context.Context) (*[]domain.Permiso, error)
+	ObtenerPermisosRol(ctx context.Context, id *int) (*[]domain.Permiso, error)
+	AsignarPermisosRol(ctx context.Context, id *int, permisos []int) error
+	ModificarDobleFactorRol(ctx context.Context, id *int, requerido bool) error
 }
 
 type RolService interface {
@@ -22,6 +26,10 @@ type RolService interface {
 	ModificarRol(ctx context.Context, id *int, rolRequest *domain.RolRequest) error
 	HabilitarRol(ctx context.Context, id *int) error
 	DeshabilitarRol(ctx context.Context, id *int) error
+	ListarPermisos(ctx context.Context) (*[]domain.Permiso, error)
+	ObtenerPermisosRol(ctx context.Context, id *int) (*[]domain.Permiso, error)
+	AsignarPermisosRol(ctx context.Context, id *int, request *domain.RolPermisosRequest) error
+	ModificarDobleFactorRol(ctx context.Context, id *int, request *domain.RolDobleFactorRequest) error
 }
 
 type RolHandler interface {
@@ -31,4 +39,8 @@ type RolHandler interface {
 	ModificarRol(c *fiber.Ctx) error
 	HabilitarRol(c *fiber.Ctx) error
 	DeshabilitarRol(c *fiber.Ctx) error
+	ListarPermisos(c *fiber.Ctx) error
+	ObtenerPermisosRol(c *fiber.Ctx) error
+	AsignarPermisosRol(c *fiber.Ctx) error
+	ModificarDobleFactorRol(c *fiber.Ctx) error
 }
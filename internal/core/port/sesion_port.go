@@ -0,0 +1,35 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SesionRepository interface {
+	RegistrarSesion(ctx context.Context, usuarioId int, refreshTokenId uuid.UUID, expiraAt time.Time, cliente *domain.SesionCliente) (*uuid.UUID, error)
+	RotarSesion(ctx context.Context, sesionId, refreshTokenId, nuevoRefreshTokenId uuid.UUID, expiraAt time.Time, cliente *domain.SesionCliente) (*domain.RotacionSesion, error)
+	VerificarSesionActiva(ctx context.Context, sesionId uuid.UUID) (bool, error)
+	ObtenerListaSesiones(ctx context.Context, usuarioId *int, soloActivas bool) (*[]domain.SesionInfo, error)
+	RevocarSesion(ctx context.Context, sesionId uuid.UUID, usuarioId *int, motivo string) error
+	RevocarSesionesUsuario(ctx context.Context, usuarioId int, motivo string) error
+}
+
+type SesionService interface {
+	ObtenerMisSesiones(ctx context.Context) (*[]domain.SesionInfo, error)
+	CerrarTodasLasSesiones(ctx context.Context) error
+	ObtenerSesionesUsuario(ctx context.Context, usuarioId *int, filtros map[string]string) (*[]domain.SesionInfo, error)
+	RevocarSesion(ctx context.Context, sesionId *uuid.UUID) error
+	RevocarSesionesUsuario(ctx context.Context, usuarioId *int) error
+}
+
+type SesionHandler interface {
+	ObtenerMisSesiones(c *fiber.Ctx) error
+	CerrarTodasLasSesiones(c *fiber.Ctx) error
+	ObtenerSesionesUsuario(c *fiber.Ctx) error
+	RevocarSesion(c *fiber.Ctx) error
+	RevocarSesionesUsuario(c *fiber.Ctx) error
+}
@@ -0,0 +1,45 @@
+package port
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UbicacionRepository interface {
+	ObtenerListaUbicaciones(ctx context.Context, filtros map[string]string) (*[]domain.UbicacionInfo, error)
+	ObtenerUbicacionById(ctx context.Context, id *int) (*domain.UbicacionInfo, error)
+	RegistrarUbicacion(ctx context.Context, request *domain.UbicacionRequest) (*int, error)
+	ModificarUbicacion(ctx context.Context, id *int, request *domain.UbicacionRequest) error
+	EliminarUbicacion(ctx context.Context, id *int) error
+	ObtenerStockUbicacion(ctx context.Context, id *int) (*[]domain.StockUbicacion, error)
+	ObtenerListaTraslados(ctx context.Context, filtros map[string]string) (*[]domain.TrasladoInfo, error)
+	ObtenerTrasladoById(ctx context.Context, id *int) (*domain.TrasladoDetail, error)
+	RegistrarTraslado(ctx context.Context, request *domain.TrasladoRequest) (*int, error)
+	ObtenerPickingVenta(ctx context.Context, ventaId *int) (*domain.PickingVenta, error)
+}
+
+type UbicacionService interface {
+	ObtenerListaUbicaciones(ctx context.Context, filtros map[string]string) (*[]domain.UbicacionInfo, error)
+	ObtenerUbicacionById(ctx context.Context, id *int) (*domain.UbicacionInfo, error)
+	RegistrarUbicacion(ctx context.Context, request *domain.UbicacionRequest) (*int, error)
+	ModificarUbicacion(ctx context.Context, id *int, request *domain.UbicacionRequest) error
+	EliminarUbicacion(ctx context.Context, id *int) error
+	ObtenerStockUbicacion(ctx context.Context, id *int) (*[]domain.StockUbicacion, error)
+	ObtenerListaTraslados(ctx context.Context, filtros map[string]string) (*[]domain.TrasladoInfo, error)
+	ObtenerTrasladoById(ctx context.Context, id *int) (*domain.TrasladoDetail, error)
+	RegistrarTraslado(ctx context.Context, request *domain.TrasladoRequest) (*int, error)
+}
+
+type UbicacionHandler interface {
+	ObtenerListaUbicaciones(c *fiber.Ctx) error
+	ObtenerUbicacionById(c *fiber.Ctx) error
+	RegistrarUbicacion(c *fiber.Ctx) error
+	ModificarUbicacion(c *fiber.Ctx) error
+	EliminarUbicacion(c *fiber.Ctx) error
+	ObtenerStockUbicacion(c *fiber.Ctx) error
+	ObtenerListaTraslados(c *fiber.Ctx) error
+	ObtenerTrasladoById(c *fiber.Ctx) error
+	RegistrarTraslado(c *fiber.Ctx) error
+}
@@ -5,6 +5,7 @@ import (
 	"farma-santi_backend/internal/core/domain"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type UsuarioRepository interface {
@@ -17,6 +18,10 @@ type UsuarioRepository interface {
 	RegistrarUsuario(ctx context.Context, usuarioRequest *domain.UsuarioRequest) (*domain.UsuarioDetail, error)
 	ListarUsuarios(ctx context.Context, filtros map[string]string) (*[]domain.UsuarioInfo, error)
 	RestablecerPassword(ctx context.Context, usuarioId *int, password *domain.UsuarioResetPassword) (*domain.UsuarioDetail, error)
+	ObtenerPermisosUsuario(ctx context.Context, usuarioId *int) ([]string, error)
+	EscucharCambiosPermisos(ctx context.Context, notificar func(payload string)) error
+	ObtenerHistorialPassword(ctx context.Context, usuarioId int, cantidad int) ([]string, error)
+	CambiarPassword(ctx context.Context, usuarioId int, hashPassword string, sesionActual *uuid.UUID) error
 }
 
 type UsuarioService interface {
@@ -28,6 +33,9 @@ type UsuarioService interface {
 	RegistrarUsuario(ctx context.Context, usuarioRequest *domain.UsuarioRequest) (*domain.UsuarioDetail, error)
 	ListarUsuarios(ctx context.Context, filtros map[string]string) (*[]domain.UsuarioInfo, error)
 	RestablecerPassword(ctx context.Context, usuarioId *int, password *domain.UsuarioResetPassword) (*domain.UsuarioDetail, error)
+	EscucharCambiosPermisos(ctx context.Context) error
+	CambiarPassword(ctx context.Context, request *domain.CambiarPasswordRequest) error
+	ObtenerPoliticaPassword() *domain.PoliticaPassword
 }
 
 type UsuarioHandler interface {
@@ -39,4 +47,6 @@ type UsuarioHandler interface {
 	ListarUsuarios(c *fiber.Ctx) error
 	ObtenerUsuarioActual(c *fiber.Ctx) error
 	RestablecerPassword(c *fiber.Ctx) error
+	CambiarPassword(c *fiber.Ctx) error
+	ObtenerPoliticaPassword(c *fiber.Ctx) error
 }
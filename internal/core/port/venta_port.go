@@ -14,13 +14,32 @@ type VentaRepository interface {
 	AnularVentaById(ctx context.Context, id *int) error
 	FacturarVentaById(ctx context.Context, ventaId *int, req *domain.FacturaCompraVentaResponse) error
 	ObtenerFacturaByVentaId(ctx context.Context, ventaId *int) (*domain.Factura, error)
+	CotizarVenta(ctx context.Context, detalles []domain.DetalleVentaRequest) (*domain.Cotizacion, error)
+	RegistrarCotizacion(ctx context.Context, request *domain.CotizacionRequest, cotizacion *domain.Cotizacion) error
+	ObtenerListaCotizaciones(ctx context.Context, filtros map[string]string) (*[]domain.CotizacionInfo, error)
+	ObtenerCotizacionById(ctx context.Context, id *int) (*domain.CotizacionDetail, error)
+	ConvertirCotizacion(ctx context.Context, id *int, request *domain.VentaRequest) (*int64, error)
+	RegistrarVentaEnEspera(ctx context.Context, request *domain.VentaRequest) (*int64, error)
+	ModificarVentaEnEspera(ctx context.Context, id *int, request *domain.VentaRequest) error
+	FinalizarVentaEnEspera(ctx context.Context, id *int, promociones []domain.VentaPromocion) error
+	LiberarReservasVencidas(ctx context.Context) (int64, error)
 }
 
 type VentaService interface {
 	ObtenerListaVentas(ctx context.Context, filtros map[string]string) (*[]domain.VentaInfo, error)
+	ObtenerListaVentasShared(ctx context.Context) (*[]domain.VentaInfo, error)
+	ObtenerVentaByIdShared(ctx context.Context, id *int) (*domain.VentaDetail, error)
 	RegistraVenta(ctx context.Context, request *domain.VentaRequest) (*int64, error)
 	ObtenerVentaById(ctx context.Context, id *int) (*domain.VentaDetail, error)
 	AnularVentaById(ctx context.Context, id *int) error
+	CotizarVenta(ctx context.Context, request *domain.CotizacionRequest) (*domain.Cotizacion, error)
+	ObtenerListaCotizaciones(ctx context.Context, filtros map[string]string) (*[]domain.CotizacionInfo, error)
+	ObtenerCotizacionById(ctx context.Context, id *int) (*domain.CotizacionDetail, error)
+	ConvertirCotizacion(ctx context.Context, id *int, request *domain.ConvertirCotizacionRequest) (*int64, error)
+	RegistrarVentaEnEspera(ctx context.Context, request *domain.VentaRequest) (*int64, error)
+	ModificarVentaEnEspera(ctx context.Context, id *int, request *domain.VentaRequest) error
+	FinalizarVentaEnEspera(ctx context.Context, id *int) (*int64, error)
+	LiberarReservasVencidas(ctx context.Context) error
 }
 
 type VentaHandler interface {
@@ -31,4 +50,11 @@ type VentaHandler interface {
 	FacturarVentaById(c *fiber.Ctx) error
 	ObtenerListaVentasShared(c *fiber.Ctx) error
 	ObtenerVentaByIdShared(c *fiber.Ctx) error
+	CotizarVenta(c *fiber.Ctx) error
+	ObtenerListaCotizaciones(c *fiber.Ctx) error
+	ObtenerCotizacionById(c *fiber.Ctx) error
+	ConvertirCotizacion(c *fiber.Ctx) error
+	RegistrarVentaEnEspera(c *fiber.Ctx) error
+	ModificarVentaEnEspera(c *fiber.Ctx) error
+	FinalizarVentaEnEspera(c *fiber.Ctx) error
 }
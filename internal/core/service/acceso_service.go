@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"strconv"
+	"time"
+)
+
+// Valores por defecto del reporte de actividad sospechosa
+const (
+	horasActividadSospechosa  = 24
+	minimoActividadSospechosa = 5
+)
+
+type AccesoService struct {
+	accesoRepository port.AccesoRepository
+}
+
+func (a AccesoService) ObtenerListaIntentosLogin(ctx context.Context, filtros map[string]string) (*[]domain.IntentoLoginInfo, error) {
+	return a.accesoRepository.ObtenerListaIntentosLogin(ctx, filtros)
+}
+
+func (a AccesoService) ObtenerActividadSospechosa(ctx context.Context, filtros map[string]string) (*[]domain.ActividadSospechosa, error) {
+	horas := horasActividadSospechosa
+	if valor := filtros["horas"]; valor != "" {
+		n, err := strconv.Atoi(valor)
+		if err != nil || n <= 0 || n > 24*30 {
+			return nil, datatype.NewBadRequestError("El valor de horas debe ser un número entre 1 y 720")
+		}
+		horas = n
+	}
+	minimo := minimoActividadSospechosa
+	if valor := filtros["minimo"]; valor != "" {
+		n, err := strconv.Atoi(valor)
+		if err != nil || n <= 0 {
+			return nil, datatype.NewBadRequestError("El valor de minimo debe ser un número entero positivo")
+		}
+		minimo = n
+	}
+	desde := time.Now().Add(-time.Duration(horas) * time.Hour)
+	return a.accesoRepository.ObtenerActividadSospechosa(ctx, desde, minimo)
+}
+
+func (a AccesoService) DesbloquearUsuario(ctx context.Context, usuarioId *int) error {
+	return a.accesoRepository.DesbloquearUsuario(ctx, usuarioId)
+}
+
+func NewAccesoService(accesoRepository port.AccesoRepository) *AccesoService {
+	return &AccesoService{accesoRepository: accesoRepository}
+}
+
+var _ port.AccesoService = (*AccesoService)(nil)
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/port"
+)
+
+type AuditoriaService struct {
+	auditoriaRepository port.AuditoriaRepository
+}
+
+func (a AuditoriaService) ObtenerListaAuditoria(ctx context.Context, filtros map[string]string) (*[]domain.AuditoriaInfo, error) {
+	return a.auditoriaRepository.ObtenerListaAuditoria(ctx, filtros)
+}
+
+func (a AuditoriaService) ObtenerAuditoriaById(ctx context.Context, id *int64) (*domain.AuditoriaInfo, error) {
+	return a.auditoriaRepository.ObtenerAuditoriaById(ctx, id)
+}
+
+func NewAuditoriaService(auditoriaRepository port.AuditoriaRepository) *AuditoriaService {
+	return &AuditoriaService{auditoriaRepository: auditoriaRepository}
+}
+
+var _ port.AuditoriaService = (*AuditoriaService)(nil)
@@ -2,61 +2,399 @@ package service
 
 import (
 	"context"
+	"errors"
 	"farma-santi_backend/internal/core/domain"
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
 	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
-	"time"
 )
 
 type AuthService struct {
-	usuarioRepository port.UsuarioRepository
+	usuarioRepository     port.UsuarioRepository
+	clienteRepository     port.ClienteRepository
+	sesionRepository      port.SesionRepository
+	accesoRepository      port.AccesoRepository
+	dobleFactorRepository port.DobleFactorRepository
 }
 
-func (a AuthService) ObtenerTokenByCredencial(ctx context.Context, credentials *domain.LoginRequest) (*domain.TokenResponse, error) {
+func (a AuthService) ObtenerTokenByCredencial(ctx context.Context, credentials *domain.LoginRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	intento := &domain.IntentoLogin{Username: credentials.Username, Ip: cliente.Ip, UserAgent: cliente.UserAgent}
 	usuario, err := a.usuarioRepository.ObtenerUsuario(ctx, &credentials.Username)
 	if err != nil {
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Code == http.StatusUnauthorized {
+			// Usuario inexistente o inactivo: se registra el intento y se responde con la misma demora
+			a.registrarIntento(ctx, intento, domain.AccesoMotivoCredenciales)
+			esperar(ctx, demoraLogin)
+		}
 		return nil, err
 	}
+	usuarioId := int(usuario.Id)
+	intento.UsuarioId = &usuarioId
+
+	if usuario.BloqueadoHasta != nil && usuario.BloqueadoHasta.After(time.Now()) {
+		a.registrarIntento(ctx, intento, domain.AccesoMotivoBloqueado)
+		return nil, errorCuentaBloqueada(*usuario.BloqueadoHasta)
+	}
 
 	// Comparar contraseña hashed y contraseña ingresada
 	if err := bcrypt.CompareHashAndPassword([]byte(usuario.Password), []byte(credentials.Password)); err != nil {
-		return nil, datatype.NewStatusUnauthorizedError("Usuario o contraseña incorrecta")
+		return nil, a.registrarFalloLogin(ctx, intento, domain.AccesoMotivoCredenciales, "Usuario o contraseña incorrecta")
+	}
+
+	// Con verificación en dos pasos la sesión se crea recién al validar el código
+	df, err := a.dobleFactorRepository.ObtenerDobleFactor(ctx, usuarioId)
+	if err != nil {
+		return nil, err
+	}
+	if df.Activo {
+		return desafioLogin(usuarioId, credentials.Username, domain.PasoVerificarDobleFactor, "Ingrese el código de verificación")
+	}
+	if df.Requerido {
+		return desafioLogin(usuarioId, credentials.Username, domain.PasoConfigurarDobleFactor, "Su rol requiere configurar la verificación en dos pasos")
+	}
+	return a.completarLogin(ctx, intento, usuario, cliente)
+}
+
+// VerificarDobleFactor continúa el inicio de sesión con un código de la app autenticadora o uno de recuperación
+func (a AuthService) VerificarDobleFactor(ctx context.Context, request *domain.DesafioDobleFactorRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	intento, usuario, err := a.desafioPendiente(ctx, request.TokenDesafio, domain.PasoVerificarDobleFactor, cliente)
+	if err != nil {
+		return nil, err
+	}
+	df, err := a.dobleFactorRepository.ObtenerDobleFactor(ctx, *intento.UsuarioId)
+	if err != nil {
+		return nil, err
+	}
+	valido, err := verificarDobleFactor(ctx, a.dobleFactorRepository, df, request.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if !valido {
+		return nil, a.registrarFalloLogin(ctx, intento, domain.AccesoMotivoDobleFactor, domain.AccesoMotivoDobleFactor)
+	}
+	if err := a.consumirDesafio(ctx, request.TokenDesafio); err != nil {
+		return nil, err
+	}
+	return a.completarLogin(ctx, intento, usuario, cliente)
+}
+
+// IniciarDobleFactorDesafio genera el código QR para el usuario cuyo rol exige la verificación en dos pasos
+func (a AuthService) IniciarDobleFactorDesafio(ctx context.Context, tokenDesafio string, cliente *domain.SesionCliente) (*domain.DobleFactorConfiguracion, error) {
+	intento, _, err := a.desafioPendiente(ctx, tokenDesafio, domain.PasoConfigurarDobleFactor, cliente)
+	if err != nil {
+		return nil, err
+	}
+	df, err := a.dobleFactorRepository.ObtenerDobleFactor(ctx, *intento.UsuarioId)
+	if err != nil {
+		return nil, err
+	}
+	return iniciarDobleFactor(context.WithValue(ctx, util.ContextUserIdKey, *intento.UsuarioId), a.dobleFactorRepository, df)
+}
+
+// ConfirmarDobleFactorDesafio activa la verificación en dos pasos y continúa el inicio de sesión
+func (a AuthService) ConfirmarDobleFactorDesafio(ctx context.Context, request *domain.DesafioDobleFactorRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	intento, usuario, err := a.desafioPendiente(ctx, request.TokenDesafio, domain.PasoConfigurarDobleFactor, cliente)
+	if err != nil {
+		return nil, err
+	}
+	df, err := a.dobleFactorRepository.ObtenerDobleFactor(ctx, *intento.UsuarioId)
+	if err != nil {
+		return nil, err
+	}
+	codigos, err := activarDobleFactor(context.WithValue(ctx, util.ContextUserIdKey, *intento.UsuarioId), a.dobleFactorRepository, df, request.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.consumirDesafio(ctx, request.TokenDesafio); err != nil {
+		return nil, err
+	}
+	tokenResponse, err := a.completarLogin(ctx, intento, usuario, cliente)
+	if err != nil {
+		return nil, err
+	}
+	tokenResponse.CodigosRecuperacion = codigos.Codigos
+	return tokenResponse, nil
+}
+
+// CambiarPasswordDesafio guarda la contraseña nueva del usuario que debía cambiarla e inicia la sesión
+func (a AuthService) CambiarPasswordDesafio(ctx context.Context, request *domain.DesafioPasswordRequest, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	intento, usuario, err := a.desafioPendiente(ctx, request.TokenDesafio, domain.PasoCambiarPassword, cliente)
+	if err != nil {
+		return nil, err
+	}
+	// El cambio ya realizado no puede repetirse con el mismo token
+	if !usuario.DebeCambiarPassword {
+		return nil, datatype.NewStatusUnauthorizedError("La contraseña ya fue cambiada, vuelva a iniciar sesión")
+	}
+	err = cambiarPassword(context.WithValue(ctx, util.ContextUserIdKey, *intento.UsuarioId), a.usuarioRepository, usuario, request.PasswordNueva, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.consumirDesafio(ctx, request.TokenDesafio); err != nil {
+		return nil, err
+	}
+	return a.iniciarSesion(ctx, intento, usuario.Username, cliente)
+}
+
+// desafioPendiente valida el token del paso anterior y que la cuenta siga activa y sin bloqueo
+func (a AuthService) desafioPendiente(ctx context.Context, tokenDesafio string, paso string, cliente *domain.SesionCliente) (*domain.IntentoLogin, *domain.Usuario, error) {
+	claims, err := util.Token.VerifyTokenType(tokenDesafio, tipoTokenDesafio)
+	if err != nil {
+		return nil, nil, err
+	}
+	username, _ := claims["username"].(string)
+	usuarioId, _ := claims["userId"].(float64)
+	if claims["paso"] != paso {
+		return nil, nil, datatype.NewStatusUnauthorizedError("Token no válido")
+	}
+
+	usuario, err := a.usuarioRepository.ObtenerUsuario(ctx, &username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if int(usuario.Id) != int(usuarioId) {
+		return nil, nil, datatype.NewStatusUnauthorizedError("Token no válido")
+	}
+	id := int(usuario.Id)
+	intento := &domain.IntentoLogin{Username: username, UsuarioId: &id, Ip: cliente.Ip, UserAgent: cliente.UserAgent}
+	if usuario.BloqueadoHasta != nil && usuario.BloqueadoHasta.After(time.Now()) {
+		a.registrarIntento(ctx, intento, domain.AccesoMotivoBloqueado)
+		return nil, nil, errorCuentaBloqueada(*usuario.BloqueadoHasta)
+	}
+	return intento, usuario, nil
+}
+
+// consumirDesafio marca el token de desafío como usado para que el paso completado no pueda repetirse
+func (a AuthService) consumirDesafio(ctx context.Context, tokenDesafio string) error {
+	claims, err := util.Token.VerifyTokenType(tokenDesafio, tipoTokenDesafio)
+	if err != nil {
+		return err
 	}
-	expAccess, expRefresh := time.Now().UTC().Add(1*time.Hour), time.Now().UTC().Add(7*24*time.Hour)
-	// Generar token
+	jti, _ := claims["jti"].(string)
+	desafioId, err := uuid.Parse(jti)
+	if err != nil {
+		return datatype.NewStatusUnauthorizedError("Token no válido")
+	}
+	usuarioId, _ := claims["userId"].(float64)
+	expiration, _ := claims["expiration"].(float64)
+	nuevo, err := a.dobleFactorRepository.ConsumirDesafioLogin(ctx, desafioId, int(usuarioId), time.Unix(int64(expiration), 0))
+	if err != nil {
+		return err
+	}
+	if !nuevo {
+		return datatype.NewStatusUnauthorizedError("El token ya fue utilizado, vuelva a iniciar sesión")
+	}
+	return nil
+}
+
+// completarLogin crea la sesión o, si la contraseña es temporal, exige cambiarla primero
+func (a AuthService) completarLogin(ctx context.Context, intento *domain.IntentoLogin, usuario *domain.Usuario, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	if usuario.DebeCambiarPassword {
+		return desafioLogin(int(usuario.Id), usuario.Username, domain.PasoCambiarPassword, "Debe cambiar su contraseña antes de continuar")
+	}
+	return a.iniciarSesion(ctx, intento, usuario.Username, cliente)
+}
+
+// iniciarSesion reinicia los intentos fallidos, registra el acceso y crea la sesión con sus tokens
+func (a AuthService) iniciarSesion(ctx context.Context, intento *domain.IntentoLogin, username string, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	usuarioId := *intento.UsuarioId
+	if err := a.accesoRepository.ReiniciarIntentosLogin(ctx, usuarioId); err != nil {
+		return nil, err
+	}
+	intento.Exitoso = true
+	a.registrarIntento(ctx, intento, "")
+
+	expRefresh := time.Now().UTC().Add(duracionRefreshToken)
+	refreshTokenId := uuid.New()
+	sesionId, err := a.sesionRepository.RegistrarSesion(ctx, usuarioId, refreshTokenId, expRefresh, cliente)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenResponse, err := generarTokens(usuarioId, username, *sesionId, &refreshTokenId, expRefresh)
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar el token")
+	}
+	tokenResponse.Message = "Usuario autenticado"
+	return tokenResponse, nil
+}
+
+// RenovarToken emite un nuevo token de acceso y rota el token de actualización de la sesión
+func (a AuthService) RenovarToken(ctx context.Context, refreshToken string, cliente *domain.SesionCliente) (*domain.TokenResponse, error) {
+	sesionId, refreshTokenId, err := claimsRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	expRefresh := time.Now().UTC().Add(duracionRefreshToken)
+	rotacion, err := a.sesionRepository.RotarSesion(ctx, sesionId, refreshTokenId, uuid.New(), expRefresh, cliente)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenResponse, err := generarTokens(rotacion.UsuarioId, rotacion.Username, sesionId, rotacion.RefreshTokenId, expRefresh)
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar el token")
+	}
+	tokenResponse.Message = "Sesión activa"
+	return tokenResponse, nil
+}
+
+// CerrarSesion revoca la sesión del token de actualización; un token inválido no tiene sesión que cerrar
+func (a AuthService) CerrarSesion(ctx context.Context, refreshToken string) error {
+	sesionId, _, err := claimsRefreshToken(refreshToken)
+	if err != nil {
+		return nil
+	}
+	err = a.sesionRepository.RevocarSesion(ctx, sesionId, nil, domain.SesionMotivoLogout)
+	var errorResponse *datatype.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+const (
+	duracionAccessToken  = 1 * time.Hour
+	duracionRefreshToken = 7 * 24 * time.Hour
+)
+
+// Política contra fuerza bruta: desde maxIntentosLogin fallos consecutivos la cuenta se bloquea por bloqueoLogin,
+// duplicándose con cada fallo posterior hasta bloqueoMaximoLogin
+const (
+	maxIntentosLogin   = 5
+	bloqueoLogin       = 15 * time.Minute
+	bloqueoMaximoLogin = 24 * time.Hour
+	demoraLogin        = 1 * time.Second
+)
+
+// registrarIntento guarda el intento en el historial; un error no debe impedir responder al inicio de sesión
+func (a AuthService) registrarIntento(ctx context.Context, intento *domain.IntentoLogin, motivo string) {
+	if motivo != "" {
+		intento.Motivo = &motivo
+	}
+	_ = a.accesoRepository.RegistrarIntentoLogin(ctx, intento)
+}
+
+// registrarFalloLogin suma el intento fallido al usuario y aplica la demora progresiva o el bloqueo
+func (a AuthService) registrarFalloLogin(ctx context.Context, intento *domain.IntentoLogin, motivo, mensaje string) error {
+	intentos, bloqueadoHasta, err := a.accesoRepository.RegistrarFalloLogin(ctx, *intento.UsuarioId, maxIntentosLogin, bloqueoLogin, bloqueoMaximoLogin)
+	if err != nil {
+		return err
+	}
+	a.registrarIntento(ctx, intento, motivo)
+	if bloqueadoHasta != nil && bloqueadoHasta.After(time.Now()) {
+		return errorCuentaBloqueada(*bloqueadoHasta)
+	}
+	// Demora progresiva según los intentos fallidos consecutivos
+	esperar(ctx, time.Duration(min(intentos, maxIntentosLogin))*demoraLogin)
+	return datatype.NewStatusUnauthorizedError(mensaje)
+}
+
+func errorCuentaBloqueada(bloqueadoHasta time.Time) error {
+	minutos := int(math.Ceil(time.Until(bloqueadoHasta).Minutes()))
+	return datatype.NewErrorResponse(http.StatusTooManyRequests, fmt.Sprintf("Cuenta bloqueada temporalmente, intente nuevamente en %d minuto(s)", max(minutos, 1)))
+}
+
+// esperar demora la respuesta sin retener la petición si el cliente se desconecta
+func esperar(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
+
+// El token de desafío solo permite completar el paso pendiente del inicio de sesión
+const (
+	tipoTokenDesafio = "desafio-2fa-adm"
+	duracionDesafio  = 5 * time.Minute
+)
+
+func desafioLogin(usuarioId int, username string, paso string, mensaje string) (*domain.TokenResponse, error) {
+	tokenDesafio, err := util.Token.CreateToken(jwt.MapClaims{
+		"userId":     usuarioId,
+		"username":   username,
+		"paso":       paso,
+		"jti":        uuid.NewString(),
+		"expiration": time.Now().UTC().Add(duracionDesafio).Unix(),
+		"type":       tipoTokenDesafio,
+	})
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar el token")
+	}
+	return &domain.TokenResponse{Message: mensaje, Paso: paso, TokenDesafio: tokenDesafio}, nil
+}
+
+// generarTokens crea el token de acceso y, si se indica refreshTokenId, el de actualización de la sesión
+func generarTokens(usuarioId int, username string, sesionId uuid.UUID, refreshTokenId *uuid.UUID, expRefresh time.Time) (*domain.TokenResponse, error) {
+	expAccess := time.Now().UTC().Add(duracionAccessToken)
 	accessToken, err := util.Token.CreateToken(jwt.MapClaims{
-		"userId":     &usuario.Id,
-		"username":   &credentials.Username,
+		"userId":     usuarioId,
+		"username":   username,
+		"sessionId":  sesionId.String(),
 		"expiration": expAccess.Unix(),
 		"type":       "access-token-adm",
 	})
+	if err != nil {
+		return nil, err
+	}
+	tokenResponse := &domain.TokenResponse{
+		AccessToken:    accessToken,
+		ExpAccessToken: expAccess,
+	}
+	if refreshTokenId == nil {
+		return tokenResponse, nil
+	}
 
-	// Generar token
 	refreshToken, err := util.Token.CreateToken(jwt.MapClaims{
-		"userId":     &usuario.Id,
-		"username":   &credentials.Username,
+		"userId":     usuarioId,
+		"username":   username,
+		"sessionId":  sesionId.String(),
+		"tokenId":    refreshTokenId.String(),
 		"expiration": expRefresh.Unix(),
 		"type":       "refresh-token-adm",
 	})
+	if err != nil {
+		return nil, err
+	}
+	tokenResponse.RefreshToken = refreshToken
+	tokenResponse.ExpRefreshToken = expRefresh
+	tokenResponse.RefreshRotado = true
+	return tokenResponse, nil
+}
 
+// claimsRefreshToken obtiene la sesión y el identificador del token de actualización
+func claimsRefreshToken(refreshToken string) (uuid.UUID, uuid.UUID, error) {
+	claims, err := util.Token.VerifyTokenType(refreshToken, "refresh-token-adm")
 	if err != nil {
-		return nil, datatype.NewStatusUnauthorizedError("Usuario o contraseña incorrecta")
+		return uuid.Nil, uuid.Nil, err
 	}
+	sesion, _ := claims["sessionId"].(string)
+	token, _ := claims["tokenId"].(string)
+	sesionId, errSesion := uuid.Parse(sesion)
+	tokenId, errToken := uuid.Parse(token)
+	if errSesion != nil || errToken != nil {
+		return uuid.Nil, uuid.Nil, datatype.NewStatusUnauthorizedError("Sesión no válida")
+	}
+	return sesionId, tokenId, nil
+}
 
-	return &domain.TokenResponse{
-		Message:         "Usuario autenticado",
-		AccessToken:     accessToken,
-		RefreshToken:    refreshToken,
-		ExpAccessToken:  expAccess,
-		ExpRefreshToken: expRefresh,
-	}, nil
+// RegistrarCuentaCliente guarda la cuenta de Firebase que inició sesión en la tienda en línea
+func (a AuthService) RegistrarCuentaCliente(ctx context.Context, usuarioUid string, email string) error {
+	return a.clienteRepository.RegistrarCuentaCliente(ctx, usuarioUid, email)
 }
 
-func NewAuthService(usuarioRepository port.UsuarioRepository) *AuthService {
-	return &AuthService{usuarioRepository}
+func NewAuthService(usuarioRepository port.UsuarioRepository, clienteRepository port.ClienteRepository, sesionRepository port.SesionRepository, accesoRepository port.AccesoRepository, dobleFactorRepository port.DobleFactorRepository) *AuthService {
+	return &AuthService{usuarioRepository: usuarioRepository, clienteRepository: clienteRepository, sesionRepository: sesionRepository, accesoRepository: accesoRepository, dobleFactorRepository: dobleFactorRepository}
 }
 
 var _ port.AuthService = (*AuthService)(nil)
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
+	"strings"
 )
 
 type ClienteService struct {
@@ -34,6 +36,57 @@ func (c ClienteService) DeshabilitarCliente(ctx context.Context, id *int) error
 	return c.clienteRepository.DeshabilitarCliente(ctx, id)
 }
 
+func (c ClienteService) ObtenerPerfil(ctx context.Context) (*domain.ClienteCuenta, error) {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.clienteRepository.ObtenerCuentaCliente(ctx, usuarioUid)
+}
+
+func (c ClienteService) GuardarPerfil(ctx context.Context, request *domain.PerfilClienteRequest) error {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return err
+	}
+
+	request.RazonSocial = strings.TrimSpace(request.RazonSocial)
+	if request.Tipo != "NIT" && request.Tipo != "CI" {
+		return datatype.NewBadRequestError("Tipo de cliente no válido")
+	}
+	if request.NitCi == nil || *request.NitCi == 0 {
+		return datatype.NewBadRequestError("El NIT/CI es obligatorio")
+	}
+	if request.RazonSocial == "" {
+		return datatype.NewBadRequestError("La razón social es obligatoria")
+	}
+	// El complemento solo aplica a CI
+	if request.Tipo == "NIT" || (request.Complemento != nil && strings.TrimSpace(*request.Complemento) == "") {
+		request.Complemento = nil
+	}
+	return c.clienteRepository.GuardarPerfilCliente(ctx, usuarioUid, request)
+}
+
+func (c ClienteService) VerificarPerfil(ctx context.Context, request *domain.VerificarCuentaRequest) error {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(request.CodigoVenta) == "" {
+		return datatype.NewBadRequestError("El código de venta es obligatorio")
+	}
+	return c.clienteRepository.VerificarCuentaCliente(ctx, usuarioUid, &request.CodigoVenta)
+}
+
+func (c ClienteService) ObtenerListaCuentasCliente(ctx context.Context, filtros map[string]string) (*[]domain.ClienteCuenta, error) {
+	return c.clienteRepository.ObtenerListaCuentasCliente(ctx, filtros)
+}
+
+func (c ClienteService) VerificarCuentaCliente(ctx context.Context, usuarioUid string) error {
+	// Verificación presencial por el personal de farmacia
+	return c.clienteRepository.VerificarCuentaCliente(ctx, usuarioUid, nil)
+}
+
 func NewClienteService(clienteRepository port.ClienteRepository) *ClienteService {
 	return &ClienteService{clienteRepository: clienteRepository}
 }
@@ -47,8 +47,8 @@ func (c CompraService) AnularOrdenCompra(ctx context.Context, id *int) error {
 	return c.compraRepository.AnularOrdenCompra(ctx, id)
 }
 
-func (c CompraService) RegistrarCompra(ctx context.Context, id *int) error {
-	return c.compraRepository.RegistrarCompra(ctx, id)
+func (c CompraService) RegistrarCompra(ctx context.Context, id *int, proponerPrecioVenta bool) error {
+	return c.compraRepository.RegistrarCompra(ctx, id, proponerPrecioVenta)
 }
 
 func NewCompraService(compraRepository port.CompraRepository) *CompraService {
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sethvargo/go-password/password"
+)
+
+type CuentaServicioService struct {
+	cuentaServicioRepository port.CuentaServicioRepository
+}
+
+func (c CuentaServicioService) ListarCuentasServicio(ctx context.Context) (*[]domain.CuentaServicioInfo, error) {
+	return c.cuentaServicioRepository.ListarCuentasServicio(ctx)
+}
+
+func (c CuentaServicioService) ObtenerCuentaServicioById(ctx context.Context, cuentaId *int) (*domain.CuentaServicioDetail, error) {
+	return c.cuentaServicioRepository.ObtenerCuentaServicioById(ctx, cuentaId)
+}
+
+// RegistrarCuentaServicio crea la cuenta con una contraseña aleatoria que nadie conoce; las cuentas de servicio
+// no inician sesión y solo se autentican con API keys
+func (c CuentaServicioService) RegistrarCuentaServicio(ctx context.Context, request *domain.CuentaServicioRequest) (*domain.CuentaServicioDetail, error) {
+	if err := validarCuentaServicio(request); err != nil {
+		return nil, err
+	}
+	passwordGenerado, err := password.Generate(32, 8, 0, false, true)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	hashPassword, err := util.Hash.HashearPassword(passwordGenerado)
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	cuentaId, err := c.cuentaServicioRepository.RegistrarCuentaServicio(ctx, request, hashPassword)
+	if err != nil {
+		return nil, err
+	}
+	return c.cuentaServicioRepository.ObtenerCuentaServicioById(ctx, cuentaId)
+}
+
+func (c CuentaServicioService) ModificarCuentaServicio(ctx context.Context, cuentaId *int, request *domain.CuentaServicioRequest) error {
+	if err := validarCuentaServicio(request); err != nil {
+		return err
+	}
+	return c.cuentaServicioRepository.ModificarCuentaServicio(ctx, cuentaId, request)
+}
+
+func (c CuentaServicioService) HabilitarCuentaServicio(ctx context.Context, cuentaId *int) error {
+	return c.cuentaServicioRepository.HabilitarCuentaServicio(ctx, cuentaId)
+}
+
+func (c CuentaServicioService) DeshabilitarCuentaServicio(ctx context.Context, cuentaId *int) error {
+	return c.cuentaServicioRepository.DeshabilitarCuentaServicio(ctx, cuentaId)
+}
+
+// GenerarApiKey crea una API key para la cuenta; la key completa solo se devuelve en esta respuesta
+func (c CuentaServicioService) GenerarApiKey(ctx context.Context, cuentaId *int, request *domain.ApiKeyRequest) (*domain.ApiKeyCreada, error) {
+	request.Nombre = strings.TrimSpace(request.Nombre)
+	if request.Nombre == "" || len(request.Nombre) > 100 {
+		return nil, datatype.NewBadRequestError("El nombre de la API key es obligatorio y debe tener como máximo 100 caracteres")
+	}
+	if request.ExpiraAt != nil && !request.ExpiraAt.After(time.Now()) {
+		return nil, datatype.NewBadRequestError("La fecha de expiración debe ser posterior a la fecha actual")
+	}
+	var permisos []string
+	for _, permiso := range request.Permisos {
+		permiso = strings.TrimSpace(permiso)
+		if permiso != "" && !slices.Contains(permisos, permiso) {
+			permisos = append(permisos, permiso)
+		}
+	}
+	request.Permisos = permisos
+
+	key, prefijo, hash, err := util.ApiKey.Generar()
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	apiKey, err := c.cuentaServicioRepository.RegistrarApiKey(ctx, cuentaId, request, prefijo, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ApiKeyCreada{ApiKeyInfo: *apiKey, ApiKey: key}, nil
+}
+
+func (c CuentaServicioService) RevocarApiKey(ctx context.Context, cuentaId *int, apiKeyId *int) error {
+	return c.cuentaServicioRepository.RevocarApiKey(ctx, cuentaId, apiKeyId)
+}
+
+// AutenticarApiKey verifica la API key recibida en la cabecera Authorization; el último uso se registra
+// como máximo una vez por minuto, o antes si cambia la IP
+func (c CuentaServicioService) AutenticarApiKey(ctx context.Context, key string, ip string) (*domain.ApiKeyAutenticada, error) {
+	prefijo, ok := util.ApiKey.Prefijo(key)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("API key no válida")
+	}
+	apiKey, enCache := util.ApiKeysCache.Obtener(prefijo)
+	generacion := util.ApiKeysCache.Generacion()
+	if !enCache {
+		vigente, err := c.cuentaServicioRepository.ObtenerApiKeyVigente(ctx, prefijo)
+		if err != nil {
+			return nil, err
+		}
+		apiKey = *vigente
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(util.ApiKey.Hash(key))) != 1 {
+		return nil, datatype.NewStatusUnauthorizedError("API key no válida")
+	}
+	if apiKey.ExpiraAt != nil && time.Now().After(*apiKey.ExpiraAt) {
+		util.ApiKeysCache.Invalidar(prefijo)
+		return nil, datatype.NewStatusUnauthorizedError("API key no válida")
+	}
+	if !enCache {
+		util.ApiKeysCache.Guardar(prefijo, apiKey, generacion)
+	}
+	if ultimaIp, ok := util.UsoApiKeysCache.Obtener(apiKey.ApiKeyId); !ok || ultimaIp != ip {
+		generacionUso := util.UsoApiKeysCache.Generacion()
+		if err := c.cuentaServicioRepository.RegistrarUsoApiKey(ctx, apiKey.ApiKeyId, ip); err != nil {
+			return nil, err
+		}
+		util.UsoApiKeysCache.Guardar(apiKey.ApiKeyId, ip, generacionUso)
+	}
+	return &apiKey, nil
+}
+
+func validarCuentaServicio(request *domain.CuentaServicioRequest) error {
+	request.Nombre = strings.TrimSpace(request.Nombre)
+	if request.Nombre == "" || len(request.Nombre) > 50 || strings.ContainsAny(request.Nombre, " \t") {
+		return datatype.NewBadRequestError("El nombre de la cuenta es obligatorio, sin espacios y de como máximo 50 caracteres")
+	}
+	if len(request.Roles) == 0 {
+		return datatype.NewBadRequestError("Debe asignar al menos un rol a la cuenta de servicio")
+	}
+	return nil
+}
+
+func NewCuentaServicioService(cuentaServicioRepository port.CuentaServicioRepository) *CuentaServicioService {
+	return &CuentaServicioService{cuentaServicioRepository: cuentaServicioRepository}
+}
+
+var _ port.CuentaServicioService = (*CuentaServicioService)(nil)
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"strings"
+	"time"
+)
+
+type DobleFactorService struct {
+	dobleFactorRepository port.DobleFactorRepository
+}
+
+func (d DobleFactorService) ObtenerEstado(ctx context.Context) (*domain.DobleFactorEstado, error) {
+	df, err := d.dobleFactorUsuarioContexto(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.DobleFactorEstado{
+		Activo:           df.Activo,
+		Requerido:        df.Requerido,
+		ActivadoAt:       df.ActivadoAt,
+		CodigosRestantes: df.CodigosRestantes,
+	}, nil
+}
+
+func (d DobleFactorService) IniciarConfiguracion(ctx context.Context) (*domain.DobleFactorConfiguracion, error) {
+	df, err := d.dobleFactorUsuarioContexto(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return iniciarDobleFactor(ctx, d.dobleFactorRepository, df)
+}
+
+func (d DobleFactorService) ActivarDobleFactor(ctx context.Context, request *domain.DobleFactorCodigoRequest) (*domain.CodigosRecuperacion, error) {
+	df, err := d.dobleFactorUsuarioContexto(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return activarDobleFactor(ctx, d.dobleFactorRepository, df, request.Codigo)
+}
+
+func (d DobleFactorService) DesactivarDobleFactor(ctx context.Context, request *domain.DobleFactorCodigoRequest) error {
+	df, err := d.dobleFactorUsuarioContexto(ctx)
+	if err != nil {
+		return err
+	}
+	if df.Requerido {
+		return datatype.NewBadRequestError("Su rol requiere la verificación en dos pasos")
+	}
+	valido, err := verificarDobleFactor(ctx, d.dobleFactorRepository, df, request.Codigo)
+	if err != nil {
+		return err
+	}
+	if !valido {
+		return datatype.NewBadRequestError(domain.AccesoMotivoDobleFactor)
+	}
+	return d.dobleFactorRepository.QuitarDobleFactor(ctx, &df.UsuarioId)
+}
+
+func (d DobleFactorService) RegenerarCodigosRecuperacion(ctx context.Context, request *domain.DobleFactorCodigoRequest) (*domain.CodigosRecuperacion, error) {
+	df, err := d.dobleFactorUsuarioContexto(ctx)
+	if err != nil {
+		return nil, err
+	}
+	valido, err := verificarDobleFactor(ctx, d.dobleFactorRepository, df, request.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if !valido {
+		return nil, datatype.NewBadRequestError(domain.AccesoMotivoDobleFactor)
+	}
+	codigos, hashes, err := generarCodigosRecuperacion()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.dobleFactorRepository.ReemplazarCodigosRecuperacion(ctx, df.UsuarioId, hashes); err != nil {
+		return nil, err
+	}
+	return &domain.CodigosRecuperacion{Codigos: codigos}, nil
+}
+
+// RestablecerDobleFactor quita la verificación en dos pasos de un usuario que perdió su teléfono y sus códigos
+func (d DobleFactorService) RestablecerDobleFactor(ctx context.Context, usuarioId *int) error {
+	return d.dobleFactorRepository.QuitarDobleFactor(ctx, usuarioId)
+}
+
+func (d DobleFactorService) dobleFactorUsuarioContexto(ctx context.Context) (*domain.DobleFactorUsuario, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	return d.dobleFactorRepository.ObtenerDobleFactor(ctx, userId)
+}
+
+// iniciarDobleFactor genera un nuevo secreto que queda pendiente hasta confirmarlo con el primer código
+func iniciarDobleFactor(ctx context.Context, repository port.DobleFactorRepository, df *domain.DobleFactorUsuario) (*domain.DobleFactorConfiguracion, error) {
+	if df.Activo {
+		return nil, datatype.NewConflictError("La verificación en dos pasos ya está activa")
+	}
+	secreto, err := util.Totp.GenerarSecreto()
+	if err != nil {
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	cifrado, err := util.Totp.CifrarSecreto(secreto)
+	if err != nil {
+		log.Println("Error al cifrar secreto de verificación en dos pasos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	if err := repository.GuardarSecretoDobleFactor(ctx, df.UsuarioId, cifrado); err != nil {
+		return nil, err
+	}
+	uri := util.Totp.Uri(df.Username, secreto)
+	qr, err := util.Totp.Qr(uri)
+	if err != nil {
+		log.Println("Error al generar código QR:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	return &domain.DobleFactorConfiguracion{Secreto: secreto, Uri: uri, Qr: qr}, nil
+}
+
+// activarDobleFactor confirma el secreto pendiente con un código de la app y entrega los códigos de recuperación
+func activarDobleFactor(ctx context.Context, repository port.DobleFactorRepository, df *domain.DobleFactorUsuario, codigo string) (*domain.CodigosRecuperacion, error) {
+	if df.Activo {
+		return nil, datatype.NewConflictError("La verificación en dos pasos ya está activa")
+	}
+	if df.Secreto == nil {
+		return nil, datatype.NewBadRequestError("Primero debe generar el código QR de configuración")
+	}
+	secreto, err := util.Totp.DescifrarSecreto(*df.Secreto)
+	if err != nil {
+		log.Println("Error al descifrar secreto de verificación en dos pasos:", err)
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	paso, ok := util.Totp.Verificar(secreto, codigo, time.Now())
+	if !ok {
+		return nil, datatype.NewBadRequestError(domain.AccesoMotivoDobleFactor)
+	}
+	codigos, hashes, err := generarCodigosRecuperacion()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ActivarDobleFactor(ctx, df.UsuarioId, paso, hashes); err != nil {
+		return nil, err
+	}
+	return &domain.CodigosRecuperacion{Codigos: codigos}, nil
+}
+
+// verificarDobleFactor acepta un código de la app autenticadora o uno de recuperación; ninguno se acepta dos veces
+func verificarDobleFactor(ctx context.Context, repository port.DobleFactorRepository, df *domain.DobleFactorUsuario, codigo string) (bool, error) {
+	if !df.Activo || df.Secreto == nil {
+		return false, datatype.NewBadRequestError("La verificación en dos pasos no está activa")
+	}
+	codigo = strings.TrimSpace(codigo)
+	if codigo == "" {
+		return false, datatype.NewBadRequestError("El código de verificación es obligatorio")
+	}
+	if !esCodigoTotp(codigo) {
+		return repository.UsarCodigoRecuperacion(ctx, df.UsuarioId, util.Totp.HashCodigoRecuperacion(codigo))
+	}
+	secreto, err := util.Totp.DescifrarSecreto(*df.Secreto)
+	if err != nil {
+		log.Println("Error al descifrar secreto de verificación en dos pasos:", err)
+		return false, datatype.NewInternalServerErrorGeneric()
+	}
+	paso, ok := util.Totp.Verificar(secreto, codigo, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return repository.RegistrarPasoDobleFactor(ctx, df.UsuarioId, paso)
+}
+
+func esCodigoTotp(codigo string) bool {
+	if len(codigo) != 6 {
+		return false
+	}
+	for _, c := range codigo {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generarCodigosRecuperacion devuelve los códigos para mostrar al usuario y sus hashes para guardar
+func generarCodigosRecuperacion() ([]string, []string, error) {
+	codigos, err := util.Totp.GenerarCodigosRecuperacion(domain.CantidadCodigosRecuperacion)
+	if err != nil {
+		return nil, nil, datatype.NewInternalServerErrorGeneric()
+	}
+	hashes := make([]string, len(codigos))
+	for i, codigo := range codigos {
+		hashes[i] = util.Totp.HashCodigoRecuperacion(codigo)
+	}
+	return codigos, hashes, nil
+}
+
+func NewDobleFactorService(dobleFactorRepository port.DobleFactorRepository) *DobleFactorService {
+	return &DobleFactorService{dobleFactorRepository: dobleFactorRepository}
+}
+
+var _ port.DobleFactorService = (*DobleFactorService)(nil)
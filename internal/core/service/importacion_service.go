@@ -0,0 +1,546 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ImportacionService struct {
+	importacionRepository port.ImportacionRepository
+}
+
+// Columnas del archivo de productos, en el orden en que se exportan
+const (
+	colNombreComercial      = "nombreComercial"
+	colLaboratorio          = "laboratorio"
+	colFormaFarmaceutica    = "formaFarmaceutica"
+	colPresentacion         = "presentacion"
+	colUnidadesPresentacion = "unidadesPresentacion"
+	colCategorias           = "categorias"
+	colPrincipiosActivos    = "principiosActivos"
+	colPrecioCompra         = "precioCompra"
+	colPrecioVenta          = "precioVenta"
+	colPrecioVentaUnidad    = "precioVentaUnidad"
+	colStockMin             = "stockMin"
+	colNivelControl         = "nivelControl"
+	colRefrigerado          = "refrigerado"
+	colControlado           = "controlado"
+	colCodigosBarra         = "codigosBarra"
+)
+
+var columnasProducto = []string{
+	colNombreComercial, colLaboratorio, colFormaFarmaceutica, colPresentacion, colUnidadesPresentacion,
+	colCategorias, colPrincipiosActivos, colPrecioCompra, colPrecioVenta, colPrecioVentaUnidad,
+	colStockMin, colNivelControl, colRefrigerado, colControlado, colCodigosBarra,
+}
+
+var columnasProductoObligatorias = []string{colNombreComercial, colLaboratorio, colFormaFarmaceutica, colPrecioVenta, colStockMin}
+
+// Columnas del archivo de inventario inicial
+const (
+	colProducto         = "producto"
+	colLote             = "lote"
+	colFechaVencimiento = "fechaVencimiento"
+	colCantidad         = "cantidad"
+	colUnidad           = "unidad"
+	colCostoUnitario    = "costoUnitario"
+	colUbicacion        = "ubicacion"
+)
+
+var columnasStockInicialObligatorias = []string{colProducto, colLaboratorio, colLote, colFechaVencimiento, colCantidad, colCostoUnitario}
+
+// separadorLista separa los valores de las columnas con varios elementos (categorías, principios activos, códigos)
+const separadorLista = "|"
+
+func (i ImportacionService) ImportarProductos(ctx context.Context, fileHeader *multipart.FileHeader, dryRun bool) (*domain.ResultadoImportacion, error) {
+	tabla, err := leerTablaImportacion(fileHeader, columnasProductoObligatorias)
+	if err != nil {
+		return nil, err
+	}
+
+	var filas []domain.ProductoImportacion
+	var errores []domain.ErrorImportacion
+	vistos := make(map[string]int)
+	for _, registro := range tabla.registros {
+		fila, erroresFila := parsearProductoImportacion(registro)
+		if len(erroresFila) > 0 {
+			errores = append(errores, erroresFila...)
+			continue
+		}
+		clave := strings.ToLower(fila.NombreComercial + "|" + fila.Laboratorio)
+		if previa, ok := vistos[clave]; ok {
+			errores = append(errores, domain.ErrorImportacion{Fila: fila.Fila, Columna: colNombreComercial,
+				Mensaje: fmt.Sprintf("El producto ya aparece en la fila %d", previa)})
+			continue
+		}
+		vistos[clave] = fila.Fila
+		filas = append(filas, fila)
+	}
+	if len(filas) == 0 && len(errores) == 0 {
+		return nil, datatype.NewBadRequestError("El archivo no tiene productos para importar")
+	}
+
+	// Con errores de formato igual se validan las demás filas contra la base, sin aplicar cambios
+	resultado, err := i.importacionRepository.ImportarProductos(ctx, &filas, dryRun || len(errores) > 0)
+	if err != nil {
+		return nil, err
+	}
+	resultado.DryRun = dryRun
+	resultado.Total += len(errores)
+	resultado.Errores = ordenarErroresImportacion(append(errores, resultado.Errores...))
+	return resultado, nil
+}
+
+func (i ImportacionService) ExportarProductos(ctx context.Context, formato string) ([]byte, string, error) {
+	formato, err := formatoExportacion(formato)
+	if err != nil {
+		return nil, "", err
+	}
+	productos, err := i.importacionRepository.ObtenerProductosExportacion(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filas := [][]string{columnasProducto}
+	for _, p := range *productos {
+		var principios []string
+		for _, pa := range p.PrincipiosActivos {
+			principios = append(principios, fmt.Sprintf("%s:%s:%s", pa.Nombre, formatearNumero(pa.Concentracion), pa.UnidadMedida))
+		}
+		var codigos []string
+		for _, c := range p.CodigosBarra {
+			codigos = append(codigos, c.Codigo)
+		}
+		presentacion := ""
+		if p.Presentacion != nil {
+			presentacion = *p.Presentacion
+		}
+		precioCompra, precioVentaUnidad := "", ""
+		if p.PrecioCompra != nil {
+			precioCompra = formatearNumero(*p.PrecioCompra)
+		}
+		if p.PrecioVentaUnidad != nil {
+			precioVentaUnidad = formatearNumero(*p.PrecioVentaUnidad)
+		}
+		filas = append(filas, []string{
+			p.NombreComercial, p.Laboratorio, p.FormaFarmaceutica, presentacion, strconv.Itoa(p.UnidadesPresentacion),
+			strings.Join(p.Categorias, separadorLista), strings.Join(principios, separadorLista), precioCompra,
+			formatearNumero(p.PrecioVenta), precioVentaUnidad, strconv.FormatInt(p.StockMin, 10), p.NivelControl,
+			formatearBooleano(p.Refrigerado), formatearBooleano(p.Controlado), strings.Join(codigos, separadorLista),
+		})
+	}
+
+	data, contentType, err := util.Tabla.Escribir(formato, "Productos", filas)
+	if err != nil {
+		return nil, "", datatype.NewInternalServerError("Error al generar el archivo de exportación")
+	}
+	return data, contentType, nil
+}
+
+func (i ImportacionService) ImportarStockInicial(ctx context.Context, fileHeader *multipart.FileHeader, dryRun bool) (*domain.ResultadoImportacion, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+
+	tabla, err := leerTablaImportacion(fileHeader, columnasStockInicialObligatorias)
+	if err != nil {
+		return nil, err
+	}
+
+	var filas []domain.StockInicialImportacion
+	var errores []domain.ErrorImportacion
+	vistos := make(map[string]int)
+	for _, registro := range tabla.registros {
+		fila, erroresFila := parsearStockInicialImportacion(registro)
+		if len(erroresFila) > 0 {
+			errores = append(errores, erroresFila...)
+			continue
+		}
+		clave := strings.ToLower(fila.Producto + "|" + fila.Laboratorio + "|" + fila.Lote)
+		if previa, ok := vistos[clave]; ok {
+			errores = append(errores, domain.ErrorImportacion{Fila: fila.Fila, Columna: colLote,
+				Mensaje: fmt.Sprintf("El lote ya aparece en la fila %d", previa)})
+			continue
+		}
+		vistos[clave] = fila.Fila
+		filas = append(filas, fila)
+	}
+	if len(filas) == 0 && len(errores) == 0 {
+		return nil, datatype.NewBadRequestError("El archivo no tiene lotes para importar")
+	}
+
+	resultado, err := i.importacionRepository.ImportarStockInicial(ctx, &filas, userId, dryRun || len(errores) > 0)
+	if err != nil {
+		return nil, err
+	}
+	resultado.DryRun = dryRun
+	resultado.Total += len(errores)
+	resultado.Errores = ordenarErroresImportacion(append(errores, resultado.Errores...))
+	return resultado, nil
+}
+
+// tablaImportacion son las filas de datos de un archivo con sus columnas indexadas por nombre
+type tablaImportacion struct {
+	registros []registroImportacion
+}
+
+// registroImportacion es una fila de datos con su número de fila en el archivo; las claves van en minúsculas
+type registroImportacion struct {
+	fila    int
+	valores map[string]string
+}
+
+func (r registroImportacion) valor(columna string) string {
+	return strings.TrimSpace(r.valores[strings.ToLower(columna)])
+}
+
+// leerTablaImportacion lee el archivo y toma la primera fila como encabezado; los nombres de columna no distinguen mayúsculas
+func leerTablaImportacion(fileHeader *multipart.FileHeader, obligatorias []string) (*tablaImportacion, error) {
+	if !util.File.ValidarTipoArchivo(fileHeader.Filename, ".csv", ".xlsx") {
+		return nil, datatype.NewBadRequestError("Tipo de archivo no válido, se espera CSV o XLSX")
+	}
+	filas, err := util.Tabla.Leer(fileHeader)
+	if err != nil {
+		return nil, datatype.NewBadRequestError(fmt.Sprintf("No se pudo leer el archivo: %s", err.Error()))
+	}
+	if len(filas) == 0 {
+		return nil, datatype.NewBadRequestError("El archivo está vacío")
+	}
+
+	encabezado := make(map[string]int)
+	for idx, nombre := range filas[0] {
+		encabezado[strings.ToLower(strings.TrimSpace(nombre))] = idx
+	}
+	var faltantes []string
+	for _, columna := range obligatorias {
+		if _, ok := encabezado[strings.ToLower(columna)]; !ok {
+			faltantes = append(faltantes, columna)
+		}
+	}
+	if len(faltantes) > 0 {
+		return nil, datatype.NewBadRequestError(fmt.Sprintf("Faltan columnas obligatorias: %s", strings.Join(faltantes, ", ")))
+	}
+
+	var tabla tablaImportacion
+	for n, fila := range filas[1:] {
+		registro := registroImportacion{fila: n + 2, valores: make(map[string]string)}
+		vacia := true
+		for nombre, idx := range encabezado {
+			if idx < len(fila) {
+				registro.valores[nombre] = fila[idx]
+				vacia = vacia && strings.TrimSpace(fila[idx]) == ""
+			}
+		}
+		if !vacia {
+			tabla.registros = append(tabla.registros, registro)
+		}
+	}
+	return &tabla, nil
+}
+
+// parsearProductoImportacion convierte una fila del archivo y devuelve todos los errores encontrados en ella
+func parsearProductoImportacion(r registroImportacion) (domain.ProductoImportacion, []domain.ErrorImportacion) {
+	var errores []domain.ErrorImportacion
+	agregarError := func(columna, mensaje string) {
+		errores = append(errores, domain.ErrorImportacion{Fila: r.fila, Columna: columna, Mensaje: mensaje})
+	}
+
+	fila := domain.ProductoImportacion{
+		Fila:              r.fila,
+		NombreComercial:   strings.ToUpper(r.valor(colNombreComercial)),
+		Laboratorio:       strings.ToUpper(r.valor(colLaboratorio)),
+		FormaFarmaceutica: r.valor(colFormaFarmaceutica),
+		NivelControl:      r.valor(colNivelControl),
+	}
+
+	validarTexto := func(columna, valor string, maximo int) {
+		if valor == "" {
+			agregarError(columna, "El valor es obligatorio")
+		} else if utf8.RuneCountInString(valor) > maximo {
+			agregarError(columna, fmt.Sprintf("El valor no puede superar los %d caracteres", maximo))
+		}
+	}
+	validarTexto(colNombreComercial, fila.NombreComercial, 70)
+	validarTexto(colLaboratorio, fila.Laboratorio, 50)
+	validarTexto(colFormaFarmaceutica, fila.FormaFarmaceutica, 50)
+
+	if presentacion := r.valor(colPresentacion); presentacion != "" {
+		validarTexto(colPresentacion, presentacion, 50)
+		fila.Presentacion = &presentacion
+	}
+
+	if valor := r.valor(colUnidadesPresentacion); valor != "" {
+		unidades, err := strconv.Atoi(valor)
+		if err != nil {
+			agregarError(colUnidadesPresentacion, "Debe ser un número entero")
+		}
+		fila.UnidadesPresentacion = unidades
+	}
+
+	for _, categoria := range dividirLista(r.valor(colCategorias)) {
+		categoria = strings.ToUpper(categoria)
+		validarTexto(colCategorias, categoria, 100)
+		fila.Categorias = append(fila.Categorias, categoria)
+	}
+
+	for _, principio := range dividirLista(r.valor(colPrincipiosActivos)) {
+		// Formato NOMBRE:CONCENTRACION:UNIDAD; el nombre puede contener dos puntos
+		partes := strings.Split(principio, ":")
+		if len(partes) < 3 {
+			agregarError(colPrincipiosActivos, fmt.Sprintf("'%s' debe tener el formato nombre:concentración:unidad", principio))
+			continue
+		}
+		nombre := strings.ToUpper(strings.TrimSpace(strings.Join(partes[:len(partes)-2], ":")))
+		concentracion, err := parsearNumero(partes[len(partes)-2])
+		if err != nil || concentracion <= 0 {
+			agregarError(colPrincipiosActivos, fmt.Sprintf("La concentración de '%s' debe ser un número mayor a cero", nombre))
+			continue
+		}
+		validarTexto(colPrincipiosActivos, nombre, 100)
+		fila.PrincipiosActivos = append(fila.PrincipiosActivos, domain.PrincipioActivoImportacion{
+			Nombre:        nombre,
+			Concentracion: concentracion,
+			UnidadMedida:  strings.TrimSpace(partes[len(partes)-1]),
+		})
+	}
+
+	if valor := r.valor(colPrecioCompra); valor != "" {
+		precio, err := parsearNumero(valor)
+		if err != nil || precio < 0 {
+			agregarError(colPrecioCompra, "Debe ser un número no negativo")
+		}
+		fila.PrecioCompra = &precio
+	}
+
+	if valor := r.valor(colPrecioVenta); valor == "" {
+		agregarError(colPrecioVenta, "El valor es obligatorio")
+	} else {
+		precio, err := parsearNumero(valor)
+		if err != nil || precio < 0 {
+			agregarError(colPrecioVenta, "Debe ser un número no negativo")
+		}
+		fila.PrecioVenta = precio
+	}
+
+	if valor := r.valor(colPrecioVentaUnidad); valor != "" {
+		precio, err := parsearNumero(valor)
+		if err != nil {
+			agregarError(colPrecioVentaUnidad, "Debe ser un número")
+		}
+		fila.PrecioVentaUnidad = &precio
+	}
+
+	if valor := r.valor(colStockMin); valor == "" {
+		agregarError(colStockMin, "El valor es obligatorio")
+	} else {
+		stockMin, err := strconv.ParseInt(valor, 10, 64)
+		if err != nil || stockMin < 0 {
+			agregarError(colStockMin, "Debe ser un número entero no negativo")
+		}
+		fila.StockMin = stockMin
+	}
+
+	var err error
+	if fila.Refrigerado, err = parsearBooleano(r.valor(colRefrigerado)); err != nil {
+		agregarError(colRefrigerado, err.Error())
+	}
+	if fila.Controlado, err = parsearBooleano(r.valor(colControlado)); err != nil {
+		agregarError(colControlado, err.Error())
+	}
+
+	for _, codigo := range dividirLista(r.valor(colCodigosBarra)) {
+		fila.CodigosBarra = append(fila.CodigosBarra, domain.CodigoBarraRequest{Codigo: codigo})
+	}
+
+	// Mismas reglas que el registro individual de productos
+	request := domain.ProductRequest{
+		NivelControl:         fila.NivelControl,
+		UnidadesPresentacion: fila.UnidadesPresentacion,
+		PrecioVentaUnidad:    fila.PrecioVentaUnidad,
+	}
+	if err := validarNivelControl(&request.NivelControl); err != nil {
+		agregarError(colNivelControl, mensajeError(err))
+	}
+	if err := validarUnidadesVenta(&request); err != nil {
+		agregarError(colUnidadesPresentacion, mensajeError(err))
+	}
+	if err := validarCodigosBarra(fila.CodigosBarra); err != nil {
+		agregarError(colCodigosBarra, mensajeError(err))
+	}
+	fila.NivelControl = request.NivelControl
+	fila.UnidadesPresentacion = request.UnidadesPresentacion
+
+	return fila, errores
+}
+
+// parsearStockInicialImportacion convierte una fila del inventario inicial; la fecha de vencimiento sigue
+// la misma regla que el trigger validar_fecha_vencimiento_lote (no puede ser anterior a hoy)
+func parsearStockInicialImportacion(r registroImportacion) (domain.StockInicialImportacion, []domain.ErrorImportacion) {
+	var errores []domain.ErrorImportacion
+	agregarError := func(columna, mensaje string) {
+		errores = append(errores, domain.ErrorImportacion{Fila: r.fila, Columna: columna, Mensaje: mensaje})
+	}
+
+	fila := domain.StockInicialImportacion{
+		Fila:        r.fila,
+		Producto:    strings.ToUpper(r.valor(colProducto)),
+		Laboratorio: strings.ToUpper(r.valor(colLaboratorio)),
+		Lote:        r.valor(colLote),
+		Unidad:      domain.UnidadVentaPresentacion,
+	}
+	for columna, valor := range map[string]string{colProducto: fila.Producto, colLaboratorio: fila.Laboratorio, colLote: fila.Lote} {
+		if valor == "" {
+			agregarError(columna, "El valor es obligatorio")
+		}
+	}
+
+	if valor := r.valor(colFechaVencimiento); valor == "" {
+		agregarError(colFechaVencimiento, "El valor es obligatorio")
+	} else if fecha, err := parsearFechaImportacion(valor); err != nil {
+		agregarError(colFechaVencimiento, "Fecha no válida, use AAAA-MM-DD o DD/MM/AAAA")
+	} else {
+		anio, mes, dia := time.Now().Date()
+		if fecha.Before(time.Date(anio, mes, dia, 0, 0, 0, 0, time.UTC)) {
+			agregarError(colFechaVencimiento, "La fecha de vencimiento no puede ser menor que la fecha actual")
+		}
+		fila.FechaVencimiento = fecha
+	}
+
+	if valor := r.valor(colCantidad); valor == "" {
+		agregarError(colCantidad, "El valor es obligatorio")
+	} else {
+		cantidad, err := strconv.ParseInt(valor, 10, 64)
+		if err != nil || cantidad <= 0 {
+			agregarError(colCantidad, "Debe ser un número entero mayor a cero")
+		}
+		fila.Cantidad = cantidad
+	}
+
+	if valor := r.valor(colUnidad); valor != "" {
+		switch strings.ToLower(valor) {
+		case strings.ToLower(domain.UnidadVentaPresentacion):
+			fila.Unidad = domain.UnidadVentaPresentacion
+		case strings.ToLower(domain.UnidadVentaUnidad):
+			fila.Unidad = domain.UnidadVentaUnidad
+		default:
+			agregarError(colUnidad, "Unidad no válida, use Presentacion o Unidad")
+		}
+	}
+
+	if valor := r.valor(colCostoUnitario); valor == "" {
+		agregarError(colCostoUnitario, "El valor es obligatorio")
+	} else {
+		costo, err := parsearNumero(valor)
+		if err != nil || costo < 0 {
+			agregarError(colCostoUnitario, "Debe ser un número no negativo")
+		}
+		fila.CostoUnitario = costo
+	}
+
+	if ubicacion := strings.ToUpper(r.valor(colUbicacion)); ubicacion != "" {
+		fila.Ubicacion = &ubicacion
+	}
+	return fila, errores
+}
+
+// parsearFechaImportacion acepta fechas ISO, DD/MM/AAAA y el número de serie con que XLSX guarda las fechas
+func parsearFechaImportacion(valor string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "2006/01/02"} {
+		if fecha, err := time.Parse(layout, valor); err == nil {
+			return fecha, nil
+		}
+	}
+	if serie, err := strconv.Atoi(valor); err == nil && serie > 0 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, serie), nil
+	}
+	return time.Time{}, errors.New("fecha no válida")
+}
+
+// dividirLista separa los valores de una columna con varios elementos, ignorando los vacíos
+func dividirLista(valor string) []string {
+	var lista []string
+	for _, item := range strings.Split(valor, separadorLista) {
+		if item = strings.TrimSpace(item); item != "" {
+			lista = append(lista, item)
+		}
+	}
+	return lista
+}
+
+// parsearNumero acepta coma decimal cuando el valor no tiene punto
+func parsearNumero(valor string) (float64, error) {
+	valor = strings.TrimSpace(valor)
+	if !strings.Contains(valor, ".") {
+		valor = strings.Replace(valor, ",", ".", 1)
+	}
+	return strconv.ParseFloat(valor, 64)
+}
+
+func parsearBooleano(valor string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(valor)) {
+	case "", "no", "false", "0", "n":
+		return false, nil
+	case "si", "sí", "true", "1", "s", "x":
+		return true, nil
+	default:
+		return false, fmt.Errorf("'%s' no es un valor válido, use SI o NO", valor)
+	}
+}
+
+func formatearNumero(valor float64) string {
+	return strconv.FormatFloat(valor, 'f', -1, 64)
+}
+
+func formatearBooleano(valor bool) string {
+	if valor {
+		return "SI"
+	}
+	return "NO"
+}
+
+// formatoExportacion normaliza el formato pedido; por defecto se exporta en XLSX
+func formatoExportacion(formato string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(formato)) {
+	case "", util.FormatoXLSX:
+		return util.FormatoXLSX, nil
+	case util.FormatoCSV:
+		return util.FormatoCSV, nil
+	default:
+		return "", datatype.NewBadRequestError("Formato no válido, use csv o xlsx")
+	}
+}
+
+func mensajeError(err error) string {
+	var errorResponse *datatype.ErrorResponse
+	if errors.As(err, &errorResponse) {
+		return errorResponse.Message
+	}
+	return err.Error()
+}
+
+// ordenarErroresImportacion ordena los errores por fila conservando el orden dentro de cada fila
+func ordenarErroresImportacion(errores []domain.ErrorImportacion) []domain.ErrorImportacion {
+	slices.SortStableFunc(errores, func(a, b domain.ErrorImportacion) int {
+		return a.Fila - b.Fila
+	})
+	return errores
+}
+
+func NewImportacionService(importacionRepository port.ImportacionRepository) *ImportacionService {
+	return &ImportacionService{importacionRepository: importacionRepository}
+}
+
+var _ port.ImportacionService = (*ImportacionService)(nil)
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"net/http"
+	"strings"
+)
+
+type InteraccionService struct {
+	interaccionRepository port.InteraccionRepository
+}
+
+func (i InteraccionService) ObtenerListaInteracciones(ctx context.Context, filtros map[string]string) (*[]domain.InteraccionInfo, error) {
+	return i.interaccionRepository.ObtenerListaInteracciones(ctx, filtros)
+}
+
+func (i InteraccionService) ObtenerInteraccionById(ctx context.Context, id *int) (*domain.InteraccionInfo, error) {
+	return i.interaccionRepository.ObtenerInteraccionById(ctx, id)
+}
+
+func (i InteraccionService) RegistrarInteraccion(ctx context.Context, request *domain.InteraccionRequest) (*int, error) {
+	if err := validarInteraccion(request); err != nil {
+		return nil, err
+	}
+	return i.interaccionRepository.RegistrarInteraccion(ctx, request)
+}
+
+func (i InteraccionService) ModificarInteraccion(ctx context.Context, id *int, request *domain.InteraccionRequest) error {
+	if err := validarInteraccion(request); err != nil {
+		return err
+	}
+	return i.interaccionRepository.ModificarInteraccion(ctx, id, request)
+}
+
+func (i InteraccionService) EliminarInteraccion(ctx context.Context, id *int) error {
+	return i.interaccionRepository.EliminarInteraccion(ctx, id)
+}
+
+// validarInteraccion verifica los datos y ordena el par de principios activos para evitar duplicados invertidos
+func validarInteraccion(request *domain.InteraccionRequest) error {
+	request.Descripcion = strings.TrimSpace(request.Descripcion)
+	if request.PrincipioActivoAId <= 0 || request.PrincipioActivoBId <= 0 {
+		return datatype.NewBadRequestError("Los principios activos de la interacción son obligatorios")
+	}
+	if request.PrincipioActivoAId == request.PrincipioActivoBId {
+		return datatype.NewBadRequestError("La interacción debe ser entre dos principios activos distintos")
+	}
+	if request.PrincipioActivoAId > request.PrincipioActivoBId {
+		request.PrincipioActivoAId, request.PrincipioActivoBId = request.PrincipioActivoBId, request.PrincipioActivoAId
+	}
+	switch request.Severidad {
+	case domain.SeveridadLeve, domain.SeveridadModerada, domain.SeveridadGrave:
+	default:
+		return datatype.NewBadRequestError("Severidad de interacción no válida")
+	}
+	if request.Descripcion == "" {
+		return datatype.NewBadRequestError("La descripción de la interacción es obligatoria")
+	}
+	return nil
+}
+
+// verificarInteracciones carga las advertencias de los productos de la venta y exige autorización ante interacciones graves
+func verificarInteracciones(ctx context.Context, interaccionRepository port.InteraccionRepository, request *domain.VentaRequest) error {
+	var productoIds []string
+	for _, d := range request.Detalles {
+		productoIds = append(productoIds, d.ProductoId)
+	}
+	advertencias, err := interaccionRepository.ObtenerAdvertenciasProductos(ctx, productoIds)
+	if err != nil {
+		return err
+	}
+	request.Advertencias = *advertencias
+	request.InteraccionesGraves = nil
+	for _, a := range request.Advertencias {
+		if a.InteraccionId != nil && a.Severidad != nil && *a.Severidad == domain.SeveridadGrave {
+			request.InteraccionesGraves = append(request.InteraccionesGraves, *a.InteraccionId)
+		}
+	}
+	if len(request.InteraccionesGraves) == 0 {
+		return nil
+	}
+	if request.AutorizacionInteraccion == nil {
+		return datatype.NewErrorDataResponse(http.StatusConflict, "La venta contiene interacciones graves que requieren autorización del farmacéutico", request.Advertencias)
+	}
+	request.AutorizacionInteraccion.Motivo = strings.TrimSpace(request.AutorizacionInteraccion.Motivo)
+	if request.AutorizacionInteraccion.Motivo == "" {
+		return datatype.NewBadRequestError("El motivo de la autorización es obligatorio")
+	}
+	return nil
+}
+
+func NewInteraccionService(interaccionRepository port.InteraccionRepository) *InteraccionService {
+	return &InteraccionService{interaccionRepository: interaccionRepository}
+}
+
+var _ port.InteraccionService = (*InteraccionService)(nil)
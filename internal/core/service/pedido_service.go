@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+type PedidoService struct {
+	pedidoRepository      port.PedidoRepository
+	ventaRepository       port.VentaRepository
+	interaccionRepository port.InteraccionRepository
+}
+
+// Estados desde los que se puede pasar a cada estado del pedido
+var transicionesPedido = map[string][]string{
+	domain.PedidoPreparando: {domain.PedidoRecibido},
+	domain.PedidoListo:      {domain.PedidoPreparando},
+	domain.PedidoCancelado:  {domain.PedidoRecibido, domain.PedidoPreparando, domain.PedidoListo},
+}
+
+func (p PedidoService) ObtenerCarrito(ctx context.Context) (*domain.Carrito, error) {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.pedidoRepository.ObtenerCarrito(ctx, usuarioUid)
+}
+
+func (p PedidoService) GuardarItemCarrito(ctx context.Context, request *domain.CarritoItemRequest) error {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return err
+	}
+	if request.ProductoId == "" {
+		return datatype.NewBadRequestError("El producto es obligatorio")
+	}
+	if request.UnidadVenta == "" {
+		request.UnidadVenta = domain.UnidadVentaPresentacion
+	}
+	if request.UnidadVenta != domain.UnidadVentaPresentacion && request.UnidadVenta != domain.UnidadVentaUnidad {
+		return datatype.NewBadRequestError("Unidad de venta no válida, valores permitidos: Presentacion, Unidad")
+	}
+	return p.pedidoRepository.GuardarItemCarrito(ctx, usuarioUid, request)
+}
+
+func (p PedidoService) VaciarCarrito(ctx context.Context) error {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return err
+	}
+	return p.pedidoRepository.VaciarCarrito(ctx, usuarioUid)
+}
+
+func (p PedidoService) RegistrarPedido(ctx context.Context, request *domain.PedidoRequest) (*int, error) {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request.UsuarioUid = usuarioUid
+	return p.pedidoRepository.RegistrarPedido(ctx, request, time.Now().Add(tiempoReservaPedido()))
+}
+
+func (p PedidoService) ObtenerMisPedidos(ctx context.Context) (*[]domain.PedidoInfo, error) {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.pedidoRepository.ObtenerListaPedidos(ctx, map[string]string{"usuarioUid": usuarioUid})
+}
+
+func (p PedidoService) ObtenerMiPedidoById(ctx context.Context, id *int) (*domain.PedidoDetail, error) {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pedido, err := p.pedidoRepository.ObtenerPedidoById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pedido.UsuarioUid != usuarioUid {
+		return nil, datatype.NewNotFoundError("Pedido no encontrado")
+	}
+	return pedido, nil
+}
+
+func (p PedidoService) CancelarMiPedido(ctx context.Context, id *int) error {
+	if _, err := p.ObtenerMiPedidoById(ctx, id); err != nil {
+		return err
+	}
+	// El cliente solo puede cancelar mientras la farmacia no haya empezado a prepararlo
+	return p.pedidoRepository.ActualizarEstadoPedido(ctx, id, domain.PedidoCancelado, []string{domain.PedidoRecibido})
+}
+
+func (p PedidoService) ObtenerListaPedidos(ctx context.Context, filtros map[string]string) (*[]domain.PedidoInfo, error) {
+	return p.pedidoRepository.ObtenerListaPedidos(ctx, filtros)
+}
+
+func (p PedidoService) ObtenerPedidoById(ctx context.Context, id *int) (*domain.PedidoDetail, error) {
+	return p.pedidoRepository.ObtenerPedidoById(ctx, id)
+}
+
+func (p PedidoService) ActualizarEstadoPedido(ctx context.Context, id *int, request *domain.PedidoEstadoRequest) error {
+	if request.Estado == domain.PedidoEntregado {
+		return datatype.NewBadRequestError("Para entregar el pedido debe registrarse su venta")
+	}
+	estadosPrevios, ok := transicionesPedido[request.Estado]
+	if !ok {
+		return datatype.NewBadRequestError("Estado de pedido no válido")
+	}
+	return p.pedidoRepository.ActualizarEstadoPedido(ctx, id, request.Estado, estadosPrevios)
+}
+
+func (p PedidoService) EntregarPedido(ctx context.Context, id *int, request *domain.EntregarPedidoRequest) (*int64, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+
+	pedido, err := p.pedidoRepository.ObtenerPedidoById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pedido.Estado != domain.PedidoListo {
+		return nil, datatype.NewConflictError("El pedido no está listo para entregarse")
+	}
+
+	// Por defecto se factura al cliente vinculado a la cuenta del pedido
+	if request.ClienteId == 0 && pedido.ClienteId != nil {
+		request.ClienteId = *pedido.ClienteId
+	}
+	if request.ClienteId == 0 {
+		return nil, datatype.NewBadRequestError("El cliente es obligatorio para registrar la venta")
+	}
+
+	venta := domain.VentaRequest{
+		ClienteId:               request.ClienteId,
+		UsuarioId:               uint(userId),
+		TipoPago:                request.TipoPago,
+		Descuento:               request.Descuento,
+		RecetaId:                request.RecetaId,
+		AutorizacionInteraccion: request.AutorizacionInteraccion,
+	}
+	// La venta se cobra al total confirmado del pedido; los precios y lotes los toma el repositorio del pedido
+	for _, d := range pedido.Detalles {
+		venta.Detalles = append(venta.Detalles, domain.DetalleVentaRequest{ProductoId: d.Producto.Id.String(), Cantidad: d.Cantidad, UnidadVenta: d.UnidadVenta})
+	}
+
+	// Verificar interacciones entre los principios activos
+	if err := verificarInteracciones(ctx, p.interaccionRepository, &venta); err != nil {
+		return nil, err
+	}
+
+	ventaId, err := p.pedidoRepository.EntregarPedido(ctx, id, &venta)
+	if err != nil {
+		return nil, err
+	}
+	return facturarVenta(ctx, p.ventaRepository, ventaId)
+}
+
+func (p PedidoService) CancelarPedidosVencidos(ctx context.Context) error {
+	cancelados, err := p.pedidoRepository.CancelarPedidosVencidos(ctx)
+	if err != nil {
+		return err
+	}
+	if cancelados > 0 {
+		log.Printf("Pedidos no recogidos cancelados: %d", cancelados)
+	}
+	return nil
+}
+
+// obtenerUsuarioUid obtiene el UID de Firebase del usuario de la tienda en línea desde el contexto
+func obtenerUsuarioUid(ctx context.Context) (string, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	usuarioUid, ok := val.(string)
+	if !ok || usuarioUid == "" {
+		return "", datatype.NewBadRequestError("UID de usuario inválido o no encontrado en el contexto")
+	}
+	return usuarioUid, nil
+}
+
+// tiempoReservaPedido obtiene el plazo para recoger un pedido en línea (HORAS_RESERVA_PEDIDO, por defecto 48)
+func tiempoReservaPedido() time.Duration {
+	horas, err := strconv.Atoi(os.Getenv("HORAS_RESERVA_PEDIDO"))
+	if err != nil || horas <= 0 {
+		horas = 48
+	}
+	return time.Duration(horas) * time.Hour
+}
+
+func NewPedidoService(pedidoRepository port.PedidoRepository, ventaRepository port.VentaRepository, interaccionRepository port.InteraccionRepository) *PedidoService {
+	return &PedidoService{pedidoRepository: pedidoRepository, ventaRepository: ventaRepository, interaccionRepository: interaccionRepository}
+}
+
+var _ port.PedidoService = (*PedidoService)(nil)
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PrecioService struct {
+	precioRepository port.PrecioRepository
+}
+
+func (p PrecioService) ObtenerHistorialPrecios(ctx context.Context, productoId *uuid.UUID) (*[]domain.HistorialPrecio, error) {
+	return p.precioRepository.ObtenerHistorialPrecios(ctx, productoId)
+}
+
+func (p PrecioService) ObtenerListaPreciosProgramados(ctx context.Context, filtros map[string]string) (*[]domain.PrecioProgramadoInfo, error) {
+	return p.precioRepository.ObtenerListaPreciosProgramados(ctx, filtros)
+}
+
+func (p PrecioService) ProgramarPrecio(ctx context.Context, request *domain.PrecioProgramadoRequest) (*int, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+
+	if request.ProductoId == uuid.Nil {
+		return nil, datatype.NewBadRequestError("El producto es obligatorio")
+	}
+	if request.PrecioVenta <= 0 || (request.PrecioVentaUnidad != nil && *request.PrecioVentaUnidad <= 0) {
+		return nil, datatype.NewBadRequestError("Los precios de venta deben ser mayores a cero")
+	}
+	if request.FechaEfectiva == nil || !request.FechaEfectiva.After(time.Now()) {
+		return nil, datatype.NewBadRequestError("La fecha efectiva debe ser posterior a la fecha actual")
+	}
+	return p.precioRepository.ProgramarPrecio(ctx, request)
+}
+
+func (p PrecioService) AprobarPrecioProgramado(ctx context.Context, id *int, request *domain.AprobarPrecioRequest) error {
+	return p.precioRepository.AprobarPrecioProgramado(ctx, id, request.FechaEfectiva)
+}
+
+func (p PrecioService) CancelarPrecioProgramado(ctx context.Context, id *int) error {
+	return p.precioRepository.CancelarPrecioProgramado(ctx, id)
+}
+
+func (p PrecioService) AplicarPreciosProgramados(ctx context.Context) error {
+	aplicados, err := p.precioRepository.AplicarPreciosProgramados(ctx)
+	if err != nil {
+		return err
+	}
+	if aplicados > 0 {
+		log.Printf("Precios programados aplicados: %d", aplicados)
+	}
+	return nil
+}
+
+func (p PrecioService) PrevisualizarCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*[]domain.CambioPrecioMasivo, error) {
+	return p.calcularCambioMasivo(ctx, request)
+}
+
+func (p PrecioService) AplicarCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*int, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+
+	cambios, err := p.calcularCambioMasivo(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(*cambios) == 0 {
+		return nil, datatype.NewBadRequestError("Ningún producto cambia de precio con los criterios indicados")
+	}
+	return p.precioRepository.AplicarCambioMasivo(ctx, request, cambios)
+}
+
+func (p PrecioService) ObtenerListaCambiosMasivos(ctx context.Context) (*[]domain.CambioMasivoInfo, error) {
+	return p.precioRepository.ObtenerListaCambiosMasivos(ctx)
+}
+
+func (p PrecioService) ObtenerCambioMasivoById(ctx context.Context, id *int) (*domain.CambioMasivoDetail, error) {
+	return p.precioRepository.ObtenerCambioMasivoById(ctx, id)
+}
+
+func (p PrecioService) RevertirCambioMasivo(ctx context.Context, id *int) (*domain.ReversionCambioMasivo, error) {
+	return p.precioRepository.RevertirCambioMasivo(ctx, id)
+}
+
+// calcularCambioMasivo obtiene los productos seleccionados y calcula sus nuevos precios;
+// se descartan los productos cuyo precio no cambia
+func (p PrecioService) calcularCambioMasivo(ctx context.Context, request *domain.CambioMasivoRequest) (*[]domain.CambioPrecioMasivo, error) {
+	if err := validarCambioMasivo(request); err != nil {
+		return nil, err
+	}
+	productos, err := p.precioRepository.ObtenerProductosCambioMasivo(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	factor := 1 + request.Valor/100
+	cambios := make([]domain.CambioPrecioMasivo, 0, len(*productos))
+	for _, item := range *productos {
+		item.PrecioVentaUnidad = item.PrecioVentaUnidadAnterior
+		switch request.Tipo {
+		case domain.CambioMasivoPorcentaje:
+			item.PrecioVenta = redondearPrecio(item.PrecioVentaAnterior*factor, request)
+			if request.IncluirPrecioUnidad && item.PrecioVentaUnidadAnterior != nil {
+				precioUnidad := redondearPrecio(*item.PrecioVentaUnidadAnterior*factor, request)
+				item.PrecioVentaUnidad = &precioUnidad
+			}
+		case domain.CambioMasivoMargen:
+			// Sin precio de compra no se puede calcular el margen
+			if item.PrecioCompra <= 0 {
+				continue
+			}
+			item.PrecioVenta = redondearPrecio(item.PrecioCompra*factor, request)
+			if request.IncluirPrecioUnidad && item.PrecioVentaUnidadAnterior != nil && item.Producto.UnidadesPresentacion > 0 {
+				precioUnidad := redondearPrecio(item.PrecioCompra/float64(item.Producto.UnidadesPresentacion)*factor, request)
+				item.PrecioVentaUnidad = &precioUnidad
+			}
+		}
+		if item.PrecioVenta <= 0 || (item.PrecioVentaUnidad != nil && *item.PrecioVentaUnidad <= 0) {
+			continue
+		}
+		if item.PrecioVenta == item.PrecioVentaAnterior && mismoPrecioMasivo(item.PrecioVentaUnidad, item.PrecioVentaUnidadAnterior) {
+			continue
+		}
+		if item.PrecioVentaAnterior > 0 {
+			item.Variacion = math.Round((item.PrecioVenta-item.PrecioVentaAnterior)/item.PrecioVentaAnterior*10000) / 100
+		}
+		cambios = append(cambios, item)
+	}
+	return &cambios, nil
+}
+
+func validarCambioMasivo(request *domain.CambioMasivoRequest) error {
+	if request.LaboratorioId == nil && request.CategoriaId == nil {
+		return datatype.NewBadRequestError("Debe indicar al menos un laboratorio o una categoría")
+	}
+	switch request.Tipo {
+	case domain.CambioMasivoPorcentaje:
+		if request.Valor <= -100 || request.Valor == 0 {
+			return datatype.NewBadRequestError("El porcentaje debe ser distinto de cero y mayor a -100")
+		}
+	case domain.CambioMasivoMargen:
+		if request.Valor < 0 {
+			return datatype.NewBadRequestError("El margen no puede ser negativo")
+		}
+	default:
+		return datatype.NewBadRequestError("El tipo de cambio debe ser 'Porcentaje' o 'Margen'")
+	}
+	if request.Redondeo != nil && *request.Redondeo <= 0 {
+		return datatype.NewBadRequestError("El redondeo debe ser mayor a cero")
+	}
+	switch request.ModoRedondeo {
+	case "":
+		request.ModoRedondeo = domain.RedondeoCercano
+	case domain.RedondeoCercano, domain.RedondeoArriba:
+	default:
+		return datatype.NewBadRequestError("El modo de redondeo debe ser 'Cercano' o 'Arriba'")
+	}
+	return nil
+}
+
+// redondearPrecio ajusta el precio al múltiplo del redondeo indicado (por ejemplo 0.10 o 0.50 Bs)
+func redondearPrecio(precio float64, request *domain.CambioMasivoRequest) float64 {
+	if request.Redondeo != nil {
+		pasos := precio / *request.Redondeo
+		// Evita que errores de coma flotante suban un paso completo (p. ej. 10.000000001)
+		pasos = math.Round(pasos*1e6) / 1e6
+		if request.ModoRedondeo == domain.RedondeoArriba {
+			pasos = math.Ceil(pasos)
+		} else {
+			pasos = math.Round(pasos)
+		}
+		precio = pasos * *request.Redondeo
+	}
+	return math.Round(precio*100) / 100
+}
+
+func mismoPrecioMasivo(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func NewPrecioService(precioRepository port.PrecioRepository) *PrecioService {
+	return &PrecioService{precioRepository: precioRepository}
+}
+
+var _ port.PrecioService = (*PrecioService)(nil)
@@ -14,12 +14,18 @@ type PrincipioActivoService struct {
 func (p PrincipioActivoService) RegistrarPrincipioActivo(ctx context.Context, request *domain.PrincipioActivoRequest) (*int, error) {
 	request.Nombre = strings.TrimSpace(request.Nombre)
 	request.Nombre = strings.ToUpper(request.Nombre)
+	if err := validarNivelControl(&request.NivelControl); err != nil {
+		return nil, err
+	}
 	return p.principioActivoRepository.RegistrarPrincipioActivo(ctx, request)
 }
 
 func (p PrincipioActivoService) ModificarPrincipioActivo(ctx context.Context, id *int, request *domain.PrincipioActivoRequest) error {
 	request.Nombre = strings.TrimSpace(request.Nombre)
 	request.Nombre = strings.ToUpper(request.Nombre)
+	if err := validarNivelControl(&request.NivelControl); err != nil {
+		return err
+	}
 	return p.principioActivoRepository.ModificarPrincipioActivo(ctx, id, request)
 }
 
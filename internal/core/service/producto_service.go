@@ -6,6 +6,7 @@ import (
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
 	"farma-santi_backend/internal/core/util"
+	"fmt"
 	"mime/multipart"
 	"strings"
 
@@ -31,6 +32,15 @@ func (p ProductoService) DeshabilitarProducto(ctx context.Context, id *uuid.UUID
 func (p ProductoService) RegistrarProducto(ctx context.Context, request *domain.ProductRequest, filesHeader *[]*multipart.FileHeader) error {
 	request.NombreComercial = strings.TrimSpace(request.NombreComercial)
 	request.NombreComercial = strings.ToUpper(request.NombreComercial)
+	if err := validarNivelControl(&request.NivelControl); err != nil {
+		return err
+	}
+	if err := validarCodigosBarra(request.CodigosBarra); err != nil {
+		return err
+	}
+	if err := validarUnidadesVenta(request); err != nil {
+		return err
+	}
 	for _, file := range *filesHeader {
 		if !util.File.ValidarTipoArchivo(file.Filename, ".png", ".jpg", ".jpeg") {
 			return datatype.NewBadRequestError("Tipo de archivo no válido")
@@ -42,6 +52,15 @@ func (p ProductoService) RegistrarProducto(ctx context.Context, request *domain.
 func (p ProductoService) ModificarProducto(ctx context.Context, id *uuid.UUID, request *domain.ProductRequest, filesHeader *[]*multipart.FileHeader) error {
 	request.NombreComercial = strings.TrimSpace(request.NombreComercial)
 	request.NombreComercial = strings.ToUpper(request.NombreComercial)
+	if err := validarNivelControl(&request.NivelControl); err != nil {
+		return err
+	}
+	if err := validarCodigosBarra(request.CodigosBarra); err != nil {
+		return err
+	}
+	if err := validarUnidadesVenta(request); err != nil {
+		return err
+	}
 	for _, file := range *filesHeader {
 		if !util.File.ValidarTipoArchivo(file.Filename, ".png", ".jpg", ".jpeg") {
 			return datatype.NewBadRequestError("Tipo de archivo no válido")
@@ -62,6 +81,84 @@ func (p ProductoService) ObtenerListaProductos(ctx context.Context, filtros map[
 	return p.productoRepository.ObtenerListaProductos(ctx, filtros)
 }
 
+func (p ProductoService) ObtenerEquivalentesProducto(ctx context.Context, id *uuid.UUID) (*[]domain.ProductoEquivalente, error) {
+	return p.productoRepository.ObtenerEquivalentesProducto(ctx, id)
+}
+
+func (p ProductoService) EscanearCodigo(ctx context.Context, codigo string) (*domain.ResultadoEscaneo, error) {
+	codigo = strings.TrimSpace(codigo)
+	if codigo == "" {
+		return nil, datatype.NewBadRequestError("El código es obligatorio")
+	}
+	if !util.Barcode.EsGS1(codigo) {
+		return p.productoRepository.EscanearCodigo(ctx, codigo, nil)
+	}
+
+	// Código GS1-128 o DataMatrix con GTIN, lote y vencimiento
+	datos, err := util.Barcode.ParsearGS1(codigo)
+	if err != nil {
+		return nil, datatype.NewBadRequestError(fmt.Sprintf("Código GS1 inválido: %s", err.Error()))
+	}
+	gs1 := domain.DatosGS1{Gtin: datos.Gtin, FechaVencimiento: datos.FechaVencimiento}
+	if datos.Lote != "" {
+		gs1.Lote = &datos.Lote
+	}
+	if datos.Serie != "" {
+		gs1.Serie = &datos.Serie
+	}
+	return p.productoRepository.EscanearCodigo(ctx, codigo, &gs1)
+}
+
+// validarCodigosBarra verifica el tipo y el dígito de control de los códigos de barras de un producto
+func validarCodigosBarra(codigos []domain.CodigoBarraRequest) error {
+	vistos := make(map[string]bool)
+	for i := range codigos {
+		c := &codigos[i]
+		c.Codigo = strings.TrimSpace(c.Codigo)
+		if c.Codigo == "" {
+			return datatype.NewBadRequestError("El código de barras no puede estar vacío")
+		}
+		if c.Tipo == "" {
+			c.Tipo = domain.CodigoInterno
+			if util.Barcode.ValidarGTIN(c.Codigo) {
+				c.Tipo = domain.CodigoGTIN
+			}
+		}
+		switch c.Tipo {
+		case domain.CodigoGTIN:
+			if !util.Barcode.ValidarGTIN(c.Codigo) {
+				return datatype.NewBadRequestError(fmt.Sprintf("El código %s no es un GTIN/EAN válido", c.Codigo))
+			}
+		case domain.CodigoInterno:
+		default:
+			return datatype.NewBadRequestError("Tipo de código de barras no válido")
+		}
+		clave := c.Codigo
+		if c.Tipo == domain.CodigoGTIN {
+			clave = util.Barcode.NormalizarGTIN(c.Codigo)
+		}
+		if vistos[clave] {
+			return datatype.NewBadRequestError(fmt.Sprintf("El código %s está repetido", c.Codigo))
+		}
+		vistos[clave] = true
+	}
+	return nil
+}
+
+// validarUnidadesVenta verifica el factor de conversión de la presentación y el precio de venta por unidad
+func validarUnidadesVenta(request *domain.ProductRequest) error {
+	if request.UnidadesPresentacion == 0 {
+		request.UnidadesPresentacion = 1
+	}
+	if request.UnidadesPresentacion < 0 {
+		return datatype.NewBadRequestError("Las unidades por presentación deben ser mayores a cero")
+	}
+	if request.PrecioVentaUnidad != nil && *request.PrecioVentaUnidad < 0 {
+		return datatype.NewBadRequestError("El precio de venta por unidad no puede ser negativo")
+	}
+	return nil
+}
+
 func NewProductoService(productoRepository port.ProductoRepository) *ProductoService {
 	return &ProductoService{productoRepository: productoRepository}
 }
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"math"
+	"sort"
+	"strings"
+)
+
+type PromocionService struct {
+	promocionRepository port.PromocionRepository
+}
+
+func (p PromocionService) ObtenerListaPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionInfo, error) {
+	return p.promocionRepository.ObtenerListaPromociones(ctx, filtros)
+}
+
+func (p PromocionService) ObtenerPromocionById(ctx context.Context, id *int) (*domain.PromocionDetail, error) {
+	return p.promocionRepository.ObtenerPromocionById(ctx, id)
+}
+
+func (p PromocionService) RegistrarPromocion(ctx context.Context, request *domain.PromocionRequest) (*int, error) {
+	if err := validarPromocionRequest(request); err != nil {
+		return nil, err
+	}
+	return p.promocionRepository.RegistrarPromocion(ctx, request)
+}
+
+func (p PromocionService) ModificarPromocion(ctx context.Context, id *int, request *domain.PromocionRequest) error {
+	if err := validarPromocionRequest(request); err != nil {
+		return err
+	}
+	return p.promocionRepository.ModificarPromocion(ctx, id, request)
+}
+
+func (p PromocionService) HabilitarPromocion(ctx context.Context, id *int) error {
+	return p.promocionRepository.HabilitarPromocion(ctx, id)
+}
+
+func (p PromocionService) DeshabilitarPromocion(ctx context.Context, id *int) error {
+	return p.promocionRepository.DeshabilitarPromocion(ctx, id)
+}
+
+func (p PromocionService) ObtenerEfectividadPromociones(ctx context.Context, filtros map[string]string) (*[]domain.PromocionEfectividad, error) {
+	return p.promocionRepository.ObtenerEfectividadPromociones(ctx, filtros)
+}
+
+func validarPromocionRequest(request *domain.PromocionRequest) error {
+	request.Nombre = strings.TrimSpace(request.Nombre)
+	if request.Nombre == "" {
+		return datatype.NewBadRequestError("El nombre de la promoción es obligatorio")
+	}
+	if !request.FechaFin.After(request.FechaInicio) {
+		return datatype.NewBadRequestError("La fecha de fin debe ser posterior a la fecha de inicio")
+	}
+
+	switch request.Tipo {
+	case domain.PromocionCompraXLlevaY:
+		if request.CantidadRequerida <= 0 || request.CantidadGratis <= 0 {
+			return datatype.NewBadRequestError("La cantidad requerida y la cantidad gratis deben ser mayores a cero")
+		}
+		if len(request.Productos) == 0 && request.CategoriaId == nil {
+			return datatype.NewBadRequestError("La promoción debe aplicarse a productos o a una categoría")
+		}
+	case domain.PromocionPrecioPaquete:
+		if len(request.Productos) == 0 {
+			return datatype.NewBadRequestError("El paquete debe tener al menos un producto")
+		}
+		if request.PrecioPaquete <= 0 {
+			return datatype.NewBadRequestError("El precio del paquete debe ser mayor a cero")
+		}
+	case domain.PromocionDescuentoCategoria:
+		if request.CategoriaId == nil {
+			return datatype.NewBadRequestError("La categoría es obligatoria para el descuento por categoría")
+		}
+		if request.Porcentaje <= 0 || request.Porcentaje > 100 {
+			return datatype.NewBadRequestError("El porcentaje debe estar entre 0 y 100")
+		}
+	default:
+		return datatype.NewBadRequestError("Tipo de promoción no válido")
+	}
+	return nil
+}
+
+// calcularPromociones obtiene las promociones vigentes y calcula las líneas de descuento para los detalles de una venta
+func calcularPromociones(ctx context.Context, promocionRepository port.PromocionRepository, detalles []domain.DetalleVentaRequest) ([]domain.VentaPromocion, error) {
+	promociones, err := promocionRepository.ObtenerPromocionesVigentes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(*promociones) == 0 {
+		return []domain.VentaPromocion{}, nil
+	}
+
+	items, err := promocionRepository.ObtenerItemsPromocion(ctx, detalles)
+	if err != nil {
+		return nil, err
+	}
+	return aplicarPromociones(*items, *promociones), nil
+}
+
+// Orden de evaluación: primero paquetes, luego compra X lleva Y y al final descuentos por categoría
+var prioridadPromocion = map[string]int{
+	domain.PromocionPrecioPaquete:      0,
+	domain.PromocionCompraXLlevaY:      1,
+	domain.PromocionDescuentoCategoria: 2,
+}
+
+// aplicarPromociones calcula los descuentos. Cada unidad vendida recibe como máximo una promoción.
+func aplicarPromociones(items []domain.PromocionItem, promociones []domain.PromocionDetail) []domain.VentaPromocion {
+	// Agrupar cantidades por producto respetando el orden de la venta
+	var orden []string
+	restantes := make(map[string]uint)
+	precios := make(map[string]float64)
+	categorias := make(map[string][]int)
+	for _, item := range items {
+		if _, ok := restantes[item.ProductoId]; !ok {
+			orden = append(orden, item.ProductoId)
+		}
+		restantes[item.ProductoId] += item.Cantidad
+		precios[item.ProductoId] = item.PrecioVenta
+		categorias[item.ProductoId] = item.Categorias
+	}
+
+	sort.SliceStable(promociones, func(i, j int) bool {
+		return prioridadPromocion[promociones[i].Tipo] < prioridadPromocion[promociones[j].Tipo]
+	})
+
+	lineas := make([]domain.VentaPromocion, 0)
+	agregar := func(promo domain.PromocionDetail, productoId string, unidades uint, descuento float64) {
+		if unidades == 0 || descuento <= 0 {
+			return
+		}
+		lineas = append(lineas, domain.VentaPromocion{
+			PromocionId: promo.Id,
+			Nombre:      promo.Nombre,
+			Tipo:        promo.Tipo,
+			ProductoId:  productoId,
+			Cantidad:    unidades,
+			Subtotal:    redondear(float64(unidades) * precios[productoId]),
+			Descuento:   redondear(descuento),
+		})
+	}
+
+	for _, promo := range promociones {
+		switch promo.Tipo {
+		case domain.PromocionPrecioPaquete:
+			if len(promo.Productos) == 0 {
+				continue
+			}
+			paquetes := uint(math.MaxUint32)
+			valorPaquete := 0.0
+			for _, pp := range promo.Productos {
+				id := pp.Producto.Id.String()
+				cantidad := uint(max(pp.Cantidad, 1))
+				paquetes = min(paquetes, restantes[id]/cantidad)
+				valorPaquete += precios[id] * float64(cantidad)
+			}
+			if paquetes == 0 || valorPaquete <= promo.PrecioPaquete {
+				continue
+			}
+			ahorro := (valorPaquete - promo.PrecioPaquete) * float64(paquetes)
+			for _, pp := range promo.Productos {
+				id := pp.Producto.Id.String()
+				cantidad := uint(max(pp.Cantidad, 1))
+				unidades := paquetes * cantidad
+				// Repartir el ahorro proporcionalmente al valor de cada producto dentro del paquete
+				proporcion := precios[id] * float64(cantidad) / valorPaquete
+				agregar(promo, id, unidades, ahorro*proporcion)
+				restantes[id] -= unidades
+			}
+
+		case domain.PromocionCompraXLlevaY:
+			grupo := uint(promo.CantidadRequerida + promo.CantidadGratis)
+			if grupo == 0 {
+				continue
+			}
+			for _, id := range orden {
+				if !promocionAplicaA(promo, id, categorias[id]) {
+					continue
+				}
+				grupos := restantes[id] / grupo
+				unidades := grupos * grupo
+				agregar(promo, id, unidades, float64(grupos)*float64(promo.CantidadGratis)*precios[id])
+				restantes[id] -= unidades
+			}
+
+		case domain.PromocionDescuentoCategoria:
+			for _, id := range orden {
+				if !promocionAplicaA(promo, id, categorias[id]) {
+					continue
+				}
+				unidades := restantes[id]
+				agregar(promo, id, unidades, float64(unidades)*precios[id]*promo.Porcentaje/100)
+				restantes[id] = 0
+			}
+		}
+	}
+	return lineas
+}
+
+// promocionAplicaA indica si la promoción alcanza al producto, por lista de productos o por categoría
+func promocionAplicaA(promo domain.PromocionDetail, productoId string, categorias []int) bool {
+	if len(promo.Productos) > 0 {
+		for _, pp := range promo.Productos {
+			if pp.Producto.Id.String() == productoId {
+				return true
+			}
+		}
+		return false
+	}
+	if promo.Categoria != nil {
+		for _, c := range categorias {
+			if int32(c) == promo.Categoria.Id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func redondear(valor float64) float64 {
+	return math.Round(valor*100) / 100
+}
+
+func NewPromocionService(promocionRepository port.PromocionRepository) *PromocionService {
+	return &PromocionService{promocionRepository: promocionRepository}
+}
+
+var _ port.PromocionService = (*PromocionService)(nil)
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"mime/multipart"
+	"strings"
+	"time"
+)
+
+type RecetaService struct {
+	recetaRepository port.RecetaRepository
+}
+
+func (r RecetaService) ObtenerListaRecetas(ctx context.Context, filtros map[string]string) (*[]domain.RecetaInfo, error) {
+	return r.recetaRepository.ObtenerListaRecetas(ctx, filtros)
+}
+
+func (r RecetaService) ObtenerRecetaById(ctx context.Context, id *int) (*domain.RecetaDetail, error) {
+	return r.recetaRepository.ObtenerRecetaById(ctx, id)
+}
+
+func (r RecetaService) ObtenerImagenReceta(ctx context.Context, id *int) (string, error) {
+	receta, err := r.recetaRepository.ObtenerRecetaById(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if receta.Imagen == nil {
+		return "", datatype.NewNotFoundError("La receta no tiene imagen registrada")
+	}
+	return fmt.Sprintf("./uploads/recetas/%d/%s", receta.Id, *receta.Imagen), nil
+}
+
+func (r RecetaService) RegistrarReceta(ctx context.Context, request *domain.RecetaRequest, imagen *multipart.FileHeader) (*int, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+
+	request.MedicoNombre = strings.TrimSpace(request.MedicoNombre)
+	request.MedicoMatricula = strings.TrimSpace(request.MedicoMatricula)
+	request.PacienteNombre = strings.TrimSpace(request.PacienteNombre)
+	if request.MedicoNombre == "" || request.MedicoMatricula == "" {
+		return nil, datatype.NewBadRequestError("El nombre y la matrícula del médico son obligatorios")
+	}
+	if request.PacienteNombre == "" {
+		return nil, datatype.NewBadRequestError("El nombre del paciente es obligatorio")
+	}
+	if request.FechaEmision.IsZero() || request.FechaEmision.After(time.Now()) {
+		return nil, datatype.NewBadRequestError("La fecha de emisión de la receta no es válida")
+	}
+	if len(request.Detalles) == 0 {
+		return nil, datatype.NewBadRequestError("La receta debe tener al menos un producto")
+	}
+	for _, d := range request.Detalles {
+		if d.Cantidad == 0 {
+			return nil, datatype.NewBadRequestError("La cantidad prescrita debe ser mayor a 0")
+		}
+	}
+	if imagen != nil && !util.File.ValidarTipoArchivo(imagen.Filename, ".png", ".jpg", ".jpeg", ".pdf") {
+		return nil, datatype.NewBadRequestError("Tipo de archivo no válido")
+	}
+	return r.recetaRepository.RegistrarReceta(ctx, request, imagen)
+}
+
+func (r RecetaService) ObtenerRegistroControlados(ctx context.Context, filtros map[string]string) (*[]domain.RegistroControlado, error) {
+	return r.recetaRepository.ObtenerRegistroControlados(ctx, filtros)
+}
+
+// validarNivelControl verifica el nivel de control de dispensación, por defecto 'Libre'
+func validarNivelControl(nivel *string) error {
+	switch *nivel {
+	case "":
+		*nivel = domain.ControlLibre
+	case domain.ControlLibre, domain.ControlReceta, domain.ControlControlado:
+	default:
+		return datatype.NewBadRequestError("Nivel de control no válido")
+	}
+	return nil
+}
+
+func NewRecetaService(recetaRepository port.RecetaRepository) *RecetaService {
+	return &RecetaService{recetaRepository: recetaRepository}
+}
+
+var _ port.RecetaService = (*RecetaService)(nil)
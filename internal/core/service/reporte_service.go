@@ -2,20 +2,27 @@ package service
 
 import (
 	"context"
+	"farma-santi_backend/internal/core/domain"
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
 	"farma-santi_backend/internal/core/util"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/google/uuid"
 	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/code"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
 	"github.com/johnfercher/maroto/v2/pkg/components/image"
 	"github.com/johnfercher/maroto/v2/pkg/components/row"
 	"github.com/johnfercher/maroto/v2/pkg/components/text"
 	"github.com/johnfercher/maroto/v2/pkg/config"
 	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/barcode"
 	"github.com/johnfercher/maroto/v2/pkg/consts/border"
 	"github.com/johnfercher/maroto/v2/pkg/consts/breakline"
 	"github.com/johnfercher/maroto/v2/pkg/consts/fontfamily"
@@ -34,6 +41,11 @@ type ReporteService struct {
 	compraRepository       port.CompraRepository
 	ventaRepository        port.VentaRepository
 	movimientoRepository   port.MovimientoRepository
+	promocionRepository    port.PromocionRepository
+	recetaRepository       port.RecetaRepository
+	retiroLoteRepository   port.RetiroLoteRepository
+	ubicacionRepository    port.UbicacionRepository
+	auditoriaRepository    port.AuditoriaRepository
 }
 
 func (r ReporteService) ReporteComprasDetallePDF(ctx context.Context, compraId *int) (core.Document, error) {
@@ -198,7 +210,7 @@ func (r ReporteService) ReporteComprasDetallePDF(ctx context.Context, compraId *
 			text.NewCol(1, fmt.Sprintf("%d", i+1), props.Text{Size: 8, Align: align.Center}).WithStyle(colStyle),
 			text.NewCol(4, detalle.LoteProducto.Producto.NombreComercial, props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.DashStrategy}).WithStyle(colStyle),
 			text.NewCol(2, loteInfo, props.Text{Size: 8, Align: align.Center}).WithStyle(colStyle),
-			text.NewCol(1, fmt.Sprintf("%d", detalle.Cantidad), props.Text{Size: 9, Align: align.Right, Right: 2}).WithStyle(colStyle),
+			text.NewCol(1, formatearCantidadCompra(detalle.Cantidad, detalle.Factor), props.Text{Size: 9, Align: align.Right, Right: 2}).WithStyle(colStyle),
 			text.NewCol(2, fmt.Sprintf("%.2f", detalle.PrecioCompra), props.Text{Size: 9, Align: align.Right, Right: 2}).WithStyle(colStyle),
 			text.NewCol(2, fmt.Sprintf("%.2f", subtotal), props.Text{Size: 9, Align: align.Right, Right: 2, Style: fontstyle.Bold}).WithStyle(colStyle),
 		)
@@ -328,7 +340,8 @@ func (r ReporteService) ReporteKardexProductoPDF(ctx context.Context, productoId
 				Size:  10,
 				Style: fontstyle.Bold,
 			}),
-			text.NewCol(6, fmt.Sprintf("Laboratorio: %s\nStock Actual: %d  |  Vencidos: %d", producto.Laboratorio.Nombre, producto.Stock, stockVencido), props.Text{
+			text.NewCol(6, fmt.Sprintf("Laboratorio: %s\nStock Actual: %s  |  Vencidos: %d", producto.Laboratorio.Nombre,
+				formatearUnidades(producto.Stock, producto.UnidadesPresentacion, producto.Presentacion.Nombre), stockVencido), props.Text{
 				Top:   2,
 				Align: align.Right,
 				Size:  10,
@@ -1159,7 +1172,7 @@ func (r ReporteService) ReporteInventarioPDF(ctx context.Context, filtros map[st
 			text.NewCol(1, p.FormaFarmaceutica, props.Text{Style: fontstyle.Normal, Align: align.Center, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(1, p.Estado, props.Text{Style: fontstyle.Normal, Align: align.Center, Bottom: 1, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(1, fmt.Sprintf("%d", p.StockMin), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
-			text.NewCol(1, fmt.Sprintf("%d", p.Stock), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(1, formatearUnidades(p.Stock, p.UnidadesPresentacion, p.Presentacion.Nombre), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(1, fmt.Sprintf("%.2f", p.PrecioCompra), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(1, fmt.Sprintf("%.2f", p.PrecioVenta), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 		)
@@ -1274,7 +1287,7 @@ func (r ReporteService) ReporteLotesProductosPDF(ctx context.Context, filtros ma
 			text.NewCol(2, l.Lote, props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(2, l.FechaVencimiento.Format("02/01/2006"), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(2, l.Producto.Laboratorio, props.Text{Style: fontstyle.Normal, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
-			text.NewCol(1, fmt.Sprintf("%d", l.Stock), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(1, formatearUnidades(int64(l.Stock), l.Producto.UnidadesPresentacion, l.Producto.Presentacion.Nombre), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 			text.NewCol(1, l.Estado, props.Text{Style: fontstyle.Normal, Right: 2, Align: align.Right, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
 		)
 	}
@@ -1286,6 +1299,965 @@ func (r ReporteService) ReporteLotesProductosPDF(ctx context.Context, filtros ma
 	return document, nil
 }
 
+func (r ReporteService) ReportePromocionesPDF(ctx context.Context, filtros map[string]string) (core.Document, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("Usuario no autorizado")
+	}
+	usuario, err := r.usuarioRepository.ObtenerUsuarioDetalle(ctx, &userId)
+	if err != nil {
+		return nil, err
+	}
+
+	promociones, err := r.promocionRepository.ObtenerEfectividadPromociones(ctx, filtros)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construcción del reporte pdf
+	pageNumber := props.PageNumber{
+		Pattern: "Página {current} de {total}",
+		Place:   props.RightBottom,
+		Family:  fontfamily.Arial,
+		Style:   fontstyle.Normal,
+		Size:    9,
+	}
+
+	cfg := config.NewBuilder().
+		WithCreator("Maroto v2", true).
+		WithTitle("Reporte de efectividad de promociones", true).
+		WithPageNumber(pageNumber).
+		WithTopMargin(10).
+		WithLeftMargin(10).
+		WithRightMargin(10).
+		WithBottomMargin(10).
+		WithOrientation(orientation.Horizontal).
+		Build()
+
+	m := maroto.New(cfg)
+
+	// Título
+	err = m.RegisterHeader(
+		row.New(20).Add(
+			image.NewFromFileCol(1, "./public/Logo.png", props.Rect{
+				Center:  true,
+				Percent: 85,
+			}),
+			text.NewCol(9, "Reporte de efectividad de promociones", props.Text{
+				Top:    5,
+				Style:  fontstyle.Bold,
+				Align:  align.Center,
+				Size:   16,
+				Family: fontfamily.Helvetica,
+			}),
+		),
+		row.New(10).Add(
+			text.NewCol(12, fmt.Sprintf("Fecha y Hora: %s", time.Now().Format("02/01/2006 15:04:05")), props.Text{
+				Top:   2,
+				Align: align.Left,
+				Size:  10,
+			}),
+		),
+	)
+
+	if err != nil {
+		log.Println("Error al construir pdf:", err.Error())
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Footer con usuario
+	_ = m.RegisterFooter(
+		row.New(10).Add(
+			text.NewCol(6, fmt.Sprintf("Usuario: %s", usuario.Username), props.Text{
+				Align:  align.Left,
+				Size:   9,
+				Family: fontfamily.Arial,
+			}),
+		),
+	)
+	// Estilo de columna
+	colStyle := &props.Cell{
+		BackgroundColor: &props.Color{Red: 255, Green: 255, Blue: 255},
+		BorderType:      border.Full,
+		BorderColor:     &props.Color{Red: 0, Green: 0, Blue: 0},
+		LineStyle:       linestyle.Solid,
+		BorderThickness: 0.2,
+	}
+	// Encabezado de tabla
+	m.AddAutoRow(
+		text.NewCol(1, "Nro", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(3, "Promoción", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Tipo", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Ventas", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Unidades", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Descuento", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Ingreso", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+	)
+
+	totalUnidades := 0
+	totalDescuento := 0.0
+	totalIngreso := 0.0
+	for i, p := range *promociones {
+		m.AddAutoRow(
+			text.NewCol(1, fmt.Sprintf("%d", i+1), props.Text{Style: fontstyle.Normal, Right: 2, Bottom: 1, Align: align.Right}).WithStyle(colStyle),
+			text.NewCol(3, p.Nombre, props.Text{Style: fontstyle.Normal, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(2, p.Tipo, props.Text{Style: fontstyle.Normal, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(1, fmt.Sprintf("%d", p.CantidadVentas), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2}).WithStyle(colStyle),
+			text.NewCol(1, fmt.Sprintf("%d", p.Unidades), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2}).WithStyle(colStyle),
+			text.NewCol(2, fmt.Sprintf("%.2f", p.Descuento), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2}).WithStyle(colStyle),
+			text.NewCol(2, fmt.Sprintf("%.2f", p.Ingreso), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2}).WithStyle(colStyle),
+		)
+		totalUnidades += p.Unidades
+		totalDescuento += p.Descuento
+		totalIngreso += p.Ingreso
+	}
+
+	// Totales
+	m.AddAutoRow(
+		text.NewCol(7, "TOTAL", props.Text{Style: fontstyle.Bold, Align: align.Right, Right: 2}).WithStyle(colStyle),
+		text.NewCol(1, fmt.Sprintf("%d", totalUnidades), props.Text{Style: fontstyle.Bold, Align: align.Right, Right: 2}).WithStyle(colStyle),
+		text.NewCol(2, fmt.Sprintf("%.2f", totalDescuento), props.Text{Style: fontstyle.Bold, Align: align.Right, Right: 2}).WithStyle(colStyle),
+		text.NewCol(2, fmt.Sprintf("%.2f", totalIngreso), props.Text{Style: fontstyle.Bold, Align: align.Right, Right: 2}).WithStyle(colStyle),
+	)
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+func (r ReporteService) ReporteControladosPDF(ctx context.Context, filtros map[string]string) (core.Document, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("Usuario no autorizado")
+	}
+	usuario, err := r.usuarioRepository.ObtenerUsuarioDetalle(ctx, &userId)
+	if err != nil {
+		return nil, err
+	}
+
+	registros, err := r.recetaRepository.ObtenerRegistroControlados(ctx, filtros)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construcción del reporte pdf
+	pageNumber := props.PageNumber{
+		Pattern: "Página {current} de {total}",
+		Place:   props.RightBottom,
+		Family:  fontfamily.Arial,
+		Style:   fontstyle.Normal,
+		Size:    9,
+	}
+
+	cfg := config.NewBuilder().
+		WithCreator("Maroto v2", true).
+		WithTitle("Libro de registro de sustancias controladas", true).
+		WithPageNumber(pageNumber).
+		WithTopMargin(10).
+		WithLeftMargin(10).
+		WithRightMargin(10).
+		WithBottomMargin(10).
+		WithOrientation(orientation.Horizontal).
+		Build()
+
+	m := maroto.New(cfg)
+
+	// Título
+	err = m.RegisterHeader(
+		row.New(20).Add(
+			image.NewFromFileCol(1, "./public/Logo.png", props.Rect{
+				Center:  true,
+				Percent: 85,
+			}),
+			text.NewCol(9, "Libro de registro de sustancias controladas", props.Text{
+				Top:    5,
+				Style:  fontstyle.Bold,
+				Align:  align.Center,
+				Size:   16,
+				Family: fontfamily.Helvetica,
+			}),
+		),
+		row.New(10).Add(
+			text.NewCol(12, fmt.Sprintf("Fecha y Hora: %s", time.Now().Format("02/01/2006 15:04:05")), props.Text{
+				Top:   2,
+				Align: align.Left,
+				Size:  10,
+			}),
+		),
+	)
+
+	if err != nil {
+		log.Println("Error al construir pdf:", err.Error())
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	// Footer con usuario
+	_ = m.RegisterFooter(
+		row.New(10).Add(
+			text.NewCol(6, fmt.Sprintf("Usuario: %s", usuario.Username), props.Text{
+				Align:  align.Left,
+				Size:   9,
+				Family: fontfamily.Arial,
+			}),
+		),
+	)
+	// Estilo de columna
+	colStyle := &props.Cell{
+		BackgroundColor: &props.Color{Red: 255, Green: 255, Blue: 255},
+		BorderType:      border.Full,
+		BorderColor:     &props.Color{Red: 0, Green: 0, Blue: 0},
+		LineStyle:       linestyle.Solid,
+		BorderThickness: 0.2,
+	}
+	// Encabezado de tabla
+	m.AddAutoRow(
+		text.NewCol(1, "Fecha", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Venta", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Producto", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Lote", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Cantidad", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Receta", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Paciente", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Médico", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Usuario", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+	)
+
+	for _, reg := range *registros {
+		paciente := reg.PacienteNombre
+		if reg.PacienteCi != nil {
+			paciente = fmt.Sprintf("%s (CI: %s)", reg.PacienteNombre, *reg.PacienteCi)
+		}
+		m.AddAutoRow(
+			text.NewCol(1, reg.Fecha.Format("02/01/2006 15:04"), props.Text{Style: fontstyle.Normal, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(1, reg.VentaCodigo, props.Text{Style: fontstyle.Normal, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(2, fmt.Sprintf("%s - %s", reg.Producto, reg.Laboratorio), props.Text{Style: fontstyle.Normal, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(1, reg.Lote, props.Text{Style: fontstyle.Normal, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(1, fmt.Sprintf("%d", reg.Cantidad), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2}).WithStyle(colStyle),
+			text.NewCol(1, fmt.Sprintf("%d", reg.RecetaId), props.Text{Style: fontstyle.Normal, Align: align.Right, Right: 2}).WithStyle(colStyle),
+			text.NewCol(2, paciente, props.Text{Style: fontstyle.Normal, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(2, fmt.Sprintf("%s (Mat. %s)", reg.MedicoNombre, reg.MedicoMatricula), props.Text{Style: fontstyle.Normal, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(1, reg.Usuario, props.Text{Style: fontstyle.Normal, Align: align.Center}).WithStyle(colStyle),
+		)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+func (r ReporteService) ReporteRetiroLotePDF(ctx context.Context, retiroId *int) (core.Document, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("Usuario no autorizado")
+	}
+	usuario, err := r.usuarioRepository.ObtenerUsuarioDetalle(ctx, &userId)
+	if err != nil {
+		return nil, err
+	}
+
+	retiro, err := r.retiroLoteRepository.ObtenerRetiroById(ctx, retiroId)
+	if err != nil {
+		return nil, err
+	}
+	producto := retiro.Producto
+
+	// Construcción del reporte pdf
+	pageNumber := props.PageNumber{
+		Pattern: "Página {current} de {total}",
+		Place:   props.RightBottom,
+		Family:  fontfamily.Arial,
+		Style:   fontstyle.Normal,
+		Size:    9,
+	}
+
+	cfg := config.NewBuilder().
+		WithCreator("FarmaSanti System", true).
+		WithTitle(fmt.Sprintf("Retiro_Lote_%s", retiro.Codigo), true).
+		WithPageNumber(pageNumber).
+		WithTopMargin(10).
+		WithLeftMargin(10).
+		WithRightMargin(10).
+		WithBottomMargin(10).
+		WithOrientation(orientation.Vertical).
+		Build()
+
+	m := maroto.New(cfg)
+
+	referencia := "-"
+	if retiro.Referencia != nil {
+		referencia = *retiro.Referencia
+	}
+	err = m.RegisterHeader(
+		row.New(25).Add(
+			image.NewFromFileCol(2, "./public/Logo.png", props.Rect{
+				Center:  true,
+				Percent: 85,
+			}),
+			text.NewCol(7, "ACTA DE RETIRO DE LOTE", props.Text{
+				Top:    8,
+				Style:  fontstyle.Bold,
+				Align:  align.Center,
+				Size:   14,
+				Family: fontfamily.Helvetica,
+			}),
+			text.NewCol(3, fmt.Sprintf("Generado:\n%s", time.Now().Format("02/01/2006 15:04")), props.Text{
+				Top:   2,
+				Align: align.Right,
+				Size:  8,
+			}),
+		),
+		row.New(5),
+		row.New(20).Add(
+			text.NewCol(6, fmt.Sprintf("Código: %s\nOrigen: %s\nReferencia: %s", retiro.Codigo, retiro.Origen, referencia), props.Text{
+				Align: align.Left,
+				Size:  10,
+				Style: fontstyle.Bold,
+			}),
+			text.NewCol(6, fmt.Sprintf("Fecha de retiro: %s\nRegistrado por: %s", retiro.CreatedAt.Format("02/01/2006 15:04"), retiro.Usuario.Username), props.Text{
+				Align: align.Right,
+				Size:  10,
+			}),
+		),
+	)
+	if err != nil {
+		log.Println("Error al construir pdf header:", err.Error())
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	_ = m.RegisterFooter(
+		row.New(10).Add(
+			text.NewCol(6, fmt.Sprintf("Emitido por: %s", usuario.Username), props.Text{
+				Align:  align.Left,
+				Size:   8,
+				Family: fontfamily.Arial,
+			}),
+		),
+	)
+
+	headerStyle := &props.Cell{
+		BackgroundColor: &props.Color{Red: 240, Green: 240, Blue: 240},
+		BorderType:      border.Full,
+		BorderColor:     &props.Color{Red: 0, Green: 0, Blue: 0},
+		LineStyle:       linestyle.Solid,
+		BorderThickness: 0.2,
+	}
+
+	colStyle := &props.Cell{
+		BorderType:      border.Full,
+		BorderColor:     &props.Color{Red: 200, Green: 200, Blue: 200},
+		LineStyle:       linestyle.Solid,
+		BorderThickness: 0.1,
+	}
+
+	// Lote retirado y stock puesto en cuarentena
+	m.AddAutoRow(
+		text.NewCol(5, "Producto", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(2, "Lote", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(2, "Vencimiento", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(3, "Stock en cuarentena", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+	)
+	m.AddAutoRow(
+		text.NewCol(5, fmt.Sprintf("%s - %s", producto.NombreComercial, producto.Laboratorio), props.Text{Size: 9, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+		text.NewCol(2, retiro.Lote.Lote, props.Text{Size: 9, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, retiro.Lote.FechaVencimiento.Format("02/01/2006"), props.Text{Size: 9, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(3, formatearUnidades(retiro.StockCuarentena, producto.UnidadesPresentacion, producto.Presentacion.Nombre), props.Text{Size: 9, Align: align.Right, Right: 2, Style: fontstyle.Bold}).WithStyle(colStyle),
+	)
+	m.AddAutoRow(
+		text.NewCol(12, fmt.Sprintf("Motivo: %s", retiro.Motivo), props.Text{Size: 9, Align: align.Left, Left: 2, Top: 1, Bottom: 1, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+	)
+	m.AddRow(8)
+
+	// Ventas que dispensaron el lote
+	m.AddRow(8, text.NewCol(12, "Ventas que dispensaron el lote", props.Text{Style: fontstyle.Bold, Size: 11}))
+	m.AddAutoRow(
+		text.NewCol(1, "N°", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(2, "Venta / Fecha", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(3, "Cliente", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(3, "Email", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(2, "Teléfono", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+		text.NewCol(1, "Cant.", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(headerStyle),
+	)
+
+	var totalDispensado int64
+	clientes := map[uint]bool{}
+	for i, venta := range retiro.Ventas {
+		cliente := venta.Cliente.RazonSocial
+		if venta.Cliente.NitCi != nil {
+			cliente = fmt.Sprintf("%s\nNIT/CI: %d", venta.Cliente.RazonSocial, *venta.Cliente.NitCi)
+		}
+		m.AddAutoRow(
+			text.NewCol(1, fmt.Sprintf("%d", i+1), props.Text{Size: 8, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(2, fmt.Sprintf("%s\n%s", util.Text.Coalesce(venta.Codigo), venta.Fecha.Format("02/01/2006 15:04")), props.Text{Size: 8, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(3, cliente, props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(colStyle),
+			text.NewCol(3, util.Text.Coalesce(venta.Cliente.Email), props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.DashStrategy}).WithStyle(colStyle),
+			text.NewCol(2, util.Text.Coalesce(venta.Cliente.Telefono), props.Text{Size: 8, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(1, fmt.Sprintf("%d", venta.Cantidad), props.Text{Size: 8, Align: align.Right, Right: 2}).WithStyle(colStyle),
+		)
+		totalDispensado += venta.Cantidad
+		clientes[venta.Cliente.Id] = true
+	}
+	if len(retiro.Ventas) == 0 {
+		m.AddAutoRow(
+			text.NewCol(12, "No se registran ventas de este lote", props.Text{Size: 9, Align: align.Center, Style: fontstyle.Italic}).WithStyle(colStyle),
+		)
+	}
+	m.AddAutoRow(
+		text.NewCol(8, fmt.Sprintf("Ventas: %d - Clientes a contactar: %d", len(retiro.Ventas), len(clientes)), props.Text{Style: fontstyle.Bold, Align: align.Left, Left: 2, Size: 9}).WithStyle(colStyle),
+		text.NewCol(4, fmt.Sprintf("Total dispensado: %s", formatearUnidades(totalDispensado, producto.UnidadesPresentacion, producto.Presentacion.Nombre)), props.Text{Style: fontstyle.Bold, Align: align.Right, Right: 2, Size: 9}).WithStyle(colStyle),
+	)
+
+	// Firmas del acta
+	m.AddRow(30)
+	m.AddRow(10,
+		text.NewCol(6, "____________________________\nResponsable de farmacia", props.Text{Size: 9, Align: align.Center}),
+		text.NewCol(6, "____________________________\nRegente farmacéutico", props.Text{Size: 9, Align: align.Center}),
+	)
+
+	document, err := m.Generate()
+	if err != nil {
+		log.Println("Error generando PDF de retiro de lote:", err.Error())
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+func (r ReporteService) ReportePickingVentaPDF(ctx context.Context, ventaId *int) (core.Document, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("Usuario no autorizado")
+	}
+	usuario, err := r.usuarioRepository.ObtenerUsuarioDetalle(ctx, &userId)
+	if err != nil {
+		return nil, err
+	}
+
+	picking, err := r.ubicacionRepository.ObtenerPickingVenta(ctx, ventaId)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := nuevoPickingPDF(fmt.Sprintf("Picking_Venta_%d", picking.VentaId), "LISTA DE RECOLECCIÓN - VENTA",
+		fmt.Sprintf("Venta: %s\nFecha: %s", util.Text.Coalesce(picking.Codigo), picking.Fecha.Format("02/01/2006 15:04")), usuario.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	m.AddAutoRow(
+		text.NewCol(1, "N°", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(3, "Ubicación", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(4, "Producto", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(2, "Lote / Venc.", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(1, "Cant.", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(1, "OK", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+	)
+	for i, item := range picking.Items {
+		m.AddAutoRow(
+			text.NewCol(1, fmt.Sprintf("%d", i+1), props.Text{Size: 8, Align: align.Center}).WithStyle(pickingColStyle),
+			text.NewCol(3, textoUbicacion(item.Ubicacion), props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(pickingColStyle),
+			text.NewCol(4, fmt.Sprintf("%s - %s", item.Producto.NombreComercial, item.Producto.Laboratorio), props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(pickingColStyle),
+			text.NewCol(2, fmt.Sprintf("%s\n%s", item.Lote.Lote, item.Lote.FechaVencimiento.Format("02/01/06")), props.Text{Size: 8, Align: align.Center}).WithStyle(pickingColStyle),
+			text.NewCol(1, formatearUnidades(item.Cantidad, item.Producto.UnidadesPresentacion, item.Producto.Presentacion.Nombre), props.Text{Size: 8, Align: align.Right, Right: 2, Style: fontstyle.Bold}).WithStyle(pickingColStyle),
+			text.NewCol(1, "", props.Text{Size: 8}).WithStyle(pickingColStyle),
+		)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		log.Println("Error generando PDF de recolección de venta:", err.Error())
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+func (r ReporteService) ReportePickingTrasladoPDF(ctx context.Context, trasladoId *int) (core.Document, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("Usuario no autorizado")
+	}
+	usuario, err := r.usuarioRepository.ObtenerUsuarioDetalle(ctx, &userId)
+	if err != nil {
+		return nil, err
+	}
+
+	traslado, err := r.ubicacionRepository.ObtenerTrasladoById(ctx, trasladoId)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := nuevoPickingPDF(fmt.Sprintf("Picking_Traslado_%s", traslado.Codigo), "LISTA DE RECOLECCIÓN - TRASLADO",
+		fmt.Sprintf("Traslado: %s\nFecha: %s\nRegistrado por: %s\n%s", traslado.Codigo, traslado.CreatedAt.Format("02/01/2006 15:04"),
+			traslado.Usuario.Username, util.Text.Coalesce(traslado.Comentario)), usuario.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	m.AddAutoRow(
+		text.NewCol(1, "N°", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(2, "Origen", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(3, "Producto", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(2, "Lote / Venc.", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(1, "Cant.", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(2, "Destino", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+		text.NewCol(1, "OK", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(pickingHeaderStyle),
+	)
+	for i, detalle := range traslado.Detalles {
+		m.AddAutoRow(
+			text.NewCol(1, fmt.Sprintf("%d", i+1), props.Text{Size: 8, Align: align.Center}).WithStyle(pickingColStyle),
+			text.NewCol(2, textoUbicacion(detalle.Origen), props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(pickingColStyle),
+			text.NewCol(3, fmt.Sprintf("%s - %s", detalle.Producto.NombreComercial, detalle.Producto.Laboratorio), props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(pickingColStyle),
+			text.NewCol(2, fmt.Sprintf("%s\n%s", detalle.Lote.Lote, detalle.Lote.FechaVencimiento.Format("02/01/06")), props.Text{Size: 8, Align: align.Center}).WithStyle(pickingColStyle),
+			text.NewCol(1, formatearUnidades(detalle.Cantidad, detalle.Producto.UnidadesPresentacion, detalle.Producto.Presentacion.Nombre), props.Text{Size: 8, Align: align.Right, Right: 2, Style: fontstyle.Bold}).WithStyle(pickingColStyle),
+			text.NewCol(2, textoUbicacion(&detalle.Destino), props.Text{Size: 8, Align: align.Left, Left: 2, BreakLineStrategy: breakline.EmptySpaceStrategy}).WithStyle(pickingColStyle),
+			text.NewCol(1, "", props.Text{Size: 8}).WithStyle(pickingColStyle),
+		)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		log.Println("Error generando PDF de recolección de traslado:", err.Error())
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+var pickingHeaderStyle = &props.Cell{
+	BackgroundColor: &props.Color{Red: 240, Green: 240, Blue: 240},
+	BorderType:      border.Full,
+	BorderColor:     &props.Color{Red: 0, Green: 0, Blue: 0},
+	LineStyle:       linestyle.Solid,
+	BorderThickness: 0.2,
+}
+
+var pickingColStyle = &props.Cell{
+	BorderType:      border.Full,
+	BorderColor:     &props.Color{Red: 200, Green: 200, Blue: 200},
+	LineStyle:       linestyle.Solid,
+	BorderThickness: 0.1,
+}
+
+func (r ReporteService) ReporteAuditoriaPDF(ctx context.Context, filtros map[string]string) (core.Document, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewStatusUnauthorizedError("Usuario no autorizado")
+	}
+	usuario, err := r.usuarioRepository.ObtenerUsuarioDetalle(ctx, &userId)
+	if err != nil {
+		return nil, err
+	}
+	registros, err := r.auditoriaRepository.ObtenerListaAuditoria(ctx, filtros)
+	if err != nil {
+		return nil, err
+	}
+	if len(*registros) == 0 {
+		return nil, datatype.NewBadRequestError("Reporte sin registros de auditoría")
+	}
+	pageNumber := props.PageNumber{
+		Pattern: "Página {current} de {total}",
+		Place:   props.RightBottom,
+		Family:  fontfamily.Arial,
+		Style:   fontstyle.Normal,
+		Size:    9,
+	}
+
+	cfg := config.NewBuilder().
+		WithCreator("FarmaSanti System", true).
+		WithTitle("Reporte_auditoria", true).
+		WithPageNumber(pageNumber).
+		WithTopMargin(10).
+		WithLeftMargin(10).
+		WithRightMargin(10).
+		WithBottomMargin(10).
+		WithOrientation(orientation.Horizontal).
+		Build()
+
+	m := maroto.New(cfg)
+
+	periodo := "Todo el historial"
+	if filtros["fechaInicio"] != "" || filtros["fechaFin"] != "" {
+		periodo = fmt.Sprintf("Del %s al %s", valorFiltro(filtros["fechaInicio"]), valorFiltro(filtros["fechaFin"]))
+	}
+	err = m.RegisterHeader(
+		row.New(20).Add(
+			image.NewFromFileCol(1, "./public/Logo.png", props.Rect{
+				Center:  true,
+				Percent: 85,
+			}),
+			text.NewCol(9, "Reporte de auditoría", props.Text{
+				Top:    5,
+				Style:  fontstyle.Bold,
+				Align:  align.Center,
+				Size:   16,
+				Family: fontfamily.Helvetica,
+			}),
+		),
+		row.New(10).Add(
+			text.NewCol(6, fmt.Sprintf("Fecha y Hora: %s", time.Now().Format("02/01/2006 15:04:05")), props.Text{
+				Top:   2,
+				Align: align.Left,
+				Size:  10,
+			}),
+			text.NewCol(6, fmt.Sprintf("Periodo: %s | Entidad: %s", periodo, valorFiltro(filtros["entidad"])), props.Text{
+				Top:   2,
+				Align: align.Right,
+				Size:  10,
+			}),
+		),
+	)
+	if err != nil {
+		log.Println("Error al construir pdf:", err.Error())
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+	_ = m.RegisterFooter(
+		row.New(10).Add(
+			text.NewCol(6, fmt.Sprintf("Usuario: %s", usuario.Username), props.Text{
+				Align:  align.Left,
+				Size:   9,
+				Family: fontfamily.Arial,
+			}),
+		),
+	)
+
+	colStyle := &props.Cell{
+		BackgroundColor: &props.Color{Red: 255, Green: 255, Blue: 255},
+		BorderType:      border.Full,
+		BorderColor:     &props.Color{Red: 0, Green: 0, Blue: 0},
+		LineStyle:       linestyle.Solid,
+		BorderThickness: 0.2,
+	}
+	m.AddAutoRow(
+		text.NewCol(2, "Fecha", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Usuario", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "IP", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(2, "Entidad", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(1, "Acción", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+		text.NewCol(5, "Cambios", props.Text{Style: fontstyle.Bold, Align: align.Center}).WithStyle(colStyle),
+	)
+	for _, registro := range *registros {
+		actor := "Sistema"
+		if registro.Usuario != nil {
+			actor = registro.Usuario.Username
+		}
+		m.AddAutoRow(
+			text.NewCol(2, registro.CreatedAt.Format("02/01/2006 15:04:05"), props.Text{Size: 8, Align: align.Center}).WithStyle(colStyle),
+			text.NewCol(1, actor, props.Text{Size: 8, Left: 2}).WithStyle(colStyle),
+			text.NewCol(1, util.Text.Coalesce(registro.Ip), props.Text{Size: 7, Left: 2}).WithStyle(colStyle),
+			text.NewCol(2, fmt.Sprintf("%s\n%s", registro.Entidad, registro.EntidadId), props.Text{Size: 7, Left: 2, BreakLineStrategy: breakline.DashStrategy}).WithStyle(colStyle),
+			text.NewCol(1, registro.Accion, props.Text{Size: 8, Left: 2}).WithStyle(colStyle),
+			text.NewCol(5, resumenCambiosAuditoria(registro), props.Text{Size: 7, Left: 2, Right: 2, BreakLineStrategy: breakline.DashStrategy}).WithStyle(colStyle),
+		)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+// resumenCambiosAuditoria describe en texto los campos modificados de un registro de auditoría
+func resumenCambiosAuditoria(registro domain.AuditoriaInfo) string {
+	if registro.Antes == nil {
+		return "Registro creado"
+	}
+	if registro.Despues == nil {
+		return "Registro eliminado"
+	}
+	campos := make([]string, 0, len(registro.Despues))
+	for campo := range registro.Despues {
+		campos = append(campos, campo)
+	}
+	sort.Strings(campos)
+	lineas := make([]string, 0, len(campos))
+	for _, campo := range campos {
+		lineas = append(lineas, fmt.Sprintf("%s: %s -> %s", campo, valorAuditoria(registro.Antes[campo]), valorAuditoria(registro.Despues[campo])))
+	}
+	return strings.Join(lineas, "\n")
+}
+
+// valorAuditoria resume un valor JSON para mostrarlo en el reporte
+func valorAuditoria(valor interface{}) string {
+	if valor == nil {
+		return "(vacío)"
+	}
+	var texto string
+	switch v := valor.(type) {
+	case string:
+		texto = v
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		texto = string(b)
+	default:
+		texto = fmt.Sprint(v)
+	}
+	if len([]rune(texto)) > 80 {
+		texto = string([]rune(texto)[:77]) + "..."
+	}
+	return texto
+}
+
+// valorFiltro muestra un filtro del reporte o "Todos" si no se indicó
+func valorFiltro(valor string) string {
+	if valor == "" {
+		return "Todos"
+	}
+	return valor
+}
+
+// nuevoPickingPDF crea el documento con la cabecera común de las listas de recolección
+func nuevoPickingPDF(nombre, titulo, datos, username string) (core.Maroto, error) {
+	pageNumber := props.PageNumber{
+		Pattern: "Página {current} de {total}",
+		Place:   props.RightBottom,
+		Family:  fontfamily.Arial,
+		Style:   fontstyle.Normal,
+		Size:    9,
+	}
+
+	cfg := config.NewBuilder().
+		WithCreator("FarmaSanti System", true).
+		WithTitle(nombre, true).
+		WithPageNumber(pageNumber).
+		WithTopMargin(10).
+		WithLeftMargin(10).
+		WithRightMargin(10).
+		WithBottomMargin(10).
+		WithOrientation(orientation.Vertical).
+		Build()
+
+	m := maroto.New(cfg)
+
+	err := m.RegisterHeader(
+		row.New(20).Add(
+			image.NewFromFileCol(2, "./public/Logo.png", props.Rect{
+				Center:  true,
+				Percent: 85,
+			}),
+			text.NewCol(7, titulo, props.Text{
+				Top:    6,
+				Style:  fontstyle.Bold,
+				Align:  align.Center,
+				Size:   13,
+				Family: fontfamily.Helvetica,
+			}),
+			text.NewCol(3, fmt.Sprintf("Generado:\n%s", time.Now().Format("02/01/2006 15:04")), props.Text{
+				Top:   2,
+				Align: align.Right,
+				Size:  8,
+			}),
+		),
+		row.New(20).Add(
+			text.NewCol(12, datos, props.Text{
+				Align: align.Left,
+				Size:  9,
+			}),
+		),
+	)
+	if err != nil {
+		log.Println("Error al construir pdf header:", err.Error())
+		return nil, datatype.NewInternalServerErrorGeneric()
+	}
+
+	_ = m.RegisterFooter(
+		row.New(10).Add(
+			text.NewCol(6, fmt.Sprintf("Emitido por: %s", username), props.Text{
+				Align:  align.Left,
+				Size:   8,
+				Family: fontfamily.Arial,
+			}),
+		),
+	)
+	return m, nil
+}
+
+// textoUbicacion muestra el código y la ruta de una ubicación, o indica stock sin ubicar
+func textoUbicacion(ubicacion *domain.UbicacionSimple) string {
+	if ubicacion == nil {
+		return "Sin ubicación"
+	}
+	return fmt.Sprintf("%s\n%s", ubicacion.Codigo, ubicacion.Ruta)
+}
+
+// formatearUnidades muestra una cantidad en unidades base junto a su equivalente en presentaciones, p. ej. "25 (2 Caja + 5 u.)"
+func formatearUnidades(cantidad int64, unidadesPresentacion int, presentacion string) string {
+	if unidadesPresentacion <= 1 {
+		return fmt.Sprintf("%d", cantidad)
+	}
+	if presentacion == "" {
+		presentacion = "pres."
+	}
+	completas, sueltas := cantidad/int64(unidadesPresentacion), cantidad%int64(unidadesPresentacion)
+	if sueltas == 0 {
+		return fmt.Sprintf("%d (%d %s)", cantidad, completas, presentacion)
+	}
+	return fmt.Sprintf("%d (%d %s + %d u.)", cantidad, completas, presentacion, sueltas)
+}
+
+// formatearCantidadCompra muestra las presentaciones compradas y las unidades base que ingresan al stock
+func formatearCantidadCompra(cantidad uint, factor uint) string {
+	if factor <= 1 {
+		return fmt.Sprintf("%d", cantidad)
+	}
+	return fmt.Sprintf("%d\n(%d u.)", cantidad, cantidad*factor)
+}
+
+// formatoEtiqueta define el tamaño de página y la grilla de una hoja de etiquetas (medidas en mm)
+type formatoEtiqueta struct {
+	ancho    float64
+	alto     float64
+	margenX  float64
+	margenY  float64
+	columnas int
+	altoFila float64
+}
+
+// Formatos de etiquetas disponibles: hojas A4 en grilla y rollos de una etiqueta por página
+var formatosEtiqueta = map[string]formatoEtiqueta{
+	"a4-2x7":      {ancho: 210, alto: 297, margenX: 5, margenY: 15, columnas: 2, altoFila: 38},
+	"a4-3x8":      {ancho: 210, alto: 297, margenX: 5, margenY: 8, columnas: 3, altoFila: 35},
+	"a4-4x10":     {ancho: 210, alto: 297, margenX: 5, margenY: 8, columnas: 4, altoFila: 28},
+	"rollo-50x25": {ancho: 50, alto: 25, margenX: 1, margenY: 1, columnas: 1, altoFila: 23},
+	"rollo-62x29": {ancho: 62, alto: 29, margenX: 1, margenY: 1, columnas: 1, altoFila: 27},
+}
+
+const formatoEtiquetaDefecto = "a4-3x8"
+
+func (r ReporteService) ReporteEtiquetasPDF(ctx context.Context, request *domain.EtiquetaRequest) (core.Document, error) {
+	if len(request.Productos) == 0 && len(request.Lotes) == 0 {
+		return nil, datatype.NewBadRequestError("Debe seleccionar al menos un producto o lote")
+	}
+	for i := range request.Productos {
+		if request.Productos[i].Cantidad <= 0 {
+			request.Productos[i].Cantidad = 1
+		}
+	}
+	for i := range request.Lotes {
+		if request.Lotes[i].Cantidad <= 0 {
+			request.Lotes[i].Cantidad = 1
+		}
+	}
+	etiquetas, err := r.productoRepository.ObtenerEtiquetas(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return generarEtiquetasPDF(*etiquetas, request.Formato)
+}
+
+func (r ReporteService) ReporteEtiquetasCompraPDF(ctx context.Context, compraId *int, filtros map[string]string) (core.Document, error) {
+	etiquetas, err := r.productoRepository.ObtenerEtiquetasCompra(ctx, compraId)
+	if err != nil {
+		return nil, err
+	}
+	// Por defecto una etiqueta por unidad recibida, o una por lote si se indica
+	if filtros["unaPorLote"] == "true" {
+		for i := range *etiquetas {
+			(*etiquetas)[i].Cantidad = 1
+		}
+	}
+	return generarEtiquetasPDF(*etiquetas, filtros["formato"])
+}
+
+// generarEtiquetasPDF arma la hoja de etiquetas con nombre, presentación, precio, lote/vencimiento y código de barras
+func generarEtiquetasPDF(etiquetas []domain.Etiqueta, nombreFormato string) (core.Document, error) {
+	if nombreFormato == "" {
+		nombreFormato = formatoEtiquetaDefecto
+	}
+	formato, ok := formatosEtiqueta[nombreFormato]
+	if !ok {
+		return nil, datatype.NewBadRequestError("Formato de etiqueta no válido")
+	}
+
+	// Repetir cada etiqueta según la cantidad solicitada
+	var lista []domain.Etiqueta
+	for _, e := range etiquetas {
+		for j := 0; j < e.Cantidad; j++ {
+			lista = append(lista, e)
+		}
+	}
+	if len(lista) == 0 {
+		return nil, datatype.NewBadRequestError("No hay etiquetas para generar")
+	}
+	if len(lista) > 5000 {
+		return nil, datatype.NewBadRequestError("La cantidad de etiquetas no puede superar 5000 por documento")
+	}
+
+	cfg := config.NewBuilder().
+		WithCreator("FarmaSanti System", true).
+		WithTitle("Etiquetas", true).
+		WithDimensions(formato.ancho, formato.alto).
+		WithLeftMargin(formato.margenX).
+		WithRightMargin(formato.margenX).
+		WithTopMargin(formato.margenY).
+		WithBottomMargin(formato.margenY).
+		WithMaxGridSize(formato.columnas).
+		Build()
+
+	m := maroto.New(cfg)
+
+	anchoEtiqueta := (formato.ancho - 2*formato.margenX) / float64(formato.columnas)
+	alto := formato.altoFila
+	tamNombre := max(5, alto*0.22)
+	tamInfo := max(4, alto*0.16)
+	tamPrecio := max(6, alto*0.3)
+
+	for i := 0; i < len(lista); i += formato.columnas {
+		var cols []core.Col
+		for j := i; j < i+formato.columnas; j++ {
+			if j >= len(lista) {
+				cols = append(cols, col.New(1))
+				continue
+			}
+			e := lista[j]
+			componentes := []core.Component{
+				text.New(e.NombreComercial, props.Text{Top: 0.5, Left: 1, Right: 1, Style: fontstyle.Bold, Size: tamNombre, Align: align.Center, BreakLineStrategy: breakline.DashStrategy}),
+				text.New(fmt.Sprintf("%s · %s", e.Presentacion, e.Laboratorio), props.Text{Top: alto * 0.2, Left: 1, Right: 1, Size: tamInfo, Align: align.Center}),
+				text.New(fmt.Sprintf("Bs. %.2f", e.PrecioVenta), props.Text{Top: alto * 0.42, Style: fontstyle.Bold, Size: tamPrecio, Align: align.Center}),
+			}
+			if e.Lote != nil && e.FechaVencimiento != nil {
+				componentes = append(componentes, text.New(fmt.Sprintf("Lote: %s  Venc.: %s", *e.Lote, e.FechaVencimiento.Format("01/2006")),
+					props.Text{Top: alto * 0.31, Left: 1, Right: 1, Size: tamInfo, Align: align.Center}))
+			}
+			if e.CodigoBarra != nil {
+				codigo, tipo := codigoEtiqueta(*e.CodigoBarra, e.TipoCodigo)
+				// El alto del código es 1/5 de su ancho, se ajusta al espacio inferior de la etiqueta
+				ancho := min(anchoEtiqueta*0.9, alto*0.34*5)
+				componentes = append(componentes, code.NewBar(codigo, props.Barcode{
+					Top:     alto * 0.62,
+					Left:    (anchoEtiqueta - ancho) / 2,
+					Percent: ancho / anchoEtiqueta * 100,
+					Type:    tipo,
+				}))
+			}
+			cols = append(cols, col.New(1).Add(componentes...))
+		}
+		m.AddRow(alto, cols...)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, datatype.NewInternalServerError("Error al generar archivo .pdf")
+	}
+	return document, nil
+}
+
+// codigoEtiqueta elige la simbología: EAN-13/EAN-8 para GTIN que lo permiten y Code128 para el resto
+func codigoEtiqueta(codigo string, tipo *string) (string, barcode.Type) {
+	if tipo == nil || *tipo != domain.CodigoGTIN {
+		return codigo, barcode.Code128
+	}
+	switch len(codigo) {
+	case 8, 13:
+		return codigo, barcode.EAN
+	case 12:
+		// Un UPC-A se imprime como EAN-13 agregando un cero a la izquierda
+		return "0" + codigo, barcode.EAN
+	}
+	return codigo, barcode.Code128
+}
+
 func NewReporteService(
 	usuarioRepository port.UsuarioRepository,
 	clienteRepository port.ClienteRepository,
@@ -1294,6 +2266,11 @@ func NewReporteService(
 	compraRepository port.CompraRepository,
 	ventaRepository port.VentaRepository,
 	movimientoRepository port.MovimientoRepository,
+	promocionRepository port.PromocionRepository,
+	recetaRepository port.RecetaRepository,
+	retiroLoteRepository port.RetiroLoteRepository,
+	ubicacionRepository port.UbicacionRepository,
+	auditoriaRepository port.AuditoriaRepository,
 ) *ReporteService {
 	return &ReporteService{
 		usuarioRepository:      usuarioRepository,
@@ -1303,6 +2280,11 @@ func NewReporteService(
 		compraRepository:       compraRepository,
 		ventaRepository:        ventaRepository,
 		movimientoRepository:   movimientoRepository,
+		promocionRepository:    promocionRepository,
+		recetaRepository:       recetaRepository,
+		retiroLoteRepository:   retiroLoteRepository,
+		ubicacionRepository:    ubicacionRepository,
+		auditoriaRepository:    auditoriaRepository,
 	}
 }
 
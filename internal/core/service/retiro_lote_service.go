@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"strings"
+)
+
+type RetiroLoteService struct {
+	retiroLoteRepository port.RetiroLoteRepository
+}
+
+func (r RetiroLoteService) ObtenerListaRetiros(ctx context.Context, filtros map[string]string) (*[]domain.RetiroLoteInfo, error) {
+	return r.retiroLoteRepository.ObtenerListaRetiros(ctx, filtros)
+}
+
+func (r RetiroLoteService) ObtenerRetiroById(ctx context.Context, id *int) (*domain.RetiroLoteDetail, error) {
+	return r.retiroLoteRepository.ObtenerRetiroById(ctx, id)
+}
+
+func (r RetiroLoteService) RegistrarRetiro(ctx context.Context, request *domain.RetiroLoteRequest) (*int, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+
+	if request.LoteId <= 0 {
+		return nil, datatype.NewBadRequestError("El lote a retirar es obligatorio")
+	}
+	switch request.Origen {
+	case domain.RetiroOrigenLaboratorio, domain.RetiroOrigenRegulador, domain.RetiroOrigenInterno:
+	default:
+		return nil, datatype.NewBadRequestError("Origen del retiro no válido")
+	}
+	request.Motivo = strings.TrimSpace(request.Motivo)
+	if request.Motivo == "" {
+		return nil, datatype.NewBadRequestError("El motivo del retiro es obligatorio")
+	}
+	if request.Referencia != nil {
+		referencia := strings.TrimSpace(*request.Referencia)
+		if referencia == "" {
+			request.Referencia = nil
+		} else if len(referencia) > 100 {
+			return nil, datatype.NewBadRequestError("La referencia del retiro no puede superar los 100 caracteres")
+		} else {
+			request.Referencia = &referencia
+		}
+	}
+	return r.retiroLoteRepository.RegistrarRetiro(ctx, request)
+}
+
+func (r RetiroLoteService) ObtenerVentasLote(ctx context.Context, loteId *int) (*[]domain.VentaLoteRetirado, error) {
+	return r.retiroLoteRepository.ObtenerVentasLote(ctx, loteId)
+}
+
+func NewRetiroLoteService(retiroLoteRepository port.RetiroLoteRepository) *RetiroLoteService {
+	return &RetiroLoteService{retiroLoteRepository: retiroLoteRepository}
+}
+
+var _ port.RetiroLoteService = (*RetiroLoteService)(nil)
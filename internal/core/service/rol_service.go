@@ -5,6 +5,7 @@ import (
 	"farma-santi_backend/internal/core/domain"
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
+	"slices"
 	"strings"
 )
 
@@ -27,7 +28,7 @@ func (r RolService) ModificarRol(ctx context.Context, id *int, rolRequestUpdate
 	if err != nil {
 		return datatype.NewInternalServerErrorGeneric()
 	}
-	if oldRol.Nombre == "ADMIN" {
+	if oldRol.EsSistema {
 		return datatype.NewBadRequestError("Rol no permitido para actualizar")
 	}
 	return r.rolRepository.ModificarRol(ctx, id, rolRequestUpdate)
@@ -46,6 +47,31 @@ func (r RolService) ObtenerRolById(ctx context.Context, id *int) (*domain.Rol, e
 	return r.rolRepository.ObtenerRolById(ctx, id)
 }
 
+func (r RolService) ListarPermisos(ctx context.Context) (*[]domain.Permiso, error) {
+	return r.rolRepository.ListarPermisos(ctx)
+}
+
+func (r RolService) ObtenerPermisosRol(ctx context.Context, id *int) (*[]domain.Permiso, error) {
+	return r.rolRepository.ObtenerPermisosRol(ctx, id)
+}
+
+func (r RolService) AsignarPermisosRol(ctx context.Context, id *int, request *domain.RolPermisosRequest) error {
+	permisos := make([]int, 0, len(request.Permisos))
+	for _, permisoId := range request.Permisos {
+		if permisoId <= 0 {
+			return datatype.NewBadRequestError("Los permisos deben ser ids válidos mayores a 0")
+		}
+		if !slices.Contains(permisos, permisoId) {
+			permisos = append(permisos, permisoId)
+		}
+	}
+	return r.rolRepository.AsignarPermisosRol(ctx, id, permisos)
+}
+
+func (r RolService) ModificarDobleFactorRol(ctx context.Context, id *int, request *domain.RolDobleFactorRequest) error {
+	return r.rolRepository.ModificarDobleFactorRol(ctx, id, request.Requerido)
+}
+
 func NewRolService(rolRepository port.RolRepository) *RolService {
 	return &RolService{rolRepository}
 }
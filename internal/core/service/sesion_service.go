@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+
+	"github.com/google/uuid"
+)
+
+type SesionService struct {
+	sesionRepository port.SesionRepository
+}
+
+func (s SesionService) ObtenerMisSesiones(ctx context.Context) (*[]domain.SesionInfo, error) {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	list, err := s.sesionRepository.ObtenerListaSesiones(ctx, &userId, true)
+	if err != nil {
+		return nil, err
+	}
+	// Marcar la sesión desde la que se consulta
+	if sesionId, ok := ctx.Value(util.ContextSesionIdKey).(string); ok {
+		for i := range *list {
+			(*list)[i].Actual = (*list)[i].Id.String() == sesionId
+		}
+	}
+	return list, nil
+}
+
+func (s SesionService) CerrarTodasLasSesiones(ctx context.Context) error {
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	return s.sesionRepository.RevocarSesionesUsuario(ctx, userId, domain.SesionMotivoLogoutTodas)
+}
+
+func (s SesionService) ObtenerSesionesUsuario(ctx context.Context, usuarioId *int, filtros map[string]string) (*[]domain.SesionInfo, error) {
+	return s.sesionRepository.ObtenerListaSesiones(ctx, usuarioId, filtros["activas"] == "true")
+}
+
+func (s SesionService) RevocarSesion(ctx context.Context, sesionId *uuid.UUID) error {
+	return s.sesionRepository.RevocarSesion(ctx, *sesionId, nil, domain.SesionMotivoAdministrador)
+}
+
+func (s SesionService) RevocarSesionesUsuario(ctx context.Context, usuarioId *int) error {
+	return s.sesionRepository.RevocarSesionesUsuario(ctx, *usuarioId, domain.SesionMotivoAdministrador)
+}
+
+func NewSesionService(sesionRepository port.SesionRepository) *SesionService {
+	return &SesionService{sesionRepository: sesionRepository}
+}
+
+var _ port.SesionService = (*SesionService)(nil)
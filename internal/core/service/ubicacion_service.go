@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/domain"
+	"farma-santi_backend/internal/core/domain/datatype"
+	"farma-santi_backend/internal/core/port"
+	"farma-santi_backend/internal/core/util"
+	"strings"
+)
+
+type UbicacionService struct {
+	ubicacionRepository port.UbicacionRepository
+}
+
+func (u UbicacionService) ObtenerListaUbicaciones(ctx context.Context, filtros map[string]string) (*[]domain.UbicacionInfo, error) {
+	return u.ubicacionRepository.ObtenerListaUbicaciones(ctx, filtros)
+}
+
+func (u UbicacionService) ObtenerUbicacionById(ctx context.Context, id *int) (*domain.UbicacionInfo, error) {
+	return u.ubicacionRepository.ObtenerUbicacionById(ctx, id)
+}
+
+func (u UbicacionService) RegistrarUbicacion(ctx context.Context, request *domain.UbicacionRequest) (*int, error) {
+	if err := validarUbicacion(request); err != nil {
+		return nil, err
+	}
+	return u.ubicacionRepository.RegistrarUbicacion(ctx, request)
+}
+
+func (u UbicacionService) ModificarUbicacion(ctx context.Context, id *int, request *domain.UbicacionRequest) error {
+	if err := validarUbicacion(request); err != nil {
+		return err
+	}
+	return u.ubicacionRepository.ModificarUbicacion(ctx, id, request)
+}
+
+func (u UbicacionService) EliminarUbicacion(ctx context.Context, id *int) error {
+	return u.ubicacionRepository.EliminarUbicacion(ctx, id)
+}
+
+func (u UbicacionService) ObtenerStockUbicacion(ctx context.Context, id *int) (*[]domain.StockUbicacion, error) {
+	return u.ubicacionRepository.ObtenerStockUbicacion(ctx, id)
+}
+
+func (u UbicacionService) ObtenerListaTraslados(ctx context.Context, filtros map[string]string) (*[]domain.TrasladoInfo, error) {
+	return u.ubicacionRepository.ObtenerListaTraslados(ctx, filtros)
+}
+
+func (u UbicacionService) ObtenerTrasladoById(ctx context.Context, id *int) (*domain.TrasladoDetail, error) {
+	return u.ubicacionRepository.ObtenerTrasladoById(ctx, id)
+}
+
+func (u UbicacionService) RegistrarTraslado(ctx context.Context, request *domain.TrasladoRequest) (*int, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+
+	if len(request.Detalles) == 0 {
+		return nil, datatype.NewBadRequestError("El traslado debe tener al menos un detalle")
+	}
+	for _, d := range request.Detalles {
+		if d.LoteId <= 0 || d.DestinoId <= 0 {
+			return nil, datatype.NewBadRequestError("El lote y la ubicación de destino son obligatorios")
+		}
+		if d.Cantidad <= 0 {
+			return nil, datatype.NewBadRequestError("La cantidad a trasladar debe ser mayor a cero")
+		}
+		if d.OrigenId != nil && *d.OrigenId == d.DestinoId {
+			return nil, datatype.NewBadRequestError("La ubicación de origen y destino deben ser distintas")
+		}
+	}
+	if request.Comentario != nil {
+		comentario := strings.TrimSpace(*request.Comentario)
+		request.Comentario = &comentario
+	}
+	return u.ubicacionRepository.RegistrarTraslado(ctx, request)
+}
+
+// validarUbicacion normaliza y verifica los datos básicos de una ubicación
+func validarUbicacion(request *domain.UbicacionRequest) error {
+	request.Codigo = strings.ToUpper(strings.TrimSpace(request.Codigo))
+	request.Nombre = strings.TrimSpace(request.Nombre)
+	if request.Codigo == "" || request.Nombre == "" {
+		return datatype.NewBadRequestError("El código y el nombre de la ubicación son obligatorios")
+	}
+	if len(request.Codigo) > 30 {
+		return datatype.NewBadRequestError("El código de la ubicación no puede superar los 30 caracteres")
+	}
+	switch request.Tipo {
+	case domain.UbicacionAlmacen, domain.UbicacionEstante, domain.UbicacionNivel:
+	default:
+		return datatype.NewBadRequestError("Tipo de ubicación no válido")
+	}
+	return nil
+}
+
+func NewUbicacionService(ubicacionRepository port.UbicacionRepository) *UbicacionService {
+	return &UbicacionService{ubicacionRepository: ubicacionRepository}
+}
+
+var _ port.UbicacionService = (*UbicacionService)(nil)
@@ -6,6 +6,15 @@ import (
 	"farma-santi_backend/internal/core/domain/datatype"
 	"farma-santi_backend/internal/core/port"
 	"farma-santi_backend/internal/core/util"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UsuarioService struct {
@@ -13,9 +22,44 @@ type UsuarioService struct {
 }
 
 func (u UsuarioService) RestablecerPassword(ctx context.Context, usuarioId *int, password *domain.UsuarioResetPassword) (*domain.UsuarioDetail, error) {
+	usuario, err := u.usuarioRepository.ObtenerUsuarioDetalle(ctx, usuarioId)
+	if err != nil {
+		return nil, err
+	}
+	if err := validarPoliticaPassword(politicaPassword(), password.NewPassword, usuario.Username); err != nil {
+		return nil, err
+	}
 	return u.usuarioRepository.RestablecerPassword(ctx, usuarioId, password)
 }
 
+// CambiarPassword permite al usuario autenticado cambiar su contraseña conociendo la actual
+func (u UsuarioService) CambiarPassword(ctx context.Context, request *domain.CambiarPasswordRequest) error {
+	username, ok := ctx.Value(util.ContextUsernameKey).(string)
+	if !ok {
+		return datatype.NewBadRequestError("Usuario inválido o no encontrado en el contexto")
+	}
+	usuario, err := u.usuarioRepository.ObtenerUsuario(ctx, &username)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(usuario.Password), []byte(request.PasswordActual)); err != nil {
+		return datatype.NewBadRequestError("La contraseña actual es incorrecta")
+	}
+	// Se conserva la sesión desde la que se hizo el cambio
+	var sesionActual *uuid.UUID
+	if sesion, ok := ctx.Value(util.ContextSesionIdKey).(string); ok {
+		if sesionId, err := uuid.Parse(sesion); err == nil {
+			sesionActual = &sesionId
+		}
+	}
+	return cambiarPassword(ctx, u.usuarioRepository, usuario, request.PasswordNueva, sesionActual)
+}
+
+func (u UsuarioService) ObtenerPoliticaPassword() *domain.PoliticaPassword {
+	politica := politicaPassword()
+	return &politica
+}
+
 func (u UsuarioService) HabilitarUsuarioById(ctx context.Context, usuarioId *int) error {
 	return u.usuarioRepository.HabilitarUsuarioById(ctx, usuarioId)
 }
@@ -40,7 +84,17 @@ func (u UsuarioService) ObtenerUsuarioDetalleByToken(ctx context.Context, token
 	if !ok {
 		return nil, datatype.NewNotFoundError("Usuario no encontrado")
 	}
-	return u.usuarioRepository.ObtenerUsuarioDetalleByUsername(ctx, &username)
+	usuarioDetalle, err := u.usuarioRepository.ObtenerUsuarioDetalleByUsername(ctx, &username)
+	if err != nil {
+		return nil, err
+	}
+	// Permisos efectivos para que el frontend oculte las acciones no permitidas
+	usuarioId := int(usuarioDetalle.Id)
+	usuarioDetalle.Permisos, err = u.usuarioRepository.ObtenerPermisosUsuario(ctx, &usuarioId)
+	if err != nil {
+		return nil, err
+	}
+	return usuarioDetalle, nil
 }
 
 func (u UsuarioService) ListarUsuarios(ctx context.Context, filtros map[string]string) (*[]domain.UsuarioInfo, error) {
@@ -59,6 +113,132 @@ func (u UsuarioService) RegistrarUsuario(ctx context.Context, usuarioRequest *do
 	return u.usuarioRepository.RegistrarUsuario(ctx, usuarioRequest)
 }
 
+// EscucharCambiosPermisos invalida la caché de permisos con cada cambio notificado por la base de datos;
+// al (re)conectar se descarta toda la caché porque pudieron perderse notificaciones
+func (u UsuarioService) EscucharCambiosPermisos(ctx context.Context) error {
+	util.PermisosCache.InvalidarTodo()
+	util.SesionesCache.InvalidarTodo()
+	util.ApiKeysCache.InvalidarTodo()
+	return u.usuarioRepository.EscucharCambiosPermisos(ctx, func(payload string) {
+		if sesionId, ok := strings.CutPrefix(payload, "sesion:"); ok {
+			util.SesionesCache.Invalidar(sesionId)
+			return
+		}
+		if prefijo, ok := strings.CutPrefix(payload, "api_key:"); ok {
+			util.ApiKeysCache.Invalidar(prefijo)
+			return
+		}
+		usuarioId, err := strconv.Atoi(payload)
+		if err != nil {
+			util.PermisosCache.InvalidarTodo()
+			return
+		}
+		util.PermisosCache.Invalidar(usuarioId)
+		// Las API keys de una cuenta de servicio deshabilitada dejan de ser válidas
+		util.ApiKeysCache.InvalidarTodo()
+	})
+}
+
+// politicaPassword obtiene la política de contraseñas: PASSWORD_LONGITUD_MINIMA (por defecto 8),
+// PASSWORD_REQUIERE_MAYUSCULA, PASSWORD_REQUIERE_MINUSCULA y PASSWORD_REQUIERE_NUMERO (por defecto true),
+// PASSWORD_REQUIERE_SIMBOLO (por defecto false) y PASSWORD_HISTORIAL (por defecto 5)
+func politicaPassword() domain.PoliticaPassword {
+	longitud, err := strconv.Atoi(os.Getenv("PASSWORD_LONGITUD_MINIMA"))
+	if err != nil || longitud <= 0 {
+		longitud = 8
+	}
+	historial, err := strconv.Atoi(os.Getenv("PASSWORD_HISTORIAL"))
+	if err != nil || historial < 0 {
+		historial = 5
+	}
+	return domain.PoliticaPassword{
+		LongitudMinima:    longitud,
+		RequiereMayuscula: variableBool("PASSWORD_REQUIERE_MAYUSCULA", true),
+		RequiereMinuscula: variableBool("PASSWORD_REQUIERE_MINUSCULA", true),
+		RequiereNumero:    variableBool("PASSWORD_REQUIERE_NUMERO", true),
+		RequiereSimbolo:   variableBool("PASSWORD_REQUIERE_SIMBOLO", false),
+		Historial:         historial,
+	}
+}
+
+func variableBool(nombre string, porDefecto bool) bool {
+	valor, err := strconv.ParseBool(os.Getenv(nombre))
+	if err != nil {
+		return porDefecto
+	}
+	return valor
+}
+
+// validarPoliticaPassword indica en un solo mensaje todos los requisitos que no cumple la contraseña
+func validarPoliticaPassword(politica domain.PoliticaPassword, password, username string) error {
+	// bcrypt solo considera los primeros 72 bytes
+	if len(password) > 72 {
+		return datatype.NewBadRequestError("La contraseña no puede superar los 72 caracteres")
+	}
+	var mayuscula, minuscula, numero, simbolo bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			mayuscula = true
+		case unicode.IsLower(c):
+			minuscula = true
+		case unicode.IsDigit(c):
+			numero = true
+		case !unicode.IsSpace(c):
+			simbolo = true
+		}
+	}
+	var faltantes []string
+	if utf8.RuneCountInString(password) < politica.LongitudMinima {
+		faltantes = append(faltantes, fmt.Sprintf("al menos %d caracteres", politica.LongitudMinima))
+	}
+	if politica.RequiereMayuscula && !mayuscula {
+		faltantes = append(faltantes, "una letra mayúscula")
+	}
+	if politica.RequiereMinuscula && !minuscula {
+		faltantes = append(faltantes, "una letra minúscula")
+	}
+	if politica.RequiereNumero && !numero {
+		faltantes = append(faltantes, "un número")
+	}
+	if politica.RequiereSimbolo && !simbolo {
+		faltantes = append(faltantes, "un símbolo")
+	}
+	if len(faltantes) > 0 {
+		return datatype.NewBadRequestError("La contraseña debe tener " + strings.Join(faltantes, ", "))
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return datatype.NewBadRequestError("La contraseña no puede contener el nombre de usuario")
+	}
+	return nil
+}
+
+// cambiarPassword valida la contraseña nueva contra la política y las contraseñas anteriores y la guarda
+func cambiarPassword(ctx context.Context, usuarioRepository port.UsuarioRepository, usuario *domain.Usuario, nueva string, sesionActual *uuid.UUID) error {
+	politica := politicaPassword()
+	if err := validarPoliticaPassword(politica, nueva, usuario.Username); err != nil {
+		return err
+	}
+	anteriores := []string{usuario.Password}
+	if politica.Historial > 0 {
+		historial, err := usuarioRepository.ObtenerHistorialPassword(ctx, int(usuario.Id), politica.Historial)
+		if err != nil {
+			return err
+		}
+		anteriores = append(anteriores, historial...)
+	}
+	for _, hash := range anteriores {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(nueva)) == nil {
+			return datatype.NewBadRequestError(fmt.Sprintf("La contraseña no puede ser igual a la actual ni a las últimas %d utilizadas", politica.Historial))
+		}
+	}
+	hashPassword, err := util.Hash.HashearPassword(nueva)
+	if err != nil {
+		return datatype.NewInternalServerErrorGeneric()
+	}
+	return usuarioRepository.CambiarPassword(ctx, int(usuario.Id), hashPassword, sesionActual)
+}
+
 func NewUsuarioService(usuarioRepository port.UsuarioRepository) *UsuarioService {
 	return &UsuarioService{usuarioRepository: usuarioRepository}
 }
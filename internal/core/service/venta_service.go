@@ -12,19 +12,44 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/goccy/go-json"
 )
 
 type VentaService struct {
-	ventaRepository port.VentaRepository
+	ventaRepository       port.VentaRepository
+	promocionRepository   port.PromocionRepository
+	interaccionRepository port.InteraccionRepository
 }
 
 func (v VentaService) ObtenerListaVentas(ctx context.Context, filtros map[string]string) (*[]domain.VentaInfo, error) {
 	return v.ventaRepository.ObtenerListaVentas(ctx, filtros)
 }
 
+func (v VentaService) ObtenerListaVentasShared(ctx context.Context) (*[]domain.VentaInfo, error) {
+	usuarioUid, err := obtenerUsuarioUid(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Solo compras de clientes vinculados y verificados con la cuenta
+	return v.ventaRepository.ObtenerListaVentas(ctx, map[string]string{"usuarioUid": usuarioUid, "estado": "Realizada"})
+}
+
+func (v VentaService) ObtenerVentaByIdShared(ctx context.Context, id *int) (*domain.VentaDetail, error) {
+	compras, err := v.ObtenerListaVentasShared(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, compra := range *compras {
+		if int(compra.Id) == *id {
+			return v.ventaRepository.ObtenerVentaById(ctx, id)
+		}
+	}
+	return nil, datatype.NewNotFoundError("Compra no encontrada")
+}
+
 func (v VentaService) RegistraVenta(ctx context.Context, request *domain.VentaRequest) (*int64, error) {
 	// Obtener ID de usuario desde el contexto
 	val := ctx.Value(util.ContextUserIdKey)
@@ -34,15 +59,33 @@ func (v VentaService) RegistraVenta(ctx context.Context, request *domain.VentaRe
 	}
 	request.UsuarioId = uint(userIdFloat)
 
+	// Verificar interacciones entre los principios activos
+	if err := verificarInteracciones(ctx, v.interaccionRepository, request); err != nil {
+		return nil, err
+	}
+
+	// Aplicar promociones vigentes
+	promociones, err := calcularPromociones(ctx, v.promocionRepository, request.Detalles)
+	if err != nil {
+		return nil, err
+	}
+	request.Promociones = promociones
+
 	// Registrar venta en DB
 	ventaId, err := v.ventaRepository.RegistraVenta(ctx, request)
 	if err != nil {
-		return nil, datatype.NewInternalServerError(err.Error())
+		return nil, err
 	}
+
+	return facturarVenta(ctx, v.ventaRepository, ventaId)
+}
+
+// facturarVenta envía la venta registrada al facturador. Los errores del facturador no bloquean la venta.
+func facturarVenta(ctx context.Context, ventaRepository port.VentaRepository, ventaId *int64) (*int64, error) {
 	ventaIdInt := int(*ventaId)
 
 	// Obtener venta completa
-	venta, err := v.ventaRepository.ObtenerVentaById(ctx, &ventaIdInt)
+	venta, err := ventaRepository.ObtenerVentaById(ctx, &ventaIdInt)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +152,7 @@ func (v VentaService) RegistraVenta(ctx context.Context, request *domain.VentaRe
 	}
 
 	// Guardar factura en DB en transacción separada
-	if err := v.ventaRepository.FacturarVentaById(context.Background(), &ventaIdInt, &facturaResponse); err != nil {
+	if err := ventaRepository.FacturarVentaById(context.Background(), &ventaIdInt, &facturaResponse); err != nil {
 		log.Printf("Error al registrar factura: %v", err)
 		return ventaId, nil
 	}
@@ -127,21 +170,41 @@ func construirFactura(venta *domain.VentaDetail) domain.FacturaCompraVenta {
 	telefono := "74425055"
 	var detalles []domain.Detalle
 
+	// Descuento por promociones acumulado por producto
+	descuentosProducto := make(map[string]float64)
+	for _, p := range venta.Promociones {
+		descuentosProducto[p.ProductoId] += p.Descuento
+	}
+
 	for _, d := range venta.Detalles {
 		var cantidad float64
 		for _, l := range d.Lotes {
 			cantidad += float64(l.Cantidad)
 		}
+		// Repartir el descuento del producto entre sus líneas sin dejar subtotales negativos
+		var montoDescuento domain.NilableFloat64
+		subTotal := d.Total
+		if restante := descuentosProducto[d.Producto.Id.String()]; restante > 0 {
+			descuento := redondear(min(restante, d.Total))
+			descuentosProducto[d.Producto.Id.String()] -= descuento
+			subTotal = redondear(d.Total - descuento)
+			montoDescuento = domain.NilableFloat64{Value: &descuento}
+		}
+		// Las unidades sueltas se distinguen de la presentación completa en la descripción
+		descripcion := d.Producto.NombreComercial
+		if d.UnidadVenta == domain.UnidadVentaUnidad {
+			descripcion += " (unidad)"
+		}
 		detalles = append(detalles, domain.Detalle{
 			ActividadEconomica: "477300",
 			CodigoProductoSin:  "622539",
 			CodigoProducto:     d.Producto.Id.String(),
-			Descripcion:        d.Producto.NombreComercial,
+			Descripcion:        descripcion,
 			Cantidad:           cantidad,
 			UnidadMedida:       57,
 			PrecioUnitario:     d.Precio,
-			MontoDescuento:     domain.NilableFloat64{},
-			SubTotal:           d.Total,
+			MontoDescuento:     montoDescuento,
+			SubTotal:           subTotal,
 			NumeroSerie:        domain.NilableString{Value: nil},
 			NumeroImei:         domain.NilableString{Value: nil},
 		})
@@ -167,10 +230,10 @@ func construirFactura(venta *domain.VentaDetail) domain.FacturaCompraVenta {
 			EmailCliente:                 "",
 			CodigoMetodoPago:             1,
 			NumeroTarjeta:                domain.NilableUint64{Value: nil},
-			MontoTotal:                   venta.Total - venta.Descuento,
+			MontoTotal:                   venta.Total - venta.DescuentoPromocion - venta.Descuento,
 			CodigoMoneda:                 1,
 			TipoCambio:                   1,
-			MontoTotalMoneda:             venta.Total - venta.Descuento,
+			MontoTotalMoneda:             venta.Total - venta.DescuentoPromocion - venta.Descuento,
 			MontoGiftCard:                domain.NilableFloat64{Value: nil},
 			DescuentoAdicional:           domain.NilableFloat64{Value: &venta.Descuento},
 			CodigoExcepcion:              domain.NilableUint64{Value: nil},
@@ -244,8 +307,209 @@ func (v VentaService) AnularVentaById(ctx context.Context, id *int) error {
 	return err
 }
 
-func NewVentaService(ventaRepository port.VentaRepository) *VentaService {
-	return &VentaService{ventaRepository: ventaRepository}
+// Días de vigencia por defecto de una cotización guardada
+const diasVigenciaCotizacion = 7
+
+func (v VentaService) CotizarVenta(ctx context.Context, request *domain.CotizacionRequest) (*domain.Cotizacion, error) {
+	// Asignación de lotes por FEFO sin afectar el stock
+	cotizacion, err := v.ventaRepository.CotizarVenta(ctx, request.Detalles)
+	if err != nil {
+		return nil, err
+	}
+
+	// Las promociones se calculan sobre las cantidades que realmente pueden venderse
+	var asignados []domain.DetalleVentaRequest
+	for _, d := range cotizacion.Detalles {
+		if d.CantidadAsignada > 0 {
+			asignados = append(asignados, domain.DetalleVentaRequest{ProductoId: d.ProductoId, Cantidad: d.CantidadAsignada, UnidadVenta: d.UnidadVenta})
+		}
+	}
+	cotizacion.Promociones = []domain.VentaPromocion{}
+	if len(asignados) > 0 {
+		cotizacion.Promociones, err = calcularPromociones(ctx, v.promocionRepository, asignados)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range cotizacion.Promociones {
+		cotizacion.DescuentoPromocion += p.Descuento
+	}
+
+	// Advertencias de interacciones y contraindicaciones de los productos cotizados
+	var productoIds []string
+	for _, d := range request.Detalles {
+		productoIds = append(productoIds, d.ProductoId)
+	}
+	advertencias, err := v.interaccionRepository.ObtenerAdvertenciasProductos(ctx, productoIds)
+	if err != nil {
+		return nil, err
+	}
+	cotizacion.Advertencias = *advertencias
+
+	cotizacion.Subtotal = redondear(cotizacion.Subtotal)
+	cotizacion.DescuentoPromocion = redondear(cotizacion.DescuentoPromocion)
+	cotizacion.Descuento = request.Descuento
+	cotizacion.Total = redondear(max(cotizacion.Subtotal-cotizacion.DescuentoPromocion-cotizacion.Descuento, 0))
+
+	if !request.Guardar {
+		return cotizacion, nil
+	}
+
+	// Guardar la cotización con fecha de expiración
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+	if request.DiasVigencia <= 0 {
+		request.DiasVigencia = diasVigenciaCotizacion
+	}
+	if err := v.ventaRepository.RegistrarCotizacion(ctx, request, cotizacion); err != nil {
+		return nil, err
+	}
+	return cotizacion, nil
+}
+
+func (v VentaService) ObtenerListaCotizaciones(ctx context.Context, filtros map[string]string) (*[]domain.CotizacionInfo, error) {
+	return v.ventaRepository.ObtenerListaCotizaciones(ctx, filtros)
+}
+
+func (v VentaService) ObtenerCotizacionById(ctx context.Context, id *int) (*domain.CotizacionDetail, error) {
+	return v.ventaRepository.ObtenerCotizacionById(ctx, id)
+}
+
+func (v VentaService) ConvertirCotizacion(ctx context.Context, id *int, request *domain.ConvertirCotizacionRequest) (*int64, error) {
+	cotizacion, err := v.ventaRepository.ObtenerCotizacionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ventaRequest := domain.VentaRequest{
+		TipoPago:                request.TipoPago,
+		Descuento:               cotizacion.Descuento,
+		RecetaId:                request.RecetaId,
+		AutorizacionInteraccion: request.AutorizacionInteraccion,
+	}
+	switch {
+	case request.ClienteId != nil:
+		ventaRequest.ClienteId = *request.ClienteId
+	case cotizacion.Cliente != nil:
+		ventaRequest.ClienteId = cotizacion.Cliente.Id
+	default:
+		return nil, datatype.NewBadRequestError("Debe indicar el cliente de la venta")
+	}
+	if ventaRequest.TipoPago == "" {
+		ventaRequest.TipoPago = "Efectivo"
+	}
+	// La venta respeta los precios y promociones cotizados mientras la cotización esté vigente
+	if len(cotizacion.Promociones) == 0 && cotizacion.DescuentoPromocion > 0 {
+		return nil, datatype.NewConflictError("La cotización no conserva el detalle de sus promociones, genere una nueva cotización")
+	}
+	for _, d := range cotizacion.Detalles {
+		if d.CantidadAsignada == 0 {
+			continue
+		}
+		precio := d.Precio
+		ventaRequest.Detalles = append(ventaRequest.Detalles, domain.DetalleVentaRequest{ProductoId: d.ProductoId, Cantidad: d.CantidadAsignada, UnidadVenta: d.UnidadVenta, Precio: &precio})
+	}
+	ventaRequest.Promociones = cotizacion.Promociones
+
+	// Obtener ID de usuario desde el contexto
+	userId, ok := ctx.Value(util.ContextUserIdKey).(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	ventaRequest.UsuarioId = uint(userId)
+
+	if err := verificarInteracciones(ctx, v.interaccionRepository, &ventaRequest); err != nil {
+		return nil, err
+	}
+
+	ventaId, err := v.ventaRepository.ConvertirCotizacion(ctx, id, &ventaRequest)
+	if err != nil {
+		return nil, err
+	}
+	return facturarVenta(ctx, v.ventaRepository, ventaId)
+}
+
+func (v VentaService) RegistrarVentaEnEspera(ctx context.Context, request *domain.VentaRequest) (*int64, error) {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return nil, datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+	if err := verificarInteracciones(ctx, v.interaccionRepository, request); err != nil {
+		return nil, err
+	}
+	request.ReservaHasta = time.Now().Add(tiempoReservaVenta())
+	return v.ventaRepository.RegistrarVentaEnEspera(ctx, request)
+}
+
+func (v VentaService) ModificarVentaEnEspera(ctx context.Context, id *int, request *domain.VentaRequest) error {
+	val := ctx.Value(util.ContextUserIdKey)
+	userId, ok := val.(int)
+	if !ok {
+		return datatype.NewBadRequestError("ID de usuario inválido o no encontrado en el contexto")
+	}
+	request.UsuarioId = uint(userId)
+	if err := verificarInteracciones(ctx, v.interaccionRepository, request); err != nil {
+		return err
+	}
+	request.ReservaHasta = time.Now().Add(tiempoReservaVenta())
+	return v.ventaRepository.ModificarVentaEnEspera(ctx, id, request)
+}
+
+func (v VentaService) FinalizarVentaEnEspera(ctx context.Context, id *int) (*int64, error) {
+	venta, err := v.ventaRepository.ObtenerVentaById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if venta.Estado != "Pendiente" {
+		return nil, datatype.NewConflictError("La venta no está en espera")
+	}
+
+	// Aplicar promociones vigentes sobre los productos de la venta
+	var detalles []domain.DetalleVentaRequest
+	for _, d := range venta.Detalles {
+		detalles = append(detalles, domain.DetalleVentaRequest{ProductoId: d.Producto.Id.String(), Cantidad: d.Cantidad, UnidadVenta: d.UnidadVenta})
+	}
+	promociones, err := calcularPromociones(ctx, v.promocionRepository, detalles)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.ventaRepository.FinalizarVentaEnEspera(ctx, id, promociones); err != nil {
+		return nil, err
+	}
+
+	ventaId := int64(*id)
+	return facturarVenta(ctx, v.ventaRepository, &ventaId)
+}
+
+func (v VentaService) LiberarReservasVencidas(ctx context.Context) error {
+	anuladas, err := v.ventaRepository.LiberarReservasVencidas(ctx)
+	if err != nil {
+		return err
+	}
+	if anuladas > 0 {
+		log.Printf("Ventas en espera anuladas por reserva vencida: %d", anuladas)
+	}
+	return nil
+}
+
+// tiempoReservaVenta obtiene la duración de la reserva de stock de una venta en espera (MINUTOS_RESERVA_VENTA, por defecto 30)
+func tiempoReservaVenta() time.Duration {
+	minutos, err := strconv.Atoi(os.Getenv("MINUTOS_RESERVA_VENTA"))
+	if err != nil || minutos <= 0 {
+		minutos = 30
+	}
+	return time.Duration(minutos) * time.Minute
+}
+
+func NewVentaService(ventaRepository port.VentaRepository, promocionRepository port.PromocionRepository, interaccionRepository port.InteraccionRepository) *VentaService {
+	return &VentaService{ventaRepository: ventaRepository, promocionRepository: promocionRepository, interaccionRepository: interaccionRepository}
 }
 
 var _ port.VentaService = (*VentaService)(nil)
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+type apiKey struct{}
+
+// ApiKey genera y reconoce las API keys de las cuentas de servicio con el formato fsk_<prefijo>_<secreto>;
+// el prefijo es público y permite buscar la key, del secreto solo se guarda el hash
+var ApiKey apiKey
+
+const (
+	apiKeyInicio          = "fsk_"
+	apiKeyLongitudPrefijo = 8
+)
+
+// Generar crea una API key nueva y devuelve la key completa, su prefijo y el hash que se guarda
+func (apiKey) Generar() (string, string, string, error) {
+	b := make([]byte, apiKeyLongitudPrefijo/2+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefijo := hex.EncodeToString(b[:apiKeyLongitudPrefijo/2])
+	key := apiKeyInicio + prefijo + "_" + base64.RawURLEncoding.EncodeToString(b[apiKeyLongitudPrefijo/2:])
+	return key, prefijo, ApiKey.Hash(key), nil
+}
+
+// Obtener extrae la API key de la cabecera Authorization ("Bearer <key>"); false si la cabecera no trae una API key
+func (apiKey) Obtener(authorization string) (string, bool) {
+	key, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || !strings.HasPrefix(key, apiKeyInicio) {
+		return "", false
+	}
+	return strings.TrimSpace(key), true
+}
+
+// Prefijo devuelve el prefijo de la API key si tiene el formato esperado
+func (apiKey) Prefijo(key string) (string, bool) {
+	resto, ok := strings.CutPrefix(key, apiKeyInicio)
+	if !ok || len(resto) <= apiKeyLongitudPrefijo+1 || resto[apiKeyLongitudPrefijo] != '_' {
+		return "", false
+	}
+	return resto[:apiKeyLongitudPrefijo], true
+}
+
+func (apiKey) Hash(key string) string {
+	suma := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(suma[:])
+}
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type barcode struct{}
+
+var Barcode barcode
+
+// Separador FNC1 de los campos de longitud variable en GS1-128 y GS1 DataMatrix
+const separadorGS1 = "\x1d"
+
+// GS1 contiene los datos extraídos de un código GS1-128 o GS1 DataMatrix
+type GS1 struct {
+	Gtin             string
+	Lote             string
+	Serie            string
+	FechaVencimiento *time.Time
+}
+
+// Longitud fija de los identificadores de aplicación soportados, 0 indica longitud variable
+var identificadoresGS1 = map[string]int{
+	"01": 14, // GTIN
+	"10": 0,  // Lote
+	"11": 6,  // Fecha de producción
+	"17": 6,  // Fecha de vencimiento
+	"21": 0,  // Número de serie
+}
+
+// ValidarGTIN verifica la longitud y el dígito de control de un GTIN-8, GTIN-12 (UPC), GTIN-13 (EAN) o GTIN-14
+func (barcode) ValidarGTIN(codigo string) bool {
+	switch len(codigo) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	suma := 0
+	for i := len(codigo) - 1; i >= 0; i-- {
+		c := codigo[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		if i == len(codigo)-1 {
+			continue
+		}
+		d := int(c - '0')
+		// Desde la derecha, las posiciones impares (sin contar el dígito de control) pesan 3
+		if (len(codigo)-1-i)%2 == 1 {
+			d *= 3
+		}
+		suma += d
+	}
+	control := (10 - suma%10) % 10
+	return int(codigo[len(codigo)-1]-'0') == control
+}
+
+// NormalizarGTIN completa un GTIN con ceros a la izquierda hasta 14 dígitos
+func (barcode) NormalizarGTIN(codigo string) string {
+	if len(codigo) >= 14 {
+		return codigo
+	}
+	return strings.Repeat("0", 14-len(codigo)) + codigo
+}
+
+// EsGS1 indica si el código leído contiene identificadores de aplicación GS1
+func (barcode) EsGS1(codigo string) bool {
+	codigo = quitarIdentificadorSimbologia(codigo)
+	return strings.HasPrefix(codigo, "(") || strings.Contains(codigo, separadorGS1) ||
+		(strings.HasPrefix(codigo, "01") && len(codigo) > 16)
+}
+
+// ParsearGS1 extrae GTIN, lote, serie y vencimiento de un código GS1 en formato legible "(01)...(10)..." o con separadores FNC1
+func (b barcode) ParsearGS1(codigo string) (*GS1, error) {
+	codigo = quitarIdentificadorSimbologia(codigo)
+	if strings.HasPrefix(codigo, "(") {
+		codigo = convertirGS1Legible(codigo)
+	}
+
+	datos := &GS1{}
+	for len(codigo) > 0 {
+		if len(codigo) < 2 {
+			return nil, errors.New("código GS1 incompleto")
+		}
+		ai := codigo[:2]
+		longitud, ok := identificadoresGS1[ai]
+		if !ok {
+			return nil, errors.New("identificador de aplicación GS1 no soportado: " + ai)
+		}
+		codigo = codigo[2:]
+
+		var valor string
+		if longitud > 0 {
+			if len(codigo) < longitud {
+				return nil, errors.New("código GS1 incompleto")
+			}
+			valor, codigo = codigo[:longitud], codigo[longitud:]
+			codigo = strings.TrimPrefix(codigo, separadorGS1)
+		} else if idx := strings.Index(codigo, separadorGS1); idx >= 0 {
+			valor, codigo = codigo[:idx], codigo[idx+1:]
+		} else {
+			valor, codigo = codigo, ""
+		}
+
+		switch ai {
+		case "01":
+			if !b.ValidarGTIN(valor) {
+				return nil, errors.New("GTIN con dígito de control inválido")
+			}
+			datos.Gtin = valor
+		case "10":
+			datos.Lote = valor
+		case "21":
+			datos.Serie = valor
+		case "17":
+			fecha, err := parsearFechaGS1(valor)
+			if err != nil {
+				return nil, err
+			}
+			datos.FechaVencimiento = &fecha
+		}
+	}
+	if datos.Gtin == "" {
+		return nil, errors.New("el código GS1 no contiene GTIN")
+	}
+	return datos, nil
+}
+
+// quitarIdentificadorSimbologia elimina los prefijos ]C1, ]d2, ]Q3 que agregan algunos lectores
+func quitarIdentificadorSimbologia(codigo string) string {
+	codigo = strings.TrimSpace(codigo)
+	for _, prefijo := range []string{"]C1", "]d2", "]Q3"} {
+		if strings.HasPrefix(codigo, prefijo) {
+			return codigo[len(prefijo):]
+		}
+	}
+	return codigo
+}
+
+// convertirGS1Legible transforma "(01)123(10)ABC" al formato con separadores FNC1
+func convertirGS1Legible(codigo string) string {
+	var sb strings.Builder
+	partes := strings.Split(codigo, "(")
+	for i, parte := range partes {
+		if parte == "" {
+			continue
+		}
+		ai, valor, ok := strings.Cut(parte, ")")
+		if !ok {
+			sb.WriteString(parte)
+			continue
+		}
+		sb.WriteString(ai)
+		sb.WriteString(valor)
+		// Los campos de longitud variable terminan con FNC1 si no son los últimos
+		if identificadoresGS1[ai] == 0 && i < len(partes)-1 {
+			sb.WriteString(separadorGS1)
+		}
+	}
+	return sb.String()
+}
+
+// parsearFechaGS1 interpreta una fecha YYMMDD; el día 00 corresponde al último día del mes
+func parsearFechaGS1(valor string) (time.Time, error) {
+	if strings.HasSuffix(valor, "00") {
+		fecha, err := time.Parse("060102", valor[:4]+"01")
+		if err != nil {
+			return time.Time{}, errors.New("fecha de vencimiento GS1 inválida")
+		}
+		return fecha.AddDate(0, 1, -1), nil
+	}
+	fecha, err := time.Parse("060102", valor)
+	if err != nil {
+		return time.Time{}, errors.New("fecha de vencimiento GS1 inválida")
+	}
+	return fecha, nil
+}
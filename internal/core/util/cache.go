@@ -0,0 +1,126 @@
+package util
+
+import (
+	"farma-santi_backend/internal/core/domain"
+	"sync"
+	"time"
+)
+
+// PermisosCache guarda los permisos efectivos de cada usuario
+var PermisosCache = NewCache[int, []string](5 * time.Minute)
+
+// SesionesCache guarda las sesiones verificadas como activas; un TTL corto limita la escritura del último acceso
+var SesionesCache = NewCache[string, bool](time.Minute)
+
+// ApiKeysCache guarda las API keys verificadas por su prefijo
+var ApiKeysCache = NewCache[string, domain.ApiKeyAutenticada](time.Minute)
+
+// UsoApiKeysCache guarda la IP del último uso registrado de cada API key; el TTL limita la escritura a una por minuto
+var UsoApiKeysCache = NewCache[int, string](time.Minute)
+
+type entradaCache[V any] struct {
+	valor  V
+	expira time.Time
+}
+
+// Máximo de entradas por caché; al llenarse se descarta la entrada más próxima a expirar
+const maxEntradasCache = 10000
+
+// Cache guarda valores con TTL; se invalida al recibir cambios desde la base de datos
+type Cache[K comparable, V any] struct {
+	mu         sync.RWMutex
+	ttl        time.Duration
+	entradas   map[K]entradaCache[V]
+	generacion uint64
+}
+
+func NewCache[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{ttl: ttl, entradas: make(map[K]entradaCache[V])}
+}
+
+// Obtener devuelve el valor guardado si no expiró; una entrada expirada se elimina
+func (c *Cache[K, V]) Obtener(clave K) (V, bool) {
+	c.mu.RLock()
+	entrada, ok := c.entradas[clave]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entrada.expira) {
+		return entrada.valor, true
+	}
+	if ok {
+		c.mu.Lock()
+		// Otra petición pudo guardar un valor nuevo mientras tanto
+		if actual, ok := c.entradas[clave]; ok && !time.Now().Before(actual.expira) {
+			delete(c.entradas, clave)
+		}
+		c.mu.Unlock()
+	}
+	var vacio V
+	return vacio, false
+}
+
+// Generacion se obtiene antes de consultar la base de datos para no guardar datos invalidados durante la consulta
+func (c *Cache[K, V]) Generacion() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generacion
+}
+
+// Guardar almacena el valor consultado si no hubo invalidaciones desde la generación indicada
+func (c *Cache[K, V]) Guardar(clave K, valor V, generacion uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generacion != c.generacion {
+		return
+	}
+	if _, ok := c.entradas[clave]; !ok && len(c.entradas) >= maxEntradasCache {
+		c.depurar()
+		if len(c.entradas) >= maxEntradasCache {
+			c.descartarProximaExpirar()
+		}
+	}
+	c.entradas[clave] = entradaCache[V]{valor: valor, expira: time.Now().Add(c.ttl)}
+}
+
+// Depurar elimina las entradas expiradas
+func (c *Cache[K, V]) Depurar() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.depurar()
+}
+
+func (c *Cache[K, V]) depurar() {
+	ahora := time.Now()
+	for clave, entrada := range c.entradas {
+		if !ahora.Before(entrada.expira) {
+			delete(c.entradas, clave)
+		}
+	}
+}
+
+func (c *Cache[K, V]) descartarProximaExpirar() {
+	var descartar K
+	var expira time.Time
+	primera := true
+	for clave, entrada := range c.entradas {
+		if primera || entrada.expira.Before(expira) {
+			descartar, expira, primera = clave, entrada.expira, false
+		}
+	}
+	if !primera {
+		delete(c.entradas, descartar)
+	}
+}
+
+func (c *Cache[K, V]) Invalidar(clave K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entradas, clave)
+	c.generacion++
+}
+
+func (c *Cache[K, V]) InvalidarTodo() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entradas = make(map[K]entradaCache[V])
+	c.generacion++
+}
@@ -4,4 +4,7 @@ const (
 	ContextFullHostnameKey string = "fullHostname"
 	ContextUsernameKey     string = "username"
 	ContextUserIdKey       string = "userId"
+	ContextClientIpKey     string = "clientIp"
+	ContextSesionIdKey     string = "sesionId"
+	ContextAlcanceKey      string = "alcance"
 )
@@ -0,0 +1,378 @@
+package util
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tabla lee y escribe hojas de cálculo simples (CSV y XLSX) como filas de texto
+type tabla struct{}
+
+var Tabla tabla
+
+// Formatos de archivo soportados para importar y exportar
+const (
+	FormatoCSV  = "csv"
+	FormatoXLSX = "xlsx"
+)
+
+// Leer obtiene las filas de un archivo CSV o XLSX según su extensión; las filas vacías se conservan
+// para que el número de fila del reporte coincida con el del archivo
+func (tabla) Leer(fileHeader *multipart.FileHeader) ([][]string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".csv":
+		return leerCSV(file)
+	case ".xlsx":
+		return leerXLSX(file, fileHeader.Size)
+	default:
+		return nil, errors.New("formato de archivo no soportado, se espera CSV o XLSX")
+	}
+}
+
+// Escribir genera el archivo en el formato indicado junto con su tipo de contenido
+func (tabla) Escribir(formato string, hoja string, filas [][]string) ([]byte, string, error) {
+	switch formato {
+	case FormatoCSV:
+		data, err := escribirCSV(filas)
+		return data, "text/csv; charset=utf-8", err
+	case FormatoXLSX:
+		data, err := escribirXLSX(hoja, filas)
+		return data, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", err
+	default:
+		return nil, "", errors.New("formato de archivo no soportado, se espera csv o xlsx")
+	}
+}
+
+// bomUTF8 es la marca de orden de bytes que agregan algunas hojas de cálculo a los CSV
+const bomUTF8 = "\xef\xbb\xbf"
+
+// leerCSV acepta coma o punto y coma como separador (Excel en español exporta con punto y coma)
+func leerCSV(r io.Reader) ([][]string, error) {
+	br := bufio.NewReader(r)
+	primera, err := br.Peek(4096)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	primera = bytes.TrimPrefix(primera, []byte(bomUTF8))
+	if i := bytes.IndexByte(primera, '\n'); i >= 0 {
+		primera = primera[:i]
+	}
+
+	if bom, _ := br.Peek(len(bomUTF8)); string(bom) == bomUTF8 {
+		_, _ = br.Discard(3)
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if bytes.Count(primera, []byte(";")) > bytes.Count(primera, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	// Las líneas en blanco se omiten al leer, se reponen para conservar la numeración
+	var filas [][]string
+	for {
+		registro, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return filas, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		linea, _ := reader.FieldPos(0)
+		for len(filas) < linea-1 {
+			filas = append(filas, nil)
+		}
+		filas = append(filas, registro)
+	}
+}
+
+func escribirCSV(filas [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	// BOM para que Excel reconozca el archivo como UTF-8
+	buf.WriteString(bomUTF8)
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(filas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type xlsxRelaciones struct {
+	Relaciones []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxLibro struct {
+	Hojas []struct {
+		RelId string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxTexto struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxTexto) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxHoja struct {
+	Filas []struct {
+		R      int `xml:"r,attr"`
+		Celdas []struct {
+			R  string    `xml:"r,attr"`
+			T  string    `xml:"t,attr"`
+			V  string    `xml:"v"`
+			Is xlsxTexto `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// leerXLSX obtiene las filas de la primera hoja del libro
+func leerXLSX(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("el archivo XLSX no es válido")
+	}
+	archivos := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		archivos[f.Name] = f
+	}
+
+	var compartidos []string
+	if f, ok := archivos["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Si []xlsxTexto `xml:"si"`
+		}
+		if err := decodificarXML(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Si {
+			compartidos = append(compartidos, si.String())
+		}
+	}
+
+	rutaHoja, err := primeraHojaXLSX(archivos)
+	if err != nil {
+		return nil, err
+	}
+	var hoja xlsxHoja
+	if err := decodificarXML(archivos[rutaHoja], &hoja); err != nil {
+		return nil, err
+	}
+
+	var filas [][]string
+	for _, fila := range hoja.Filas {
+		numero := fila.R
+		if numero <= 0 {
+			numero = len(filas) + 1
+		}
+		for len(filas) < numero {
+			filas = append(filas, nil)
+		}
+		var valores []string
+		for i, celda := range fila.Celdas {
+			columna := i
+			if celda.R != "" {
+				columna = columnaXLSX(celda.R)
+			}
+			var valor string
+			switch celda.T {
+			case "s":
+				idx, err := strconv.Atoi(celda.V)
+				if err != nil || idx < 0 || idx >= len(compartidos) {
+					return nil, errors.New("el archivo XLSX tiene referencias de texto inválidas")
+				}
+				valor = compartidos[idx]
+			case "inlineStr":
+				valor = celda.Is.String()
+			default:
+				valor = celda.V
+			}
+			for len(valores) <= columna {
+				valores = append(valores, "")
+			}
+			valores[columna] = valor
+		}
+		filas[numero-1] = valores
+	}
+	return filas, nil
+}
+
+// primeraHojaXLSX obtiene la ruta de la primera hoja declarada en el libro
+func primeraHojaXLSX(archivos map[string]*zip.File) (string, error) {
+	libro, okLibro := archivos["xl/workbook.xml"]
+	rels, okRels := archivos["xl/_rels/workbook.xml.rels"]
+	if okLibro && okRels {
+		var l xlsxLibro
+		var r xlsxRelaciones
+		if decodificarXML(libro, &l) == nil && decodificarXML(rels, &r) == nil && len(l.Hojas) > 0 {
+			for _, rel := range r.Relaciones {
+				if rel.Id != l.Hojas[0].RelId {
+					continue
+				}
+				ruta := strings.TrimPrefix(rel.Target, "/")
+				if !strings.HasPrefix(ruta, "xl/") {
+					ruta = path.Join("xl", ruta)
+				}
+				if _, ok := archivos[ruta]; ok {
+					return ruta, nil
+				}
+			}
+		}
+	}
+
+	var hojas []string
+	for nombre := range archivos {
+		if strings.HasPrefix(nombre, "xl/worksheets/") && strings.HasSuffix(nombre, ".xml") {
+			hojas = append(hojas, nombre)
+		}
+	}
+	if len(hojas) == 0 {
+		return "", errors.New("el archivo XLSX no tiene hojas")
+	}
+	sort.Strings(hojas)
+	return hojas[0], nil
+}
+
+func decodificarXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("el archivo XLSX no es válido: %s", f.Name)
+	}
+	return nil
+}
+
+// columnaXLSX convierte la referencia de una celda (p. ej. "AB12") en el índice de columna desde 0
+func columnaXLSX(ref string) int {
+	columna := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		columna = columna*26 + int(c-'A'+1)
+	}
+	return columna - 1
+}
+
+// nombreColumnaXLSX convierte un índice de columna desde 0 en su letra (0 → "A", 27 → "AB")
+func nombreColumnaXLSX(columna int) string {
+	nombre := ""
+	for columna++; columna > 0; columna = (columna - 1) / 26 {
+		nombre = string(rune('A'+(columna-1)%26)) + nombre
+	}
+	return nombre
+}
+
+// escribirXLSX genera un libro con una sola hoja; los textos van en línea para no requerir sharedStrings
+func escribirXLSX(hoja string, filas [][]string) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, fila := range filas {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, valor := range fila {
+			ref := fmt.Sprintf("%s%d", nombreColumnaXLSX(j), i+1)
+			if esNumeroXLSX(valor) {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, valor)
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&sheet, []byte(valor)); err != nil {
+				return nil, err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var nombreHoja bytes.Buffer
+	if err := xml.EscapeText(&nombreHoja, []byte(hoja)); err != nil {
+		return nil, err
+	}
+
+	partes := []struct {
+		nombre    string
+		contenido string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + nombreHoja.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, parte := range partes {
+		w, err := zw.Create(parte.nombre)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, parte.contenido); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// esNumeroXLSX indica si el valor se guarda como número; los códigos largos o con ceros a la izquierda quedan como texto
+func esNumeroXLSX(valor string) bool {
+	if valor == "" || len(valor) > 11 {
+		return false
+	}
+	if len(valor) > 1 && valor[0] == '0' && valor[1] != '.' {
+		return false
+	}
+	_, err := strconv.ParseFloat(valor, 64)
+	return err == nil && !strings.ContainsAny(valor, "eEnN+")
+}
@@ -0,0 +1,178 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/url"
+	"strings"
+	"time"
+
+	barcodelib "github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+)
+
+type totp struct{}
+
+// Totp implementa los códigos de un solo uso basados en tiempo (RFC 6238) compatibles con las apps autenticadoras
+var Totp totp
+
+const (
+	totpEmisor  = "Farma Santi"
+	totpDigitos = 6
+	totpPeriodo = 30
+	// Pasos aceptados antes y después del actual para tolerar el desfase del reloj del teléfono
+	totpVentana = 1
+)
+
+var base32SinRelleno = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerarSecreto crea un secreto aleatorio de 160 bits codificado en base32
+func (totp) GenerarSecreto() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32SinRelleno.EncodeToString(b), nil
+}
+
+// Uri devuelve el enlace otpauth que las apps autenticadoras leen desde el código QR
+func (totp) Uri(cuenta, secreto string) string {
+	params := url.Values{}
+	params.Set("secret", secreto)
+	params.Set("issuer", totpEmisor)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totpDigitos))
+	params.Set("period", fmt.Sprintf("%d", totpPeriodo))
+	return "otpauth://totp/" + url.PathEscape(totpEmisor+":"+cuenta) + "?" + params.Encode()
+}
+
+// Qr genera la imagen PNG del código QR como data URI
+func (totp) Qr(contenido string) (string, error) {
+	codigo, err := qr.Encode(contenido, qr.M, qr.Auto)
+	if err != nil {
+		return "", err
+	}
+	codigo, err = barcodelib.Scale(codigo, 256, 256)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, codigo); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Verificar comprueba el código contra los pasos de la ventana y devuelve el paso que coincidió
+func (t totp) Verificar(secreto, codigo string, ahora time.Time) (int64, bool) {
+	codigo = strings.TrimSpace(codigo)
+	if len(codigo) != totpDigitos {
+		return 0, false
+	}
+	actual := ahora.Unix() / totpPeriodo
+	for paso := actual - totpVentana; paso <= actual+totpVentana; paso++ {
+		esperado, err := t.codigo(secreto, paso)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(esperado), []byte(codigo)) {
+			return paso, true
+		}
+	}
+	return 0, false
+}
+
+func (totp) codigo(secreto string, paso int64) (string, error) {
+	clave, err := base32SinRelleno.DecodeString(strings.ToUpper(secreto))
+	if err != nil {
+		return "", err
+	}
+	mensaje := make([]byte, 8)
+	binary.BigEndian.PutUint64(mensaje, uint64(paso))
+	mac := hmac.New(sha1.New, clave)
+	mac.Write(mensaje)
+	suma := mac.Sum(nil)
+	// Truncamiento dinámico
+	desplazamiento := suma[len(suma)-1] & 0x0f
+	valor := binary.BigEndian.Uint32(suma[desplazamiento:desplazamiento+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigitos, valor%1000000), nil
+}
+
+// GenerarCodigosRecuperacion crea códigos de un solo uso con el formato XXXXX-XXXXX
+func (totp) GenerarCodigosRecuperacion(cantidad int) ([]string, error) {
+	codigos := make([]string, 0, cantidad)
+	for range cantidad {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		c := base32SinRelleno.EncodeToString(b)[:10]
+		codigos = append(codigos, c[:5]+"-"+c[5:])
+	}
+	return codigos, nil
+}
+
+// HashCodigoRecuperacion normaliza el código ingresado y devuelve su hash para guardarlo o buscarlo
+func (totp) HashCodigoRecuperacion(codigo string) string {
+	normalizado := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(codigo))
+	suma := sha256.Sum256([]byte(normalizado))
+	return hex.EncodeToString(suma[:])
+}
+
+// claveCifrado deriva de SECRET_KEY la clave AES con la que se guardan los secretos en la base de datos
+func claveCifrado() []byte {
+	suma := sha256.Sum256(append([]byte("totp:"), secretKeyJwtAdmin...))
+	return suma[:]
+}
+
+// CifrarSecreto cifra el secreto con AES-GCM para no guardarlo en texto plano
+func (totp) CifrarSecreto(secreto string) (string, error) {
+	bloque, err := aes.NewCipher(claveCifrado())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(bloque)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secreto), nil)), nil
+}
+
+func (totp) DescifrarSecreto(cifrado string) (string, error) {
+	datos, err := base64.StdEncoding.DecodeString(cifrado)
+	if err != nil {
+		return "", err
+	}
+	bloque, err := aes.NewCipher(claveCifrado())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(bloque)
+	if err != nil {
+		return "", err
+	}
+	if len(datos) < gcm.NonceSize() {
+		return "", errors.New("secreto cifrado no válido")
+	}
+	secreto, err := gcm.Open(nil, datos[:gcm.NonceSize()], datos[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secreto), nil
+}
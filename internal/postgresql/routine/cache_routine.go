@@ -0,0 +1,27 @@
+package routine
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/util"
+	"time"
+)
+
+func startDepurarCaches(ctx context.Context) {
+	go func() {
+		// Eliminar las entradas expiradas de las cachés cada minuto
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				util.PermisosCache.Depurar()
+				util.SesionesCache.Depurar()
+				util.ApiKeysCache.Depurar()
+				util.UsoApiKeysCache.Depurar()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
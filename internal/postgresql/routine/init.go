@@ -9,4 +9,9 @@ func Init(ctx context.Context) {
 	deps := setup.GetDependencies()
 
 	go startActualizarLotesVencidos(ctx, deps.Service.LoteProducto)
+	go startLiberarReservasVencidas(ctx, deps.Service.Venta)
+	go startCancelarPedidosVencidos(ctx, deps.Service.Pedido)
+	go startAplicarPreciosProgramados(ctx, deps.Service.Precio)
+	go startEscucharCambiosPermisos(ctx, deps.Service.Usuario)
+	go startDepurarCaches(ctx)
 }
@@ -0,0 +1,28 @@
+package routine
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/port"
+	"log"
+	"time"
+)
+
+func startCancelarPedidosVencidos(ctx context.Context, service port.PedidoService) {
+	go func() {
+		// Cancelar pedidos no recogidos cada 5 minutos
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			if err := service.CancelarPedidosVencidos(ctx); err != nil {
+				log.Printf("Error al cancelar pedidos vencidos: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
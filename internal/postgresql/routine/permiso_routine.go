@@ -0,0 +1,27 @@
+package routine
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/port"
+	"log"
+	"time"
+)
+
+func startEscucharCambiosPermisos(ctx context.Context, service port.UsuarioService) {
+	go func() {
+		for {
+			err := service.EscucharCambiosPermisos(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error al escuchar cambios de permisos: %v", err)
+
+			// Reintentar la conexión después de unos segundos
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package routine
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/port"
+	"log"
+	"time"
+)
+
+func startAplicarPreciosProgramados(ctx context.Context, service port.PrecioService) {
+	go func() {
+		// Aplicar los precios cuya fecha efectiva ya llegó cada minuto
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			if err := service.AplicarPreciosProgramados(ctx); err != nil {
+				log.Printf("Error al aplicar precios programados: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
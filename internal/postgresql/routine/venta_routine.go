@@ -0,0 +1,28 @@
+package routine
+
+import (
+	"context"
+	"farma-santi_backend/internal/core/port"
+	"log"
+	"time"
+)
+
+func startLiberarReservasVencidas(ctx context.Context, service port.VentaService) {
+	go func() {
+		// Revisar reservas de ventas en espera cada minuto
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			if err := service.LiberarReservasVencidas(ctx); err != nil {
+				log.Printf("Error al liberar reservas vencidas: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // HostnameMiddleware guarda y registra el hostname completo de la petición
@@ -24,7 +26,12 @@ func HostnameMiddleware(c *fiber.Ctx) error {
 }
 
 func VerifyUserAdminMiddleware(c *fiber.Ctx) error {
-	claimsAccessToken, err := util.Token.VerifyToken(c.Cookies("access-token"))
+	// Las integraciones se autentican con la API key de una cuenta de servicio en lugar de la cookie
+	if key, ok := util.ApiKey.Obtener(c.Get(fiber.HeaderAuthorization)); ok {
+		return verifyApiKey(c, key)
+	}
+
+	claimsAccessToken, err := util.Token.VerifyTokenType(c.Cookies("access-token"), "access-token-adm")
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
 	}
@@ -41,39 +48,93 @@ func VerifyUserAdminMiddleware(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
 	}
 	userId := int(userIdFloat)
+
+	// Verificar que la sesión no haya sido revocada
+	sesionId, ok := claimsAccessToken["sessionId"].(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
+	}
+	if _, ok := util.SesionesCache.Obtener(sesionId); !ok {
+		id, err := uuid.Parse(sesionId)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
+		}
+		generacion := util.SesionesCache.Generacion()
+		activa, err := setup.GetDependencies().Repository.Sesion.VerificarSesionActiva(c.UserContext(), id)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(util.NewMessage(err.Error()))
+		}
+		if !activa {
+			return c.Status(fiber.StatusUnauthorized).JSON(util.NewMessage("Sesión finalizada, vuelva a iniciar sesión"))
+		}
+		util.SesionesCache.Guardar(sesionId, true, generacion)
+	}
+
 	// Guardar en el contexto
 	ctx := context.WithValue(c.UserContext(), util.ContextUsernameKey, username)
 	ctx = context.WithValue(ctx, util.ContextUserIdKey, userId)
+	ctx = context.WithValue(ctx, util.ContextClientIpKey, c.IP())
+	ctx = context.WithValue(ctx, util.ContextSesionIdKey, sesionId)
 	c.SetUserContext(ctx)
 
 	return c.Next()
 }
 
-func VerifyRolesMiddleware(roles ...string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		username := c.UserContext().Value(util.ContextUsernameKey).(string)
-		user, err := setup.GetDependencies().Repository.Usuario.ObtenerUsuarioDetalleByUsername(c.UserContext(), &username)
-		if err != nil {
-			log.Print(err.Error())
-			var errorResponse *datatype.ErrorResponse
-			if errors.As(err, &errorResponse) {
-				return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
-			}
-			return datatype.NewInternalServerErrorGeneric()
+// verifyApiKey autentica la petición como la cuenta de servicio dueña de la API key
+func verifyApiKey(c *fiber.Ctx, key string) error {
+	apiKey, err := setup.GetDependencies().Service.CuentaServicio.AutenticarApiKey(c.UserContext(), key, c.IP())
+	if err != nil {
+		var errorResponse *datatype.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Code != http.StatusUnauthorized {
+			return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
 		}
-		if user.Estado == "Inactivo" {
-			return datatype.NewBadRequestError("Usuario no autorizado")
+		return c.Status(fiber.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
+	}
+
+	// Guardar en el contexto
+	ctx := context.WithValue(c.UserContext(), util.ContextUsernameKey, apiKey.Username)
+	ctx = context.WithValue(ctx, util.ContextUserIdKey, apiKey.UsuarioId)
+	ctx = context.WithValue(ctx, util.ContextClientIpKey, c.IP())
+	if apiKey.Permisos != nil {
+		ctx = context.WithValue(ctx, util.ContextAlcanceKey, apiKey.Permisos)
+	}
+	c.SetUserContext(ctx)
+
+	return c.Next()
+}
+
+// VerifyPermisosMiddleware permite continuar si el usuario tiene alguno de los permisos indicados;
+// con una API key limitada el permiso también debe estar en su alcance
+func VerifyPermisosMiddleware(permisos ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, ok := c.UserContext().Value(util.ContextUserIdKey).(int)
+		if !ok {
+			return c.Status(http.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
 		}
-		// Verificar si tiene el rol
-		for _, rol := range roles {
-			for _, userRole := range user.Roles {
-				if rol == userRole.Nombre {
-					return c.Next()
+		permisosUsuario, ok := util.PermisosCache.Obtener(userId)
+		if !ok {
+			generacion := util.PermisosCache.Generacion()
+			// Un usuario inactivo o con roles inactivos no tiene permisos efectivos
+			var err error
+			permisosUsuario, err = setup.GetDependencies().Repository.Usuario.ObtenerPermisosUsuario(c.UserContext(), &userId)
+			if err != nil {
+				log.Print(err.Error())
+				var errorResponse *datatype.ErrorResponse
+				if errors.As(err, &errorResponse) {
+					return c.Status(errorResponse.Code).JSON(util.NewMessage(errorResponse.Message))
 				}
+				return datatype.NewInternalServerErrorGeneric()
+			}
+			util.PermisosCache.Guardar(userId, permisosUsuario, generacion)
+		}
+		alcance, limitado := c.UserContext().Value(util.ContextAlcanceKey).([]string)
+		for _, permiso := range permisos {
+			if slices.Contains(permisosUsuario, permiso) && (!limitado || slices.Contains(alcance, permiso)) {
+				return c.Next()
 			}
 		}
 
-		return c.Status(http.StatusUnauthorized).JSON(util.NewMessage("Usuario no autorizado"))
+		return c.Status(http.StatusForbidden).JSON(util.NewMessage("No tiene permiso para realizar esta acción"))
 	}
 }
 
@@ -97,6 +158,7 @@ func VerifyUsuarioShared(c *fiber.Ctx) error {
 
 	// Guardar en el contexto
 	ctx := context.WithValue(c.UserContext(), util.ContextUserIdKey, userId)
+	ctx = context.WithValue(ctx, util.ContextClientIpKey, c.IP())
 	c.SetUserContext(ctx)
 
 	// Guardar en local
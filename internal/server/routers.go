@@ -1,6 +1,7 @@
 package server
 
 import (
+	"farma-santi_backend/internal/core/domain"
 	"farma-santi_backend/internal/core/util"
 	"farma-santi_backend/internal/server/middleware"
 	"time"
@@ -33,6 +34,18 @@ func limited(max int, expiration, delay time.Duration) fiber.Handler {
 	})
 
 }
+
+// bloqueo rechaza las peticiones por IP que superan el límite en lugar de solo demorarlas
+func bloqueo(max int, expiration time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: expiration,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(util.NewMessage("Demasiados intentos, intente nuevamente más tarde"))
+		},
+	})
+}
+
 func (s *Server) initEndPoints(app *fiber.App) {
 	app.Static("/", "./public", fiber.Static{
 		ModifyResponse: util.EnviarArchivo,
@@ -63,21 +76,34 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 	v1Movimientos := v1.Group("/movimientos")
 	v1Reportes := v1.Group("/reportes")
 	// path: /api/v1/usuarios/me
-	v1UsuariosMe.Get("", limited(20, 5*time.Minute, 5*time.Second), s.handlers.Usuario.ObtenerUsuarioActual)
+	v1UsuariosMe.Get("", limited(20, 5*time.Minute, 5*time.Second), middleware.VerifyUserAdminMiddleware, s.handlers.Usuario.ObtenerUsuarioActual)
+	v1UsuariosMe.Put("/password", middleware.VerifyUserAdminMiddleware, bloqueo(10, 5*time.Minute), s.handlers.Usuario.CambiarPassword)
 
 	// Middleware para endpoint
-	v1Roles.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE"))
-	v1Usuarios.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE"))
-	v1Categorias.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"))
+	v1Roles.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoRolesGestionar))
+	v1Usuarios.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoUsuariosGestionar))
+	v1Categorias.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoCategoriasGestionar))
 
-	v1PrincipiosActivos.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"))
-	v1Clientes.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "FARMACEUTICO"))
+	v1PrincipiosActivos.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoPrincipiosActivosGestionar))
+	v1Clientes.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoClientesGestionar))
 
 	// path: /api/v1/auth
-	v1Auth.Post("/login", limited(5, 5*time.Minute, 5*time.Minute), s.handlers.Auth.Login)
+	v1Auth.Post("/login", bloqueo(20, 5*time.Minute), s.handlers.Auth.Login)
+	v1Auth.Post("/login/2fa/verificar", bloqueo(20, 5*time.Minute), s.handlers.Auth.VerificarDobleFactor)
+	v1Auth.Post("/login/2fa/configurar", bloqueo(20, 5*time.Minute), s.handlers.Auth.IniciarDobleFactorDesafio)
+	v1Auth.Post("/login/2fa/confirmar", bloqueo(20, 5*time.Minute), s.handlers.Auth.ConfirmarDobleFactorDesafio)
+	v1Auth.Post("/login/password", bloqueo(20, 5*time.Minute), s.handlers.Auth.CambiarPasswordDesafio)
+	v1Auth.Get("/password/politica", limite, s.handlers.Usuario.ObtenerPoliticaPassword)
 	v1Auth.Get("/logout", limited(30, 5*time.Minute, 5*time.Second), s.handlers.Auth.Logout)
 	v1Auth.Get("/refresh", limited(50, 5*time.Minute, 5*time.Second), s.handlers.Auth.RefreshOrVerify)
 	v1Auth.Get("/verify", limited(50, 5*time.Minute, 5*time.Second), s.handlers.Auth.RefreshOrVerify)
+	v1Auth.Get("/sesiones", middleware.VerifyUserAdminMiddleware, limite, s.handlers.Sesion.ObtenerMisSesiones)
+	v1Auth.Post("/logout-all", middleware.VerifyUserAdminMiddleware, limite, s.handlers.Sesion.CerrarTodasLasSesiones)
+	v1Auth.Get("/2fa", middleware.VerifyUserAdminMiddleware, limite, s.handlers.DobleFactor.ObtenerEstado)
+	v1Auth.Post("/2fa/configurar", middleware.VerifyUserAdminMiddleware, limite, s.handlers.DobleFactor.IniciarConfiguracion)
+	v1Auth.Post("/2fa/confirmar", middleware.VerifyUserAdminMiddleware, limite, s.handlers.DobleFactor.ActivarDobleFactor)
+	v1Auth.Post("/2fa/desactivar", middleware.VerifyUserAdminMiddleware, bloqueo(10, 5*time.Minute), s.handlers.DobleFactor.DesactivarDobleFactor)
+	v1Auth.Post("/2fa/codigos-recuperacion", middleware.VerifyUserAdminMiddleware, bloqueo(10, 5*time.Minute), s.handlers.DobleFactor.RegenerarCodigosRecuperacion)
 
 	// path: /api/v1/roles
 	v1Roles.Get("", limite, s.handlers.Rol.ListarRoles)
@@ -86,6 +112,14 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 	v1Roles.Patch("/estado/habilitar/:rolId", limite, s.handlers.Rol.HabilitarRol)
 	v1Roles.Patch("/estado/deshabilitar/:rolId", limite, s.handlers.Rol.DeshabilitarRol)
 	v1Roles.Put("/:rolId", limite, s.handlers.Rol.ModificarRol)
+	v1Roles.Get("/:rolId/permisos", limite, s.handlers.Rol.ObtenerPermisosRol)
+	v1Roles.Put("/:rolId/permisos", limite, s.handlers.Rol.AsignarPermisosRol)
+	v1Roles.Put("/:rolId/2fa", limite, s.handlers.Rol.ModificarDobleFactorRol)
+
+	// path: /api/v1/permisos
+	v1Permisos := v1.Group("/permisos")
+	v1Permisos.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoRolesGestionar))
+	v1Permisos.Get("", s.handlers.Rol.ListarPermisos)
 
 	// path: /api/v1/usuarios
 	v1Usuarios.Get("", limite, s.handlers.Usuario.ListarUsuarios)
@@ -95,6 +129,11 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 	v1Usuarios.Patch("/estado/deshabilitar/:usuarioId", limite, s.handlers.Usuario.DeshabilitarUsuarioById)
 	v1Usuarios.Patch("/password/restablecer/:usuarioId", limite, s.handlers.Usuario.RestablecerPassword)
 	v1Usuarios.Put("/:usuarioId", limite, s.handlers.Usuario.ModificarUsuario)
+	v1Usuarios.Get("/:usuarioId/sesiones", limite, s.handlers.Sesion.ObtenerSesionesUsuario)
+	v1Usuarios.Patch("/:usuarioId/sesiones/revocar", limite, s.handlers.Sesion.RevocarSesionesUsuario)
+	v1Usuarios.Patch("/sesiones/revocar/:sesionId", limite, s.handlers.Sesion.RevocarSesion)
+	v1Usuarios.Patch("/desbloquear/:usuarioId", limite, s.handlers.Acceso.DesbloquearUsuario)
+	v1Usuarios.Patch("/2fa/restablecer/:usuarioId", limite, s.handlers.DobleFactor.RestablecerDobleFactor)
 
 	//path: /api/v1/categorias
 	v1Categorias.Get("/activos", limite, s.handlers.Categoria.ListarCategoriasDisponibles)
@@ -121,7 +160,7 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 	v1Laboratorios.Get("/activos", limite, s.handlers.Laboratorio.ListarLaboratoriosDisponibles)
 	v1Laboratorios.Get("", limite, s.handlers.Laboratorio.ListarLaboratorios)
 	v1Laboratorios.Get("/:laboratorioId", limite, s.handlers.Laboratorio.ObtenerLaboratorioById)
-	v1Laboratorios.Use(middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"))
+	v1Laboratorios.Use(middleware.VerifyPermisosMiddleware(domain.PermisoLaboratoriosGestionar))
 	v1Laboratorios.Post("", limite, s.handlers.Laboratorio.RegistrarLaboratorio)
 	v1Laboratorios.Put("/:laboratorioId", limite, s.handlers.Laboratorio.ModificarLaboratorio)
 	v1Laboratorios.Patch("/estado/habilitar/:laboratorioId", limite, s.handlers.Laboratorio.HabilitarLaboratorio)
@@ -130,21 +169,28 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 	//path: /api/v1/productos
 	v1Productos.Use(middleware.HostnameMiddleware, middleware.VerifyUserAdminMiddleware)
 	v1Productos.Get("/unidades-medida", limite, s.handlers.Producto.ListarUnidadesMedida)
+	v1Productos.Get("/exportar", middleware.VerifyPermisosMiddleware(domain.PermisoProductosExportar), limite, s.handlers.Importacion.ExportarProductos)
+	v1Productos.Post("/importar", middleware.VerifyPermisosMiddleware(domain.PermisoProductosImportar), limite, s.handlers.Importacion.ImportarProductos)
 	v1Productos.Get("/formas-farmaceuticas", limite, s.handlers.Producto.ListarFormasFarmaceuticas)
 	v1Productos.Get("", limite, s.handlers.Producto.ObtenerListaProductos)
+	v1Productos.Get("/scan/:code", limite, s.handlers.Producto.EscanearCodigo)
 	v1Productos.Get("/:productoId", limite, s.handlers.Producto.ObtenerProductoById)
-	v1Productos.Post("", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.Producto.RegistrarProducto)
-	v1Productos.Put("/:productoId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.Producto.ModificarProducto)
-	v1Productos.Patch("/estado/habilitar/:productoId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.Producto.HabilitarProducto)
-	v1Productos.Patch("/estado/deshabilitar/:productoId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.Producto.DeshabilitarProducto)
+	v1Productos.Get("/:productoId/equivalentes", limite, s.handlers.Producto.ObtenerEquivalentesProducto)
+	v1Productos.Get("/:productoId/precios", middleware.VerifyPermisosMiddleware(domain.PermisoPreciosGestionar), limite, s.handlers.Precio.ObtenerHistorialPrecios)
+	v1Productos.Post("", middleware.VerifyPermisosMiddleware(domain.PermisoProductosGestionar), limite, s.handlers.Producto.RegistrarProducto)
+	v1Productos.Put("/:productoId", middleware.VerifyPermisosMiddleware(domain.PermisoProductosGestionar), limite, s.handlers.Producto.ModificarProducto)
+	v1Productos.Patch("/estado/habilitar/:productoId", middleware.VerifyPermisosMiddleware(domain.PermisoProductosGestionar), limite, s.handlers.Producto.HabilitarProducto)
+	v1Productos.Patch("/estado/deshabilitar/:productoId", middleware.VerifyPermisosMiddleware(domain.PermisoProductosGestionar), limite, s.handlers.Producto.DeshabilitarProducto)
 
 	//path: /api/v1/lotes-productos
 	v1LotesProductos.Use(middleware.VerifyUserAdminMiddleware)
-	v1LotesProductos.Get("", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN", "FARMACEUTICO"), limite, s.handlers.LoteProducto.ObtenerListaLotesProductos)
-	v1LotesProductos.Get("/byProducto/:productoId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.LoteProducto.ListarLotesProductosByProductoId)
-	v1LotesProductos.Get("/:loteProductoId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.LoteProducto.ObtenerLoteProductoById)
-	v1LotesProductos.Post("", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.LoteProducto.RegistrarLoteProducto)
-	v1LotesProductos.Put("/:loteProductoId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), limite, s.handlers.LoteProducto.ModificarLoteProducto)
+	v1LotesProductos.Get("", middleware.VerifyPermisosMiddleware(domain.PermisoLotesVer), limite, s.handlers.LoteProducto.ObtenerListaLotesProductos)
+	v1LotesProductos.Get("/byProducto/:productoId", middleware.VerifyPermisosMiddleware(domain.PermisoLotesVer), limite, s.handlers.LoteProducto.ListarLotesProductosByProductoId)
+	v1LotesProductos.Get("/:loteProductoId", middleware.VerifyPermisosMiddleware(domain.PermisoLotesVer), limite, s.handlers.LoteProducto.ObtenerLoteProductoById)
+	v1LotesProductos.Get("/:loteProductoId/ventas", middleware.VerifyPermisosMiddleware(domain.PermisoRetirosGestionar), limite, s.handlers.RetiroLote.ObtenerVentasLote)
+	v1LotesProductos.Post("", middleware.VerifyPermisosMiddleware(domain.PermisoLotesGestionar), limite, s.handlers.LoteProducto.RegistrarLoteProducto)
+	v1LotesProductos.Post("/inventario-inicial", middleware.VerifyPermisosMiddleware(domain.PermisoInventarioImportar), limite, s.handlers.Importacion.ImportarStockInicial)
+	v1LotesProductos.Put("/:loteProductoId", middleware.VerifyPermisosMiddleware(domain.PermisoLotesGestionar), limite, s.handlers.LoteProducto.ModificarLoteProducto)
 
 	//path: /api/v1/principios-activos
 	v1PrincipiosActivos.Post("", limite, s.handlers.PrincipioActivo.RegistrarPrincipioActivo)
@@ -154,15 +200,17 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 
 	v1Compras.Use(limite, middleware.VerifyUserAdminMiddleware)
 	//path: /api/v1/compras
-	v1Compras.Get("", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN", "FARMACEUTICO"), s.handlers.Compra.ObtenerListaCompras)
-	v1Compras.Get("/:compraId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN", "FARMACEUTICO"), s.handlers.Compra.ObtenerCompraById)
-	v1Compras.Post("", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), s.handlers.Compra.RegistrarOrdenCompra)
-	v1Compras.Patch("/completar/:compraId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), s.handlers.Compra.RegistrarCompra)
-	v1Compras.Put("/:compraId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), s.handlers.Compra.ModificarOrdenCompra)
-	v1Compras.Patch("/anular/:compraId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN"), s.handlers.Compra.AnularOrdenCompra)
+	v1Compras.Get("", middleware.VerifyPermisosMiddleware(domain.PermisoComprasVer), s.handlers.Compra.ObtenerListaCompras)
+	v1Compras.Get("/:compraId", middleware.VerifyPermisosMiddleware(domain.PermisoComprasVer), s.handlers.Compra.ObtenerCompraById)
+	v1Compras.Post("", middleware.VerifyPermisosMiddleware(domain.PermisoComprasGestionar), s.handlers.Compra.RegistrarOrdenCompra)
+	v1Compras.Patch("/completar/:compraId", middleware.VerifyPermisosMiddleware(domain.PermisoComprasCompletar), s.handlers.Compra.RegistrarCompra)
+	v1Compras.Put("/:compraId", middleware.VerifyPermisosMiddleware(domain.PermisoComprasGestionar), s.handlers.Compra.ModificarOrdenCompra)
+	v1Compras.Patch("/anular/:compraId", middleware.VerifyPermisosMiddleware(domain.PermisoComprasAnular), s.handlers.Compra.AnularOrdenCompra)
 
 	//path: /api/v1/clientes
 	v1Clientes.Get("", limite, s.handlers.Cliente.ObtenerListaClientes)
+	v1Clientes.Get("/cuentas", limite, s.handlers.Cliente.ObtenerListaCuentasCliente)
+	v1Clientes.Patch("/cuentas/verificar/:usuarioUid", limite, s.handlers.Cliente.VerificarCuentaCliente)
 	v1Clientes.Get("/:clienteId", limite, s.handlers.Cliente.ObtenerClienteById)
 	v1Clientes.Post("", limite, s.handlers.Cliente.RegistrarCliente)
 	v1Clientes.Put("/:clienteId", limite, s.handlers.Cliente.ModificarClienteById)
@@ -175,39 +223,157 @@ func (s *Server) endPointsAPI(api fiber.Router) {
 
 	//path: /api/v1/ventas
 	v1Ventas.Use(middleware.VerifyUserAdminMiddleware, limite)
-	v1Ventas.Get("", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN", "FARMACEUTICO"), s.handlers.Venta.ObtenerListaVentas)
-	v1Ventas.Get("/:ventaId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "FARMACEUTICO"), s.handlers.Venta.ObtenerVentaById)
-	v1Ventas.Post("/registrar", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "FARMACEUTICO"), s.handlers.Venta.RegistrarVenta)
-	v1Ventas.Patch("/anular/:ventaId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "FARMACEUTICO"), s.handlers.Venta.AnularVentaById)
-	//v1Ventas.Post("/facturar/:ventaId", middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "FARMACEUTICO"), s.handlers.Venta.FacturarVentaById)
+	v1Ventas.Get("", middleware.VerifyPermisosMiddleware(domain.PermisoVentasVer), s.handlers.Venta.ObtenerListaVentas)
+	v1Ventas.Post("/cotizar", middleware.VerifyPermisosMiddleware(domain.PermisoVentasCotizar), s.handlers.Venta.CotizarVenta)
+	v1Ventas.Get("/cotizaciones", middleware.VerifyPermisosMiddleware(domain.PermisoVentasCotizar), s.handlers.Venta.ObtenerListaCotizaciones)
+	v1Ventas.Get("/cotizaciones/:cotizacionId", middleware.VerifyPermisosMiddleware(domain.PermisoVentasCotizar), s.handlers.Venta.ObtenerCotizacionById)
+	v1Ventas.Post("/cotizaciones/:cotizacionId/convertir", middleware.VerifyPermisosMiddleware(domain.PermisoVentasCotizar), s.handlers.Venta.ConvertirCotizacion)
+	v1Ventas.Get("/:ventaId", middleware.VerifyPermisosMiddleware(domain.PermisoVentasVer), s.handlers.Venta.ObtenerVentaById)
+	v1Ventas.Post("/registrar", middleware.VerifyPermisosMiddleware(domain.PermisoVentasRegistrar), s.handlers.Venta.RegistrarVenta)
+	v1Ventas.Post("/espera", middleware.VerifyPermisosMiddleware(domain.PermisoVentasRegistrar), s.handlers.Venta.RegistrarVentaEnEspera)
+	v1Ventas.Put("/espera/:ventaId", middleware.VerifyPermisosMiddleware(domain.PermisoVentasRegistrar), s.handlers.Venta.ModificarVentaEnEspera)
+	v1Ventas.Patch("/espera/finalizar/:ventaId", middleware.VerifyPermisosMiddleware(domain.PermisoVentasRegistrar), s.handlers.Venta.FinalizarVentaEnEspera)
+	v1Ventas.Patch("/anular/:ventaId", middleware.VerifyPermisosMiddleware(domain.PermisoVentasAnular), s.handlers.Venta.AnularVentaById)
+	//v1Ventas.Post("/facturar/:ventaId", middleware.VerifyPermisosMiddleware(domain.PermisoVentasRegistrar), s.handlers.Venta.FacturarVentaById)
+
+	//path: /api/v1/promociones
+	v1Promociones := v1.Group("/promociones")
+	v1Promociones.Use(middleware.VerifyUserAdminMiddleware, limite)
+	v1Promociones.Get("", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesVer), s.handlers.Promocion.ObtenerListaPromociones)
+	v1Promociones.Get("/efectividad", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesGestionar), s.handlers.Promocion.ObtenerEfectividadPromociones)
+	v1Promociones.Get("/:promocionId", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesVer), s.handlers.Promocion.ObtenerPromocionById)
+	v1Promociones.Post("", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesGestionar), s.handlers.Promocion.RegistrarPromocion)
+	v1Promociones.Put("/:promocionId", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesGestionar), s.handlers.Promocion.ModificarPromocion)
+	v1Promociones.Patch("/estado/habilitar/:promocionId", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesGestionar), s.handlers.Promocion.HabilitarPromocion)
+	v1Promociones.Patch("/estado/deshabilitar/:promocionId", middleware.VerifyPermisosMiddleware(domain.PermisoPromocionesGestionar), s.handlers.Promocion.DeshabilitarPromocion)
+
+	//path: /api/v1/pedidos
+	v1Pedidos := v1.Group("/pedidos")
+	v1Pedidos.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoPedidosGestionar))
+	v1Pedidos.Get("", s.handlers.Pedido.ObtenerListaPedidos)
+	v1Pedidos.Get("/:pedidoId", s.handlers.Pedido.ObtenerPedidoById)
+	v1Pedidos.Patch("/estado/:pedidoId", s.handlers.Pedido.ActualizarEstadoPedido)
+	v1Pedidos.Patch("/entregar/:pedidoId", s.handlers.Pedido.EntregarPedido)
+
+	//path: /api/v1/recetas
+	v1Recetas := v1.Group("/recetas")
+	v1Recetas.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoRecetasGestionar))
+	v1Recetas.Get("", s.handlers.Receta.ObtenerListaRecetas)
+	v1Recetas.Get("/registro-controlados", s.handlers.Receta.ObtenerRegistroControlados)
+	v1Recetas.Get("/:recetaId", s.handlers.Receta.ObtenerRecetaById)
+	v1Recetas.Get("/:recetaId/imagen", s.handlers.Receta.ObtenerImagenReceta)
+	v1Recetas.Post("", s.handlers.Receta.RegistrarReceta)
+
+	//path: /api/v1/interacciones
+	v1Interacciones := v1.Group("/interacciones")
+	v1Interacciones.Use(middleware.VerifyUserAdminMiddleware, limite)
+	v1Interacciones.Get("", middleware.VerifyPermisosMiddleware(domain.PermisoInteraccionesVer), s.handlers.Interaccion.ObtenerListaInteracciones)
+	v1Interacciones.Get("/:interaccionId", middleware.VerifyPermisosMiddleware(domain.PermisoInteraccionesVer), s.handlers.Interaccion.ObtenerInteraccionById)
+	v1Interacciones.Post("", middleware.VerifyPermisosMiddleware(domain.PermisoInteraccionesGestionar), s.handlers.Interaccion.RegistrarInteraccion)
+	v1Interacciones.Put("/:interaccionId", middleware.VerifyPermisosMiddleware(domain.PermisoInteraccionesGestionar), s.handlers.Interaccion.ModificarInteraccion)
+	v1Interacciones.Delete("/:interaccionId", middleware.VerifyPermisosMiddleware(domain.PermisoInteraccionesGestionar), s.handlers.Interaccion.EliminarInteraccion)
+
+	//path: /api/v1/retiros-lotes
+	v1RetirosLotes := v1.Group("/retiros-lotes")
+	v1RetirosLotes.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoRetirosGestionar))
+	v1RetirosLotes.Get("", s.handlers.RetiroLote.ObtenerListaRetiros)
+	v1RetirosLotes.Get("/:retiroId", s.handlers.RetiroLote.ObtenerRetiroById)
+	v1RetirosLotes.Post("", s.handlers.RetiroLote.RegistrarRetiro)
+
+	//path: /api/v1/ubicaciones
+	v1Ubicaciones := v1.Group("/ubicaciones")
+	v1Ubicaciones.Use(middleware.VerifyUserAdminMiddleware, limite)
+	v1Ubicaciones.Get("", middleware.VerifyPermisosMiddleware(domain.PermisoUbicacionesVer), s.handlers.Ubicacion.ObtenerListaUbicaciones)
+	v1Ubicaciones.Get("/:ubicacionId", middleware.VerifyPermisosMiddleware(domain.PermisoUbicacionesVer), s.handlers.Ubicacion.ObtenerUbicacionById)
+	v1Ubicaciones.Get("/:ubicacionId/stock", middleware.VerifyPermisosMiddleware(domain.PermisoUbicacionesVer), s.handlers.Ubicacion.ObtenerStockUbicacion)
+	v1Ubicaciones.Post("", middleware.VerifyPermisosMiddleware(domain.PermisoUbicacionesGestionar), s.handlers.Ubicacion.RegistrarUbicacion)
+	v1Ubicaciones.Put("/:ubicacionId", middleware.VerifyPermisosMiddleware(domain.PermisoUbicacionesGestionar), s.handlers.Ubicacion.ModificarUbicacion)
+	v1Ubicaciones.Delete("/:ubicacionId", middleware.VerifyPermisosMiddleware(domain.PermisoUbicacionesGestionar), s.handlers.Ubicacion.EliminarUbicacion)
+
+	//path: /api/v1/traslados
+	v1Traslados := v1.Group("/traslados")
+	v1Traslados.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoTrasladosGestionar))
+	v1Traslados.Get("", s.handlers.Ubicacion.ObtenerListaTraslados)
+	v1Traslados.Get("/:trasladoId", s.handlers.Ubicacion.ObtenerTrasladoById)
+	v1Traslados.Post("", s.handlers.Ubicacion.RegistrarTraslado)
+
+	//path: /api/v1/auditoria
+	v1Auditoria := v1.Group("/auditoria")
+	v1Auditoria.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoAuditoriaVer))
+	v1Auditoria.Get("", s.handlers.Auditoria.ObtenerListaAuditoria)
+	v1Auditoria.Get("/:auditoriaId", s.handlers.Auditoria.ObtenerAuditoriaById)
+
+	// path: /api/v1/accesos
+	v1Accesos := v1.Group("/accesos")
+	v1Accesos.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoAccesosVer))
+	v1Accesos.Get("", s.handlers.Acceso.ObtenerListaIntentosLogin)
+	v1Accesos.Get("/sospechosos", s.handlers.Acceso.ObtenerActividadSospechosa)
+
+	// path: /api/v1/cuentas-servicio
+	v1CuentasServicio := v1.Group("/cuentas-servicio")
+	v1CuentasServicio.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoCuentasServicioGestionar))
+	v1CuentasServicio.Get("", s.handlers.CuentaServicio.ListarCuentasServicio)
+	v1CuentasServicio.Get("/:cuentaId", s.handlers.CuentaServicio.ObtenerCuentaServicioById)
+	v1CuentasServicio.Post("", s.handlers.CuentaServicio.RegistrarCuentaServicio)
+	v1CuentasServicio.Put("/:cuentaId", s.handlers.CuentaServicio.ModificarCuentaServicio)
+	v1CuentasServicio.Patch("/estado/habilitar/:cuentaId", s.handlers.CuentaServicio.HabilitarCuentaServicio)
+	v1CuentasServicio.Patch("/estado/deshabilitar/:cuentaId", s.handlers.CuentaServicio.DeshabilitarCuentaServicio)
+	v1CuentasServicio.Post("/:cuentaId/api-keys", s.handlers.CuentaServicio.GenerarApiKey)
+	v1CuentasServicio.Patch("/:cuentaId/api-keys/revocar/:apiKeyId", s.handlers.CuentaServicio.RevocarApiKey)
+
+	//path: /api/v1/precios-programados
+	v1PreciosProgramados := v1.Group("/precios-programados")
+	v1PreciosProgramados.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoPreciosGestionar))
+	v1PreciosProgramados.Get("", s.handlers.Precio.ObtenerListaPreciosProgramados)
+	v1PreciosProgramados.Post("", s.handlers.Precio.ProgramarPrecio)
+	v1PreciosProgramados.Patch("/aprobar/:precioProgramadoId", s.handlers.Precio.AprobarPrecioProgramado)
+	v1PreciosProgramados.Patch("/cancelar/:precioProgramadoId", s.handlers.Precio.CancelarPrecioProgramado)
+
+	//path: /api/v1/precios-masivos
+	v1PreciosMasivos := v1.Group("/precios-masivos")
+	v1PreciosMasivos.Use(middleware.VerifyUserAdminMiddleware, limite, middleware.VerifyPermisosMiddleware(domain.PermisoPreciosGestionar))
+	v1PreciosMasivos.Get("", s.handlers.Precio.ObtenerListaCambiosMasivos)
+	v1PreciosMasivos.Post("", s.handlers.Precio.AplicarCambioMasivo)
+	v1PreciosMasivos.Post("/previsualizar", s.handlers.Precio.PrevisualizarCambioMasivo)
+	v1PreciosMasivos.Get("/:cambioMasivoId", s.handlers.Precio.ObtenerCambioMasivoById)
+	v1PreciosMasivos.Patch("/revertir/:cambioMasivoId", s.handlers.Precio.RevertirCambioMasivo)
 
 	//path: /api/v1/movimientos
+	v1Movimientos.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoReportesKardex))
 	v1Movimientos.Get("", limite, s.handlers.Movimiento.ObtenerListaMovimientos)
 	v1Movimientos.Get("/kardex", limite, s.handlers.Movimiento.ObtenerMovimientosKardex)
 
 	//path: /api/stats
 	v1Stats := v1.Group("/stats")
-	v1Stats.Use(middleware.HostnameMiddleware, middleware.VerifyUserAdminMiddleware, limite)
+	v1Stats.Use(middleware.HostnameMiddleware, middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoEstadisticasVer), limite)
 	v1Stats.Get("/top10Productos", s.handlers.Stat.ObtenerTopProductosVendidos)
 	v1Stats.Get("/dashboard", s.handlers.Stat.ObtenerEstadisticasDashboard)
 
 	v1Backups := v1.Group("/backups")
-	v1Backups.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE"), limite)
+	v1Backups.Use(middleware.VerifyUserAdminMiddleware, middleware.VerifyPermisosMiddleware(domain.PermisoBackupsGestionar), limite)
 	v1Backups.Get("", s.handlers.Backup.ListarBackups)
 	v1Backups.Get("/generate", s.handlers.Backup.DownloadBackup)
 	v1Backups.Get("/download/:filename", s.handlers.Backup.DownloadBackupFile)
 
 	//path: /api/v1/reportes
-	v1Reportes.Use(middleware.HostnameMiddleware, middleware.VerifyUserAdminMiddleware, middleware.VerifyRolesMiddleware("ADMIN", "GERENTE", "AUXILIAR DE ALMACEN", "FARMACEUTICO"))
-	v1Reportes.Get("/usuarios", s.handlers.Reporte.ReporteUsuariosPDF)
-	v1Reportes.Get("/clientes", s.handlers.Reporte.ReporteClientesPDF)
-	v1Reportes.Get("/lotes-productos", s.handlers.Reporte.ReporteLotesProductosPDF)
-	v1Reportes.Get("/compras", s.handlers.Reporte.ReporteComprasPDF)
-	v1Reportes.Get("/compras/:compraId", s.handlers.Reporte.ReporteComprasDetallePDF)
-	v1Reportes.Get("/ventas", s.handlers.Reporte.ReporteVentasPDF)
-	v1Reportes.Get("/inventario", s.handlers.Reporte.ReporteInventarioPDF)
-	v1Reportes.Get("/movimientos", s.handlers.Reporte.ReporteMovimientosPDF)
-	v1Reportes.Get("/kardex/:productoId", s.handlers.Reporte.ReporteKardexProductoPDF)
+	v1Reportes.Use(middleware.HostnameMiddleware, middleware.VerifyUserAdminMiddleware)
+	v1Reportes.Get("/usuarios", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteUsuariosPDF)
+	v1Reportes.Get("/clientes", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteClientesPDF)
+	v1Reportes.Get("/lotes-productos", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteLotesProductosPDF)
+	v1Reportes.Get("/compras", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteComprasPDF)
+	v1Reportes.Get("/compras/:compraId", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteComprasDetallePDF)
+	v1Reportes.Get("/ventas", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteVentasPDF)
+	v1Reportes.Get("/inventario", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteInventarioPDF)
+	v1Reportes.Get("/movimientos", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteMovimientosPDF)
+	v1Reportes.Get("/kardex/:productoId", middleware.VerifyPermisosMiddleware(domain.PermisoReportesKardex), s.handlers.Reporte.ReporteKardexProductoPDF)
+	v1Reportes.Get("/promociones", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReportePromocionesPDF)
+	v1Reportes.Get("/controlados", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteControladosPDF)
+	v1Reportes.Post("/etiquetas", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteEtiquetasPDF)
+	v1Reportes.Get("/etiquetas/compras/:compraId", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteEtiquetasCompraPDF)
+	v1Reportes.Get("/retiros-lotes/:retiroId", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReporteRetiroLotePDF)
+	v1Reportes.Get("/auditoria", middleware.VerifyPermisosMiddleware(domain.PermisoAuditoriaVer), s.handlers.Reporte.ReporteAuditoriaPDF)
+	v1Reportes.Get("/picking/ventas/:ventaId", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReportePickingVentaPDF)
+	v1Reportes.Get("/picking/traslados/:trasladoId", middleware.VerifyPermisosMiddleware(domain.PermisoReportesVer), s.handlers.Reporte.ReportePickingTrasladoPDF)
 }
 
 func (s *Server) endPointsShared(api fiber.Router) {
@@ -217,6 +383,7 @@ func (s *Server) endPointsShared(api fiber.Router) {
 	v1Productos.Get("", limite, s.handlers.Producto.ObtenerListaProductosShared)
 	v1Productos.Get("/formas-farmaceuticas", limite, s.handlers.Producto.ListarFormasFarmaceuticas)
 	v1Productos.Get("/:productoId", limite, s.handlers.Producto.ObtenerProductoByIdShared)
+	v1Productos.Get("/:productoId/equivalentes", limite, s.handlers.Producto.ObtenerEquivalentesProducto)
 
 	v1Categorias := apiShared.Group("/categorias")
 	v1Categorias.Get("", limite, s.handlers.Categoria.ListarCategoriasDisponibles)
@@ -233,4 +400,20 @@ func (s *Server) endPointsShared(api fiber.Router) {
 	v1MisCompras.Use(middleware.VerifyUsuarioShared)
 	v1MisCompras.Get("", limite, s.handlers.Venta.ObtenerListaVentasShared)
 	v1MisCompras.Get("/:ventaId", limite, s.handlers.Venta.ObtenerVentaByIdShared)
+
+	v1Perfil := apiShared.Group("/perfil")
+	v1Perfil.Use(middleware.VerifyUsuarioShared)
+	v1Perfil.Get("", limite, s.handlers.Cliente.ObtenerPerfil)
+	v1Perfil.Put("", limite, s.handlers.Cliente.GuardarPerfil)
+	v1Perfil.Post("/verificar", limite, s.handlers.Cliente.VerificarPerfil)
+
+	v1Pedidos := apiShared.Group("/pedidos")
+	v1Pedidos.Use(middleware.VerifyUsuarioShared)
+	v1Pedidos.Get("/carrito", limite, s.handlers.Pedido.ObtenerCarrito)
+	v1Pedidos.Put("/carrito", limite, s.handlers.Pedido.GuardarItemCarrito)
+	v1Pedidos.Delete("/carrito", limite, s.handlers.Pedido.VaciarCarrito)
+	v1Pedidos.Get("", limite, s.handlers.Pedido.ObtenerMisPedidos)
+	v1Pedidos.Post("", limite, s.handlers.Pedido.RegistrarPedido)
+	v1Pedidos.Get("/:pedidoId", limite, s.handlers.Pedido.ObtenerMiPedidoById)
+	v1Pedidos.Patch("/cancelar/:pedidoId", limite, s.handlers.Pedido.CancelarMiPedido)
 }
@@ -27,6 +27,19 @@ type Repository struct {
 	Movimiento      port.MovimientoRepository
 	Presentacion    port.PresentacionRepository
 	Stat            port.StatRepository
+	Promocion       port.PromocionRepository
+	Pedido          port.PedidoRepository
+	Receta          port.RecetaRepository
+	Interaccion     port.InteraccionRepository
+	RetiroLote      port.RetiroLoteRepository
+	Ubicacion       port.UbicacionRepository
+	Auditoria       port.AuditoriaRepository
+	Precio          port.PrecioRepository
+	Importacion     port.ImportacionRepository
+	Sesion          port.SesionRepository
+	Acceso          port.AccesoRepository
+	DobleFactor     port.DobleFactorRepository
+	CuentaServicio  port.CuentaServicioRepository
 }
 
 type Service struct {
@@ -47,6 +60,19 @@ type Service struct {
 	Presentacion    port.PresentacionService
 	Stat            port.StatService
 	Backup          port.BackupService
+	Promocion       port.PromocionService
+	Pedido          port.PedidoService
+	Receta          port.RecetaService
+	Interaccion     port.InteraccionService
+	RetiroLote      port.RetiroLoteService
+	Ubicacion       port.UbicacionService
+	Auditoria       port.AuditoriaService
+	Precio          port.PrecioService
+	Importacion     port.ImportacionService
+	Sesion          port.SesionService
+	Acceso          port.AccesoService
+	DobleFactor     port.DobleFactorService
+	CuentaServicio  port.CuentaServicioService
 }
 
 type Handler struct {
@@ -67,6 +93,19 @@ type Handler struct {
 	Presentacion    port.PresentacionHandler
 	Stat            port.StatHandler
 	Backup          port.BackupHandler
+	Promocion       port.PromocionHandler
+	Pedido          port.PedidoHandler
+	Receta          port.RecetaHandler
+	Interaccion     port.InteraccionHandler
+	RetiroLote      port.RetiroLoteHandler
+	Ubicacion       port.UbicacionHandler
+	Auditoria       port.AuditoriaHandler
+	Precio          port.PrecioHandler
+	Importacion     port.ImportacionHandler
+	Sesion          port.SesionHandler
+	Acceso          port.AccesoHandler
+	DobleFactor     port.DobleFactorHandler
+	CuentaServicio  port.CuentaServicioHandler
 }
 
 type Dependencies struct {
@@ -130,8 +169,21 @@ func Init() {
 		repositories.Movimiento = repository.NewMovimientoRepository(pool)
 		repositories.Presentacion = repository.NewPresentacionRepository(pool)
 		repositories.Stat = repository.NewStatRepository(pool)
+		repositories.Promocion = repository.NewPromocionRepository(pool)
+		repositories.Pedido = repository.NewPedidoRepository(pool)
+		repositories.Receta = repository.NewRecetaRepository(pool)
+		repositories.Interaccion = repository.NewInteraccionRepository(pool)
+		repositories.RetiroLote = repository.NewRetiroLoteRepository(pool)
+		repositories.Ubicacion = repository.NewUbicacionRepository(pool)
+		repositories.Auditoria = repository.NewAuditoriaRepository(pool)
+		repositories.Precio = repository.NewPrecioRepository(pool)
+		repositories.Importacion = repository.NewImportacionRepository(pool)
+		repositories.Sesion = repository.NewSesionRepository(pool)
+		repositories.Acceso = repository.NewAccesoRepository(pool)
+		repositories.DobleFactor = repository.NewDobleFactorRepository(pool)
+		repositories.CuentaServicio = repository.NewCuentaServicioRepository(pool)
 		// Services
-		services.Auth = service.NewAuthService(repositories.Usuario)
+		services.Auth = service.NewAuthService(repositories.Usuario, repositories.Cliente, repositories.Sesion, repositories.Acceso, repositories.DobleFactor)
 		services.Usuario = service.NewUsuarioService(repositories.Usuario)
 		services.Rol = service.NewRolService(repositories.Rol)
 		services.Categoria = service.NewCategoriaService(repositories.Categoria)
@@ -142,12 +194,25 @@ func Init() {
 		services.PrincipioActivo = service.NewPrincipioActivoService(repositories.PrincipioActivo)
 		services.Compra = service.NewCompraService(repositories.Compra)
 		services.Cliente = service.NewClienteService(repositories.Cliente)
-		services.Venta = service.NewVentaService(repositories.Venta)
+		services.Venta = service.NewVentaService(repositories.Venta, repositories.Promocion, repositories.Interaccion)
 		services.Movimiento = service.NewMovimientoService(repositories.Movimiento)
-		services.Reporte = service.NewReporteService(repositories.Usuario, repositories.Cliente, repositories.LoteProducto, repositories.Producto, repositories.Compra, repositories.Venta, repositories.Movimiento)
+		services.Reporte = service.NewReporteService(repositories.Usuario, repositories.Cliente, repositories.LoteProducto, repositories.Producto, repositories.Compra, repositories.Venta, repositories.Movimiento, repositories.Promocion, repositories.Receta, repositories.RetiroLote, repositories.Ubicacion, repositories.Auditoria)
 		services.Presentacion = service.NewPresentacionService(repositories.Presentacion)
 		services.Stat = service.NewStatService(repositories.Stat)
 		services.Backup = service.NewBackupService()
+		services.Promocion = service.NewPromocionService(repositories.Promocion)
+		services.Pedido = service.NewPedidoService(repositories.Pedido, repositories.Venta, repositories.Interaccion)
+		services.Receta = service.NewRecetaService(repositories.Receta)
+		services.Interaccion = service.NewInteraccionService(repositories.Interaccion)
+		services.RetiroLote = service.NewRetiroLoteService(repositories.RetiroLote)
+		services.Ubicacion = service.NewUbicacionService(repositories.Ubicacion)
+		services.Auditoria = service.NewAuditoriaService(repositories.Auditoria)
+		services.Precio = service.NewPrecioService(repositories.Precio)
+		services.Importacion = service.NewImportacionService(repositories.Importacion)
+		services.Sesion = service.NewSesionService(repositories.Sesion)
+		services.Acceso = service.NewAccesoService(repositories.Acceso)
+		services.DobleFactor = service.NewDobleFactorService(repositories.DobleFactor)
+		services.CuentaServicio = service.NewCuentaServicioService(repositories.CuentaServicio)
 		// Handlers
 		handlers.Auth = handler.NewAuthHandler(services.Auth)
 		handlers.Usuario = handler.NewUsuarioHandler(services.Usuario)
@@ -166,6 +231,19 @@ func Init() {
 		handlers.Presentacion = handler.NewPresentacionHandler(services.Presentacion)
 		handlers.Stat = handler.NewStatHandler(services.Stat)
 		handlers.Backup = handler.NewBackupHandler(services.Backup)
+		handlers.Promocion = handler.NewPromocionHandler(services.Promocion)
+		handlers.Pedido = handler.NewPedidoHandler(services.Pedido)
+		handlers.Receta = handler.NewRecetaHandler(services.Receta)
+		handlers.Interaccion = handler.NewInteraccionHandler(services.Interaccion)
+		handlers.RetiroLote = handler.NewRetiroLoteHandler(services.RetiroLote)
+		handlers.Ubicacion = handler.NewUbicacionHandler(services.Ubicacion)
+		handlers.Auditoria = handler.NewAuditoriaHandler(services.Auditoria)
+		handlers.Precio = handler.NewPrecioHandler(services.Precio)
+		handlers.Importacion = handler.NewImportacionHandler(services.Importacion)
+		handlers.Sesion = handler.NewSesionHandler(services.Sesion)
+		handlers.Acceso = handler.NewAccesoHandler(services.Acceso)
+		handlers.DobleFactor = handler.NewDobleFactorHandler(services.DobleFactor)
+		handlers.CuentaServicio = handler.NewCuentaServicioHandler(services.CuentaServicio)
 
 		instance = d
 	})
@@ -6,14 +6,24 @@ BEGIN;
 
 -- 1.1 Borrar Triggers PRIMERO (antes que las funciones que usan)
 DROP TRIGGER IF EXISTS trigger_fecha_vencimiento_lote ON lote_producto;
+DROP TRIGGER IF EXISTS trigger_cambio_permisos_usuario ON usuario;
+DROP TRIGGER IF EXISTS trigger_cambio_permisos_usuario_rol ON usuario_rol;
+DROP TRIGGER IF EXISTS trigger_cambio_permisos_rol ON rol;
+DROP TRIGGER IF EXISTS trigger_cambio_permisos_rol_permiso ON rol_permiso;
+DROP TRIGGER IF EXISTS trigger_sesion_revocada ON sesion;
+DROP TRIGGER IF EXISTS trigger_api_key_revocada ON api_key;
 
 -- 1.2 Ahora sí podemos borrar las Funciones
 DROP FUNCTION IF EXISTS validar_fecha_vencimiento_lote();
+DROP FUNCTION IF EXISTS notificar_cambio_permisos();
 DROP FUNCTION IF EXISTS obtener_usuario_detalle_by_id(INT);
 DROP FUNCTION IF EXISTS obtener_usuario_detalle_by_username(VARCHAR);
 DROP FUNCTION IF EXISTS listar_productos_info(TEXT);
 DROP FUNCTION IF EXISTS obtener_producto_detalle_by_id(UUID, TEXT);
 DROP FUNCTION IF EXISTS obtener_lote_by_id(INT);
+DROP FUNCTION IF EXISTS nivel_control_producto(UUID);
+DROP FUNCTION IF EXISTS ruta_ubicacion(INT);
+DROP FUNCTION IF EXISTS ubicaciones_descendientes(INT);
 
 -- 1.3 Borrar Vistas (Usamos CASCADE por si unas dependen de otras)
 DROP VIEW IF EXISTS view_movimiento_info CASCADE;
@@ -150,7 +160,12 @@ CREATE OR REPLACE FUNCTION listar_productos_info(url TEXT)
                       url_foto              TEXT,
                       deleted_at            TIMESTAMPTZ,
                       presentacion          JSONB,
-                      unidades_presentacion INT
+                      unidades_presentacion INT,
+                      nivel_control         TEXT,
+                      nivel_control_efectivo TEXT,
+                      precio_venta_unidad   NUMERIC,
+                      refrigerado           BOOLEAN,
+                      controlado            BOOLEAN
                   )
 AS $$
 BEGIN
@@ -177,7 +192,12 @@ BEGIN
                     ),
                     '{}'
             ) AS presentacion,
-            p.unidades_presentacion
+            p.unidades_presentacion,
+            p.nivel_control::TEXT,
+            nivel_control_producto(p.id)::TEXT,
+            p.precio_venta_unidad,
+            p.refrigerado,
+            p.controlado
         FROM producto p
                  LEFT JOIN presentacion p2 on p.presentacion_id = p2.id
                  LEFT JOIN laboratorio l ON l.id = p.laboratorio_id
@@ -186,6 +206,54 @@ END;
 $$ LANGUAGE plpgsql;
 
 
+-- Función: nivel_control_producto
+-- Nivel de control efectivo: el mayor entre el del producto y el de sus principios activos
+CREATE OR REPLACE FUNCTION nivel_control_producto(p_producto_id UUID)
+    RETURNS nivel_control
+AS $$
+SELECT GREATEST(
+               p.nivel_control,
+               COALESCE((SELECT MAX(pa.nivel_control)
+                         FROM producto_principio_activo ppa
+                                  JOIN principio_activo pa ON pa.id = ppa.principio_activo_id
+                         WHERE ppa.producto_id = p.id), 'Libre')
+       )
+FROM producto p
+WHERE p.id = p_producto_id;
+$$ LANGUAGE sql STABLE;
+
+-- Función: ruta_ubicacion
+-- Ruta legible de una ubicación desde su almacén, p. ej. "Almacén central / Estante A / Nivel 1"
+CREATE OR REPLACE FUNCTION ruta_ubicacion(p_ubicacion_id INT)
+    RETURNS TEXT
+AS $$
+WITH RECURSIVE ruta AS (SELECT u.id, u.padre_id, u.nombre, 0 AS profundidad
+                        FROM ubicacion u
+                        WHERE u.id = p_ubicacion_id
+                        UNION ALL
+                        SELECT u.id, u.padre_id, u.nombre, r.profundidad + 1
+                        FROM ubicacion u
+                                 INNER JOIN ruta r ON r.padre_id = u.id)
+SELECT string_agg(nombre, ' / ' ORDER BY profundidad DESC)
+FROM ruta;
+$$ LANGUAGE sql STABLE;
+
+-- Función: ubicaciones_descendientes
+-- Ids de la ubicación y de todas las que contiene
+CREATE OR REPLACE FUNCTION ubicaciones_descendientes(p_ubicacion_id INT)
+    RETURNS SETOF INT
+AS $$
+WITH RECURSIVE arbol AS (SELECT u.id
+                         FROM ubicacion u
+                         WHERE u.id = p_ubicacion_id
+                         UNION ALL
+                         SELECT u.id
+                         FROM ubicacion u
+                                  INNER JOIN arbol a ON u.padre_id = a.id)
+SELECT id
+FROM arbol;
+$$ LANGUAGE sql STABLE;
+
 -- Función: obtener_producto_detalle_by_id
 CREATE OR REPLACE FUNCTION obtener_producto_detalle_by_id(p_producto_id UUID, url TEXT)
     RETURNS TABLE (
@@ -204,7 +272,12 @@ CREATE OR REPLACE FUNCTION obtener_producto_detalle_by_id(p_producto_id UUID, ur
                       categorias JSONB,
                       principio_activos     JSONB,
                       presentacion          JSONB,
-                      unidades_presentacion INT
+                      unidades_presentacion INT,
+                      nivel_control         TEXT,
+                      nivel_control_efectivo TEXT,
+                      precio_venta_unidad   NUMERIC,
+                      refrigerado           BOOLEAN,
+                      controlado            BOOLEAN
                   )
 AS $$
 BEGIN
@@ -266,7 +339,12 @@ BEGIN
                     ),
                     '{}'
             ) AS presentacion,
-            p.unidades_presentacion
+            p.unidades_presentacion,
+            p.nivel_control::TEXT,
+            nivel_control_producto(p.id)::TEXT,
+            p.precio_venta_unidad,
+            p.refrigerado,
+            p.controlado
         FROM producto p
                  LEFT JOIN laboratorio l ON l.id = p.laboratorio_id
                  LEFT JOIN forma_farmaceutica ff ON ff.id = p.forma_farmaceutica_id
@@ -341,7 +419,8 @@ SELECT
     u.updated_at,
     u.deleted_at
 FROM usuario u
-         LEFT JOIN persona p ON p.id = u.persona_id;
+         LEFT JOIN persona p ON p.id = u.persona_id
+WHERE NOT u.es_servicio;
 
 -- Vista: view_compras
 CREATE OR REPLACE VIEW view_compras AS
@@ -377,11 +456,17 @@ SELECT
             'id', p.id,
             'nombreComercial', p.nombre_comercial,
             'formaFarmaceutica', ff.nombre,
-            'laboratorio', l.nombre
+            'laboratorio', l.nombre,
+            'presentacion', json_build_object(
+                    'id', pr.id,
+                    'nombre', pr.nombre
+                            ),
+            'unidadesPresentacion', p.unidades_presentacion
     ) AS producto,
     lp.producto_id
 FROM lote_producto lp
          LEFT JOIN producto p ON p.id = lp.producto_id
+         LEFT JOIN presentacion pr ON pr.id = p.presentacion_id
          INNER JOIN forma_farmaceutica ff ON ff.id = p.forma_farmaceutica_id
          INNER JOIN laboratorio l ON l.id = p.laboratorio_id
 ORDER BY lp.fecha_vencimiento, p.nombre_comercial;
@@ -401,10 +486,12 @@ SELECT
                     jsonb_agg(DISTINCT jsonb_build_object(
                     'id', dc.id,
                     'cantidad', dc.cantidad,
+                    'factor', dc.factor,
                     'precioCompra', dc.precio_compra,
                     'precioVenta', dc.precio_venta,
                     'loteProductoId', dc.lote_producto_id,
-                    'productoId', lp.producto_id
+                    'productoId', lp.producto_id,
+                    'ubicacionId', dc.ubicacion_id
                                        )) FILTER (WHERE dc.id IS NOT NULL),
                     '[]'::jsonb
     ) AS detalles
@@ -437,6 +524,7 @@ SELECT
                     jsonb_build_object(
                             'id', dc.id,
                             'cantidad', dc.cantidad,
+                            'factor', dc.factor,
                             'precioCompra', dc.precio_compra,
                             'precioVenta', dc.precio_venta,
                             'loteProducto', jsonb_build_object(
@@ -454,7 +542,14 @@ SELECT
                                                             ),
                                             'unidadesPresentacion', p2.unidades_presentacion
                                                 )
-                                            )
+                                            ),
+                            'ubicacion', CASE
+                                             WHEN ub.id IS NOT NULL THEN jsonb_build_object(
+                                                     'id', ub.id,
+                                                     'codigo', ub.codigo,
+                                                     'ruta', ruta_ubicacion(ub.id)
+                                                                         )
+                                END
                     )
                              ) FILTER (WHERE dc.id IS NOT NULL),
                     '[]'
@@ -466,6 +561,7 @@ FROM compra c
          LEFT JOIN producto p2 ON p2.id = lp.producto_id
          LEFT JOIN laboratorio l ON l.id = p2.laboratorio_id
          LEFT JOIN presentacion p3 ON p2.presentacion_id = p3.id
+         LEFT JOIN ubicacion ub ON ub.id = dc.ubicacion_id
 GROUP BY c.id, c.codigo, c.comentario, c.estado, c.total, c.fecha, c.deleted_at, u.id, l.id, l.nombre
 ORDER BY c.id DESC;
 
@@ -493,7 +589,8 @@ SELECT v.id,
        ) AS cliente,
        v.cliente_id,
        v.tipo_pago,
-       v.descuento
+       v.descuento,
+       v.descuento_promocion
 FROM venta v
          INNER JOIN usuario u on v.usuario_id = u.id
          INNER JOIN cliente c on v.cliente_id = c.id;
@@ -505,6 +602,8 @@ SELECT dv.id,
        dv.cantidad,
        dv.precio,
        (dv.cantidad * dv.precio) AS total,
+       dv.unidad_venta           AS "unidadVenta",
+       (dv.cantidad * dv.factor) AS "cantidadUnidades",
 
        jsonb_build_object(
                'id', p.id,
@@ -537,7 +636,7 @@ FROM detalle_venta dv
          INNER JOIN forma_farmaceutica ff ON ff.id = p.forma_farmaceutica_id
          INNER JOIN laboratorio l ON l.id = p.laboratorio_id
 
-GROUP BY dv.id, dv.venta_id, dv.cantidad, dv.precio,
+GROUP BY dv.id, dv.venta_id, dv.cantidad, dv.precio, dv.unidad_venta, dv.factor,
          p.id, p.nombre_comercial, p2.id,
          ff.nombre, l.nombre, lp.id;
 
@@ -578,7 +677,23 @@ SELECT v.id,
        v.usuario,
        'VENTA' AS tipo,
        v.total
-FROM view_venta_info v;
+FROM view_venta_info v
+
+UNION ALL
+
+SELECT ii.id,
+       ii.codigo,
+       'Completado' AS estado,
+       ii.fecha,
+       jsonb_build_object(
+               'id', u.id,
+               'username', u.username,
+               'estado', u.estado
+       ) AS usuario,
+       'INVENTARIO INICIAL' AS tipo,
+       ii.total
+FROM inventario_inicial ii
+         INNER JOIN usuario u ON u.id = ii.usuario_id;
 
 -- =============================================================================
 -- 4. FUNCIONES TRIGGER Y VISTA KARDEX
@@ -596,6 +711,39 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+-- Función: notificar_cambio_permisos
+-- Avisa a las instancias de la API que invaliden los permisos en caché: el id del usuario afectado
+-- o '*' cuando cambia un rol o sus permisos; 'sesion:<id>' cuando se revoca una sesión
+-- y 'api_key:<prefijo>' cuando se revoca una API key
+CREATE OR REPLACE FUNCTION notificar_cambio_permisos()
+    RETURNS trigger AS $$
+DECLARE
+    fila RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        fila := OLD;
+    ELSE
+        fila := NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'usuario' THEN
+        PERFORM pg_notify('cambio_permisos', fila.id::TEXT);
+    ELSIF TG_TABLE_NAME = 'sesion' THEN
+        PERFORM pg_notify('cambio_permisos', 'sesion:' || fila.id::TEXT);
+    ELSIF TG_TABLE_NAME = 'api_key' THEN
+        PERFORM pg_notify('cambio_permisos', 'api_key:' || fila.prefijo);
+    ELSIF TG_TABLE_NAME = 'usuario_rol' THEN
+        PERFORM pg_notify('cambio_permisos', fila.usuario_id::TEXT);
+        IF TG_OP = 'UPDATE' THEN
+            PERFORM pg_notify('cambio_permisos', OLD.usuario_id::TEXT);
+        END IF;
+    ELSE
+        PERFORM pg_notify('cambio_permisos', '*');
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
 CREATE OR REPLACE VIEW view_kardex AS
 SELECT ROW_NUMBER() OVER (ORDER BY sub.fecha_movimiento, sub.id_transaccion) as id_fila,
        sub.*
@@ -612,10 +760,10 @@ FROM (
                 'Compra'                         as concepto,
                 u.username                       as usuario,
 
-                dc.cantidad                      as cantidad_entrada,
-                0                                as cantidad_salida,
-                dc.precio_compra                 as costo_unitario,
-                (dc.cantidad * dc.precio_compra) as total_moneda,
+                dc.cantidad * dc.factor                as cantidad_entrada,
+                0                                      as cantidad_salida,
+                ROUND(dc.precio_compra / dc.factor, 2) as costo_unitario,
+                (dc.cantidad * dc.precio_compra)       as total_moneda,
 
                 c.id                             as id_transaccion
 
@@ -640,10 +788,10 @@ FROM (
                 'Venta'                   as concepto,
                 u.username                as usuario,
 
-                0                         as cantidad_entrada,
-                dv.cantidad               as cantidad_salida,
-                dv.precio                 as costo_unitario,
-                (dv.cantidad * dv.precio) as total_moneda,
+                0                            as cantidad_entrada,
+                dv.cantidad * dv.factor      as cantidad_salida,
+                ROUND(dv.precio / dv.factor, 2) as costo_unitario,
+                (dv.cantidad * dv.precio)    as total_moneda,
 
                 v.id                      as id_transaccion
 
@@ -652,7 +800,34 @@ FROM (
                   JOIN lote_producto l ON dv.lote_id = l.id
                   JOIN producto p ON l.producto_id = p.id
                   JOIN usuario u ON v.usuario_id = u.id
-         WHERE v.estado = 'Realizada') sub;
+         WHERE v.estado = 'Realizada'
+
+         UNION ALL
+
+         -- BLOQUE 3: INVENTARIO INICIAL (ENTRADAS)
+         SELECT p.id                           as producto_id,
+                l.id                           as lote_id,
+                l.lote                         as codigo_lote,
+                l.fecha_vencimiento,
+
+                'ENTRADA'                      as tipo_movimiento,
+                ii.fecha                       as fecha_movimiento,
+                ii.codigo                      as documento,
+                'Inventario inicial'           as concepto,
+                u.username                     as usuario,
+
+                dii.cantidad                   as cantidad_entrada,
+                0                              as cantidad_salida,
+                dii.costo_unitario             as costo_unitario,
+                (dii.cantidad * dii.costo_unitario) as total_moneda,
+
+                ii.id                          as id_transaccion
+
+         FROM detalle_inventario_inicial dii
+                  JOIN inventario_inicial ii ON dii.inventario_inicial_id = ii.id
+                  JOIN lote_producto l ON dii.lote_id = l.id
+                  JOIN producto p ON l.producto_id = p.id
+                  JOIN usuario u ON ii.usuario_id = u.id) sub;
 
 
 
@@ -665,5 +840,34 @@ CREATE TRIGGER trigger_fecha_vencimiento_lote
     BEFORE INSERT OR UPDATE ON lote_producto
     FOR EACH ROW EXECUTE FUNCTION validar_fecha_vencimiento_lote();
 
+-- Crear triggers para invalidar la caché de permisos
+CREATE TRIGGER trigger_cambio_permisos_usuario
+    AFTER UPDATE OF estado, deleted_at OR DELETE ON usuario
+    FOR EACH ROW EXECUTE FUNCTION notificar_cambio_permisos();
+
+CREATE TRIGGER trigger_cambio_permisos_usuario_rol
+    AFTER INSERT OR UPDATE OR DELETE ON usuario_rol
+    FOR EACH ROW EXECUTE FUNCTION notificar_cambio_permisos();
+
+CREATE TRIGGER trigger_cambio_permisos_rol
+    AFTER UPDATE OR DELETE ON rol
+    FOR EACH ROW EXECUTE FUNCTION notificar_cambio_permisos();
+
+CREATE TRIGGER trigger_cambio_permisos_rol_permiso
+    AFTER INSERT OR UPDATE OR DELETE ON rol_permiso
+    FOR EACH ROW EXECUTE FUNCTION notificar_cambio_permisos();
+
+CREATE TRIGGER trigger_sesion_revocada
+    AFTER UPDATE OF revocada_at ON sesion
+    FOR EACH ROW
+    WHEN (OLD.revocada_at IS NULL AND NEW.revocada_at IS NOT NULL)
+    EXECUTE FUNCTION notificar_cambio_permisos();
+
+CREATE TRIGGER trigger_api_key_revocada
+    AFTER UPDATE OF revocada_at ON api_key
+    FOR EACH ROW
+    WHEN (OLD.revocada_at IS NULL AND NEW.revocada_at IS NOT NULL)
+    EXECUTE FUNCTION notificar_cambio_permisos();
+
 -- Confirmar la transacción
 COMMIT;
\ No newline at end of file
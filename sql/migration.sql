@@ -54,6 +54,9 @@ $$
     END
 $$;
 
+-- Lotes bloqueados por un retiro del mercado
+ALTER TYPE lote_estado ADD VALUE IF NOT EXISTS 'Cuarentena';
+
 
 -- Estado de tipoPago
 DO
@@ -65,6 +68,53 @@ $$
     END
 $$;
 
+-- Tipo de promoción
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'tipo_promocion') THEN
+            CREATE TYPE tipo_promocion AS ENUM ('CompraXLlevaY','PrecioPaquete','DescuentoCategoria');
+        END IF;
+    END
+$$;
+
+-- Estado de cotización
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'estado_cotizacion') THEN
+            CREATE TYPE estado_cotizacion AS ENUM ('Vigente','Convertida');
+        END IF;
+    END
+$$;
+
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'estado_pedido') THEN
+            CREATE TYPE estado_pedido AS ENUM ('Recibido','Preparando','Listo','Entregado','Cancelado');
+        END IF;
+    END
+$$;
+
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'nivel_control') THEN
+            CREATE TYPE nivel_control AS ENUM ('Libre','Receta','Controlado');
+        END IF;
+    END
+$$;
+
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'severidad_interaccion') THEN
+            CREATE TYPE severidad_interaccion AS ENUM ('Leve','Moderada','Grave');
+        END IF;
+    END
+$$;
+
 -- 4. Tablas de usuarios y roles
 
 -- rol
@@ -286,4 +336,632 @@ CREATE TABLE IF NOT EXISTS factura
     url                TEXT       NOT NULL
 );
 
-COMMIT;
\ No newline at end of file
+-- promocion
+CREATE TABLE IF NOT EXISTS promocion
+(
+    id                 SERIAL PRIMARY KEY,
+    nombre             VARCHAR(100)   NOT NULL UNIQUE,
+    descripcion        TEXT,
+    tipo               tipo_promocion NOT NULL,
+    estado             tipo_estado    NOT NULL DEFAULT 'Activo',
+    fecha_inicio       TIMESTAMPTZ    NOT NULL,
+    fecha_fin          TIMESTAMPTZ    NOT NULL,
+    cantidad_requerida INT            NOT NULL DEFAULT 0 CHECK (cantidad_requerida >= 0),
+    cantidad_gratis    INT            NOT NULL DEFAULT 0 CHECK (cantidad_gratis >= 0),
+    precio_paquete     NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (precio_paquete >= 0),
+    porcentaje         NUMERIC(5, 2)  NOT NULL DEFAULT 0 CHECK (porcentaje >= 0 AND porcentaje <= 100),
+    categoria_id       INT REFERENCES categoria (id),
+    created_at         TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at         TIMESTAMPTZ,
+    CHECK (fecha_fin > fecha_inicio)
+);
+
+-- promocion_producto
+CREATE TABLE IF NOT EXISTS promocion_producto
+(
+    promocion_id INT  NOT NULL REFERENCES promocion (id) ON DELETE CASCADE,
+    producto_id  UUID NOT NULL REFERENCES producto (id) ON DELETE CASCADE,
+    cantidad     INT  NOT NULL DEFAULT 1 CHECK (cantidad > 0),
+    PRIMARY KEY (promocion_id, producto_id)
+);
+
+-- venta_promocion (líneas de descuento por promoción)
+CREATE TABLE IF NOT EXISTS venta_promocion
+(
+    id           BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    venta_id     INT            NOT NULL REFERENCES venta (id) ON DELETE CASCADE,
+    promocion_id INT            NOT NULL REFERENCES promocion (id),
+    producto_id  UUID           NOT NULL REFERENCES producto (id),
+    cantidad     INT            NOT NULL CHECK (cantidad > 0),
+    subtotal     NUMERIC(10, 2) NOT NULL CHECK (subtotal >= 0),
+    descuento    NUMERIC(10, 2) NOT NULL CHECK (descuento >= 0)
+);
+
+-- cotizacion
+CREATE TABLE IF NOT EXISTS cotizacion
+(
+    id                  SERIAL PRIMARY KEY,
+    codigo              TEXT UNIQUE,
+    estado              estado_cotizacion NOT NULL DEFAULT 'Vigente',
+    cliente_id          INT REFERENCES cliente (id),
+    usuario_id          INT               NOT NULL REFERENCES usuario (id),
+    fecha               TIMESTAMPTZ       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_expiracion    TIMESTAMPTZ       NOT NULL,
+    subtotal            NUMERIC(10, 2)    NOT NULL DEFAULT 0,
+    descuento_promocion NUMERIC(10, 2)    NOT NULL DEFAULT 0,
+    descuento           NUMERIC(10, 2)    NOT NULL DEFAULT 0,
+    total               NUMERIC(10, 2)    NOT NULL DEFAULT 0,
+    venta_id            INT REFERENCES venta (id)
+);
+
+-- detalle_cotizacion
+CREATE TABLE IF NOT EXISTS detalle_cotizacion
+(
+    id            SERIAL PRIMARY KEY,
+    cotizacion_id INT            NOT NULL REFERENCES cotizacion (id) ON DELETE CASCADE,
+    producto_id   UUID           NOT NULL REFERENCES producto (id),
+    cantidad      INT            NOT NULL CHECK (cantidad > 0),
+    precio        NUMERIC(10, 2) NOT NULL CHECK (precio >= 0),
+    subtotal      NUMERIC(10, 2) NOT NULL CHECK (subtotal >= 0)
+);
+
+-- reserva_lote (reserva de stock de ventas en espera y pedidos en línea)
+CREATE TABLE IF NOT EXISTS reserva_lote
+(
+    id               BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    lote_id          INT         NOT NULL REFERENCES lote_producto (id),
+    cantidad         INT         NOT NULL CHECK (cantidad > 0),
+    venta_id         INT REFERENCES venta (id) ON DELETE CASCADE,
+    fecha_expiracion TIMESTAMPTZ NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reserva_lote_lote ON reserva_lote (lote_id, fecha_expiracion);
+
+-- carrito_item (carrito de compras de usuarios de la tienda en línea)
+CREATE TABLE IF NOT EXISTS carrito_item
+(
+    usuario_uid TEXT        NOT NULL,
+    producto_id UUID        NOT NULL REFERENCES producto (id),
+    cantidad    INT         NOT NULL CHECK (cantidad > 0),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (usuario_uid, producto_id)
+);
+
+-- pedido (pedidos en línea para recoger en farmacia)
+CREATE TABLE IF NOT EXISTS pedido
+(
+    id           SERIAL PRIMARY KEY,
+    codigo       TEXT UNIQUE   NOT NULL,
+    usuario_uid  TEXT          NOT NULL,
+    email        TEXT,
+    telefono     TEXT,
+    comentario   TEXT,
+    estado       estado_pedido NOT NULL DEFAULT 'Recibido',
+    total        NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    fecha_limite TIMESTAMPTZ   NOT NULL,
+    venta_id     INT REFERENCES venta (id),
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMPTZ   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pedido_usuario ON pedido (usuario_uid);
+
+-- detalle_pedido
+CREATE TABLE IF NOT EXISTS detalle_pedido
+(
+    id          SERIAL PRIMARY KEY,
+    pedido_id   INT            NOT NULL REFERENCES pedido (id) ON DELETE CASCADE,
+    producto_id UUID           NOT NULL REFERENCES producto (id),
+    cantidad    INT            NOT NULL CHECK (cantidad > 0),
+    precio      NUMERIC(10, 2) NOT NULL CHECK (precio >= 0)
+);
+
+-- cliente_cuenta (vínculo entre una cuenta de Firebase y un cliente)
+CREATE TABLE IF NOT EXISTS cliente_cuenta
+(
+    usuario_uid   TEXT PRIMARY KEY,
+    email         TEXT,
+    cliente_id    INT REFERENCES cliente (id),
+    verificado    BOOLEAN     NOT NULL DEFAULT FALSE,
+    verificado_at TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cliente_cuenta_cliente ON cliente_cuenta (cliente_id);
+
+-- receta (recetas médicas para productos bajo control)
+CREATE TABLE IF NOT EXISTS receta
+(
+    id               SERIAL PRIMARY KEY,
+    medico_nombre    TEXT        NOT NULL,
+    medico_matricula TEXT        NOT NULL,
+    paciente_nombre  TEXT        NOT NULL,
+    paciente_ci      TEXT,
+    fecha_emision    DATE        NOT NULL,
+    imagen           TEXT,
+    usuario_id       INT         NOT NULL REFERENCES usuario (id),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- detalle_receta (cantidades prescritas y dispensadas por producto)
+CREATE TABLE IF NOT EXISTS detalle_receta
+(
+    id                  SERIAL PRIMARY KEY,
+    receta_id           INT  NOT NULL REFERENCES receta (id) ON DELETE CASCADE,
+    producto_id         UUID NOT NULL REFERENCES producto (id),
+    cantidad_prescrita  INT  NOT NULL CHECK (cantidad_prescrita > 0),
+    cantidad_dispensada INT  NOT NULL DEFAULT 0 CHECK (cantidad_dispensada >= 0),
+    UNIQUE (receta_id, producto_id),
+    CHECK (cantidad_dispensada <= cantidad_prescrita)
+);
+
+ALTER TABLE principio_activo ADD COLUMN IF NOT EXISTS nivel_control nivel_control NOT NULL DEFAULT 'Libre';
+ALTER TABLE producto ADD COLUMN IF NOT EXISTS nivel_control nivel_control NOT NULL DEFAULT 'Libre';
+ALTER TABLE venta ADD COLUMN IF NOT EXISTS receta_id INT REFERENCES receta (id);
+
+-- interaccion_principio_activo (pares de principios activos que interactúan)
+CREATE TABLE IF NOT EXISTS interaccion_principio_activo
+(
+    id                    SERIAL PRIMARY KEY,
+    principio_activo_a_id INT                   NOT NULL REFERENCES principio_activo (id) ON DELETE CASCADE,
+    principio_activo_b_id INT                   NOT NULL REFERENCES principio_activo (id) ON DELETE CASCADE,
+    severidad             severidad_interaccion NOT NULL,
+    descripcion           TEXT                  NOT NULL,
+    created_at            TIMESTAMPTZ           NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (principio_activo_a_id, principio_activo_b_id),
+    CHECK (principio_activo_a_id < principio_activo_b_id)
+);
+
+-- venta_interaccion (autorizaciones del farmacéutico para interacciones graves)
+CREATE TABLE IF NOT EXISTS venta_interaccion
+(
+    venta_id       INT         NOT NULL REFERENCES venta (id) ON DELETE CASCADE,
+    interaccion_id INT         NOT NULL REFERENCES interaccion_principio_activo (id),
+    usuario_id     INT         NOT NULL REFERENCES usuario (id),
+    motivo         TEXT        NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (venta_id, interaccion_id)
+);
+
+ALTER TABLE principio_activo ADD COLUMN IF NOT EXISTS contraindicaciones TEXT;
+
+-- codigo_barra (códigos GTIN/EAN e internos de cada producto)
+CREATE TABLE IF NOT EXISTS codigo_barra
+(
+    id                 SERIAL PRIMARY KEY,
+    producto_id        UUID        NOT NULL REFERENCES producto (id) ON DELETE CASCADE,
+    codigo             VARCHAR(50) NOT NULL,
+    tipo               VARCHAR(10) NOT NULL DEFAULT 'GTIN' CHECK (tipo IN ('GTIN', 'Interno')),
+    codigo_normalizado VARCHAR(50) GENERATED ALWAYS AS (CASE WHEN tipo = 'GTIN' THEN LPAD(codigo, 14, '0') ELSE codigo END) STORED,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (codigo_normalizado)
+);
+
+CREATE INDEX IF NOT EXISTS idx_codigo_barra_producto ON codigo_barra (producto_id);
+
+-- Venta por presentación o por unidad: el stock se lleva en unidades base y unidades_presentacion es el factor de conversión
+DO $$
+    BEGIN
+        IF NOT EXISTS (SELECT 1
+                       FROM information_schema.columns
+                       WHERE table_name = 'producto' AND column_name = 'precio_venta_unidad') THEN
+            ALTER TABLE producto ADD COLUMN precio_venta_unidad NUMERIC(10, 2) CHECK (precio_venta_unidad >= 0);
+            ALTER TABLE detalle_venta ADD COLUMN unidad_venta VARCHAR(12) NOT NULL DEFAULT 'Presentacion' CHECK (unidad_venta IN ('Presentacion', 'Unidad'));
+            ALTER TABLE detalle_venta ADD COLUMN factor INT NOT NULL DEFAULT 1 CHECK (factor > 0);
+            ALTER TABLE detalle_compra ADD COLUMN factor INT NOT NULL DEFAULT 1 CHECK (factor > 0);
+
+            -- Convertir a unidades base las existencias y movimientos registrados por presentación
+            UPDATE producto SET unidades_presentacion = 1 WHERE unidades_presentacion IS NULL OR unidades_presentacion < 1;
+            UPDATE lote_producto lp SET stock = lp.stock * p.unidades_presentacion FROM producto p WHERE p.id = lp.producto_id;
+            UPDATE producto SET stock = stock * unidades_presentacion, stock_min = stock_min * unidades_presentacion;
+            UPDATE reserva_lote r SET cantidad = r.cantidad * p.unidades_presentacion
+            FROM lote_producto lp INNER JOIN producto p ON p.id = lp.producto_id
+            WHERE lp.id = r.lote_id;
+            UPDATE detalle_venta dv SET factor = p.unidades_presentacion
+            FROM lote_producto lp INNER JOIN producto p ON p.id = lp.producto_id
+            WHERE lp.id = dv.lote_id;
+            UPDATE detalle_compra dc SET factor = p.unidades_presentacion
+            FROM lote_producto lp INNER JOIN producto p ON p.id = lp.producto_id
+            WHERE lp.id = dc.lote_producto_id;
+            UPDATE detalle_receta dr SET cantidad_prescrita = dr.cantidad_prescrita * p.unidades_presentacion,
+                                         cantidad_dispensada = dr.cantidad_dispensada * p.unidades_presentacion
+            FROM producto p WHERE p.id = dr.producto_id;
+        END IF;
+    END
+$$;
+
+ALTER TABLE producto ALTER COLUMN unidades_presentacion SET DEFAULT 1;
+ALTER TABLE detalle_cotizacion ADD COLUMN IF NOT EXISTS unidad_venta VARCHAR(12) NOT NULL DEFAULT 'Presentacion' CHECK (unidad_venta IN ('Presentacion', 'Unidad'));
+
+-- cotizacion_promocion (promociones cotizadas; se respetan al convertir la cotización vigente)
+CREATE TABLE IF NOT EXISTS cotizacion_promocion
+(
+    id            BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    cotizacion_id INT            NOT NULL REFERENCES cotizacion (id) ON DELETE CASCADE,
+    promocion_id  INT            NOT NULL REFERENCES promocion (id),
+    producto_id   UUID           NOT NULL REFERENCES producto (id),
+    cantidad      INT            NOT NULL CHECK (cantidad > 0),
+    subtotal      NUMERIC(10, 2) NOT NULL CHECK (subtotal >= 0),
+    descuento     NUMERIC(10, 2) NOT NULL CHECK (descuento >= 0)
+);
+
+-- retiro_lote (retiro del mercado de un lote ordenado por el laboratorio o el regulador)
+CREATE TABLE IF NOT EXISTS retiro_lote
+(
+    id               SERIAL PRIMARY KEY,
+    codigo           TEXT UNIQUE  NOT NULL,
+    lote_id          INT UNIQUE   NOT NULL REFERENCES lote_producto (id),
+    origen           VARCHAR(20)  NOT NULL CHECK (origen IN ('Laboratorio', 'Regulador', 'Interno')),
+    referencia       VARCHAR(100),
+    motivo           TEXT         NOT NULL,
+    stock_cuarentena INT          NOT NULL CHECK (stock_cuarentena >= 0),
+    usuario_id       INT          NOT NULL REFERENCES usuario (id),
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ubicacion (jerarquía almacén -> estante -> nivel donde se guarda el stock)
+CREATE TABLE IF NOT EXISTS ubicacion
+(
+    id          SERIAL PRIMARY KEY,
+    codigo      VARCHAR(30) UNIQUE NOT NULL,
+    nombre      TEXT        NOT NULL,
+    tipo        VARCHAR(10) NOT NULL CHECK (tipo IN ('Almacen', 'Estante', 'Nivel')),
+    padre_id    INT REFERENCES ubicacion (id),
+    refrigerado BOOLEAN     NOT NULL DEFAULT FALSE,
+    controlado  BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at  TIMESTAMPTZ,
+    CHECK ((tipo = 'Almacen') = (padre_id IS NULL))
+);
+
+-- lote_ubicacion (stock de cada lote en unidades base asignado a una ubicación)
+CREATE TABLE IF NOT EXISTS lote_ubicacion
+(
+    lote_id      INT NOT NULL REFERENCES lote_producto (id),
+    ubicacion_id INT NOT NULL REFERENCES ubicacion (id),
+    cantidad     INT NOT NULL CHECK (cantidad >= 0),
+    PRIMARY KEY (lote_id, ubicacion_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lote_ubicacion_ubicacion ON lote_ubicacion (ubicacion_id);
+
+-- detalle_venta_ubicacion (ubicaciones de donde se retiró cada línea de venta)
+CREATE TABLE IF NOT EXISTS detalle_venta_ubicacion
+(
+    detalle_venta_id BIGINT NOT NULL REFERENCES detalle_venta (id) ON DELETE CASCADE,
+    ubicacion_id     INT    NOT NULL REFERENCES ubicacion (id),
+    cantidad         INT    NOT NULL CHECK (cantidad > 0),
+    PRIMARY KEY (detalle_venta_id, ubicacion_id)
+);
+
+-- traslado (movimiento de stock entre ubicaciones)
+CREATE TABLE IF NOT EXISTS traslado
+(
+    id         SERIAL PRIMARY KEY,
+    codigo     TEXT UNIQUE NOT NULL,
+    comentario TEXT,
+    usuario_id INT         NOT NULL REFERENCES usuario (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- detalle_traslado (origen nulo indica stock del lote aún sin ubicación)
+CREATE TABLE IF NOT EXISTS detalle_traslado
+(
+    id          SERIAL PRIMARY KEY,
+    traslado_id INT NOT NULL REFERENCES traslado (id) ON DELETE CASCADE,
+    lote_id     INT NOT NULL REFERENCES lote_producto (id),
+    origen_id   INT REFERENCES ubicacion (id),
+    destino_id  INT NOT NULL REFERENCES ubicacion (id),
+    cantidad    INT NOT NULL CHECK (cantidad > 0),
+    CHECK (origen_id IS DISTINCT FROM destino_id)
+);
+
+ALTER TABLE detalle_compra ADD COLUMN IF NOT EXISTS ubicacion_id INT REFERENCES ubicacion (id);
+ALTER TABLE producto ADD COLUMN IF NOT EXISTS refrigerado BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE producto ADD COLUMN IF NOT EXISTS controlado BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- auditoria (bitácora de cambios de las entidades con su estado antes y después)
+CREATE TABLE IF NOT EXISTS auditoria
+(
+    id         BIGSERIAL PRIMARY KEY,
+    usuario_id INT REFERENCES usuario (id),
+    ip         VARCHAR(45),
+    entidad    VARCHAR(30) NOT NULL,
+    entidad_id TEXT        NOT NULL,
+    accion     VARCHAR(30) NOT NULL,
+    antes      JSONB,
+    despues    JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auditoria_entidad ON auditoria (entidad, entidad_id);
+CREATE INDEX IF NOT EXISTS idx_auditoria_created_at ON auditoria (created_at);
+
+-- historial_precio (cambios de precios de cada producto con su origen)
+CREATE TABLE IF NOT EXISTS historial_precio
+(
+    id                           BIGSERIAL PRIMARY KEY,
+    producto_id                  UUID           NOT NULL REFERENCES producto (id),
+    origen                       VARCHAR(15)    NOT NULL CHECK (origen IN ('Manual', 'Compra', 'Masivo', 'Programado')),
+    referencia                   TEXT,
+    precio_compra_anterior       NUMERIC(10, 2),
+    precio_compra                NUMERIC(10, 2) NOT NULL,
+    precio_venta_anterior        NUMERIC(10, 2),
+    precio_venta                 NUMERIC(10, 2) NOT NULL,
+    precio_venta_unidad_anterior NUMERIC(10, 2),
+    precio_venta_unidad          NUMERIC(10, 2),
+    usuario_id                   INT REFERENCES usuario (id),
+    created_at                   TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_historial_precio_producto ON historial_precio (producto_id, created_at);
+
+-- precio_programado (precio de venta a aplicar en una fecha, o propuesto por una compra hasta su aprobación)
+CREATE TABLE IF NOT EXISTS precio_programado
+(
+    id                  SERIAL PRIMARY KEY,
+    producto_id         UUID           NOT NULL REFERENCES producto (id),
+    precio_venta        NUMERIC(10, 2) NOT NULL CHECK (precio_venta >= 0),
+    precio_venta_unidad NUMERIC(10, 2) CHECK (precio_venta_unidad >= 0),
+    fecha_efectiva      TIMESTAMPTZ,
+    estado              VARCHAR(10)    NOT NULL DEFAULT 'Pendiente' CHECK (estado IN ('Propuesto', 'Pendiente', 'Aplicado', 'Cancelado')),
+    compra_id           INT REFERENCES compra (id),
+    usuario_id          INT REFERENCES usuario (id),
+    created_at          TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    aplicado_at         TIMESTAMPTZ,
+    CHECK (estado <> 'Pendiente' OR fecha_efectiva IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_precio_programado_pendiente ON precio_programado (fecha_efectiva) WHERE estado = 'Pendiente';
+
+-- inventario_inicial (carga inicial de existencias, se registra como entrada en el kardex)
+CREATE TABLE IF NOT EXISTS inventario_inicial
+(
+    id         SERIAL PRIMARY KEY,
+    codigo     TEXT UNIQUE    NOT NULL,
+    total      NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    usuario_id INT            NOT NULL REFERENCES usuario (id),
+    fecha      TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- detalle_inventario_inicial (lote cargado con su cantidad en unidades base y costo por unidad base)
+CREATE TABLE IF NOT EXISTS detalle_inventario_inicial
+(
+    id                    SERIAL PRIMARY KEY,
+    inventario_inicial_id INT            NOT NULL REFERENCES inventario_inicial (id) ON DELETE CASCADE,
+    lote_id               INT UNIQUE     NOT NULL REFERENCES lote_producto (id),
+    cantidad              INT            NOT NULL CHECK (cantidad > 0),
+    costo_unitario        NUMERIC(10, 2) NOT NULL CHECK (costo_unitario >= 0)
+);
+
+-- cambio_precio_masivo (ajuste de precios aplicado a varios productos en una operación, reversible)
+CREATE TABLE IF NOT EXISTS cambio_precio_masivo
+(
+    id            SERIAL PRIMARY KEY,
+    tipo          VARCHAR(15)    NOT NULL CHECK (tipo IN ('Porcentaje', 'Margen')),
+    valor         NUMERIC(10, 2) NOT NULL,
+    redondeo      NUMERIC(10, 2) CHECK (redondeo > 0),
+    modo_redondeo VARCHAR(10)    NOT NULL DEFAULT 'Cercano' CHECK (modo_redondeo IN ('Cercano', 'Arriba')),
+    filtros       JSONB          NOT NULL DEFAULT '{}',
+    comentario    TEXT,
+    estado        VARCHAR(10)    NOT NULL DEFAULT 'Aplicado' CHECK (estado IN ('Aplicado', 'Revertido')),
+    usuario_id    INT            NOT NULL REFERENCES usuario (id),
+    created_at    TIMESTAMPTZ    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revertido_por INT REFERENCES usuario (id),
+    revertido_at  TIMESTAMPTZ
+);
+
+-- detalle_cambio_precio_masivo (precios anteriores y nuevos de cada producto del cambio masivo)
+CREATE TABLE IF NOT EXISTS detalle_cambio_precio_masivo
+(
+    id                           SERIAL PRIMARY KEY,
+    cambio_precio_masivo_id      INT            NOT NULL REFERENCES cambio_precio_masivo (id) ON DELETE CASCADE,
+    producto_id                  UUID           NOT NULL REFERENCES producto (id),
+    precio_venta_anterior        NUMERIC(10, 2) NOT NULL,
+    precio_venta                 NUMERIC(10, 2) NOT NULL,
+    precio_venta_unidad_anterior NUMERIC(10, 2),
+    precio_venta_unidad          NUMERIC(10, 2),
+    revertido                    BOOLEAN        NOT NULL DEFAULT FALSE,
+    UNIQUE (cambio_precio_masivo_id, producto_id)
+);
+
+-- permiso (catálogo de permisos que se asignan a los roles)
+CREATE TABLE IF NOT EXISTS permiso
+(
+    id          SERIAL PRIMARY KEY,
+    codigo      VARCHAR(50)  NOT NULL UNIQUE,
+    modulo      VARCHAR(50)  NOT NULL,
+    descripcion VARCHAR(150) NOT NULL
+);
+
+-- rol_permiso
+CREATE TABLE IF NOT EXISTS rol_permiso
+(
+    rol_id     INT NOT NULL REFERENCES rol (id) ON DELETE CASCADE,
+    permiso_id INT NOT NULL REFERENCES permiso (id) ON DELETE CASCADE,
+    PRIMARY KEY (rol_id, permiso_id)
+);
+
+-- Los permisos nuevos se asignan una sola vez a los roles que tenían acceso; después se administran desde la API
+WITH catalogo (codigo, modulo, descripcion, roles) AS (VALUES
+    ('roles.gestionar', 'Roles', 'Gestionar roles y sus permisos', ARRAY['GERENTE']),
+    ('usuarios.gestionar', 'Usuarios', 'Gestionar usuarios', ARRAY['GERENTE']),
+    ('accesos.ver', 'Usuarios', 'Ver el historial de inicios de sesión y la actividad sospechosa', ARRAY['GERENTE']),
+    ('cuentas_servicio.gestionar', 'Usuarios', 'Gestionar cuentas de servicio y sus API keys', ARRAY[]::TEXT[]),
+    ('categorias.gestionar', 'Categorías', 'Gestionar categorías', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('principios_activos.gestionar', 'Principios activos', 'Gestionar principios activos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('laboratorios.gestionar', 'Laboratorios', 'Registrar, modificar y habilitar laboratorios', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('productos.gestionar', 'Productos', 'Registrar, modificar y habilitar productos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('productos.importar', 'Productos', 'Importar el catálogo de productos', ARRAY['GERENTE']),
+    ('productos.exportar', 'Productos', 'Exportar el catálogo de productos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('precios.gestionar', 'Precios', 'Ver historial, programar y aplicar cambios masivos de precios', ARRAY['GERENTE']),
+    ('lotes.ver', 'Lotes', 'Ver lotes de productos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO']),
+    ('lotes.gestionar', 'Lotes', 'Registrar y modificar lotes de productos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('inventario.importar', 'Lotes', 'Importar el inventario inicial', ARRAY['GERENTE']),
+    ('compras.ver', 'Compras', 'Ver compras', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO']),
+    ('compras.gestionar', 'Compras', 'Registrar y modificar órdenes de compra', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('compras.completar', 'Compras', 'Completar órdenes de compra', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('compras.anular', 'Compras', 'Anular órdenes de compra', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('clientes.gestionar', 'Clientes', 'Gestionar clientes y cuentas de clientes', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('ventas.ver', 'Ventas', 'Ver ventas', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO']),
+    ('ventas.cotizar', 'Ventas', 'Cotizar ventas y convertir cotizaciones', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('ventas.registrar', 'Ventas', 'Registrar ventas y ventas en espera', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('ventas.anular', 'Ventas', 'Anular ventas', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('promociones.ver', 'Promociones', 'Ver promociones', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('promociones.gestionar', 'Promociones', 'Gestionar promociones y ver su efectividad', ARRAY['GERENTE']),
+    ('pedidos.gestionar', 'Pedidos', 'Gestionar y entregar pedidos', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('recetas.gestionar', 'Recetas', 'Registrar recetas y ver el registro de controlados', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('interacciones.ver', 'Interacciones', 'Ver interacciones entre principios activos', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('interacciones.gestionar', 'Interacciones', 'Gestionar interacciones entre principios activos', ARRAY[]::TEXT[]),
+    ('retiros.gestionar', 'Retiros de lotes', 'Registrar retiros de lotes y ver las ventas afectadas', ARRAY['GERENTE', 'FARMACEUTICO']),
+    ('ubicaciones.ver', 'Ubicaciones', 'Ver ubicaciones y su stock', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO']),
+    ('ubicaciones.gestionar', 'Ubicaciones', 'Gestionar ubicaciones', ARRAY['GERENTE']),
+    ('traslados.gestionar', 'Traslados', 'Registrar y ver traslados entre ubicaciones', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN']),
+    ('auditoria.ver', 'Auditoría', 'Ver la bitácora de auditoría', ARRAY['GERENTE']),
+    ('backups.gestionar', 'Backups', 'Generar y descargar backups', ARRAY['GERENTE']),
+    ('reportes.ver', 'Reportes', 'Generar reportes', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO']),
+    ('reportes.kardex', 'Reportes', 'Generar el kardex de productos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO']),
+    ('estadisticas.ver', 'Estadísticas', 'Ver el dashboard y los productos más vendidos', ARRAY['GERENTE', 'AUXILIAR DE ALMACEN', 'FARMACEUTICO'])
+),
+nuevos AS (
+    INSERT INTO permiso (codigo, modulo, descripcion)
+    SELECT codigo, modulo, descripcion FROM catalogo
+    ON CONFLICT (codigo) DO NOTHING
+    RETURNING id, codigo
+)
+INSERT INTO rol_permiso (rol_id, permiso_id)
+SELECT r.id, n.id
+FROM nuevos n
+INNER JOIN catalogo c ON c.codigo = n.codigo
+INNER JOIN rol r ON r.nombre = ANY (c.roles)
+ON CONFLICT DO NOTHING;
+
+-- El rol del sistema (ADMIN) no se puede renombrar, deshabilitar ni cambiar sus permisos; recibe todo el catálogo
+ALTER TABLE rol ADD COLUMN IF NOT EXISTS es_sistema BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE rol SET es_sistema = TRUE WHERE nombre = 'ADMIN' AND NOT es_sistema;
+INSERT INTO rol_permiso (rol_id, permiso_id)
+SELECT r.id, p.id
+FROM rol r
+CROSS JOIN permiso p
+WHERE r.es_sistema
+ON CONFLICT DO NOTHING;
+
+-- sesion (sesiones de usuarios administrativos; el token de actualización rota en cada renovación)
+CREATE TABLE IF NOT EXISTS sesion
+(
+    id                     UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    usuario_id             INT         NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+    refresh_token_id       UUID        NOT NULL,
+    refresh_token_anterior UUID,
+    rotado_at              TIMESTAMPTZ,
+    dispositivo            VARCHAR(100),
+    ip                     VARCHAR(45),
+    user_agent             TEXT,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_acceso          TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expira_at              TIMESTAMPTZ NOT NULL,
+    revocada_at            TIMESTAMPTZ,
+    motivo_revocacion      VARCHAR(100)
+);
+CREATE INDEX IF NOT EXISTS idx_sesion_usuario ON sesion (usuario_id) WHERE revocada_at IS NULL;
+
+-- Protección contra fuerza bruta en el inicio de sesión
+ALTER TABLE usuario ADD COLUMN IF NOT EXISTS intentos_fallidos INT NOT NULL DEFAULT 0;
+ALTER TABLE usuario ADD COLUMN IF NOT EXISTS bloqueado_hasta TIMESTAMPTZ;
+
+-- intento_login (historial de inicios de sesión del panel administrativo)
+CREATE TABLE IF NOT EXISTS intento_login
+(
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT        NOT NULL,
+    usuario_id INT REFERENCES usuario (id) ON DELETE SET NULL,
+    exitoso    BOOLEAN     NOT NULL,
+    motivo     VARCHAR(50),
+    ip         VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_intento_login_created_at ON intento_login (created_at);
+
+-- Verificación en dos pasos (TOTP); los roles pueden exigirla a sus usuarios
+ALTER TABLE rol ADD COLUMN IF NOT EXISTS requiere_doble_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- usuario_doble_factor (el secreto se guarda cifrado; activo solo después de confirmar el primer código)
+CREATE TABLE IF NOT EXISTS usuario_doble_factor
+(
+    usuario_id  INT PRIMARY KEY REFERENCES usuario (id) ON DELETE CASCADE,
+    secreto     TEXT        NOT NULL,
+    activo      BOOLEAN     NOT NULL DEFAULT FALSE,
+    ultimo_paso BIGINT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activado_at TIMESTAMPTZ
+);
+
+-- codigo_recuperacion (códigos de un solo uso para cuando no se tiene el teléfono)
+CREATE TABLE IF NOT EXISTS codigo_recuperacion
+(
+    id          SERIAL PRIMARY KEY,
+    usuario_id  INT         NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+    codigo_hash CHAR(64)    NOT NULL,
+    usado_at    TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (usuario_id, codigo_hash)
+);
+
+-- desafio_login_usado (tokens de desafío del inicio de sesión ya utilizados; se eliminan al expirar)
+CREATE TABLE IF NOT EXISTS desafio_login_usado
+(
+    id         UUID PRIMARY KEY,
+    usuario_id INT         NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+    expira_at  TIMESTAMPTZ NOT NULL,
+    usado_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Política de contraseñas: cambio obligatorio tras el restablecimiento e historial para evitar su reutilización
+ALTER TABLE usuario ADD COLUMN IF NOT EXISTS debe_cambiar_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- historial_password (hashes de las contraseñas anteriores de cada usuario)
+CREATE TABLE IF NOT EXISTS historial_password
+(
+    id         SERIAL PRIMARY KEY,
+    usuario_id INT         NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+    password   TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_historial_password_usuario ON historial_password (usuario_id, created_at DESC);
+
+-- Cuentas de servicio: usuarios sin persona que no inician sesión y se autentican con API keys
+ALTER TABLE usuario ADD COLUMN IF NOT EXISTS es_servicio BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE usuario ADD COLUMN IF NOT EXISTS descripcion TEXT;
+ALTER TABLE usuario ALTER COLUMN persona_id DROP NOT NULL;
+
+-- api_key (solo se guarda el hash; permisos NULL usa todos los permisos de los roles de la cuenta)
+CREATE TABLE IF NOT EXISTS api_key
+(
+    id          SERIAL PRIMARY KEY,
+    usuario_id  INT          NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+    nombre      VARCHAR(100) NOT NULL,
+    prefijo     CHAR(8)      NOT NULL UNIQUE,
+    key_hash    CHAR(64)     NOT NULL,
+    permisos    TEXT[],
+    expira_at   TIMESTAMPTZ,
+    ultimo_uso  TIMESTAMPTZ,
+    ultima_ip   VARCHAR(45),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by  INT REFERENCES usuario (id) ON DELETE SET NULL,
+    revocada_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_api_key_usuario ON api_key (usuario_id);
+
+ALTER TABLE reserva_lote ADD COLUMN IF NOT EXISTS pedido_id INT REFERENCES pedido (id) ON DELETE CASCADE;
+
+-- Pedidos por presentación o por unidad; la reserva indica el detalle del pedido para entregar los lotes reservados
+ALTER TABLE carrito_item ADD COLUMN IF NOT EXISTS unidad_venta VARCHAR(12) NOT NULL DEFAULT 'Presentacion' CHECK (unidad_venta IN ('Presentacion', 'Unidad'));
+ALTER TABLE detalle_pedido ADD COLUMN IF NOT EXISTS unidad_venta VARCHAR(12) NOT NULL DEFAULT 'Presentacion' CHECK (unidad_venta IN ('Presentacion', 'Unidad'));
+ALTER TABLE reserva_lote ADD COLUMN IF NOT EXISTS detalle_pedido_id INT REFERENCES detalle_pedido (id) ON DELETE CASCADE;
+
+ALTER TABLE venta ADD COLUMN IF NOT EXISTS descuento_promocion NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+COMMIT;